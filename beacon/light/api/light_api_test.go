@@ -0,0 +1,135 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/beacon/types"
+)
+
+var testConfig = (&types.ChainConfig{GenesisTime: 123}).AddFork("GENESIS", 0, []byte{0, 0, 0, 0})
+
+type jsonObject = map[string]any
+
+func encodeBeaconHeader(h types.Header) jsonObject {
+	return jsonObject{"beacon": h}
+}
+
+func TestBeaconLightApi(t *testing.T) {
+	var (
+		committee, next = light.GenerateTestCommittee(), light.GenerateTestCommittee()
+		checkpoint      = light.GenerateTestCheckpoint(5, committee, next)
+		update          = light.GenerateTestUpdate(testConfig, 5, committee, next, 400, true)
+		head            = light.GenerateTestSignedHeader(testConfig, types.Header{Slot: 50000}, committee, 50001, 400)
+		finality        = light.GenerateTestFinalityUpdate(testConfig, 50000, committee, 400)
+	)
+	responses := map[string]any{
+		"/eth/v1/beacon/light_client/bootstrap/" + checkpoint.Header.Hash().Hex(): jsonObject{
+			"data": jsonObject{
+				"header":                        encodeBeaconHeader(checkpoint.Header),
+				"current_sync_committee":        checkpoint.Committee,
+				"current_sync_committee_branch": checkpoint.CommitteeBranch,
+			},
+		},
+		"/eth/v1/beacon/light_client/updates?start_period=5&count=1": []any{
+			jsonObject{
+				"version": "capella",
+				"data": jsonObject{
+					"attested_header":            encodeBeaconHeader(update.AttestedHeader.Header),
+					"next_sync_committee":        next,
+					"next_sync_committee_branch": update.NextSyncCommitteeBranch,
+					"finalized_header":           encodeBeaconHeader(*update.FinalizedHeader),
+					"finality_branch":            update.FinalityBranch,
+					"sync_aggregate":             update.AttestedHeader.Signature,
+					"signature_slot":             strconv.FormatUint(update.AttestedHeader.SignatureSlot, 10),
+				},
+			},
+		},
+		"/eth/v1/beacon/light_client/optimistic_update": jsonObject{
+			"data": jsonObject{
+				"attested_header": encodeBeaconHeader(head.Header),
+				"sync_aggregate":  head.Signature,
+				"signature_slot":  strconv.FormatUint(head.SignatureSlot, 10),
+			},
+		},
+		"/eth/v1/beacon/light_client/finality_update": jsonObject{
+			"data": jsonObject{
+				"attested_header":  encodeBeaconHeader(finality.Attested),
+				"finalized_header": encodeBeaconHeader(finality.Finalized),
+				"finality_branch":  finality.FinalityBranch,
+				"sync_aggregate":   finality.Signature,
+				"signature_slot":   strconv.FormatUint(finality.SignatureSlot, 10),
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "yes" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	var (
+		ctx = context.Background()
+		api = NewBeaconLightApi(server.URL, map[string]string{"X-Test": "yes"})
+	)
+	bootstrap, err := api.GetCheckpointData(ctx, checkpoint.Header.Hash())
+	if err != nil {
+		t.Fatalf("failed to fetch checkpoint: %v", err)
+	}
+	if bootstrap.Header != checkpoint.Header || *bootstrap.Committee != *committee {
+		t.Fatal("wrong checkpoint data")
+	}
+	if _, err := api.GetCheckpointData(ctx, head.Header.Hash()); err != ErrNotFound {
+		t.Fatalf("wrong error for unknown checkpoint: %v", err)
+	}
+	updates, committees, err := api.GetBestUpdatesAndCommittees(ctx, 5, 1)
+	if err != nil {
+		t.Fatalf("failed to fetch updates: %v", err)
+	}
+	if updates[0].AttestedHeader != update.AttestedHeader || *committees[0] != *next || updates[0].FinalizedHeader == nil {
+		t.Fatal("wrong committee update")
+	}
+	signedHead, err := api.GetOptimisticHeadUpdate(ctx)
+	if err != nil {
+		t.Fatalf("failed to fetch optimistic update: %v", err)
+	}
+	if signedHead != head {
+		t.Fatal("wrong optimistic update")
+	}
+	finalityUpdate, err := api.GetFinalityUpdate(ctx)
+	if err != nil {
+		t.Fatalf("failed to fetch finality update: %v", err)
+	}
+	if finalityUpdate.Finalized != finality.Finalized || finalityUpdate.SignatureSlot != finality.SignatureSlot {
+		t.Fatal("wrong finality update")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+var testConfig = (&types.ChainConfig{GenesisTime: 123}).AddFork("GENESIS", 0, []byte{0, 0, 0, 0})
+
+// testClock returns a clock source pointing to the end of the given period.
+func testClock(period uint64) func() int64 {
+	return func() int64 {
+		return int64(time.Second)*int64(testConfig.GenesisTime) + int64(slotDuration)*int64(types.SyncPeriodStart(period+1))
+	}
+}
+
+func TestCommitteeChainCheckpointInit(t *testing.T) {
+	db := memorydb.New()
+	committees := []*types.SerializedSyncCommittee{GenerateTestCommittee(), GenerateTestCommittee(), GenerateTestCommittee()}
+	chain := NewTestCommitteeChain(db, testConfig, 300, true, testClock(12))
+
+	if _, ok := chain.NextSyncPeriod(); ok {
+		t.Fatal("uninitialized chain reports next sync period")
+	}
+	checkpoint := GenerateTestCheckpoint(10, committees[0], committees[1])
+	if err := chain.CheckpointInit(*checkpoint); err != nil {
+		t.Fatalf("checkpoint init failed: %v", err)
+	}
+	if next, ok := chain.NextSyncPeriod(); !ok || next != 10 {
+		t.Fatalf("wrong next sync period: have %d/%v, want 10/true", next, ok)
+	}
+	// The checkpoint fixes the root of the next committee too, an update
+	// proving a different one must be rejected.
+	if err := chain.InsertUpdate(GenerateTestUpdate(testConfig, 10, committees[0], committees[2], 400, false), committees[2]); !errors.Is(err, ErrCannotReorg) {
+		t.Fatalf("conflicting update not rejected: %v", err)
+	}
+	// A broken checkpoint proof must be rejected.
+	invalid := GenerateTestCheckpoint(10, committees[0], committees[1])
+	invalid.CommitteeBranch[1][0]++
+	if err := chain.CheckpointInit(*invalid); err == nil {
+		t.Fatal("invalid checkpoint accepted")
+	}
+}
+
+func TestCommitteeChainInsertUpdate(t *testing.T) {
+	db := memorydb.New()
+	chain := NewTestCommitteeChain(db, testConfig, 300, true, testClock(15))
+
+	committees := []*types.SerializedSyncCommittee{GenerateTestCommittee(), GenerateTestCommittee()}
+	if err := chain.CheckpointInit(*GenerateTestCheckpoint(10, committees[0], committees[1])); err != nil {
+		t.Fatalf("checkpoint init failed: %v", err)
+	}
+	for period := uint64(10); period < 14; period++ {
+		if period > 10 {
+			committees = append(committees, GenerateTestCommittee())
+		}
+		next := committees[period-10+1]
+		// update with too few signers
+		if err := chain.InsertUpdate(GenerateTestUpdate(testConfig, period, committees[period-10], next, 200, true), next); !errors.Is(err, ErrInvalidUpdate) {
+			t.Fatalf("period %d: low signer count update not rejected: %v", period, err)
+		}
+		// update signed by the wrong committee
+		if err := chain.InsertUpdate(GenerateTestUpdate(testConfig, period, next, next, 400, true), next); !errors.Is(err, ErrInvalidUpdate) {
+			t.Fatalf("period %d: badly signed update not rejected: %v", period, err)
+		}
+		// missing next committee
+		update := GenerateTestUpdate(testConfig, period, committees[period-10], next, 400, true)
+		if err := chain.InsertUpdate(update, nil); !errors.Is(err, ErrNeedCommittee) {
+			t.Fatalf("period %d: update without committee not rejected: %v", period, err)
+		}
+		if err := chain.InsertUpdate(update, next); err != nil {
+			t.Fatalf("period %d: valid update rejected: %v", period, err)
+		}
+		if have, _ := chain.NextSyncPeriod(); have != period+1 {
+			t.Fatalf("wrong next sync period: have %d, want %d", have, period+1)
+		}
+	}
+	// Updates can not be inserted out of order.
+	if err := chain.InsertUpdate(GenerateTestUpdate(testConfig, 16, committees[4], committees[4], 400, true), committees[4]); !errors.Is(err, ErrInvalidPeriod) {
+		t.Fatalf("update with gap not rejected: %v", err)
+	}
+	// Signed heads of the synced periods can be verified.
+	head := GenerateTestSignedHeader(testConfig, types.Header{Slot: types.SyncPeriodStart(14) + 5}, committees[4], types.SyncPeriodStart(14)+6, 400)
+	if ok, _, err := chain.VerifySignedHeader(head); !ok || err != nil {
+		t.Fatalf("valid signed head not verified: %v %v", ok, err)
+	}
+	head.Signature.Signers[0] ^= 1
+	if ok, _, _ := chain.VerifySignedHeader(head); ok {
+		t.Fatal("tampered signed head verified")
+	}
+	future := GenerateTestSignedHeader(testConfig, types.Header{Slot: types.SyncPeriodStart(14) + 5}, committees[4], types.SyncPeriodStart(14)+6, 400)
+	chain.unixNano = testClock(9)
+	if ok, _, _ := chain.VerifySignedHeader(future); ok {
+		t.Fatal("future signed head verified")
+	}
+	chain.unixNano = testClock(15)
+
+	// The chain is persisted in the database.
+	chain = NewTestCommitteeChain(db, testConfig, 300, true, testClock(15))
+	if next, ok := chain.NextSyncPeriod(); !ok || next != 14 {
+		t.Fatalf("wrong next sync period after reload: have %d/%v, want 14/true", next, ok)
+	}
+	// Resetting removes everything.
+	chain.Reset()
+	if _, ok := chain.NextSyncPeriod(); ok {
+		t.Fatal("chain still initialized after reset")
+	}
+	it := db.NewIterator(rawdb.SyncCommitteeKey, nil)
+	defer it.Release()
+	if it.Next() {
+		t.Fatal("committees left in database after reset")
+	}
+}
+
+func TestHeadTracker(t *testing.T) {
+	chain := NewTestCommitteeChain(memorydb.New(), testConfig, 300, true, testClock(10))
+	committee, next := GenerateTestCommittee(), GenerateTestCommittee()
+	if err := chain.CheckpointInit(*GenerateTestCheckpoint(10, committee, next)); err != nil {
+		t.Fatalf("checkpoint init failed: %v", err)
+	}
+	tracker := NewHeadTracker(chain, 300)
+	if _, ok := tracker.ValidatedHead(); ok {
+		t.Fatal("validated head available before validation")
+	}
+	slot := types.SyncPeriodStart(10) + 100
+	head := GenerateTestSignedHeader(testConfig, types.Header{Slot: slot}, committee, slot+1, 400)
+	if changed, err := tracker.ValidateHead(head); !changed || err != nil {
+		t.Fatalf("valid head not accepted: %v %v", changed, err)
+	}
+	// older head is ignored
+	older := GenerateTestSignedHeader(testConfig, types.Header{Slot: slot - 1}, committee, slot, 400)
+	if changed, err := tracker.ValidateHead(older); changed || err != nil {
+		t.Fatalf("older head not ignored: %v %v", changed, err)
+	}
+	// low signer count is rejected
+	low := GenerateTestSignedHeader(testConfig, types.Header{Slot: slot + 1}, committee, slot+2, 100)
+	if changed, err := tracker.ValidateHead(low); changed || err == nil {
+		t.Fatalf("low signer count head not rejected: %v %v", changed, err)
+	}
+	if have, _ := tracker.ValidatedHead(); have.Header != head.Header {
+		t.Fatal("wrong validated head")
+	}
+	finality := GenerateTestFinalityUpdate(testConfig, slot, committee, 400)
+	if changed, err := tracker.ValidateFinality(finality); !changed || err != nil {
+		t.Fatalf("valid finality update not accepted: %v %v", changed, err)
+	}
+	finality = GenerateTestFinalityUpdate(testConfig, slot+10, committee, 400)
+	finality.FinalityBranch[0][0]++
+	if changed, err := tracker.ValidateFinality(finality); changed || err == nil {
+		t.Fatalf("invalid finality update not rejected: %v %v", changed, err)
+	}
+}
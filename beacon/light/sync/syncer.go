@@ -0,0 +1,221 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package sync drives a beacon light client, keeping the local committee chain
+// and head tracker in sync with a beacon node REST API.
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// maxUpdateRequest is the maximum number of committee updates requested at
+	// once (MAX_REQUEST_LIGHT_CLIENT_UPDATES in the consensus specs).
+	maxUpdateRequest = 128
+
+	defaultPollInterval = 12 * time.Second
+)
+
+// BeaconAPI is the source of light client data used by the Syncer. It is
+// implemented by api.BeaconLightApi and can be stubbed in tests.
+type BeaconAPI interface {
+	GetCheckpointData(ctx context.Context, checkpointHash common.Hash) (*types.BootstrapData, error)
+	GetBestUpdatesAndCommittees(ctx context.Context, firstPeriod, count uint64) ([]*types.LightClientUpdate, []*types.SerializedSyncCommittee, error)
+	GetOptimisticHeadUpdate(ctx context.Context) (types.SignedHeader, error)
+	GetFinalityUpdate(ctx context.Context) (types.FinalityUpdate, error)
+}
+
+// Syncer periodically polls a beacon API, bootstraps the committee chain from a
+// trusted checkpoint if necessary, keeps it synced up to the period of the
+// latest signed head and announces newly validated optimistic and finalized
+// headers.
+type Syncer struct {
+	api          BeaconAPI
+	chain        *light.CommitteeChain
+	headTracker  *light.HeadTracker
+	checkpoint   common.Hash
+	pollInterval time.Duration
+
+	optimisticFeed event.Feed
+	finalizedFeed  event.Feed
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// NewSyncer creates a new light client syncer. The checkpoint is the block root
+// of a trusted beacon header which is used for bootstrapping the committee chain
+// if it is not initialized yet.
+func NewSyncer(api BeaconAPI, chain *light.CommitteeChain, headTracker *light.HeadTracker, checkpoint common.Hash, pollInterval time.Duration) *Syncer {
+	if pollInterval == 0 {
+		pollInterval = defaultPollInterval
+	}
+	return &Syncer{
+		api:          api,
+		chain:        chain,
+		headTracker:  headTracker,
+		checkpoint:   checkpoint,
+		pollInterval: pollInterval,
+	}
+}
+
+// SubscribeOptimisticHead subscribes to newly validated signed heads.
+func (s *Syncer) SubscribeOptimisticHead(ch chan<- types.SignedHeader) event.Subscription {
+	return s.optimisticFeed.Subscribe(ch)
+}
+
+// SubscribeFinalizedHead subscribes to newly validated finality updates.
+func (s *Syncer) SubscribeFinalizedHead(ch chan<- types.FinalityUpdate) event.Subscription {
+	return s.finalizedFeed.Subscribe(ch)
+}
+
+// Start starts the sync loop in a background goroutine.
+func (s *Syncer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go s.loop(ctx)
+}
+
+// Stop terminates the sync loop and waits for it to exit.
+func (s *Syncer) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Syncer) loop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.syncStep(ctx); err != nil && ctx.Err() == nil {
+			log.Warn("Beacon light sync failed", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// syncStep performs a single round of synchronization: it initializes the
+// committee chain if needed, fetches committee updates up to the signature
+// period of the latest optimistic head and validates the latest optimistic and
+// finality updates.
+func (s *Syncer) syncStep(ctx context.Context) error {
+	if _, ok := s.chain.NextSyncPeriod(); !ok {
+		if err := s.bootstrap(ctx); err != nil {
+			return err
+		}
+	}
+	head, err := s.api.GetOptimisticHeadUpdate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve optimistic update: %w", err)
+	}
+	if err := s.syncCommittees(ctx, types.SyncPeriod(head.SignatureSlot)); err != nil {
+		return err
+	}
+	changed, err := s.headTracker.ValidateHead(head)
+	if err != nil {
+		return fmt.Errorf("invalid optimistic update: %w", err)
+	}
+	if changed {
+		log.Debug("New optimistic head", "slot", head.Header.Slot, "root", head.Header.Hash(), "signers", head.Signature.SignerCount())
+		s.optimisticFeed.Send(head)
+	}
+	finality, err := s.api.GetFinalityUpdate(ctx)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		// Finality updates may be unavailable shortly after startup of the
+		// server, do not treat it as a sync failure.
+		log.Debug("Failed to retrieve finality update", "err", err)
+		return nil
+	}
+	if changed, err := s.headTracker.ValidateFinality(finality); err != nil {
+		return fmt.Errorf("invalid finality update: %w", err)
+	} else if changed {
+		log.Debug("New finalized head", "slot", finality.Finalized.Slot, "root", finality.Finalized.Hash())
+		s.finalizedFeed.Send(finality)
+	}
+	return nil
+}
+
+// bootstrap initializes the committee chain based on the trusted checkpoint.
+func (s *Syncer) bootstrap(ctx context.Context) error {
+	if s.checkpoint == (common.Hash{}) {
+		return errors.New("committee chain not initialized and no checkpoint specified")
+	}
+	bootstrap, err := s.api.GetCheckpointData(ctx, s.checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve checkpoint data: %w", err)
+	}
+	if hash := bootstrap.Header.Hash(); hash != s.checkpoint {
+		return fmt.Errorf("checkpoint header hash mismatch: have %v, want %v", hash, s.checkpoint)
+	}
+	if err := s.chain.CheckpointInit(*bootstrap); err != nil {
+		return fmt.Errorf("failed to initialize committee chain: %w", err)
+	}
+	log.Info("Initialized beacon light sync from checkpoint", "slot", bootstrap.Header.Slot, "period", bootstrap.Header.SyncPeriod(), "root", s.checkpoint)
+	return nil
+}
+
+// syncCommittees requests and inserts committee updates until the committee
+// of the target period is available.
+func (s *Syncer) syncCommittees(ctx context.Context, target uint64) error {
+	for {
+		next, ok := s.chain.NextSyncPeriod()
+		if !ok {
+			return errors.New("committee chain not initialized")
+		}
+		if next >= target {
+			return nil
+		}
+		count := target - next
+		if count > maxUpdateRequest {
+			count = maxUpdateRequest
+		}
+		updates, committees, err := s.api.GetBestUpdatesAndCommittees(ctx, next, count)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve committee updates: %w", err)
+		}
+		if len(updates) == 0 {
+			return fmt.Errorf("no committee updates available from period %d", next)
+		}
+		for i, update := range updates {
+			if err := s.chain.InsertUpdate(update, committees[i]); err != nil {
+				return fmt.Errorf("failed to insert committee update for period %d: %w", next+uint64(i), err)
+			}
+		}
+	}
+}
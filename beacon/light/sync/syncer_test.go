@@ -0,0 +1,173 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package sync
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+var testConfig = (&types.ChainConfig{GenesisTime: 123}).AddFork("GENESIS", 0, []byte{0, 0, 0, 0})
+
+// testAPI is a BeaconAPI serving a generated chain of committee updates.
+type testAPI struct {
+	firstPeriod uint64
+	committees  []*types.SerializedSyncCommittee
+	updates     []*types.LightClientUpdate
+	checkpoint  *types.BootstrapData
+	head        types.SignedHeader
+	finality    *types.FinalityUpdate
+
+	updateRequests int
+}
+
+func newTestAPI(firstPeriod, lastPeriod uint64) *testAPI {
+	api := &testAPI{firstPeriod: firstPeriod}
+	for period := firstPeriod; period <= lastPeriod+1; period++ {
+		api.committees = append(api.committees, light.GenerateTestCommittee())
+	}
+	for period := firstPeriod; period < lastPeriod; period++ {
+		i := period - firstPeriod
+		api.updates = append(api.updates, light.GenerateTestUpdate(testConfig, period, api.committees[i], api.committees[i+1], 400, true))
+	}
+	api.checkpoint = light.GenerateTestCheckpoint(firstPeriod, api.committees[0], api.committees[1])
+	api.setHead(types.SyncPeriodStart(lastPeriod) + 10)
+	return api
+}
+
+func (api *testAPI) committee(period uint64) *types.SerializedSyncCommittee {
+	return api.committees[period-api.firstPeriod]
+}
+
+func (api *testAPI) setHead(slot uint64) {
+	api.head = light.GenerateTestSignedHeader(testConfig, types.Header{Slot: slot}, api.committee(types.SyncPeriod(slot+1)), slot+1, 400)
+	finality := light.GenerateTestFinalityUpdate(testConfig, slot, api.committee(types.SyncPeriod(slot+1)), 400)
+	api.finality = &finality
+}
+
+func (api *testAPI) GetCheckpointData(ctx context.Context, checkpointHash common.Hash) (*types.BootstrapData, error) {
+	if api.checkpoint.Header.Hash() != checkpointHash {
+		return nil, errors.New("unknown checkpoint")
+	}
+	return api.checkpoint, nil
+}
+
+func (api *testAPI) GetBestUpdatesAndCommittees(ctx context.Context, firstPeriod, count uint64) ([]*types.LightClientUpdate, []*types.SerializedSyncCommittee, error) {
+	api.updateRequests++
+	if firstPeriod < api.firstPeriod || firstPeriod+count > api.firstPeriod+uint64(len(api.updates)) {
+		return nil, nil, errors.New("updates not available")
+	}
+	first := firstPeriod - api.firstPeriod
+	return api.updates[first : first+count], api.committees[first+1 : first+count+1], nil
+}
+
+func (api *testAPI) GetOptimisticHeadUpdate(ctx context.Context) (types.SignedHeader, error) {
+	return api.head, nil
+}
+
+func (api *testAPI) GetFinalityUpdate(ctx context.Context) (types.FinalityUpdate, error) {
+	if api.finality == nil {
+		return types.FinalityUpdate{}, errors.New("no finality update")
+	}
+	return *api.finality, nil
+}
+
+func testClock(slot uint64) func() int64 {
+	return func() int64 {
+		return int64(time.Second)*int64(testConfig.GenesisTime) + int64(12*time.Second)*int64(slot+2)
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	api := newTestAPI(100, 300)
+	chain := light.NewTestCommitteeChain(memorydb.New(), testConfig, 300, true, testClock(types.SyncPeriodStart(301)))
+	tracker := light.NewHeadTracker(chain, 300)
+	syncer := NewSyncer(api, chain, tracker, api.checkpoint.Header.Hash(), 0)
+
+	heads := make(chan types.SignedHeader, 10)
+	finalized := make(chan types.FinalityUpdate, 10)
+	sub1 := syncer.SubscribeOptimisticHead(heads)
+	defer sub1.Unsubscribe()
+	sub2 := syncer.SubscribeFinalizedHead(finalized)
+	defer sub2.Unsubscribe()
+
+	if err := syncer.syncStep(context.Background()); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if next, _ := chain.NextSyncPeriod(); next != 300 {
+		t.Fatalf("wrong next sync period: have %d, want 300", next)
+	}
+	if api.updateRequests != 2 {
+		t.Fatalf("wrong number of update requests: have %d, want 2", api.updateRequests)
+	}
+	select {
+	case head := <-heads:
+		if head.Header != api.head.Header {
+			t.Fatal("wrong optimistic head announced")
+		}
+	default:
+		t.Fatal("optimistic head not announced")
+	}
+	select {
+	case update := <-finalized:
+		if update.Finalized != api.finality.Finalized {
+			t.Fatal("wrong finalized head announced")
+		}
+	default:
+		t.Fatal("finalized head not announced")
+	}
+
+	// Repeating the sync with the same head announces nothing new.
+	if err := syncer.syncStep(context.Background()); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if len(heads) != 0 || len(finalized) != 0 {
+		t.Fatal("unchanged heads announced again")
+	}
+	// A new head is announced without requesting further updates.
+	api.setHead(api.head.Header.Slot + 5)
+	if err := syncer.syncStep(context.Background()); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if len(heads) != 1 || len(finalized) != 1 || api.updateRequests != 2 {
+		t.Fatalf("new head not announced properly: heads %d, finalized %d, update requests %d", len(heads), len(finalized), api.updateRequests)
+	}
+	// A head signed by an unknown committee is rejected.
+	api.head = light.GenerateTestSignedHeader(testConfig, types.Header{Slot: api.head.Header.Slot + 5}, light.GenerateTestCommittee(), api.head.Header.Slot+6, 400)
+	if err := syncer.syncStep(context.Background()); err == nil {
+		t.Fatal("head with invalid signature accepted")
+	}
+}
+
+func TestSyncerCheckpointMismatch(t *testing.T) {
+	api := newTestAPI(100, 101)
+	chain := light.NewTestCommitteeChain(memorydb.New(), testConfig, 300, true, testClock(types.SyncPeriodStart(102)))
+	syncer := NewSyncer(api, chain, light.NewHeadTracker(chain, 300), common.Hash{1}, 0)
+	if err := syncer.syncStep(context.Background()); err == nil {
+		t.Fatal("sync succeeded with unknown checkpoint")
+	}
+	if _, ok := chain.NextSyncPeriod(); ok {
+		t.Fatal("committee chain initialized from unknown checkpoint")
+	}
+}
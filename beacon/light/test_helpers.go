@@ -0,0 +1,207 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/ethereum/go-ethereum/beacon/merkle"
+	"github.com/ethereum/go-ethereum/beacon/params"
+	"github.com/ethereum/go-ethereum/beacon/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// NewTestCommitteeChain creates a CommitteeChain using dummy signature
+// verification and the given clock source, for use in tests.
+func NewTestCommitteeChain(db ethdb.KeyValueStore, config *types.ChainConfig, signerThreshold int, enforceTime bool, unixNano func() int64) *CommitteeChain {
+	return newCommitteeChain(db, config, signerThreshold, enforceTime, dummyVerifier{}, unixNano)
+}
+
+// GenerateTestCommittee creates a random serialized sync committee.
+func GenerateTestCommittee() *types.SerializedSyncCommittee {
+	s := new(types.SerializedSyncCommittee)
+	rand.Read(s[:])
+	return s
+}
+
+// GenerateTestUpdate creates an update for the given period proving the next
+// committee, signed by signerCount members of the given committee.
+func GenerateTestUpdate(config *types.ChainConfig, period uint64, committee, nextCommittee *types.SerializedSyncCommittee, signerCount int, finalizedHeader bool) *types.LightClientUpdate {
+	update := new(types.LightClientUpdate)
+	update.NextSyncCommitteeRoot = nextCommittee.Root()
+	tree := newTestTree()
+	tree.set(params.StateIndexNextSyncCommittee, merkle.Value(update.NextSyncCommitteeRoot))
+	var finalized types.Header
+	if finalizedHeader {
+		finalized = types.Header{Slot: types.SyncPeriodStart(period) + 100, StateRoot: randomHash()}
+		tree.set(params.StateIndexFinalBlock, merkle.Value(finalized.Hash()))
+	}
+	header := types.Header{
+		Slot:      types.SyncPeriodStart(period) + 200,
+		StateRoot: tree.root(),
+	}
+	update.NextSyncCommitteeBranch = tree.proof(params.StateIndexNextSyncCommittee)
+	if finalizedHeader {
+		update.FinalizedHeader = &finalized
+		update.FinalityBranch = tree.proof(params.StateIndexFinalBlock)
+	}
+	update.AttestedHeader = GenerateTestSignedHeader(config, header, committee, header.Slot+1, signerCount)
+	return update
+}
+
+// GenerateTestCheckpoint creates bootstrap data for the given period, proving the
+// given committee and the next one.
+func GenerateTestCheckpoint(period uint64, committee, nextCommittee *types.SerializedSyncCommittee) *types.BootstrapData {
+	tree := newTestTree()
+	root := committee.Root()
+	tree.set(params.StateIndexSyncCommittee, merkle.Value(root))
+	tree.set(params.StateIndexNextSyncCommittee, merkle.Value(nextCommittee.Root()))
+	return &types.BootstrapData{
+		Header: types.Header{
+			Slot:      types.SyncPeriodStart(period),
+			StateRoot: tree.root(),
+		},
+		Committee:       committee,
+		CommitteeRoot:   root,
+		CommitteeBranch: tree.proof(params.StateIndexSyncCommittee),
+	}
+}
+
+// GenerateTestFinalityUpdate creates a finality update with an attested header
+// at the given slot, signed by signerCount members of the given committee.
+func GenerateTestFinalityUpdate(config *types.ChainConfig, slot uint64, committee *types.SerializedSyncCommittee, signerCount int) types.FinalityUpdate {
+	tree := newTestTree()
+	finalized := types.Header{Slot: slot - 64, StateRoot: randomHash()}
+	tree.set(params.StateIndexFinalBlock, merkle.Value(finalized.Hash()))
+	attested := types.Header{Slot: slot, StateRoot: tree.root()}
+	signed := GenerateTestSignedHeader(config, attested, committee, slot+1, signerCount)
+	return types.FinalityUpdate{
+		Attested:       attested,
+		Finalized:      finalized,
+		FinalityBranch: tree.proof(params.StateIndexFinalBlock),
+		Signature:      signed.Signature,
+		SignatureSlot:  signed.SignatureSlot,
+	}
+}
+
+// GenerateTestSignedHeader signs the given header with a dummy signature made by
+// the first signerCount members of the given committee.
+func GenerateTestSignedHeader(config *types.ChainConfig, header types.Header, committee *types.SerializedSyncCommittee, signatureSlot uint64, signerCount int) types.SignedHeader {
+	var signers [params.SyncCommitteeBitmaskSize]byte
+	for i := 0; i < signerCount; i++ {
+		signers[i/8] |= byte(1) << (i % 8)
+	}
+	signingRoot, _ := config.Forks.SigningRoot(header)
+	return types.SignedHeader{
+		Header: header,
+		Signature: types.SyncAggregate{
+			Signers:   signers,
+			Signature: makeDummySignature(committee, signingRoot, signers),
+		},
+		SignatureSlot: signatureSlot,
+	}
+}
+
+// dummyVerifier implements committeeSigVerifier with fake signatures that are
+// derived from the committee root, the signing root and the signer bitmask.
+type dummyVerifier struct{}
+
+// deserializeSyncCommittee implements committeeSigVerifier
+func (dummyVerifier) deserializeSyncCommittee(s *types.SerializedSyncCommittee) (syncCommittee, error) {
+	return s, nil
+}
+
+// verifySignature implements committeeSigVerifier
+func (dummyVerifier) verifySignature(committee syncCommittee, signingRoot common.Hash, aggregate *types.SyncAggregate) bool {
+	return aggregate.Signature == makeDummySignature(committee.(*types.SerializedSyncCommittee), signingRoot, aggregate.Signers)
+}
+
+func makeDummySignature(committee *types.SerializedSyncCommittee, signingRoot common.Hash, signers [params.SyncCommitteeBitmaskSize]byte) (sig [params.BLSSignatureSize]byte) {
+	root := committee.Root()
+	hasher := sha256.New()
+	hasher.Write(root[:])
+	hasher.Write(signingRoot[:])
+	hasher.Write(signers[:])
+	hasher.Sum(sig[:0])
+	return
+}
+
+func randomHash() (h common.Hash) {
+	rand.Read(h[:])
+	return
+}
+
+// testTree is a sparse binary merkle tree addressed by generalized tree indices.
+// Nodes that are not on the path of any explicitly set leaf are filled with
+// random values, which is sufficient for generating verifiable test proofs.
+type testTree struct {
+	nodes  map[uint64]merkle.Value
+	leaves []uint64
+}
+
+func newTestTree() *testTree {
+	return &testTree{nodes: make(map[uint64]merkle.Value)}
+}
+
+func (t *testTree) set(index uint64, value merkle.Value) {
+	t.nodes[index] = value
+	t.leaves = append(t.leaves, index)
+}
+
+// hasLeafBelow reports whether any set leaf is in the subtree of the given node.
+func (t *testTree) hasLeafBelow(index uint64) bool {
+	for _, leaf := range t.leaves {
+		for l := leaf; l > index; l /= 2 {
+			if l/2 == index {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *testTree) node(index uint64) merkle.Value {
+	if v, ok := t.nodes[index]; ok {
+		return v
+	}
+	var v merkle.Value
+	if t.hasLeafBelow(index) {
+		left, right := t.node(index*2), t.node(index*2+1)
+		hasher := sha256.New()
+		hasher.Write(left[:])
+		hasher.Write(right[:])
+		hasher.Sum(v[:0])
+	} else {
+		rand.Read(v[:])
+	}
+	t.nodes[index] = v
+	return v
+}
+
+func (t *testTree) root() common.Hash {
+	return common.Hash(t.node(1))
+}
+
+func (t *testTree) proof(index uint64) merkle.Values {
+	var branch merkle.Values
+	for ; index > 1; index /= 2 {
+		branch = append(branch, t.node(index^1))
+	}
+	return branch
+}
@@ -34,6 +34,11 @@ type Values []Value
 
 var valueT = reflect.TypeOf(Value{})
 
+// MarshalText encodes a merkle value as hex.
+func (m Value) MarshalText() ([]byte, error) {
+	return hexutil.Bytes(m[:]).MarshalText()
+}
+
 // UnmarshalJSON parses a merkle value in hex syntax.
 func (m *Value) UnmarshalJSON(input []byte) error {
 	return hexutil.UnmarshalFixedJSON(valueT, input, m[:])
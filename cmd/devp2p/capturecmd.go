@@ -0,0 +1,511 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/capture"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	captureCommand = &cli.Command{
+		Name:  "capture",
+		Usage: "Tools for p2p message captures (geth --netcapture)",
+		Subcommands: []*cli.Command{
+			captureInspectCommand,
+			captureReplayCommand,
+		},
+	}
+	captureInspectCommand = &cli.Command{
+		Name:      "inspect",
+		Usage:     "Prints the decoded records of a capture",
+		ArgsUsage: "<capture>",
+		Action:    captureInspect,
+		Flags:     []cli.Flag{capturePeerFlag, captureRawFlag},
+	}
+	captureReplayCommand = &cli.Command{
+		Name:      "replay",
+		Usage:     "Replays the messages received from a captured peer against a node",
+		ArgsUsage: "<capture> <node>",
+		Action:    captureReplay,
+		Flags:     []cli.Flag{capturePeerFlag, captureRealtimeFlag, captureWaitFlag, nodekeyFlag},
+	}
+)
+
+var (
+	capturePeerFlag = &cli.StringFlag{
+		Name:  "peer",
+		Usage: "Hex prefix of the node ID of the captured peer",
+	}
+	captureRawFlag = &cli.BoolFlag{
+		Name:  "raw",
+		Usage: "Print message payloads as hex instead of decoding them",
+	}
+	captureRealtimeFlag = &cli.BoolFlag{
+		Name:  "realtime",
+		Usage: "Keep the original delay between replayed messages",
+	}
+	captureWaitFlag = &cli.DurationFlag{
+		Name:  "wait",
+		Usage: "How long to wait for responses after replaying all messages",
+		Value: 5 * time.Second,
+	}
+)
+
+// Base protocol message codes.
+const (
+	replayHandshakeMsg = 0x00
+	replayDiscMsg      = 0x01
+	replayPingMsg      = 0x02
+	replayPongMsg      = 0x03
+	replayBaseLength   = 16
+)
+
+// captureProtocolLengths are the message space sizes of the protocols which can be
+// replayed. They are needed to compute the message code offsets of the protocols.
+var captureProtocolLengths = map[string]uint64{
+	eth.ProtocolName:  17,
+	snap.ProtocolName: 8,
+}
+
+// openCapture opens a capture file for reading.
+func openCapture(file string) (*capture.Reader, io.Closer, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := capture.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return r, f, nil
+}
+
+// matchPeer reports whether the node ID matches the --peer flag.
+func matchPeer(ctx *cli.Context, id enode.ID) bool {
+	prefix := strings.TrimPrefix(strings.ToLower(ctx.String(capturePeerFlag.Name)), "0x")
+	return strings.HasPrefix(hex.EncodeToString(id[:]), prefix)
+}
+
+func captureInspect(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need capture file as argument")
+	}
+	r, f, err := openCapture(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !matchPeer(ctx, rec.Peer) {
+			continue
+		}
+		prefix := fmt.Sprintf("%s %s", rec.Time.Format("2006-01-02 15:04:05.000000"), rec.Peer.TerminalString())
+		switch rec.Type {
+		case capture.Connect:
+			dir := "outbound"
+			if rec.Inbound {
+				dir = "inbound"
+			}
+			fmt.Printf("%s connect %s name=%q caps=%v node=%s\n", prefix, dir, rec.Name, rec.Caps, rec.Node)
+		case capture.Disconnect:
+			fmt.Printf("%s disconnect reason=%q\n", prefix, rec.Reason)
+		case capture.Message:
+			dir := "->"
+			if rec.Inbound {
+				dir = "<-"
+			}
+			fmt.Printf("%s %s %s\n", prefix, dir, formatCaptureMessage(rec.Protocol, rec.Version, rec.Code, rec.Payload, ctx.Bool(captureRawFlag.Name)))
+		}
+	}
+}
+
+// formatCaptureMessage renders a message of the given protocol for display.
+func formatCaptureMessage(proto string, version uint, code uint64, payload []byte, raw bool) string {
+	name, packet := newCapturePacket(proto, version, code)
+	prefix := fmt.Sprintf("%s/%d %s(%#x)", proto, version, name, code)
+	if raw || packet == nil {
+		return fmt.Sprintf("%s %x", prefix, payload)
+	}
+	if err := rlp.DecodeBytes(payload, packet); err != nil {
+		return fmt.Sprintf("%s %x (decoding failed: %v)", prefix, payload, err)
+	}
+	// Blocks don't have a JSON encoding, show their parts instead.
+	if nb, ok := packet.(*eth.NewBlockPacket); ok {
+		packet = map[string]interface{}{
+			"header":       nb.Block.Header(),
+			"transactions": nb.Block.Transactions(),
+			"uncles":       nb.Block.Uncles(),
+			"td":           (*hexutil.Big)(nb.TD),
+		}
+	}
+	enc, err := json.Marshal(packet)
+	if err != nil {
+		return fmt.Sprintf("%s %x (encoding failed: %v)", prefix, payload, err)
+	}
+	return fmt.Sprintf("%s %s", prefix, enc)
+}
+
+// newCapturePacket returns the name and an empty packet of the given message of
+// the eth or snap protocols. The packet is nil for unknown messages.
+func newCapturePacket(proto string, version uint, code uint64) (string, interface{}) {
+	switch proto {
+	case eth.ProtocolName:
+		switch code {
+		case eth.StatusMsg:
+			return "Status", new(eth.StatusPacket)
+		case eth.NewBlockHashesMsg:
+			return "NewBlockHashes", new(eth.NewBlockHashesPacket)
+		case eth.TransactionsMsg:
+			return "Transactions", new(eth.TransactionsPacket)
+		case eth.GetBlockHeadersMsg:
+			return "GetBlockHeaders", new(eth.GetBlockHeadersPacket)
+		case eth.BlockHeadersMsg:
+			return "BlockHeaders", new(eth.BlockHeadersPacket)
+		case eth.GetBlockBodiesMsg:
+			return "GetBlockBodies", new(eth.GetBlockBodiesPacket)
+		case eth.BlockBodiesMsg:
+			return "BlockBodies", new(eth.BlockBodiesPacket)
+		case eth.NewBlockMsg:
+			return "NewBlock", new(eth.NewBlockPacket)
+		case eth.NewPooledTransactionHashesMsg:
+			if version >= eth.ETH68 {
+				return "NewPooledTransactionHashes", new(eth.NewPooledTransactionHashesPacket68)
+			}
+			return "NewPooledTransactionHashes", new(eth.NewPooledTransactionHashesPacket67)
+		case eth.GetPooledTransactionsMsg:
+			return "GetPooledTransactions", new(eth.GetPooledTransactionsPacket)
+		case eth.PooledTransactionsMsg:
+			return "PooledTransactions", new(eth.PooledTransactionsPacket)
+		case eth.GetReceiptsMsg:
+			return "GetReceipts", new(eth.GetReceiptsPacket)
+		case eth.ReceiptsMsg:
+			return "Receipts", new(eth.ReceiptsPacket)
+		}
+	case snap.ProtocolName:
+		switch code {
+		case snap.GetAccountRangeMsg:
+			return "GetAccountRange", new(snap.GetAccountRangePacket)
+		case snap.AccountRangeMsg:
+			return "AccountRange", new(snap.AccountRangePacket)
+		case snap.GetStorageRangesMsg:
+			return "GetStorageRanges", new(snap.GetStorageRangesPacket)
+		case snap.StorageRangesMsg:
+			return "StorageRanges", new(snap.StorageRangesPacket)
+		case snap.GetByteCodesMsg:
+			return "GetByteCodes", new(snap.GetByteCodesPacket)
+		case snap.ByteCodesMsg:
+			return "ByteCodes", new(snap.ByteCodesPacket)
+		case snap.GetTrieNodesMsg:
+			return "GetTrieNodes", new(snap.GetTrieNodesPacket)
+		case snap.TrieNodesMsg:
+			return "TrieNodes", new(snap.TrieNodesPacket)
+		}
+	}
+	return "Unknown", nil
+}
+
+// captureSession is the recorded session of a single peer.
+type captureSession struct {
+	connect  *capture.Record
+	messages []*capture.Record // messages received from the peer
+}
+
+// readCaptureSession reads the first session of a peer matching the --peer flag.
+func readCaptureSession(ctx *cli.Context, r *capture.Reader) (*captureSession, error) {
+	var session *captureSession
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case session == nil:
+			if rec.Type == capture.Connect && matchPeer(ctx, rec.Peer) {
+				session = &captureSession{connect: rec}
+			}
+		case rec.Peer != session.connect.Peer:
+			continue
+		case rec.Type == capture.Message && rec.Inbound:
+			session.messages = append(session.messages, rec)
+		case rec.Type == capture.Disconnect:
+			return session, nil
+		}
+	}
+	if session == nil {
+		return nil, errors.New("no matching peer session in capture")
+	}
+	return session, nil
+}
+
+// replayProtocol is a protocol negotiated with the replay target.
+type replayProtocol struct {
+	name    string
+	version uint
+	offset  uint64
+}
+
+// matchReplayCaps computes the protocols shared with the remote node and their
+// message code offsets, in the same way as p2p.Server.
+func matchReplayCaps(ours, theirs []p2p.Cap) (map[string]replayProtocol, error) {
+	sorted := make([]p2p.Cap, len(theirs))
+	copy(sorted, theirs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+
+	var (
+		offset  = uint64(replayBaseLength)
+		matched = make(map[string]replayProtocol)
+	)
+	for _, cap := range sorted {
+		for _, our := range ours {
+			if our != cap {
+				continue
+			}
+			length, ok := captureProtocolLengths[cap.Name]
+			if !ok {
+				return nil, fmt.Errorf("can't replay unknown protocol %v", cap)
+			}
+			// If an old version of the protocol matched, replace it.
+			if old, ok := matched[cap.Name]; ok {
+				offset = old.offset
+			}
+			matched[cap.Name] = replayProtocol{cap.Name, cap.Version, offset}
+			offset += length
+		}
+	}
+	return matched, nil
+}
+
+// replayConn is a connection to the replay target.
+type replayConn struct {
+	*rlpx.Conn
+	protos  map[string]replayProtocol
+	writeMu sync.Mutex
+}
+
+// dialReplay connects to the node and runs the devp2p handshake, announcing the
+// given capabilities.
+func dialReplay(n *enode.Node, key *ecdsa.PrivateKey, caps []p2p.Cap) (*replayConn, error) {
+	fd, err := net.Dial("tcp", fmt.Sprintf("%v:%d", n.IP(), n.TCP()))
+	if err != nil {
+		return nil, err
+	}
+	conn := &replayConn{Conn: rlpx.NewConn(fd, n.Pubkey())}
+	if _, err := conn.Handshake(key); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ourHello := &ethtest.Hello{
+		Version: 5,
+		Name:    "devp2p-capture-replay",
+		Caps:    caps,
+		ID:      crypto.FromECDSAPub(&key.PublicKey)[1:],
+	}
+	if err := conn.writeMsg(replayHandshakeMsg, ourHello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	code, data, _, err := conn.Read()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	switch code {
+	case replayHandshakeMsg:
+	case replayDiscMsg:
+		conn.Close()
+		return nil, fmt.Errorf("disconnected during handshake: %v", decodeDiscReason(data))
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("unexpected message %#x during handshake", code)
+	}
+	var theirHello ethtest.Hello
+	if err := rlp.DecodeBytes(data, &theirHello); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("invalid handshake: %v", err)
+	}
+	if theirHello.Version >= 5 {
+		conn.SetSnappy(true)
+	}
+	if conn.protos, err = matchReplayCaps(caps, theirHello.Caps); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *replayConn) writeMsg(code uint64, msg interface{}) error {
+	payload, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	return c.writeRaw(code, payload)
+}
+
+func (c *replayConn) writeRaw(code uint64, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.Write(code, payload)
+	return err
+}
+
+// readLoop prints the messages sent by the target and answers pings. It returns
+// when the connection is closed.
+func (c *replayConn) readLoop() error {
+	for {
+		code, data, _, err := c.Read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case code == replayPingMsg:
+			c.writeMsg(replayPongMsg, []interface{}{})
+		case code == replayDiscMsg:
+			return fmt.Errorf("disconnected: %v", decodeDiscReason(data))
+		case code < replayBaseLength:
+			continue
+		default:
+			proto, ok := c.protocolOf(code)
+			if !ok {
+				fmt.Printf("%s <- unknown message %#x %x\n", time.Now().Format("15:04:05.000000"), code, data)
+				continue
+			}
+			fmt.Printf("%s <- %s\n", time.Now().Format("15:04:05.000000"), formatCaptureMessage(proto.name, proto.version, code-proto.offset, data, false))
+		}
+	}
+}
+
+// protocolOf returns the protocol of an absolute message code.
+func (c *replayConn) protocolOf(code uint64) (replayProtocol, bool) {
+	for _, proto := range c.protos {
+		if code >= proto.offset && code < proto.offset+captureProtocolLengths[proto.name] {
+			return proto, true
+		}
+	}
+	return replayProtocol{}, false
+}
+
+func decodeDiscReason(data []byte) p2p.DiscReason {
+	var reason []p2p.DiscReason
+	if err := rlp.DecodeBytes(data, &reason); err != nil || len(reason) == 0 {
+		return p2p.DiscProtocolError
+	}
+	return reason[0]
+}
+
+func captureReplay(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return errors.New("need capture file and node as arguments")
+	}
+	r, f, err := openCapture(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	session, err := readCaptureSession(ctx, r)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	n, err := parseNode(ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	key, _ := crypto.GenerateKey()
+	if ctx.IsSet(nodekeyFlag.Name) {
+		if key, err = crypto.HexToECDSA(ctx.String(nodekeyFlag.Name)); err != nil {
+			return fmt.Errorf("-%s: %v", nodekeyFlag.Name, err)
+		}
+	}
+
+	// Connect with the capabilities of the captured peer.
+	caps := make([]p2p.Cap, len(session.connect.Caps))
+	for i, c := range session.connect.Caps {
+		caps[i] = p2p.Cap{Name: c.Name, Version: c.Version}
+	}
+	conn, err := dialReplay(n, key, caps)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	fmt.Printf("Replaying %d messages of peer %v (%s)\n", len(session.messages), session.connect.Peer.TerminalString(), session.connect.Name)
+
+	readErr := make(chan error, 1)
+	go func() { readErr <- conn.readLoop() }()
+
+	var last time.Time
+	for _, msg := range session.messages {
+		proto, ok := conn.protos[msg.Protocol]
+		if !ok || proto.version != msg.Version {
+			fmt.Printf("Skipping %s/%d message, protocol not negotiated\n", msg.Protocol, msg.Version)
+			continue
+		}
+		if ctx.Bool(captureRealtimeFlag.Name) && !last.IsZero() {
+			time.Sleep(msg.Time.Sub(last))
+		}
+		last = msg.Time
+		select {
+		case err := <-readErr:
+			return err
+		default:
+		}
+		fmt.Printf("%s -> %s\n", time.Now().Format("15:04:05.000000"), formatCaptureMessage(msg.Protocol, msg.Version, msg.Code, msg.Payload, false))
+		if err := conn.writeRaw(proto.offset+msg.Code, msg.Payload); err != nil {
+			return err
+		}
+	}
+
+	// Wait for the responses.
+	select {
+	case err := <-readErr:
+		return err
+	case <-time.After(ctx.Duration(captureWaitFlag.Name)):
+		conn.writeMsg(replayDiscMsg, []p2p.DiscReason{p2p.DiscRequested})
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestMatchReplayCaps(t *testing.T) {
+	ours := []p2p.Cap{{Name: "eth", Version: 67}, {Name: "eth", Version: 68}, {Name: "snap", Version: 1}}
+	theirs := []p2p.Cap{{Name: "snap", Version: 1}, {Name: "eth", Version: 68}, {Name: "eth", Version: 67}, {Name: "les", Version: 4}}
+	protos, err := matchReplayCaps(ours, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]replayProtocol{
+		"eth":  {"eth", 68, 16},
+		"snap": {"snap", 1, 33},
+	}
+	if !reflect.DeepEqual(protos, want) {
+		t.Fatalf("wrong protocols: %v", protos)
+	}
+
+	// Unknown shared protocols can't be replayed.
+	unknown := []p2p.Cap{{Name: "les", Version: 4}}
+	if _, err := matchReplayCaps(unknown, theirs); err == nil {
+		t.Fatal("expected error for unknown protocol")
+	}
+}
+
+func TestFormatCaptureMessage(t *testing.T) {
+	payload, _ := rlp.EncodeToBytes(&eth.GetBlockHeadersPacket{
+		RequestId:              7,
+		GetBlockHeadersRequest: &eth.GetBlockHeadersRequest{Amount: 3},
+	})
+	out := formatCaptureMessage("eth", 68, eth.GetBlockHeadersMsg, payload, false)
+	if !strings.HasPrefix(out, "eth/68 GetBlockHeaders(0x3) {") || !strings.Contains(out, `"Amount":3`) {
+		t.Fatalf("wrong output: %s", out)
+	}
+	out = formatCaptureMessage("foo", 1, 5, []byte{0xc0}, false)
+	if out != "foo/1 Unknown(0x5) c0" {
+		t.Fatalf("wrong output for unknown protocol: %s", out)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -57,6 +58,7 @@ import (
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -282,6 +284,16 @@ var (
 		Value:    ethconfig.Defaults.TransactionHistory,
 		Category: flags.StateCategory,
 	}
+	HistoryPruneFlag = &cli.BoolFlag{
+		Name:     "history.prune",
+		Usage:    "Prune the block bodies and receipts below the history cutoff on startup",
+		Category: flags.StateCategory,
+	}
+	HistoryCutoffFlag = &cli.Uint64Flag{
+		Name:     "history.cutoff",
+		Usage:    "Block number below which the block bodies and receipts are pruned (default = merge block)",
+		Category: flags.StateCategory,
+	}
 	// Light server and client settings
 	LightServeFlag = &cli.IntFlag{
 		Name:     "light.serve",
@@ -500,6 +512,22 @@ var (
 		Value:    ethconfig.Defaults.Miner.NewPayloadTimeout,
 		Category: flags.MinerCategory,
 	}
+	MinerBundlesFlag = &cli.BoolFlag{
+		Name:     "miner.bundles",
+		Usage:    "Enable private bundle submission (eth_sendBundle, eth_callBundle)",
+		Category: flags.MinerCategory,
+	}
+	MinerTxOrderingFlag = &cli.StringFlag{
+		Name:     "miner.ordering",
+		Usage:    "Transaction ordering policy for block building (price, fifo, fair, priority)",
+		Value:    miner.TxOrderingPrice,
+		Category: flags.MinerCategory,
+	}
+	MinerPriorityAddressesFlag = &cli.StringFlag{
+		Name:     "miner.priority",
+		Usage:    "Comma separated accounts whose transactions are included first by the priority ordering",
+		Category: flags.MinerCategory,
+	}
 
 	// Account settings
 	UnlockedAccountFlag = &cli.StringFlag{
@@ -532,6 +560,18 @@ var (
 		Usage:    "Record information useful for VM and contract debugging",
 		Category: flags.VMCategory,
 	}
+	VMTraceFlag = &cli.StringFlag{
+		Name:     "vmtrace",
+		Usage:    "Name of tracer which should record internal VM operations during block import (costly)",
+		Value:    "",
+		Category: flags.VMCategory,
+	}
+	VMTraceJsonConfigFlag = &cli.StringFlag{
+		Name:     "vmtrace.jsonconfig",
+		Usage:    "Tracer configuration (JSON)",
+		Value:    "",
+		Category: flags.VMCategory,
+	}
 
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
@@ -701,6 +741,33 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	GRPCEnabledFlag = &cli.BoolFlag{
+		Name:     "grpc",
+		Usage:    "Enable the gRPC gateway",
+		Category: flags.APICategory,
+	}
+	GRPCListenAddrFlag = &cli.StringFlag{
+		Name:     "grpc.addr",
+		Usage:    "gRPC gateway listening interface",
+		Value:    node.DefaultGRPCHost,
+		Category: flags.APICategory,
+	}
+	GRPCPortFlag = &cli.IntFlag{
+		Name:     "grpc.port",
+		Usage:    "gRPC gateway listening port",
+		Value:    node.DefaultGRPCPort,
+		Category: flags.APICategory,
+	}
+	GRPCApiFlag = &cli.StringFlag{
+		Name:     "grpc.api",
+		Usage:    "API's offered over the gRPC gateway (default = same as --http.api)",
+		Category: flags.APICategory,
+	}
+	GRPCAuthFlag = &cli.BoolFlag{
+		Name:     "grpc.auth",
+		Usage:    "Require JWT authentication on the gRPC gateway, using the secret set by --authrpc.jwtsecret",
+		Category: flags.APICategory,
+	}
 	ExecFlag = &cli.StringFlag{
 		Name:     "exec",
 		Usage:    "Execute JavaScript statement",
@@ -728,6 +795,27 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCRateLimitFlag = &cli.StringSliceFlag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Per-client call rate limits of HTTP and WS endpoints as method=rate[/burst], method may be a namespace (eth_*) or *",
+		Category: flags.APICategory,
+	}
+	RPCConcurrencyLimitFlag = &cli.StringSliceFlag{
+		Name:     "rpc.concurrencylimit",
+		Usage:    "Per-client limits of concurrently running calls of HTTP and WS endpoints as method=count",
+		Category: flags.APICategory,
+	}
+	RPCLimitKeyFlag = &cli.StringFlag{
+		Name:     "rpc.limitkey",
+		Usage:    "Client identity RPC limits are applied to (ip, jwt, header:<name>)",
+		Value:    "ip",
+		Category: flags.APICategory,
+	}
+	RPCLimitProxiesFlag = &cli.StringFlag{
+		Name:     "rpc.limitproxies",
+		Usage:    "Comma separated list of trusted proxy IPs or CIDR ranges whose requests are keyed by the header:<name> limit key",
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
@@ -737,7 +825,7 @@ var (
 	// Network Settings
 	MaxPeersFlag = &cli.IntFlag{
 		Name:     "maxpeers",
-		Usage:    "Maximum number of network peers (network disabled if set to 0)",
+		Usage:    "Maximum number of network peers (network disabled if set to 0). A limit set through admin_setMaxPeers is stored in the node database and takes precedence",
 		Value:    node.DefaultConfig.P2P.MaxPeers,
 		Category: flags.NetworkingCategory,
 	}
@@ -753,6 +841,11 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	ListenAddr6Flag = &cli.StringFlag{
+		Name:     "listenaddr6",
+		Usage:    "IPv6 network listening address for dual-stack operation (e.g. [::]:30303)",
+		Category: flags.NetworkingCategory,
+	}
 	BootnodesFlag = &cli.StringFlag{
 		Name:     "bootnodes",
 		Usage:    "Comma separated enode URLs for P2P discovery bootstrap",
@@ -798,6 +891,11 @@ var (
 		Usage:    "Restricts network communication to the given IP networks (CIDR masks)",
 		Category: flags.NetworkingCategory,
 	}
+	NetCaptureFlag = &cli.StringFlag{
+		Name:     "netcapture",
+		Usage:    "Records all p2p protocol messages to the given file (inspect with devp2p capture)",
+		Category: flags.NetworkingCategory,
+	}
 	DNSDiscoveryFlag = &cli.StringFlag{
 		Name:     "discovery.dns",
 		Usage:    "Sets DNS discovery entry points (use \"\" to disable DNS)",
@@ -944,6 +1042,36 @@ Please note that --` + MetricsHTTPFlag.Name + ` must be set to start the server.
 		Value:    metrics.DefaultConfig.InfluxDBOrganization,
 		Category: flags.MetricsCategory,
 	}
+
+	// OpenTelemetry tracing settings
+	OTelEnabledFlag = &cli.BoolFlag{
+		Name:     "otel",
+		Usage:    "Enable exporting OpenTelemetry spans of RPC calls, engine API calls and block imports",
+		Category: flags.MetricsCategory,
+	}
+	OTelEndpointFlag = &cli.StringFlag{
+		Name:     "otel.endpoint",
+		Usage:    "URL of the OTLP/HTTP collector spans are exported to",
+		Value:    "http://localhost:4318",
+		Category: flags.MetricsCategory,
+	}
+	OTelServiceNameFlag = &cli.StringFlag{
+		Name:     "otel.service-name",
+		Usage:    "Service name reported in exported spans",
+		Value:    "geth",
+		Category: flags.MetricsCategory,
+	}
+	OTelInstanceIDFlag = &cli.StringFlag{
+		Name:     "otel.instance-id",
+		Usage:    "Instance identifier reported in exported spans",
+		Category: flags.MetricsCategory,
+	}
+	OTelSampleRatioFlag = &cli.Float64Flag{
+		Name:     "otel.sample-ratio",
+		Usage:    "Fraction of traces recorded, traces started by RPC callers follow the caller's decision",
+		Value:    1.0,
+		Category: flags.MetricsCategory,
+	}
 )
 
 var (
@@ -1096,6 +1224,16 @@ func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(DiscoveryPortFlag.Name) {
 		cfg.DiscAddr = fmt.Sprintf(":%d", ctx.Int(DiscoveryPortFlag.Name))
 	}
+	if ctx.IsSet(ListenAddr6Flag.Name) {
+		cfg.ListenAddr6 = ctx.String(ListenAddr6Flag.Name)
+		if ctx.IsSet(DiscoveryPortFlag.Name) {
+			host, _, err := net.SplitHostPort(cfg.ListenAddr6)
+			if err != nil {
+				Fatalf("Option %s: %v", ListenAddr6Flag.Name, err)
+			}
+			cfg.DiscAddr6 = net.JoinHostPort(host, strconv.Itoa(ctx.Int(DiscoveryPortFlag.Name)))
+		}
+	}
 }
 
 // setNAT creates a port mapper from command line flags.
@@ -1173,6 +1311,47 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+	setRPCLimits(ctx, &cfg.RPCLimits)
+}
+
+// setRPCLimits creates the RPC call limits from the set CLI flags.
+func setRPCLimits(ctx *cli.Context, cfg *rpc.LimitConfig) {
+	if ctx.IsSet(RPCLimitKeyFlag.Name) {
+		cfg.ClientKey = ctx.String(RPCLimitKeyFlag.Name)
+	}
+	if ctx.IsSet(RPCLimitProxiesFlag.Name) {
+		cfg.TrustedProxies = SplitAndTrim(ctx.String(RPCLimitProxiesFlag.Name))
+	}
+	for _, spec := range ctx.StringSlice(RPCRateLimitFlag.Name) {
+		pattern, limit, ok := strings.Cut(spec, "=")
+		if !ok {
+			Fatalf("Invalid --%s value %q, want method=rate[/burst]", RPCRateLimitFlag.Name, spec)
+		}
+		rule := rpc.LimitRule{Pattern: pattern}
+		limit, burst, hasBurst := strings.Cut(limit, "/")
+		rate, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			Fatalf("Invalid --%s rate %q: %v", RPCRateLimitFlag.Name, limit, err)
+		}
+		rule.Rate = rate
+		if hasBurst {
+			if rule.Burst, err = strconv.Atoi(burst); err != nil {
+				Fatalf("Invalid --%s burst %q: %v", RPCRateLimitFlag.Name, burst, err)
+			}
+		}
+		cfg.Rules = append(cfg.Rules, rule)
+	}
+	for _, spec := range ctx.StringSlice(RPCConcurrencyLimitFlag.Name) {
+		pattern, limit, ok := strings.Cut(spec, "=")
+		if !ok {
+			Fatalf("Invalid --%s value %q, want method=count", RPCConcurrencyLimitFlag.Name, spec)
+		}
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			Fatalf("Invalid --%s count %q: %v", RPCConcurrencyLimitFlag.Name, limit, err)
+		}
+		cfg.Rules = append(cfg.Rules, rpc.LimitRule{Pattern: pattern, MaxConcurrent: n})
+	}
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1212,6 +1391,25 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setGRPC creates the gRPC gateway configuration from the set command line flags.
+func setGRPC(ctx *cli.Context, cfg *node.Config) {
+	if ctx.Bool(GRPCEnabledFlag.Name) && cfg.GRPCHost == "" {
+		cfg.GRPCHost = "127.0.0.1"
+		if ctx.IsSet(GRPCListenAddrFlag.Name) {
+			cfg.GRPCHost = ctx.String(GRPCListenAddrFlag.Name)
+		}
+	}
+	if ctx.IsSet(GRPCPortFlag.Name) {
+		cfg.GRPCPort = ctx.Int(GRPCPortFlag.Name)
+	}
+	if ctx.IsSet(GRPCApiFlag.Name) {
+		cfg.GRPCModules = SplitAndTrim(ctx.String(GRPCApiFlag.Name))
+	}
+	if ctx.IsSet(GRPCAuthFlag.Name) {
+		cfg.GRPCAuth = ctx.Bool(GRPCAuthFlag.Name)
+	}
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -1399,6 +1597,9 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NetRestrict = list
 	}
+	if ctx.IsSet(NetCaptureFlag.Name) {
+		cfg.CaptureFile = ctx.String(NetCaptureFlag.Name)
+	}
 
 	if ctx.Bool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
@@ -1417,6 +1618,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setHTTP(ctx, cfg)
 	setGraphQL(ctx, cfg)
 	setWS(ctx, cfg)
+	setGRPC(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 	SetDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
@@ -1575,6 +1777,26 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerNewPayloadTimeout.Name) {
 		cfg.NewPayloadTimeout = ctx.Duration(MinerNewPayloadTimeout.Name)
 	}
+	if ctx.IsSet(MinerBundlesFlag.Name) {
+		cfg.Bundles = ctx.Bool(MinerBundlesFlag.Name)
+	}
+	if ctx.IsSet(MinerPriorityAddressesFlag.Name) {
+		cfg.PriorityAddresses = cfg.PriorityAddresses[:0]
+		for _, account := range SplitAndTrim(ctx.String(MinerPriorityAddressesFlag.Name)) {
+			if !common.IsHexAddress(account) {
+				Fatalf("Invalid priority account: %s", account)
+			}
+			cfg.PriorityAddresses = append(cfg.PriorityAddresses, common.HexToAddress(account))
+		}
+	}
+	if ctx.IsSet(MinerTxOrderingFlag.Name) {
+		cfg.TxOrdering = ctx.String(MinerTxOrderingFlag.Name)
+	}
+	if cfg.TxOrdering != "" {
+		if _, err := miner.NewTxOrderingPolicy(cfg.TxOrdering, cfg.PriorityAddresses); err != nil {
+			Fatalf("Invalid transaction ordering: %v", err)
+		}
+	}
 }
 
 func setRequiredBlocks(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1718,6 +1940,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateSchemeFlag.Name) {
 		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
 	}
+	if ctx.IsSet(HistoryPruneFlag.Name) {
+		cfg.HistoryPrune = ctx.Bool(HistoryPruneFlag.Name)
+	}
+	if ctx.IsSet(HistoryCutoffFlag.Name) {
+		cfg.HistoryCutoff = ctx.Uint64(HistoryCutoffFlag.Name)
+	}
 	// Parse transaction history flag, if user is still using legacy config
 	// file with 'TxLookupLimit' configured, copy the value to 'TransactionHistory'.
 	if cfg.TransactionHistory == ethconfig.Defaults.TransactionHistory && cfg.TxLookupLimit != ethconfig.Defaults.TxLookupLimit {
@@ -1765,6 +1993,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.Bool(VMEnableDebugFlag.Name)
 	}
+	// VM tracing config.
+	if ctx.IsSet(VMTraceFlag.Name) {
+		if name := ctx.String(VMTraceFlag.Name); name != "" {
+			cfg.VMTrace = name
+			cfg.VMTraceJsonConfig = ctx.String(VMTraceJsonConfigFlag.Name)
+		}
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -1967,6 +2202,24 @@ func RegisterFullSyncTester(stack *node.Node, eth *eth.Ethereum, target common.H
 	log.Info("Registered full-sync tester", "hash", target)
 }
 
+// SetupTelemetry installs the OpenTelemetry span exporter if enabled, flushing
+// it when the node stops.
+func SetupTelemetry(ctx *cli.Context, stack *node.Node) {
+	if !ctx.Bool(OTelEnabledFlag.Name) {
+		return
+	}
+	provider, err := telemetry.Setup(telemetry.Config{
+		Endpoint:    ctx.String(OTelEndpointFlag.Name),
+		ServiceName: ctx.String(OTelServiceNameFlag.Name),
+		InstanceID:  ctx.String(OTelInstanceIDFlag.Name),
+		SampleRatio: ctx.Float64(OTelSampleRatioFlag.Name),
+	})
+	if err != nil {
+		Fatalf("Failed to set up OpenTelemetry tracing: %v", err)
+	}
+	stack.RegisterLifecycle(provider)
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -2187,6 +2440,19 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		cache.TrieDirtyLimit = ctx.Int(CacheFlag.Name) * ctx.Int(CacheGCFlag.Name) / 100
 	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.Bool(VMEnableDebugFlag.Name)}
+	if ctx.IsSet(VMTraceFlag.Name) {
+		if name := ctx.String(VMTraceFlag.Name); name != "" {
+			var config json.RawMessage
+			if ctx.IsSet(VMTraceJsonConfigFlag.Name) {
+				config = json.RawMessage(ctx.String(VMTraceJsonConfigFlag.Name))
+			}
+			t, err := tracers.LiveDirectory.New(name, config)
+			if err != nil {
+				Fatalf("Failed to create tracer %q: %v", name, err)
+			}
+			vmcfg.Tracer = t
+		}
+	}
 
 	// Disable transaction indexing/unindexing by default.
 	chain, err := core.NewBlockChain(chainDb, cache, gspec, nil, engine, vmcfg, nil, nil)
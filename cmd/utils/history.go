@@ -0,0 +1,344 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// eraNetworkName returns the network name used for naming Era1 files of the
+// given chain.
+func eraNetworkName(config *params.ChainConfig) string {
+	if name, ok := params.NetworkNames[config.ChainID.String()]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ExportHistory exports the canonical chain from block first to last into Era1
+// archives of step blocks each, written to dir. The block data is copied raw
+// out of the database, which serves frozen blocks straight from the ancient
+// store. A checksums.txt file holding the sha256 of each archive is written
+// alongside.
+func ExportHistory(bc *core.BlockChain, db ethdb.Reader, dir string, first, last, step uint64) error {
+	log.Info("Exporting blockchain history", "dir", dir)
+	if step == 0 || step > era.MaxEra1Size {
+		return fmt.Errorf("invalid epoch size %d", step)
+	}
+	if first%step != 0 {
+		return fmt.Errorf("first block %d is not aligned to the epoch size %d", first, step)
+	}
+	if head := bc.CurrentSnapBlock().Number.Uint64(); head < last {
+		log.Warn("Last block beyond head, setting last = head", "head", head, "last", last)
+		last = head
+	}
+	if first > last {
+		return fmt.Errorf("invalid range: first %d > last %d", first, last)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	var (
+		network   = eraNetworkName(bc.Config())
+		start     = time.Now()
+		reported  = time.Now()
+		checksums []string
+	)
+	for i := first; i <= last; i += step {
+		end := i + step - 1
+		if end > last {
+			end = last
+		}
+		checksum, err := exportEpoch(bc.Config(), db, dir, network, i, end, int(i/step))
+		if err != nil {
+			return err
+		}
+		checksums = append(checksums, checksum)
+
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting blocks", "exported", i, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checksums.txt"), []byte(strings.Join(checksums, "\n")), os.ModePerm); err != nil {
+		return err
+	}
+	log.Info("Exported blockchain history", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// exportEpoch writes the blocks [first, last] into a single Era1 archive and
+// returns the checksum of the file.
+func exportEpoch(config *params.ChainConfig, db ethdb.Reader, dir, network string, first, last uint64, epoch int) (string, error) {
+	filename := filepath.Join(dir, era.Filename(network, epoch, common.Hash{}))
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", fmt.Errorf("could not create era file: %w", err)
+	}
+	defer f.Close()
+
+	w := era.NewBuilder(f)
+	for n := first; n <= last; n++ {
+		hash := rawdb.ReadCanonicalHash(db, n)
+		if hash == (common.Hash{}) {
+			return "", fmt.Errorf("export failed on #%d: not found", n)
+		}
+		header := rawdb.ReadHeaderRLP(db, hash, n)
+		body := rawdb.ReadBodyRLP(db, hash, n)
+		if len(header) == 0 || len(body) == 0 {
+			return "", fmt.Errorf("export failed on #%d: missing block data", n)
+		}
+		td := rawdb.ReadTd(db, hash, n)
+		if td == nil {
+			return "", fmt.Errorf("export failed on #%d: total difficulty not found", n)
+		}
+		h := new(types.Header)
+		if err := rlp.DecodeBytes(header, h); err != nil {
+			return "", fmt.Errorf("export failed on #%d: invalid header: %w", n, err)
+		}
+		receipts := rawdb.ReadReceipts(db, hash, n, h.Time, config)
+		if receipts == nil {
+			if rawdb.ReadRawReceipts(db, hash, n) == nil {
+				return "", fmt.Errorf("export failed on #%d: receipts not found", n)
+			}
+			receipts = types.Receipts{}
+		}
+		rr, err := rlp.EncodeToBytes(receipts)
+		if err != nil {
+			return "", err
+		}
+		if err := w.AddRLP(header, body, rr, n, hash, td, h.Difficulty); err != nil {
+			return "", err
+		}
+	}
+	root, err := w.Finalize()
+	if err != nil {
+		return "", fmt.Errorf("export failed to finalize %d: %w", epoch, err)
+	}
+	// Set correct filename with root.
+	if err := os.Rename(filename, filepath.Join(dir, era.Filename(network, epoch, root))); err != nil {
+		return "", err
+	}
+	// Compute checksum of entire Era1.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("unable to calculate checksum: %w", err)
+	}
+	return common.BytesToHash(h.Sum(nil)).Hex(), nil
+}
+
+// ImportHistory imports Era1 archives from dir into the chain. Every archive
+// is checked against checksums.txt, and its accumulator, block bodies and
+// receipts are verified against the contained headers before insertion.
+// Importing is only supported into a chain which is still at genesis.
+func ImportHistory(chain *core.BlockChain, dir string) error {
+	if chain.CurrentSnapBlock().Number.BitLen() != 0 {
+		return errors.New("history import only supported when starting from genesis")
+	}
+	network := eraNetworkName(chain.Config())
+	entries, err := era.ReadDir(dir, network)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", dir, err)
+	}
+	checksums, err := readList(filepath.Join(dir, "checksums.txt"))
+	if err != nil {
+		return fmt.Errorf("unable to read checksums.txt: %w", err)
+	}
+	if len(checksums) != len(entries) {
+		return fmt.Errorf("expected equal number of checksums and entries, have: %d checksums, %d entries", len(checksums), len(entries))
+	}
+	var (
+		start    = time.Now()
+		reported = time.Now()
+		imported = 0
+	)
+	for i, filename := range entries {
+		n, err := importEpoch(chain, filepath.Join(dir, filename), checksums[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		imported += n
+
+		// Give the user some feedback that something is happening.
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Importing Era files", "head", chain.CurrentSnapBlock().Number, "imported", imported, "elapsed", common.PrettyDuration(time.Since(start)))
+			imported = 0
+			reported = time.Now()
+		}
+	}
+	log.Info("Imported blockchain history", "head", chain.CurrentSnapBlock().Number, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// importEpoch verifies and imports a single Era1 archive, returning the number
+// of blocks inserted.
+func importEpoch(chain *core.BlockChain, filename string, checksum string) (int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("unable to open era: %w", err)
+	}
+	defer f.Close()
+
+	// Validate checksum.
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, fmt.Errorf("unable to recalculate checksum: %w", err)
+	}
+	if have := common.BytesToHash(h.Sum(nil)).Hex(); have != checksum {
+		return 0, fmt.Errorf("checksum mismatch: have %s, want %s", have, checksum)
+	}
+	e, err := era.From(f)
+	if err != nil {
+		return 0, fmt.Errorf("error opening era: %w", err)
+	}
+	it, err := era.NewIterator(e)
+	if err != nil {
+		return 0, fmt.Errorf("error making era reader: %w", err)
+	}
+	var (
+		blocks   types.Blocks
+		receipts []types.Receipts
+		hashes   []common.Hash
+		tds      []*big.Int
+	)
+	for it.Next() {
+		block, receipt, err := it.BlockAndReceipts()
+		if err != nil {
+			return 0, fmt.Errorf("error reading block %d: %w", it.Number(), err)
+		}
+		td, err := it.TotalDifficulty()
+		if err != nil {
+			return 0, fmt.Errorf("error reading total difficulty %d: %w", it.Number(), err)
+		}
+		if block.NumberU64() != it.Number() {
+			return 0, fmt.Errorf("block number mismatch: have %d, want %d", block.NumberU64(), it.Number())
+		}
+		if err := verifyBlockContents(block, receipt); err != nil {
+			return 0, fmt.Errorf("invalid block %d: %w", it.Number(), err)
+		}
+		hashes = append(hashes, block.Hash())
+		tds = append(tds, td)
+
+		if block.NumberU64() == 0 {
+			if block.Hash() != chain.Genesis().Hash() {
+				return 0, fmt.Errorf("genesis mismatch: have %x, want %x", block.Hash(), chain.Genesis().Hash())
+			}
+			continue // skip genesis
+		}
+		blocks = append(blocks, block)
+		receipts = append(receipts, receipt)
+	}
+	if err := it.Error(); err != nil {
+		return 0, fmt.Errorf("error iterating era: %w", err)
+	}
+	// Verify the accumulator before touching the database.
+	want, err := e.Accumulator()
+	if err != nil {
+		return 0, fmt.Errorf("error reading accumulator: %w", err)
+	}
+	if have, err := era.ComputeAccumulator(hashes, tds); err != nil {
+		return 0, fmt.Errorf("error computing accumulator: %w", err)
+	} else if have != want {
+		return 0, fmt.Errorf("accumulator mismatch: have %x, want %x", have, want)
+	}
+	if len(blocks) == 0 {
+		return 0, nil
+	}
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if _, err := chain.InsertHeaderChain(headers); err != nil {
+		return 0, fmt.Errorf("error inserting headers: %w", err)
+	}
+	// The total difficulties recorded in the archive must match the ones
+	// computed by the header chain.
+	for i, block := range blocks {
+		if td := chain.GetTd(block.Hash(), block.NumberU64()); td == nil || td.Cmp(tds[len(tds)-len(blocks)+i]) != 0 {
+			return 0, fmt.Errorf("total difficulty mismatch for block %d", block.NumberU64())
+		}
+	}
+	if _, err := chain.InsertReceiptChain(blocks, receipts, math.MaxUint64); err != nil {
+		return 0, fmt.Errorf("error inserting bodies: %w", err)
+	}
+	return len(blocks), nil
+}
+
+// verifyBlockContents checks that the transactions, uncles, withdrawals and
+// receipts of a block match the roots committed to in its header.
+func verifyBlockContents(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
+	}
+	if header.WithdrawalsHash != nil {
+		if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+			return fmt.Errorf("withdrawals root hash mismatch: have %x, want %x", hash, *header.WithdrawalsHash)
+		}
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != header.ReceiptHash {
+		return fmt.Errorf("receipt root hash mismatch: have %x, want %x", hash, header.ReceiptHash)
+	}
+	return nil
+}
+
+// readList reads a newline-separated list from the given file.
+func readList(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		list    []string
+		scanner = bufio.NewScanner(f)
+	)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			list = append(list, line)
+		}
+	}
+	return list, scanner.Err()
+}
@@ -0,0 +1,219 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	count uint64 = 128
+	step  uint64 = 16
+)
+
+func TestHistoryImportAndExport(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{address: {Balance: big.NewInt(1000000000000000000)}},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	// Generate chain.
+	db, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), int(count), func(i int, g *core.BlockGen) {
+		if i == 0 {
+			return
+		}
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     uint64(i - 1),
+			GasTipCap: common.Big0,
+			GasFeeCap: g.PrevBlock(0).BaseFee(),
+			Gas:       50000,
+			To:        &common.Address{0xaa},
+			Value:     big.NewInt(int64(i)),
+			Data:      nil,
+		})
+		if err != nil {
+			t.Fatalf("error creating tx: %v", err)
+		}
+		g.AddTx(tx)
+	})
+	// Initialize BlockChain.
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+	// Make temp directory for era files.
+	dir := t.TempDir()
+
+	// Export history to temp directory.
+	if err := ExportHistory(chain, db, dir, 0, count, step); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+	// Read checksums.
+	b, err := os.ReadFile(filepath.Join(dir, "checksums.txt"))
+	if err != nil {
+		t.Fatalf("failed to read checksums: %v", err)
+	}
+	checksums := strings.Split(string(b), "\n")
+
+	// Verify each Era.
+	entries, _ := era.ReadDir(dir, eraNetworkName(genesis.Config))
+	if len(entries) != int(count/step)+1 || len(entries) != len(checksums) {
+		t.Fatalf("unexpected number of era files: have %d, checksums %d", len(entries), len(checksums))
+	}
+	for i, filename := range entries {
+		func() {
+			f, err := os.Open(filepath.Join(dir, filename))
+			if err != nil {
+				t.Fatalf("error opening era file: %v", err)
+			}
+			defer f.Close()
+
+			e, err := era.From(f)
+			if err != nil {
+				t.Fatalf("error opening era: %v", err)
+			}
+			it, err := era.NewIterator(e)
+			if err != nil {
+				t.Fatalf("error making era reader: %v", err)
+			}
+			for j := 0; it.Next(); j++ {
+				n := i*int(step) + j
+				block, receipts, err := it.BlockAndReceipts()
+				if err != nil {
+					t.Fatalf("error reading block entry %d: %v", n, err)
+				}
+				if want := chain.GetBlockByNumber(uint64(n)); want.Hash() != block.Hash() {
+					t.Fatalf("block %d mismatch: have %x, want %x", n, block.Hash(), want.Hash())
+				}
+				if want := chain.GetReceiptsByHash(block.Hash()); len(want) != len(receipts) {
+					t.Fatalf("receipts %d mismatch: have %d, want %d", n, len(receipts), len(want))
+				}
+				td, err := it.TotalDifficulty()
+				if err != nil {
+					t.Fatalf("error reading td: %v", err)
+				}
+				if want := chain.GetTd(block.Hash(), block.NumberU64()); want.Cmp(td) != 0 {
+					t.Fatalf("td %d mismatch: have %v, want %v", n, td, want)
+				}
+			}
+			if err := it.Error(); err != nil {
+				t.Fatalf("error iterating era: %v", err)
+			}
+		}()
+	}
+	// Now import Era.
+	db2, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("error creating database: %v", err)
+	}
+	defer db2.Close()
+
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer imported.Stop()
+
+	if err := ImportHistory(imported, dir); err != nil {
+		t.Fatalf("failed to import chain: %v", err)
+	}
+	if have, want := imported.CurrentSnapBlock().Hash(), chain.CurrentBlock().Hash(); have != want {
+		t.Fatalf("imported chain does not match expected, have (%d, %s) want (%d, %s)", imported.CurrentSnapBlock().Number, have, chain.CurrentBlock().Number, want)
+	}
+	for n := uint64(1); n <= count; n++ {
+		want := chain.GetBlockByNumber(n)
+		have := imported.GetBlockByNumber(n)
+		if have == nil || have.Hash() != want.Hash() {
+			t.Fatalf("block %d missing or mismatched after import", n)
+		}
+		if len(imported.GetReceiptsByHash(want.Hash())) != len(want.Transactions()) {
+			t.Fatalf("receipts %d missing after import", n)
+		}
+	}
+	// Importing the history again must be refused.
+	if err := ImportHistory(imported, dir); err == nil {
+		t.Fatal("expected error importing into non-empty chain")
+	}
+}
+
+func TestHistoryImportCorrupted(t *testing.T) {
+	var (
+		genesis   = &core.Genesis{Config: params.TestChainConfig}
+		db, bs, _ = core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 20, nil)
+	)
+	chain, err := core.NewBlockChain(db, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(bs); err != nil {
+		t.Fatalf("error inserting chain: %v", err)
+	}
+	dir := t.TempDir()
+	if err := ExportHistory(chain, db, dir, 0, 20, 8); err != nil {
+		t.Fatalf("error exporting history: %v", err)
+	}
+	// Flip a byte in the middle of the second archive.
+	entries, _ := era.ReadDir(dir, eraNetworkName(genesis.Config))
+	path := filepath.Join(dir, entries[1])
+	data, _ := os.ReadFile(path)
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(path, data, 0644)
+
+	db2, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("error creating database: %v", err)
+	}
+	defer db2.Close()
+
+	imported, err := core.NewBlockChain(db2, nil, genesis, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("unable to initialize chain: %v", err)
+	}
+	defer imported.Stop()
+	err = ImportHistory(imported, dir)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	// The first archive was imported before the corruption was detected.
+	if have := imported.CurrentSnapBlock().Number.Uint64(); have != 7 {
+		t.Fatalf("unexpected head after partial import: have %d, want 7", have)
+	}
+}
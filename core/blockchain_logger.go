@@ -0,0 +1,61 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlockchainLogger is used to collect traces during chain processing, i.e. a
+// live tracer. On top of the EVM execution traces, it is notified about the
+// blocks and transactions being processed as well as all the state changes
+// made along the way, including the ones made outside of the EVM such as
+// gas purchases, block rewards and withdrawals.
+//
+// A BlockchainLogger is installed by passing it as the tracer of the vm.Config
+// given to NewBlockChain. Note that reference types are actual VM and chain
+// data structures; make copies if you need to retain them beyond the current
+// call.
+type BlockchainLogger interface {
+	vm.EVMLogger
+	tracing.StateLogger
+
+	// OnBlockchainInit is called once the chain is initialized, before any
+	// block is processed.
+	OnBlockchainInit(chainConfig *params.ChainConfig)
+	// OnBlockStart is called before executing a block. td is the total
+	// difficulty of the chain including the block. finalized and safe are
+	// the current finalized and safe heads, they may be nil.
+	OnBlockStart(block *types.Block, td *big.Int, finalized *types.Header, safe *types.Header)
+	// OnBlockEnd is called after executing a block and receives the error
+	// that occurred during processing, validation or writing, if any.
+	OnBlockEnd(err error)
+	// OnGenesisBlock is called when the chain is started at the genesis block,
+	// along with the genesis allocation.
+	OnGenesisBlock(genesis *types.Block, alloc GenesisAlloc)
+	// OnTxStart is called before executing a transaction of a block.
+	OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address)
+	// OnTxEnd is called after executing a transaction of a block. It receives
+	// the receipt, or the error if the transaction was invalid.
+	OnTxEnd(receipt *types.Receipt, err error)
+}
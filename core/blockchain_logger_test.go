@@ -0,0 +1,159 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// recordingLogger is a BlockchainLogger which records the hooks invoked
+// during block processing.
+type recordingLogger struct {
+	initialized bool
+	genesis     *types.Block
+	blocks      []uint64
+	blockErrs   []error
+	txs         []common.Hash
+	receipts    []*types.Receipt
+	reasons     map[tracing.BalanceChangeReason]int
+	nonces      int
+	logs        int
+	calls       int
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{reasons: make(map[tracing.BalanceChangeReason]int)}
+}
+
+func (l *recordingLogger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	l.calls++
+}
+func (l *recordingLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+func (l *recordingLogger) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+func (l *recordingLogger) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+func (l *recordingLogger) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+func (l *recordingLogger) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (l *recordingLogger) CaptureTxStart(gasLimit uint64)                       {}
+func (l *recordingLogger) CaptureTxEnd(restGas uint64)                          {}
+
+func (l *recordingLogger) OnBlockchainInit(chainConfig *params.ChainConfig) { l.initialized = true }
+func (l *recordingLogger) OnBlockStart(b *types.Block, td *big.Int, finalized, safe *types.Header) {
+	l.blocks = append(l.blocks, b.NumberU64())
+}
+func (l *recordingLogger) OnBlockEnd(err error) { l.blockErrs = append(l.blockErrs, err) }
+func (l *recordingLogger) OnGenesisBlock(b *types.Block, alloc GenesisAlloc) {
+	l.genesis = b
+}
+func (l *recordingLogger) OnTxStart(env *vm.EVM, tx *types.Transaction, from common.Address) {
+	l.txs = append(l.txs, tx.Hash())
+}
+func (l *recordingLogger) OnTxEnd(receipt *types.Receipt, err error) {
+	l.receipts = append(l.receipts, receipt)
+}
+func (l *recordingLogger) OnBalanceChange(a common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	l.reasons[reason]++
+}
+func (l *recordingLogger) OnNonceChange(a common.Address, prev, new uint64) { l.nonces++ }
+func (l *recordingLogger) OnCodeChange(a common.Address, prevCodeHash common.Hash, prev []byte, codeHash common.Hash, code []byte) {
+}
+func (l *recordingLogger) OnStorageChange(a common.Address, k, prev, new common.Hash) {}
+func (l *recordingLogger) OnLog(log *types.Log)                                       { l.logs++ }
+
+// Tests that a live tracer passed in through the vm config is notified about
+// block and transaction boundaries, as well as the state changes made during
+// block import.
+func TestBlockchainLogger(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.HexToAddress("0x000000000000000000000000000000000000aaaa")
+		funds  = big.NewInt(params.Ether)
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: funds}}}
+		signer = types.LatestSigner(gspec.Config)
+		logger = newRecordingLogger()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), to, big.NewInt(1000), params.TxGas, new(big.Int).Mul(b.header.BaseFee, common.Big2), nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{Tracer: logger}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.vmConfig.Tracer != nil {
+		t.Fatal("live tracer leaked into the shared vm config")
+	}
+	if !logger.initialized {
+		t.Fatal("tracer not initialized")
+	}
+	if logger.genesis == nil || logger.genesis.Hash() != chain.Genesis().Hash() {
+		t.Fatal("genesis block not reported")
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if len(logger.blocks) != 2 || logger.blocks[0] != 1 || logger.blocks[1] != 2 {
+		t.Fatalf("unexpected blocks traced: %v", logger.blocks)
+	}
+	for i, err := range logger.blockErrs {
+		if err != nil {
+			t.Fatalf("block %d: unexpected error reported: %v", i, err)
+		}
+	}
+	if len(logger.txs) != 2 || len(logger.receipts) != 2 {
+		t.Fatalf("unexpected txs traced: have %d txs, %d receipts", len(logger.txs), len(logger.receipts))
+	}
+	for i, block := range blocks {
+		if have, want := logger.txs[i], block.Transactions()[0].Hash(); have != want {
+			t.Fatalf("tx %d: hash mismatch: have %x, want %x", i, have, want)
+		}
+		if logger.receipts[i] == nil || logger.receipts[i].TxHash != logger.txs[i] {
+			t.Fatalf("tx %d: missing receipt", i)
+		}
+	}
+	if logger.calls != 2 {
+		t.Fatalf("unexpected number of evm calls traced: have %d, want 2", logger.calls)
+	}
+	if logger.nonces != 2 {
+		t.Fatalf("unexpected number of nonce changes: have %d, want 2", logger.nonces)
+	}
+	for reason, want := range map[tracing.BalanceChangeReason]int{
+		tracing.BalanceDecreaseGasBuy:               2,
+		tracing.BalanceChangeTransfer:               4, // debit sender, credit recipient
+		tracing.BalanceIncreaseRewardTransactionFee: 2,
+		tracing.BalanceIncreaseRewardMineBlock:      2,
+	} {
+		if have := logger.reasons[reason]; have != want {
+			t.Errorf("unexpected number of balance changes for %v: have %d, want %d", reason, have, want)
+		}
+	}
+}
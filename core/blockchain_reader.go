@@ -163,6 +163,11 @@ func (bc *BlockChain) GetBlock(hash common.Hash, number uint64) *types.Block {
 	}
 	block := rawdb.ReadBlock(bc.db, hash, number)
 	if block == nil {
+		// The genesis body might be pruned along with the chain history,
+		// serve the block restored from the header instead.
+		if number == 0 && bc.genesisBlock != nil && bc.genesisBlock.Hash() == hash {
+			return bc.genesisBlock
+		}
 		return nil
 	}
 	// Cache the found block for next time and return
@@ -382,6 +387,18 @@ func (bc *BlockChain) TxLookupLimit() uint64 {
 	return bc.txLookupLimit
 }
 
+// HistoryTail returns the oldest block whose body and receipts are retained,
+// the chain history below it has been pruned.
+func (bc *BlockChain) HistoryTail() uint64 {
+	return bc.historyTail
+}
+
+// HistoryPruned reports whether the body and receipts of the block with the
+// given number have been pruned.
+func (bc *BlockChain) HistoryPruned(number uint64) bool {
+	return number < bc.historyTail
+}
+
 // TrieDB retrieves the low level trie database used for data storage.
 func (bc *BlockChain) TrieDB() *trie.Database {
 	return bc.triedb
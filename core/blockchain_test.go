@@ -17,6 +17,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/big"
@@ -40,6 +41,10 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // So we can deterministically seed different blockchains
@@ -2238,9 +2243,9 @@ func testSideImport(t *testing.T, numCanonBlocksInSidechain, blocksBetweenCommon
 //	[ Cn, Cn+1, Cc, Sn+3 ... Sm]
 //	^    ^    ^  pruned
 func TestPrunedImportSide(t *testing.T) {
-	//glogger := log.NewGlogHandler(log.StreamHandler(os.Stdout, log.TerminalFormat(false)))
+	//glogger := log.NewGlogHandler(log.NewTerminalHandler(os.Stdout, false))
 	//glogger.Verbosity(3)
-	//log.Root().SetHandler(log.Handler(glogger))
+	//log.SetDefault(log.NewLogger(glogger))
 	testSideImport(t, 3, 3, -1)
 	testSideImport(t, 3, -3, -1)
 	testSideImport(t, 10, 0, -1)
@@ -2249,9 +2254,9 @@ func TestPrunedImportSide(t *testing.T) {
 }
 
 func TestPrunedImportSideWithMerging(t *testing.T) {
-	//glogger := log.NewGlogHandler(log.StreamHandler(os.Stdout, log.TerminalFormat(false)))
+	//glogger := log.NewGlogHandler(log.NewTerminalHandler(os.Stdout, false))
 	//glogger.Verbosity(3)
-	//log.Root().SetHandler(log.Handler(glogger))
+	//log.SetDefault(log.NewLogger(glogger))
 	testSideImport(t, 3, 3, 0)
 	testSideImport(t, 3, -3, 0)
 	testSideImport(t, 10, 0, 0)
@@ -3913,7 +3918,7 @@ func TestSetCanonical(t *testing.T) {
 }
 
 func testSetCanonical(t *testing.T, scheme string) {
-	//log.Root().SetHandler(log.LvlFilterHandler(log.LvlDebug, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	//log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlDebug, true)))
 
 	var (
 		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
@@ -4716,3 +4721,93 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that block imports are traced, with the spans of the import nested in
+// the span of the caller.
+func TestInsertBlockSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = ethash.NewFaker()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 2, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, gen.header.BaseFee, nil), signer, key)
+		gen.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "root")
+	if err := chain.InsertBlockWithoutSetHeadContext(ctx, blocks[0]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if _, err := chain.SetCanonicalContext(ctx, blocks[0]); err != nil {
+		t.Fatalf("failed to set head: %v", err)
+	}
+	// Blocks failing validation are reported on the spans of the import.
+	header := blocks[1].Header()
+	header.Root = common.Hash{0x01}
+	bad := types.NewBlockWithHeader(header).WithBody(blocks[1].Transactions(), nil)
+	if err := chain.InsertBlockWithoutSetHeadContext(ctx, bad); err == nil {
+		t.Fatal("block with invalid state root imported")
+	}
+	root.End()
+
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	parent := func(name string, index int) string {
+		if len(spans[name]) <= index {
+			t.Fatalf("span %s #%d not recorded", name, index)
+		}
+		id := spans[name][index].Parent().SpanID()
+		for name, list := range spans {
+			for _, span := range list {
+				if span.SpanContext().SpanID() == id {
+					return name
+				}
+			}
+		}
+		return ""
+	}
+	for _, tt := range []struct {
+		name   string
+		index  int
+		parent string
+	}{
+		{"BlockChain.insertChain", 0, "root"},
+		{"BlockChain.processBlock", 0, "BlockChain.insertChain"},
+		{"execute", 0, "BlockChain.processBlock"},
+		{"validate", 0, "BlockChain.processBlock"},
+		{"commit", 0, "BlockChain.processBlock"},
+		{"BlockChain.SetCanonical", 0, "root"},
+		{"BlockChain.insertChain", 1, "root"},
+		{"BlockChain.processBlock", 1, "BlockChain.insertChain"},
+	} {
+		if have := parent(tt.name, tt.index); have != tt.parent {
+			t.Errorf("span %s #%d: parent mismatch: have %q, want %q", tt.name, tt.index, have, tt.parent)
+		}
+	}
+	if have := spans["BlockChain.processBlock"][0].Status().Code; have != codes.Unset {
+		t.Errorf("valid block import status mismatch: have %v, want %v", have, codes.Unset)
+	}
+	for _, name := range []string{"BlockChain.processBlock", "BlockChain.insertChain", "validate"} {
+		list := spans[name]
+		if have := list[len(list)-1].Status().Code; have != codes.Error {
+			t.Errorf("span %s of invalid block import status mismatch: have %v, want %v", name, have, codes.Error)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ErrHistoryPruned is returned if the requested block body or receipts are
+// not available because the chain history below them has been pruned.
+var ErrHistoryPruned = errors.New("history pruned")
+
+// ErrNotMerged is returned by MergeHistoryCutoff if the chain hasn't passed the
+// merge transition yet, so there's no default pruning point.
+var ErrNotMerged = errors.New("chain has not transitioned to proof-of-stake yet")
+
+// ErrHistoryNotFrozen is returned by PruneChainHistory if the blocks below the
+// requested cutoff haven't all been moved into the ancient store yet.
+var ErrHistoryNotFrozen = errors.New("chain history is not frozen yet")
+
+// MergeHistoryCutoff returns the default chain history pruning point, which is
+// the last block of the proof-of-work chain. The bodies and receipts below it
+// are not needed anymore to follow the proof-of-stake chain.
+//
+// The point is located by a binary search on the canonical headers for the first
+// block with zero difficulty, an error is returned if the chain hasn't passed
+// the merge transition yet (ErrNotMerged).
+func MergeHistoryCutoff(db ethdb.Reader) (uint64, error) {
+	head := rawdb.ReadHeadHeader(db)
+	if head == nil || head.Difficulty.Sign() != 0 {
+		return 0, ErrNotMerged
+	}
+	var (
+		lo = uint64(0)
+		hi = head.Number.Uint64()
+	)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, mid), mid)
+		if header == nil {
+			return 0, fmt.Errorf("canonical header #%d is missing", mid)
+		}
+		if header.Difficulty.Sign() == 0 {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	// The chain is proof-of-stake since genesis, nothing to prune.
+	if lo == 0 {
+		return 0, nil
+	}
+	return lo - 1, nil
+}
+
+// PruneChainHistory removes the block bodies and receipts below the given block
+// number from the ancient store, leaving the headers intact. The transaction
+// indexes of the pruned blocks are deleted as well and the new history tail is
+// recorded in the database.
+//
+// The blocks to be pruned must already be moved into the ancient store.
+func PruneChainHistory(db ethdb.Database, cutoff uint64) error {
+	if tail := rawdb.ReadChainHistoryTail(db); tail != nil && *tail >= cutoff {
+		log.Debug("Chain history already pruned", "tail", *tail)
+		return nil
+	}
+	frozen, err := db.Ancients()
+	if err != nil {
+		return fmt.Errorf("ancient store is not available: %w", err)
+	}
+	if cutoff > frozen {
+		return fmt.Errorf("%w: cutoff %d, frozen %d", ErrHistoryNotFrozen, cutoff, frozen)
+	}
+	start := time.Now()
+
+	// Drop the transaction indexes first, they are resolved via the block
+	// bodies which are not available after the pruning.
+	if tail := rawdb.ReadTxIndexTail(db); tail != nil && *tail < cutoff {
+		rawdb.UnindexTransactions(db, *tail, cutoff, nil)
+	}
+	if _, err := db.TruncateTail(cutoff); err != nil {
+		return err
+	}
+	if err := db.Sync(); err != nil {
+		return err
+	}
+	rawdb.WriteChainHistoryTail(db, cutoff)
+	log.Info("Pruned chain history", "tail", cutoff, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPruneChainHistory(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{address: {Balance: big.NewInt(100000000000000000)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		signer = types.LatestSigner(gspec.Config)
+		cutoff = uint64(64)
+	)
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 128, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), params.TxGas, block.header.BaseFee, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	rawdb.WriteAncientBlocks(db, append([]*types.Block{gspec.ToBlock()}, blocks...), append([]types.Receipts{{}}, receipts...), big.NewInt(0))
+
+	// Index all the transactions before pruning
+	limit := uint64(0)
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, &limit)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	chain.indexBlocks(rawdb.ReadTxIndexTail(db), 128, make(chan struct{}))
+	chain.Stop()
+
+	if err := PruneChainHistory(db, 130); !errors.Is(err, ErrHistoryNotFrozen) {
+		t.Fatalf("pruning beyond the ancient store: have %v, want %v", err, ErrHistoryNotFrozen)
+	}
+	if err := PruneChainHistory(db, cutoff); err != nil {
+		t.Fatalf("failed to prune chain history: %v", err)
+	}
+	if tail := rawdb.ReadChainHistoryTail(db); tail == nil || *tail != cutoff {
+		t.Fatalf("history tail mismatch: have %v, want %d", tail, cutoff)
+	}
+	if tail := rawdb.ReadTxIndexTail(db); tail == nil || *tail != cutoff {
+		t.Fatalf("transaction index tail mismatch: have %v, want %d", tail, cutoff)
+	}
+	for _, block := range blocks {
+		var (
+			number = block.NumberU64()
+			pruned = number < cutoff
+		)
+		if rawdb.ReadHeader(db, block.Hash(), number) == nil {
+			t.Fatalf("block #%d: header is missing", number)
+		}
+		if body := rawdb.ReadBody(db, block.Hash(), number); (body == nil) != pruned {
+			t.Fatalf("block #%d: body availability mismatch, pruned: %v", number, pruned)
+		}
+		if receipts := rawdb.ReadRawReceipts(db, block.Hash(), number); (receipts == nil) != pruned {
+			t.Fatalf("block #%d: receipts availability mismatch, pruned: %v", number, pruned)
+		}
+		if entry := rawdb.ReadTxLookupEntry(db, block.Transactions()[0].Hash()); (entry == nil) != pruned {
+			t.Fatalf("block #%d: transaction index availability mismatch, pruned: %v", number, pruned)
+		}
+	}
+	// Reopen the chain on top of the pruned database
+	chain, err = NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, &limit)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.Genesis().Hash() != gspec.ToBlock().Hash() {
+		t.Fatal("genesis block mismatch")
+	}
+	if !chain.HistoryPruned(cutoff-1) || chain.HistoryPruned(cutoff) {
+		t.Fatalf("history pruning status mismatch, tail: %d", chain.HistoryTail())
+	}
+	if chain.GetBlockByNumber(cutoff) == nil {
+		t.Fatal("retained block is missing")
+	}
+}
+
+func TestMergeHistoryCutoff(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	if _, err := MergeHistoryCutoff(db); !errors.Is(err, ErrNotMerged) {
+		t.Fatalf("empty database: have %v, want %v", err, ErrNotMerged)
+	}
+	var parent common.Hash
+	for i := 0; i < 10; i++ {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(int64(i)),
+			Difficulty: big.NewInt(0),
+		}
+		// Blocks #0-#5 are proof-of-work blocks
+		if i <= 5 {
+			header.Difficulty = big.NewInt(1)
+		}
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), uint64(i))
+		rawdb.WriteHeadHeaderHash(db, header.Hash())
+		parent = header.Hash()
+
+		cutoff, err := MergeHistoryCutoff(db)
+		if i <= 5 {
+			if !errors.Is(err, ErrNotMerged) {
+				t.Fatalf("block #%d: have %v, want %v", i, err, ErrNotMerged)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("block #%d: failed to resolve cutoff: %v", i, err)
+		}
+		if cutoff != 5 {
+			t.Fatalf("block #%d: cutoff mismatch, have %d, want %d", i, cutoff, 5)
+		}
+	}
+}
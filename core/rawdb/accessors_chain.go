@@ -278,6 +278,25 @@ func WriteTxIndexTail(db ethdb.KeyValueWriter, number uint64) {
 	}
 }
 
+// ReadChainHistoryTail retrieves the number of oldest block whose body and
+// receipts are retained. Nil is returned if the chain history is not pruned.
+func ReadChainHistoryTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(chainHistoryTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteChainHistoryTail stores the number of oldest block whose body and
+// receipts are retained into database.
+func WriteChainHistoryTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(chainHistoryTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the chain history tail", "err", err)
+	}
+}
+
 // ReadFastTxLookupLimit retrieves the tx lookup limit used in fast sync.
 func ReadFastTxLookupLimit(db ethdb.KeyValueReader) *uint64 {
 	data, _ := db.Get(fastTxLookupLimitKey)
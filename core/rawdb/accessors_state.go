@@ -160,6 +160,116 @@ func DeleteTrieJournal(db ethdb.KeyValueWriter) {
 	}
 }
 
+// ReadStateHistoryIndexHead retrieves the id of the latest indexed state history.
+// Zero is returned if no state history has been indexed yet.
+func ReadStateHistoryIndexHead(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(stateHistoryIndexHeadKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteStateHistoryIndexHead stores the id of the latest indexed state history.
+func WriteStateHistoryIndexHead(db ethdb.KeyValueWriter, id uint64) {
+	var buff [8]byte
+	binary.BigEndian.PutUint64(buff[:], id)
+	if err := db.Put(stateHistoryIndexHeadKey, buff[:]); err != nil {
+		log.Crit("Failed to store state history index head", "err", err)
+	}
+}
+
+// WriteStateHistoryAccountIndex marks the account as mutated in the state
+// history with the given id.
+func WriteStateHistoryAccountIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Put(stateHistoryAccountIndexKey(address, id), nil); err != nil {
+		log.Crit("Failed to store state history account index", "err", err)
+	}
+}
+
+// DeleteStateHistoryAccountIndex removes the account mutation marker of the
+// state history with the given id.
+func DeleteStateHistoryAccountIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Delete(stateHistoryAccountIndexKey(address, id)); err != nil {
+		log.Crit("Failed to delete state history account index", "err", err)
+	}
+}
+
+// WriteStateHistoryStorageIndex marks the storage slot as mutated in the state
+// history with the given id.
+func WriteStateHistoryStorageIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, id uint64) {
+	if err := db.Put(stateHistoryStorageIndexKey(address, slot, id), nil); err != nil {
+		log.Crit("Failed to store state history storage index", "err", err)
+	}
+}
+
+// DeleteStateHistoryStorageIndex removes the storage slot mutation marker of
+// the state history with the given id.
+func DeleteStateHistoryStorageIndex(db ethdb.KeyValueWriter, address common.Address, slot common.Hash, id uint64) {
+	if err := db.Delete(stateHistoryStorageIndexKey(address, slot, id)); err != nil {
+		log.Crit("Failed to delete state history storage index", "err", err)
+	}
+}
+
+// WriteStateHistoryIncompleteIndex marks the storage set of the account as
+// incomplete in the state history with the given id.
+func WriteStateHistoryIncompleteIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Put(stateHistoryIncompleteIndexKey(address, id), nil); err != nil {
+		log.Crit("Failed to store state history incomplete index", "err", err)
+	}
+}
+
+// DeleteStateHistoryIncompleteIndex removes the incomplete storage marker of
+// the account in the state history with the given id.
+func DeleteStateHistoryIncompleteIndex(db ethdb.KeyValueWriter, address common.Address, id uint64) {
+	if err := db.Delete(stateHistoryIncompleteIndexKey(address, id)); err != nil {
+		log.Crit("Failed to delete state history incomplete index", "err", err)
+	}
+}
+
+// findStateHistoryIndex returns the id of the first index entry with the given
+// prefix whose id is in the range [from, to].
+func findStateHistoryIndex(db ethdb.Iteratee, prefix []byte, from, to uint64) (uint64, bool) {
+	var start [8]byte
+	binary.BigEndian.PutUint64(start[:], from)
+
+	it := db.NewIterator(prefix, start[:])
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+8 {
+			continue
+		}
+		id := binary.BigEndian.Uint64(key[len(prefix):])
+		if id > to {
+			return 0, false
+		}
+		return id, true
+	}
+	return 0, false
+}
+
+// FindStateHistoryAccountIndex returns the id of the first state history in the
+// range [from, to] in which the given account was mutated.
+func FindStateHistoryAccountIndex(db ethdb.Iteratee, address common.Address, from, to uint64) (uint64, bool) {
+	return findStateHistoryIndex(db, append(common.CopyBytes(StateHistoryAccountIndexPrefix), address.Bytes()...), from, to)
+}
+
+// FindStateHistoryStorageIndex returns the id of the first state history in the
+// range [from, to] in which the given storage slot was mutated.
+func FindStateHistoryStorageIndex(db ethdb.Iteratee, address common.Address, slot common.Hash, from, to uint64) (uint64, bool) {
+	prefix := append(common.CopyBytes(StateHistoryStorageIndexPrefix), address.Bytes()...)
+	return findStateHistoryIndex(db, append(prefix, slot.Bytes()...), from, to)
+}
+
+// FindStateHistoryIncompleteIndex returns the id of the first state history in
+// the range [from, to] in which the storage set of the given account is
+// incomplete.
+func FindStateHistoryIncompleteIndex(db ethdb.Iteratee, address common.Address, from, to uint64) (uint64, bool) {
+	return findStateHistoryIndex(db, append(common.CopyBytes(StateHistoryIncompleteIndexPrefix), address.Bytes()...), from, to)
+}
+
 // ReadStateHistoryMeta retrieves the metadata corresponding to the specified
 // state history. Compute the position of state history in freezer by minus
 // one since the id of first state history starts from one(zero for initial
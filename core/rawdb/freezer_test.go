@@ -480,3 +480,64 @@ func TestFreezerCloseSync(t *testing.T) {
 		t.Fatalf("want %v, have %v", have, want)
 	}
 }
+
+// This checks that the tail truncation only affects the prunable tables and
+// that the freezer can be reopened afterwards.
+func TestFreezerTruncateTailPrunable(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir      = t.TempDir()
+		tables   = map[string]bool{"a": true, "b": true}
+		prunable = map[string]bool{"b": true}
+		item     = make([]byte, 1024)
+	)
+	f, err := newFreezer(dir, "", false, 2049, tables, prunable)
+	if err != nil {
+		t.Fatal("can't open freezer", err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			if err := op.AppendRaw("a", i, item); err != nil {
+				return err
+			}
+			if err := op.AppendRaw("b", i, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("ModifyAncients failed:", err)
+	}
+	if _, err := f.TruncateTail(5); err != nil {
+		t.Fatal("TruncateTail failed:", err)
+	}
+	check := func(f *Freezer) {
+		t.Helper()
+		if tail, _ := f.Tail(); tail != 5 {
+			t.Fatalf("Tail() returned %d, want %d", tail, 5)
+		}
+		if _, err := f.Ancient("a", 0); err != nil {
+			t.Fatalf("non-prunable item was truncated: %v", err)
+		}
+		if _, err := f.Ancient("b", 4); err == nil {
+			t.Fatal("prunable item was not truncated")
+		}
+		if _, err := f.Ancient("b", 5); err != nil {
+			t.Fatalf("prunable item was truncated unexpectedly: %v", err)
+		}
+	}
+	check(f)
+	require.NoError(t, f.Close())
+
+	// Reopen the freezer in both modes, the differing tails must be accepted.
+	for _, readonly := range []bool{true, false} {
+		f, err = newFreezer(dir, "", readonly, 2049, tables, prunable)
+		if err != nil {
+			t.Fatalf("can't reopen freezer (readonly: %v): %v", readonly, err)
+		}
+		check(f)
+		require.NoError(t, f.Close())
+	}
+}
@@ -79,6 +79,10 @@ var (
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// chainHistoryTailKey tracks the oldest block whose body and receipts are
+	// retained, the ones below have been pruned from the ancient store.
+	chainHistoryTailKey = []byte("ChainHistoryTail")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	fastTxLookupLimitKey = []byte("FastTransactionLookupLimit")
 
@@ -94,6 +98,9 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// stateHistoryIndexHeadKey tracks the id of the latest indexed state history.
+	stateHistoryIndexHeadKey = []byte("StateHistoryIndexHead")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -115,6 +122,11 @@ var (
 	trieNodeStoragePrefix = []byte("O") // trieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
+	// Path-based state history indexes.
+	StateHistoryAccountIndexPrefix    = []byte("ma") // StateHistoryAccountIndexPrefix + address + id (uint64 big endian) -> nil
+	StateHistoryStorageIndexPrefix    = []byte("ms") // StateHistoryStorageIndexPrefix + address + slot hash + id (uint64 big endian) -> nil
+	StateHistoryIncompleteIndexPrefix = []byte("mi") // StateHistoryIncompleteIndexPrefix + address + id (uint64 big endian) -> nil
+
 	PreimagePrefix = []byte("secure-key-")       // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-")  // config prefix for the db
 	genesisPrefix  = []byte("ethereum-genesis-") // genesis state prefix for the db
@@ -132,6 +144,10 @@ var (
 
 	CliqueSnapshotPrefix = []byte("clique-")
 
+	BestUpdateKey         = []byte("update-")    // bigEndian64(syncPeriod) -> RLP(types.LightClientUpdate)  (nextCommittee only referenced by root hash)
+	FixedCommitteeRootKey = []byte("fixedRoot-") // bigEndian64(syncPeriod) -> committee root hash
+	SyncCommitteeKey      = []byte("committee-") // bigEndian64(syncPeriod) -> serialized committee
+
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
@@ -259,6 +275,34 @@ func stateIDKey(root common.Hash) []byte {
 	return append(stateIDPrefix, root.Bytes()...)
 }
 
+// stateHistoryAccountIndexKey = StateHistoryAccountIndexPrefix + address + id (uint64 big endian)
+func stateHistoryAccountIndexKey(address common.Address, id uint64) []byte {
+	buf := make([]byte, len(StateHistoryAccountIndexPrefix)+common.AddressLength+8)
+	n := copy(buf, StateHistoryAccountIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	binary.BigEndian.PutUint64(buf[n:], id)
+	return buf
+}
+
+// stateHistoryStorageIndexKey = StateHistoryStorageIndexPrefix + address + slot hash + id (uint64 big endian)
+func stateHistoryStorageIndexKey(address common.Address, slot common.Hash, id uint64) []byte {
+	buf := make([]byte, len(StateHistoryStorageIndexPrefix)+common.AddressLength+common.HashLength+8)
+	n := copy(buf, StateHistoryStorageIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	n += copy(buf[n:], slot.Bytes())
+	binary.BigEndian.PutUint64(buf[n:], id)
+	return buf
+}
+
+// stateHistoryIncompleteIndexKey = StateHistoryIncompleteIndexPrefix + address + id (uint64 big endian)
+func stateHistoryIncompleteIndexKey(address common.Address, id uint64) []byte {
+	buf := make([]byte, len(StateHistoryIncompleteIndexPrefix)+common.AddressLength+8)
+	n := copy(buf, StateHistoryIncompleteIndexPrefix)
+	n += copy(buf[n:], address.Bytes())
+	binary.BigEndian.PutUint64(buf[n:], id)
+	return buf
+}
+
 // accountTrieNodeKey = trieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(trieNodeAccountPrefix, path...)
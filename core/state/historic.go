@@ -0,0 +1,196 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// historicDB is a read-only state database for a historical state that is not
+// available in the path-based trie database anymore, but is still covered by
+// the retained state histories. Accounts and storage slots are served from
+// the flat historical state without resolving trie nodes.
+type historicDB struct {
+	Database
+	reader *pathdb.HistoricalStateReader
+}
+
+// NewHistoric creates a read-only state for the given historical state root.
+// It's only supported by the path-based trie database, and only for the states
+// within the range of retained state histories.
+func NewHistoric(root common.Hash, db Database) (*StateDB, error) {
+	reader, err := db.TrieDB().HistoricReader(root)
+	if err != nil {
+		return nil, err
+	}
+	return New(root, &historicDB{Database: db, reader: reader}, nil)
+}
+
+// OpenTrie opens the main account trie of the historical state.
+func (db *historicDB) OpenTrie(root common.Hash) (Trie, error) {
+	if root != db.reader.Root() {
+		return nil, fmt.Errorf("historical state %x is not available", root)
+	}
+	return newHistoricTrie(db.reader, nil, root), nil
+}
+
+// OpenStorageTrie opens the storage trie of an account in the historical state.
+func (db *historicDB) OpenStorageTrie(stateRoot common.Hash, address common.Address, root common.Hash) (Trie, error) {
+	if stateRoot != db.reader.Root() {
+		return nil, fmt.Errorf("historical state %x is not available", stateRoot)
+	}
+	return newHistoricTrie(db.reader, &address, root), nil
+}
+
+// CopyTrie returns an independent copy of the given trie.
+func (db *historicDB) CopyTrie(t Trie) Trie {
+	if t, ok := t.(*historicTrie); ok {
+		return t.copy()
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// errHistoricTrie is returned for the operations which require the trie nodes
+// of a historical state served from the state histories.
+var errHistoricTrie = errors.New("operation not supported by historical state")
+
+// historicTrie implements the Trie interface on top of the historical state
+// reader. The trie is meant for read-only state access, e.g. serving RPC calls and
+// tracing. Mutations are kept in memory so that reads are consistent with the
+// writes, but the trie hash is never recomputed and it can't be committed.
+type historicTrie struct {
+	reader  *pathdb.HistoricalStateReader
+	address *common.Address // Owner of the storage trie, nil for the account trie
+	root    common.Hash     // Root hash of the trie, never recomputed
+
+	accounts map[common.Address]*types.StateAccount // Dirty accounts, nil means deleted
+	storages map[common.Hash][]byte                 // Dirty storage slots keyed by slot hash, nil means deleted
+}
+
+// newHistoricTrie creates an account trie (if address is nil) or the storage
+// trie of the given account for the historical state held by the reader.
+func newHistoricTrie(reader *pathdb.HistoricalStateReader, address *common.Address, root common.Hash) *historicTrie {
+	return &historicTrie{
+		reader:   reader,
+		address:  address,
+		root:     root,
+		accounts: make(map[common.Address]*types.StateAccount),
+		storages: make(map[common.Hash][]byte),
+	}
+}
+
+// GetKey returns the sha3 preimage of a hashed key. Preimages are not tracked
+// by historical states.
+func (t *historicTrie) GetKey(key []byte) []byte {
+	return nil
+}
+
+// GetStorage returns the value for key stored in the trie.
+func (t *historicTrie) GetStorage(addr common.Address, key []byte) ([]byte, error) {
+	hash := crypto.Keccak256Hash(key)
+	if value, ok := t.storages[hash]; ok {
+		return value, nil
+	}
+	enc, err := t.reader.Storage(addr, hash)
+	if err != nil || len(enc) == 0 {
+		return nil, err
+	}
+	_, content, _, err := rlp.Split(enc)
+	return content, err
+}
+
+// GetAccount returns the account with the given address.
+func (t *historicTrie) GetAccount(address common.Address) (*types.StateAccount, error) {
+	if account, ok := t.accounts[address]; ok {
+		return account, nil
+	}
+	return t.reader.Account(address)
+}
+
+// UpdateStorage associates key with value in the trie.
+func (t *historicTrie) UpdateStorage(addr common.Address, key, value []byte) error {
+	t.storages[crypto.Keccak256Hash(key)] = common.CopyBytes(value)
+	return nil
+}
+
+// UpdateAccount writes the account into the trie.
+func (t *historicTrie) UpdateAccount(address common.Address, account *types.StateAccount) error {
+	t.accounts[address] = account.Copy()
+	return nil
+}
+
+// UpdateContractCode is a no-op for historical states.
+func (t *historicTrie) UpdateContractCode(address common.Address, codeHash common.Hash, code []byte) error {
+	return nil
+}
+
+// DeleteStorage removes any existing value for key from the trie.
+func (t *historicTrie) DeleteStorage(addr common.Address, key []byte) error {
+	t.storages[crypto.Keccak256Hash(key)] = nil
+	return nil
+}
+
+// DeleteAccount removes the account from the trie.
+func (t *historicTrie) DeleteAccount(address common.Address) error {
+	t.accounts[address] = nil
+	return nil
+}
+
+// Hash returns the original root hash of the trie, mutations are not hashed.
+func (t *historicTrie) Hash() common.Hash {
+	return t.root
+}
+
+// Commit is not supported by historical states.
+func (t *historicTrie) Commit(collectLeaf bool) (common.Hash, *trienode.NodeSet, error) {
+	return common.Hash{}, nil, errHistoricTrie
+}
+
+// NodeIterator is not supported by historical states.
+func (t *historicTrie) NodeIterator(startKey []byte) (trie.NodeIterator, error) {
+	return nil, errHistoricTrie
+}
+
+// Prove is not supported by historical states.
+func (t *historicTrie) Prove(key []byte, proofDb ethdb.KeyValueWriter) error {
+	return errHistoricTrie
+}
+
+// copy returns an independent copy of the trie.
+func (t *historicTrie) copy() *historicTrie {
+	cpy := newHistoricTrie(t.reader, t.address, t.root)
+	for addr, account := range t.accounts {
+		if account != nil {
+			account = account.Copy()
+		}
+		cpy.accounts[addr] = account
+	}
+	for hash, value := range t.storages {
+		cpy.storages[hash] = common.CopyBytes(value)
+	}
+	return cpy
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -88,9 +89,10 @@ const (
 // bare minimum needed fields to keep the size down (and thus number of entries
 // larger with the same memory consumption).
 type blobTxMeta struct {
-	hash common.Hash // Transaction hash to maintain the lookup table
-	id   uint64      // Storage ID in the pool's persistent store
-	size uint32      // Byte size in the pool's persistent store
+	hash    common.Hash   // Transaction hash to maintain the lookup table
+	vhashes []common.Hash // Blob versioned hashes to maintain the lookup table
+	id      uint64        // Storage ID in the pool's persistent store
+	size    uint32        // Byte size in the pool's persistent store
 
 	nonce      uint64       // Needed to prioritize inclusion order within an account
 	costCap    *uint256.Int // Needed to validate cumulative balance sufficiency
@@ -113,6 +115,7 @@ type blobTxMeta struct {
 func newBlobTxMeta(id uint64, size uint32, tx *types.Transaction) *blobTxMeta {
 	meta := &blobTxMeta{
 		hash:       tx.Hash(),
+		vhashes:    tx.BlobHashes(),
 		id:         id,
 		size:       size,
 		nonce:      tx.Nonce(),
@@ -306,11 +309,13 @@ type BlobPool struct {
 	state  *state.StateDB // Current state at the head of the chain
 	gasTip *uint256.Int   // Currently accepted minimum gas tip
 
-	lookup map[common.Hash]uint64           // Lookup table mapping hashes to tx billy entries
+	lookup *lookup                          // Lookup table mapping blobs to txs and txs to billy entries
 	index  map[common.Address][]*blobTxMeta // Blob transactions grouped by accounts, sorted by nonce
 	spent  map[common.Address]*uint256.Int  // Expenditure tracking for individual accounts
 	evict  *evictHeap                       // Heap of cheapest accounts for eviction when full
 
+	history *txpool.History // Lifecycle events of the recently seen transactions
+
 	discoverFeed event.Feed // Event feed to send out new tx events on pool discovery (reorg excluded)
 	insertFeed   event.Feed // Event feed to send out new tx events on pool inclusion (reorg included)
 
@@ -325,12 +330,13 @@ func New(config Config, chain BlockChain) *BlobPool {
 
 	// Create the transaction pool with its initial settings
 	return &BlobPool{
-		config: config,
-		signer: types.LatestSigner(chain.Config()),
-		chain:  chain,
-		lookup: make(map[common.Hash]uint64),
-		index:  make(map[common.Address][]*blobTxMeta),
-		spent:  make(map[common.Address]*uint256.Int),
+		config:  config,
+		signer:  types.LatestSigner(chain.Config()),
+		chain:   chain,
+		lookup:  newLookup(),
+		index:   make(map[common.Address][]*blobTxMeta),
+		spent:   make(map[common.Address]*uint256.Int),
+		history: txpool.NewHistory(txpool.DefaultHistoryLimit),
 	}
 }
 
@@ -440,6 +446,8 @@ func (p *BlobPool) Close() error {
 	if err := p.store.Close(); err != nil {
 		errs = append(errs, err)
 	}
+	p.history.Close()
+
 	switch {
 	case errs == nil:
 		return nil
@@ -486,7 +494,7 @@ func (p *BlobPool) parseTransaction(id uint64, size uint32, blob []byte) error {
 	p.index[sender] = append(p.index[sender], meta)
 	p.spent[sender] = new(uint256.Int).Add(p.spent[sender], meta.costCap)
 
-	p.lookup[meta.hash] = meta.id
+	p.lookup.track(meta)
 	p.stored += uint64(meta.size)
 
 	return nil
@@ -521,7 +529,12 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 			nonces = append(nonces, txs[i].nonce)
 
 			p.stored -= uint64(txs[i].size)
-			delete(p.lookup, txs[i].hash)
+			p.lookup.untrack(txs[i])
+			if gapped {
+				p.history.Record(txs[i].hash, txpool.TxEventDropped, core.ErrNonceTooHigh)
+			} else {
+				p.recordFilled(txs[i].hash, inclusions)
+			}
 
 			// Included transactions blobs need to be moved to the limbo
 			if filled && inclusions != nil {
@@ -560,7 +573,8 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[0].costCap)
 			p.stored -= uint64(txs[0].size)
-			delete(p.lookup, txs[0].hash)
+			p.lookup.untrack(txs[0])
+			p.recordFilled(txs[0].hash, inclusions)
 
 			// Included transactions blobs need to be moved to the limbo
 			if inclusions != nil {
@@ -616,7 +630,8 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[j].costCap)
 			p.stored -= uint64(txs[j].size)
-			delete(p.lookup, txs[j].hash)
+			p.lookup.untrack(txs[j])
+			p.history.Record(txs[j].hash, txpool.TxEventDropped, core.ErrNonceTooHigh)
 		}
 		txs = txs[:i]
 
@@ -652,7 +667,8 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
-			delete(p.lookup, last.hash)
+			p.lookup.untrack(last)
+			p.history.Record(last.hash, txpool.TxEventDropped, core.ErrInsufficientFunds)
 		}
 		if len(txs) == 0 {
 			delete(p.index, addr)
@@ -690,7 +706,8 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 
 			p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], last.costCap)
 			p.stored -= uint64(last.size)
-			delete(p.lookup, last.hash)
+			p.lookup.untrack(last)
+			p.history.Record(last.hash, txpool.TxEventDropped, txpool.ErrAccountLimitExceeded)
 		}
 		p.index[addr] = txs
 
@@ -708,6 +725,19 @@ func (p *BlobPool) recheck(addr common.Address, inclusions map[common.Hash]uint6
 	}
 }
 
+// recordFilled adds the lifecycle event of a transaction whose nonce was consumed
+// by the chain. If the inclusions are known and the transaction is not among them,
+// the signer swapped it out for a different one, which counts as a drop.
+func (p *BlobPool) recordFilled(hash common.Hash, inclusions map[common.Hash]uint64) {
+	if inclusions != nil {
+		if _, ok := inclusions[hash]; !ok {
+			p.history.Record(hash, txpool.TxEventDropped, core.ErrNonceTooLow)
+			return
+		}
+	}
+	p.history.Record(hash, txpool.TxEventIncluded, nil)
+}
+
 // offload removes a tracked blob transaction from the pool and moves it into the
 // limbo for tracking until finality.
 //
@@ -966,8 +996,9 @@ func (p *BlobPool) reinject(addr common.Address, txhash common.Hash) error {
 		p.index[addr] = append(p.index[addr], meta)
 		p.spent[addr] = new(uint256.Int).Add(p.spent[addr], meta.costCap)
 	}
-	p.lookup[meta.hash] = meta.id
+	p.lookup.track(meta)
 	p.stored += uint64(meta.size)
+	p.history.Record(meta.hash, txpool.TxEventAdded, nil)
 	return nil
 }
 
@@ -993,7 +1024,8 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 					)
 					p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], txs[i].costCap)
 					p.stored -= uint64(tx.size)
-					delete(p.lookup, tx.hash)
+					p.lookup.untrack(tx)
+					p.history.Record(tx.hash, txpool.TxEventDropped, txpool.ErrUnderpriced)
 					txs[i] = nil
 
 					// Drop everything afterwards, no gaps allowed
@@ -1003,7 +1035,8 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 
 						p.spent[addr] = new(uint256.Int).Sub(p.spent[addr], tx.costCap)
 						p.stored -= uint64(tx.size)
-						delete(p.lookup, tx.hash)
+						p.lookup.untrack(tx)
+						p.history.Record(tx.hash, txpool.TxEventDropped, txpool.ErrUnderpriced)
 						txs[i+1+j] = nil
 					}
 					// Clear out the dropped transactions from the index
@@ -1125,8 +1158,7 @@ func (p *BlobPool) Has(hash common.Hash) bool {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
-	_, ok := p.lookup[hash]
-	return ok
+	return p.lookup.exists(hash)
 }
 
 // Get returns a transaction if it is contained in the pool, or nil otherwise.
@@ -1143,7 +1175,7 @@ func (p *BlobPool) Get(hash common.Hash) *types.Transaction {
 	}(time.Now())
 
 	// Pull the blob from disk and return an assembled response
-	id, ok := p.lookup[hash]
+	id, ok := p.lookup.storeidOfTx(hash)
 	if !ok {
 		return nil
 	}
@@ -1160,6 +1192,58 @@ func (p *BlobPool) Get(hash common.Hash) *types.Transaction {
 	return item
 }
 
+// GetBlobs returns the blobs and KZG proofs for the given versioned hashes if
+// they are carried by any transaction in the pool, or nil entries otherwise.
+func (p *BlobPool) GetBlobs(vhashes []common.Hash) ([]*kzg4844.Blob, []*kzg4844.Proof) {
+	var (
+		blobs  = make([]*kzg4844.Blob, len(vhashes))
+		proofs = make([]*kzg4844.Proof, len(vhashes))
+	)
+	// Map the requested versioned hashes to their positions in the response,
+	// so a single transaction may fill multiple requested blobs.
+	index := make(map[common.Hash][]int)
+	for i, vhash := range vhashes {
+		index[vhash] = append(index[vhash], i)
+	}
+	for i, vhash := range vhashes {
+		// If already filled by a previously retrieved transaction, skip
+		if blobs[i] != nil {
+			continue
+		}
+		// Retrieve the transaction carrying the blob from disk, only holding
+		// the lock while touching the pool's internals
+		p.lock.RLock()
+		id, ok := p.lookup.storeidOfBlob(vhash)
+		if !ok {
+			p.lock.RUnlock()
+			continue
+		}
+		data, err := p.store.Get(id)
+		p.lock.RUnlock()
+
+		if err != nil {
+			log.Error("Tracked blob transaction missing from store", "vhash", vhash, "id", id, "err", err)
+			continue
+		}
+		item := new(types.Transaction)
+		if err = rlp.DecodeBytes(data, item); err != nil {
+			log.Error("Blobs corrupted for traced transaction", "vhash", vhash, "id", id, "err", err)
+			continue
+		}
+		sidecar := item.BlobTxSidecar()
+		if sidecar == nil {
+			log.Error("Blob transaction stored without sidecar", "vhash", vhash, "id", id)
+			continue
+		}
+		for j, blobhash := range item.BlobHashes() {
+			for _, idx := range index[blobhash] {
+				blobs[idx], proofs[idx] = &sidecar.Blobs[j], &sidecar.Proofs[j]
+			}
+		}
+	}
+	return blobs, proofs
+}
+
 // Add inserts a set of blob transactions into the pool if they pass validation (both
 // consensus validity and pool restictions).
 func (p *BlobPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
@@ -1195,6 +1279,15 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		addtimeHist.Update(time.Since(start).Nanoseconds())
 	}(time.Now())
 
+	// Record the reason if the transaction is refused by any of the checks below.
+	//
+	// Note, `err` here is the named error return, same as for the reservation
+	// release further down.
+	defer func() {
+		if err != nil {
+			p.history.Record(tx.Hash(), txpool.TxEventRejected, err)
+		}
+	}()
 	// Ensure the transaction is valid from all perspectives
 	if err := p.validateTx(tx); err != nil {
 		log.Trace("Transaction validation failed", "hash", tx.Hash(), "err", err)
@@ -1254,9 +1347,11 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 		p.spent[from] = new(uint256.Int).Sub(p.spent[from], prev.costCap)
 		p.spent[from] = new(uint256.Int).Add(p.spent[from], meta.costCap)
 
-		delete(p.lookup, prev.hash)
-		p.lookup[meta.hash] = meta.id
+		p.lookup.untrack(prev)
+		p.lookup.track(meta)
 		p.stored += uint64(meta.size) - uint64(prev.size)
+
+		p.history.RecordReplaced(prev.hash, meta.hash)
 	} else {
 		// Transaction extends previously scheduled ones
 		p.index[from] = append(p.index[from], meta)
@@ -1265,7 +1360,7 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 			newacc = true
 		}
 		p.spent[from] = new(uint256.Int).Add(p.spent[from], meta.costCap)
-		p.lookup[meta.hash] = meta.id
+		p.lookup.track(meta)
 		p.stored += uint64(meta.size)
 	}
 	// Recompute the rolling eviction fields. In case of a replacement, this will
@@ -1315,6 +1410,8 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 			heap.Fix(p.evict, p.evict.index[from])
 		}
 	}
+	p.history.Record(meta.hash, txpool.TxEventAdded, nil)
+
 	// If the pool went over the allowed data limit, evict transactions until
 	// we're again below the threshold
 	for p.stored > p.config.Datacap {
@@ -1353,7 +1450,8 @@ func (p *BlobPool) drop() {
 		p.spent[from] = new(uint256.Int).Sub(p.spent[from], drop.costCap)
 	}
 	p.stored -= uint64(drop.size)
-	delete(p.lookup, drop.hash)
+	p.lookup.untrack(drop)
+	p.history.Record(drop.hash, txpool.TxEventDropped, txpool.ErrUnderpriced)
 
 	// Remove the transaction from the pool's evicion heap:
 	//   - If the entire account was dropped, pop off the address
@@ -1377,6 +1475,17 @@ func (p *BlobPool) drop() {
 	}
 }
 
+// History retrieves the recorded lifecycle events of a transaction, oldest first.
+func (p *BlobPool) History(hash common.Hash) []txpool.TxEvent {
+	return p.history.Get(hash)
+}
+
+// SubscribeDropped registers a subscription for transactions being dropped from
+// or replaced in the pool.
+func (p *BlobPool) SubscribeDropped(ch chan<- txpool.TxEvent) event.Subscription {
+	return p.history.SubscribeDropped(ch)
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce.
 func (p *BlobPool) Pending(enforceTips bool) map[common.Address][]*txpool.LazyTransaction {
@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -35,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -233,7 +235,7 @@ func verifyPoolInternals(t *testing.T, pool *BlobPool) {
 			seen[tx.hash] = struct{}{}
 		}
 	}
-	for hash, id := range pool.lookup {
+	for hash, id := range pool.lookup.txIndex {
 		if _, ok := seen[hash]; !ok {
 			t.Errorf("lookup entry missing from transaction index: hash #%x, id %d", hash, id)
 		}
@@ -242,6 +244,32 @@ func verifyPoolInternals(t *testing.T, pool *BlobPool) {
 	for hash := range seen {
 		t.Errorf("indexed transaction hash #%x missing from lookup table", hash)
 	}
+	// Verify that all blobs in the index are present in the blob lookup and nothing more
+	blobs := make(map[common.Hash]map[common.Hash]struct{})
+	for _, txs := range pool.index {
+		for _, tx := range txs {
+			for _, vhash := range tx.vhashes {
+				if blobs[vhash] == nil {
+					blobs[vhash] = make(map[common.Hash]struct{})
+				}
+				blobs[vhash][tx.hash] = struct{}{}
+			}
+		}
+	}
+	for vhash, txs := range pool.lookup.blobIndex {
+		for txhash := range txs {
+			if _, ok := blobs[vhash][txhash]; !ok {
+				t.Errorf("blob lookup entry missing from transaction index: blob hash #%x, tx hash #%x", vhash, txhash)
+			}
+			delete(blobs[vhash], txhash)
+			if len(blobs[vhash]) == 0 {
+				delete(blobs, vhash)
+			}
+		}
+	}
+	for vhash := range blobs {
+		t.Errorf("indexed transaction blob hash #%x missing from blob lookup table", vhash)
+	}
 	// Verify that transactions are sorted per account and contain no nonce gaps
 	for addr, txs := range pool.index {
 		for i := 1; i < len(txs); i++ {
@@ -319,7 +347,7 @@ func verifyPoolInternals(t *testing.T, pool *BlobPool) {
 //   - 3. All transactions after a nonce gap must be dropped
 //   - 4. All transactions after an underpriced one (including it) must be dropped
 func TestOpenDrops(t *testing.T) {
-	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlTrace, true)))
 
 	// Create a temporary folder for the persistent backend
 	storage, _ := os.MkdirTemp("", "blobpool-")
@@ -512,17 +540,17 @@ func TestOpenDrops(t *testing.T) {
 
 	// Create a blob pool out of the pre-seeded data
 	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
-	statedb.AddBalance(crypto.PubkeyToAddress(gapper.PublicKey), big.NewInt(1000000))
-	statedb.AddBalance(crypto.PubkeyToAddress(dangler.PublicKey), big.NewInt(1000000))
-	statedb.AddBalance(crypto.PubkeyToAddress(filler.PublicKey), big.NewInt(1000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(gapper.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(crypto.PubkeyToAddress(dangler.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(crypto.PubkeyToAddress(filler.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
 	statedb.SetNonce(crypto.PubkeyToAddress(filler.PublicKey), 3)
-	statedb.AddBalance(crypto.PubkeyToAddress(overlapper.PublicKey), big.NewInt(1000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(overlapper.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
 	statedb.SetNonce(crypto.PubkeyToAddress(overlapper.PublicKey), 2)
-	statedb.AddBalance(crypto.PubkeyToAddress(underpayer.PublicKey), big.NewInt(1000000))
-	statedb.AddBalance(crypto.PubkeyToAddress(outpricer.PublicKey), big.NewInt(1000000))
-	statedb.AddBalance(crypto.PubkeyToAddress(exceeder.PublicKey), big.NewInt(1000000))
-	statedb.AddBalance(crypto.PubkeyToAddress(overdrafter.PublicKey), big.NewInt(1000000))
-	statedb.AddBalance(crypto.PubkeyToAddress(overcapper.PublicKey), big.NewInt(10000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(underpayer.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(crypto.PubkeyToAddress(outpricer.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(crypto.PubkeyToAddress(exceeder.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(crypto.PubkeyToAddress(overdrafter.PublicKey), big.NewInt(1000000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(crypto.PubkeyToAddress(overcapper.PublicKey), big.NewInt(10000000), tracing.BalanceChangeUnspecified)
 	statedb.Commit(0, true)
 
 	chain := &testBlockChain{
@@ -600,7 +628,7 @@ func TestOpenDrops(t *testing.T) {
 //   - 2. Eviction thresholds are calculated correctly for the sequences
 //   - 3. Balance usage of an account is totals across all transactions
 func TestOpenIndex(t *testing.T) {
-	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlTrace, true)))
 
 	// Create a temporary folder for the persistent backend
 	storage, _ := os.MkdirTemp("", "blobpool-")
@@ -637,7 +665,7 @@ func TestOpenIndex(t *testing.T) {
 
 	// Create a blob pool out of the pre-seeded data
 	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
-	statedb.AddBalance(addr, big.NewInt(1_000_000_000))
+	statedb.AddBalance(addr, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
 	statedb.Commit(0, true)
 
 	chain := &testBlockChain{
@@ -689,7 +717,7 @@ func TestOpenIndex(t *testing.T) {
 // Tests that after indexing all the loaded transactions from disk, a price heap
 // is correctly constructed based on the head basefee and blobfee.
 func TestOpenHeap(t *testing.T) {
-	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlTrace, true)))
 
 	// Create a temporary folder for the persistent backend
 	storage, _ := os.MkdirTemp("", "blobpool-")
@@ -737,9 +765,9 @@ func TestOpenHeap(t *testing.T) {
 
 	// Create a blob pool out of the pre-seeded data
 	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
-	statedb.AddBalance(addr1, big.NewInt(1_000_000_000))
-	statedb.AddBalance(addr2, big.NewInt(1_000_000_000))
-	statedb.AddBalance(addr3, big.NewInt(1_000_000_000))
+	statedb.AddBalance(addr1, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(addr2, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.AddBalance(addr3, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
 	statedb.Commit(0, true)
 
 	chain := &testBlockChain{
@@ -776,7 +804,7 @@ func TestOpenHeap(t *testing.T) {
 // Tests that after the pool's previous state is loaded back, any transactions
 // over the new storage cap will get dropped.
 func TestOpenCap(t *testing.T) {
-	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlTrace, true)))
 
 	// Create a temporary folder for the persistent backend
 	storage, _ := os.MkdirTemp("", "blobpool-")
@@ -817,9 +845,9 @@ func TestOpenCap(t *testing.T) {
 	for _, datacap := range []uint64{2 * (txAvgSize + blobSize), 100 * (txAvgSize + blobSize)} {
 		// Create a blob pool out of the pre-seeded data, but cap it to 2 blob transaction
 		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
-		statedb.AddBalance(addr1, big.NewInt(1_000_000_000))
-		statedb.AddBalance(addr2, big.NewInt(1_000_000_000))
-		statedb.AddBalance(addr3, big.NewInt(1_000_000_000))
+		statedb.AddBalance(addr1, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
+		statedb.AddBalance(addr2, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
+		statedb.AddBalance(addr3, big.NewInt(1_000_000_000), tracing.BalanceChangeUnspecified)
 		statedb.Commit(0, true)
 
 		chain := &testBlockChain{
@@ -868,7 +896,7 @@ func TestOpenCap(t *testing.T) {
 // specific to the blob pool. It does not do an exhaustive transaction validity
 // check.
 func TestAdd(t *testing.T) {
-	log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlTrace, true)))
 
 	// seed is a helper tumpe to seed an initial state db and pool
 	type seed struct {
@@ -1210,7 +1238,7 @@ func TestAdd(t *testing.T) {
 			addrs[acc] = crypto.PubkeyToAddress(keys[acc].PublicKey)
 
 			// Seed the state database with this acocunt
-			statedb.AddBalance(addrs[acc], new(big.Int).SetUint64(seed.balance))
+			statedb.AddBalance(addrs[acc], new(big.Int).SetUint64(seed.balance), tracing.BalanceChangeUnspecified)
 			statedb.SetNonce(addrs[acc], seed.nonce)
 
 			// Sign the seed transactions and store them in the data store
@@ -1249,3 +1277,237 @@ func TestAdd(t *testing.T) {
 		pool.Close()
 	}
 }
+
+// Tests that blobs and proofs can be retrieved by their versioned hashes
+// for transactions loaded from disk on startup.
+func TestGetBlobs(t *testing.T) {
+	log.SetDefault(log.NewLogger(log.NewTerminalHandlerWithLevel(os.Stderr, log.LvlTrace, true)))
+
+	// Create a temporary folder for the persistent backend
+	storage, _ := os.MkdirTemp("", "blobpool-")
+	defer os.RemoveAll(storage)
+
+	os.MkdirAll(filepath.Join(storage, pendingTransactionStore), 0700)
+	store, _ := billy.Open(billy.Options{Path: filepath.Join(storage, pendingTransactionStore)}, newSlotter(), nil)
+
+	// Create a few distinct blobs and spread them across two transactions
+	var (
+		blobs   = make([]kzg4844.Blob, 3)
+		commits = make([]kzg4844.Commitment, 3)
+		proofs  = make([]kzg4844.Proof, 3)
+		vhashes = make([]common.Hash, 3)
+	)
+	for i := range blobs {
+		blobs[i][1] = byte(i + 1) // keep the field element below the modulus
+		commits[i], _ = kzg4844.BlobToCommitment(blobs[i])
+		proofs[i], _ = kzg4844.ComputeBlobProof(blobs[i], commits[i])
+		vhashes[i] = blobHash(commits[i])
+	}
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+	)
+	tx0 := makeUnsignedTx(0, 10, 100, 10)
+	tx0.BlobHashes = vhashes[:2]
+	tx0.Sidecar = &types.BlobTxSidecar{Blobs: blobs[:2], Commitments: commits[:2], Proofs: proofs[:2]}
+
+	tx1 := makeUnsignedTx(1, 10, 100, 10)
+	tx1.BlobHashes = vhashes[2:]
+	tx1.Sidecar = &types.BlobTxSidecar{Blobs: blobs[2:], Commitments: commits[2:], Proofs: proofs[2:]}
+
+	for _, tx := range []*types.BlobTx{tx0, tx1} {
+		blob, _ := rlp.EncodeToBytes(types.MustSignNewTx(key, types.LatestSigner(testChainConfig), tx))
+		store.Put(blob)
+	}
+	store.Close()
+
+	// Create a blob pool out of the pre-seeded data
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
+	statedb.AddBalance(addr, big.NewInt(1_000_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.Commit(0, true)
+
+	chain := &testBlockChain{
+		config:  testChainConfig,
+		basefee: uint256.NewInt(params.InitialBaseFee),
+		blobfee: uint256.NewInt(params.BlobTxMinBlobGasprice),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: storage}, chain)
+	if err := pool.Init(big.NewInt(1), chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	verifyPoolInternals(t, pool)
+
+	// Request the blobs out of order, with an unknown and a duplicate hash mixed in
+	request := []common.Hash{vhashes[2], {0x01, 0xff}, vhashes[0], vhashes[1], vhashes[2]}
+	expect := []int{2, -1, 0, 1, 2}
+
+	haveBlobs, haveProofs := pool.GetBlobs(request)
+	if len(haveBlobs) != len(request) || len(haveProofs) != len(request) {
+		t.Fatalf("result length mismatch: have %d/%d, want %d", len(haveBlobs), len(haveProofs), len(request))
+	}
+	for i, idx := range expect {
+		if idx < 0 {
+			if haveBlobs[i] != nil || haveProofs[i] != nil {
+				t.Errorf("item %d: unexpected blob for unknown hash", i)
+			}
+			continue
+		}
+		if haveBlobs[i] == nil || *haveBlobs[i] != blobs[idx] {
+			t.Errorf("item %d: blob mismatch", i)
+		}
+		if haveProofs[i] == nil || *haveProofs[i] != proofs[idx] {
+			t.Errorf("item %d: proof mismatch", i)
+		}
+	}
+}
+
+// Tests that the lifecycle events of blob transactions are recorded along with
+// the reasons of their removal, and that removals are fed to the subscribers.
+func TestHistory(t *testing.T) {
+	// Create a temporary folder for the persistent backend
+	storage, _ := os.MkdirTemp("", "blobpool-")
+	defer os.RemoveAll(storage)
+
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
+	statedb.AddBalance(addr, big.NewInt(1_000_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.Commit(0, true)
+
+	chain := &testBlockChain{
+		config:  testChainConfig,
+		basefee: uint256.NewInt(1050),
+		blobfee: uint256.NewInt(105),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: storage}, chain)
+	if err := pool.Init(big.NewInt(1), chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	drops := make(chan txpool.TxEvent, 16)
+	sub := pool.SubscribeDropped(drops)
+	defer sub.Unsubscribe()
+
+	// Add a transaction, replace it and try to replace it again without a price
+	// bump, finally raise the tip threshold above the replacement's tip
+	var (
+		tx      = makeTx(0, 1, 1100, 110, key)
+		replace = makeTx(0, 2, 2200, 220, key)
+		cheap   = makeTx(0, 3, 2201, 221, key)
+	)
+	if err := pool.add(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.add(replace); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if err := pool.add(cheap); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("underpriced replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	pool.SetGasTip(big.NewInt(3))
+	verifyPoolInternals(t, pool)
+
+	if events := pool.History(tx.Hash()); len(events) != 2 || events[0].Kind != txpool.TxEventAdded || events[1].Kind != txpool.TxEventReplaced {
+		t.Errorf("replaced transaction history mismatch: %v", events)
+	} else if events[1].ReplacedBy == nil || *events[1].ReplacedBy != replace.Hash() {
+		t.Errorf("replacement mismatch: have %v, want %x", events[1].ReplacedBy, replace.Hash())
+	}
+	if events := pool.History(replace.Hash()); len(events) != 2 || events[0].Kind != txpool.TxEventAdded || events[1].Kind != txpool.TxEventDropped {
+		t.Errorf("dropped transaction history mismatch: %v", events)
+	} else if events[1].Reason != txpool.ErrUnderpriced.Error() {
+		t.Errorf("drop reason mismatch: have %q, want %q", events[1].Reason, txpool.ErrUnderpriced)
+	}
+	if events := pool.History(cheap.Hash()); len(events) != 1 || events[0].Kind != txpool.TxEventRejected {
+		t.Errorf("rejected transaction history mismatch: %v", events)
+	} else if !strings.HasPrefix(events[0].Reason, txpool.ErrReplaceUnderpriced.Error()) {
+		t.Errorf("reject reason mismatch: have %q, want %q", events[0].Reason, txpool.ErrReplaceUnderpriced)
+	}
+	// Ensure the removals were fed to the subscriber in order
+	for i, want := range []common.Hash{tx.Hash(), replace.Hash()} {
+		select {
+		case event := <-drops:
+			if event.Hash != want {
+				t.Errorf("drop event %d: hash mismatch: have %x, want %x", i, event.Hash, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("drop event %d: timeout", i)
+		}
+	}
+}
+
+// Tests that blob transactions whose nonces were consumed by the chain are only
+// recorded as dropped if the signer swapped them out for different ones.
+func TestHistoryIncluded(t *testing.T) {
+	// Create a temporary folder for the persistent backend
+	storage, _ := os.MkdirTemp("", "blobpool-")
+	defer os.RemoveAll(storage)
+
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+	)
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
+	statedb.AddBalance(addr, big.NewInt(1_000_000_000_000), tracing.BalanceChangeUnspecified)
+	statedb.Commit(0, true)
+
+	chain := &testBlockChain{
+		config:  testChainConfig,
+		basefee: uint256.NewInt(1050),
+		blobfee: uint256.NewInt(105),
+		statedb: statedb,
+	}
+	pool := New(Config{Datadir: storage}, chain)
+	if err := pool.Init(big.NewInt(1), chain.CurrentBlock(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	drops := make(chan txpool.TxEvent, 16)
+	sub := pool.SubscribeDropped(drops)
+	defer sub.Unsubscribe()
+
+	var (
+		included = makeTx(0, 1, 1100, 110, key)
+		swapped  = makeTx(1, 1, 1100, 110, key)
+	)
+	if err := pool.add(included); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.add(swapped); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Consume both nonces, but only include the first transaction
+	statedb.SetNonce(addr, 2)
+	pool.recheck(addr, map[common.Hash]uint64{included.Hash(): 1})
+	verifyPoolInternals(t, pool)
+
+	if events := pool.History(included.Hash()); len(events) != 2 || events[1].Kind != txpool.TxEventIncluded {
+		t.Errorf("included transaction history mismatch: %v", events)
+	}
+	if events := pool.History(swapped.Hash()); len(events) != 2 || events[1].Kind != txpool.TxEventDropped {
+		t.Errorf("swapped transaction history mismatch: %v", events)
+	} else if events[1].Reason != core.ErrNonceTooLow.Error() {
+		t.Errorf("drop reason mismatch: have %q, want %q", events[1].Reason, core.ErrNonceTooLow)
+	}
+	// Ensure only the swapped out transaction was fed to the subscriber
+	select {
+	case event := <-drops:
+		if event.Hash != swapped.Hash() {
+			t.Errorf("drop event hash mismatch: have %x, want %x", event.Hash, swapped.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("drop event timeout")
+	}
+	select {
+	case event := <-drops:
+		t.Errorf("unexpected drop event: %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
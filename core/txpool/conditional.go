@@ -0,0 +1,276 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// maxConditionalCost is the maximum number of storage roots and slots a
+	// single conditional may reference, bounding the cost of re-checking it on
+	// every new head.
+	maxConditionalCost = 1000
+
+	// conditionalBudget is the total cost of all the conditionals tracked by the
+	// pool, bounding the work of re-checking them on every new head. Every
+	// conditional costs one unit on top of its state items.
+	conditionalBudget = 100_000
+
+	// maxEvictedConditionals is the number of evicted conditional transactions
+	// for which the eviction reason is remembered.
+	maxEvictedConditionals = 4096
+)
+
+// Remover is an optional interface for subpools able to forcefully remove a
+// single transaction, needed to evict conditional transactions whose conditions
+// no longer hold.
+type Remover interface {
+	// RemoveTx removes a transaction from the pool for the given reason, returning
+	// whether it was tracked. Subsequent transactions from the same account are
+	// demoted.
+	RemoveTx(hash common.Hash, reason error) bool
+}
+
+// KnownAccount is the expected state of an account a conditional transaction
+// depends on: either the root of its storage trie, or the values of some of its
+// storage slots.
+type KnownAccount struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+// TxConditional is a set of conditions a transaction is only valid under. The
+// conditions are checked against the head state whenever the chain moves and
+// against the pending block before inclusion.
+type TxConditional struct {
+	KnownAccounts  map[common.Address]KnownAccount
+	BlockNumberMin *uint64
+	BlockNumberMax *uint64
+	TimestampMin   *uint64
+	TimestampMax   *uint64
+}
+
+// Cost returns the number of state items that need to be checked to verify the
+// conditional.
+func (c *TxConditional) Cost() int {
+	cost := 0
+	for _, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			cost++
+		}
+		cost += len(account.StorageSlots)
+	}
+	return cost
+}
+
+// HasStorageRoots reports whether the conditional references any storage roots,
+// requiring the storage tries of the checked state to be hashed.
+func (c *TxConditional) HasStorageRoots() bool {
+	for _, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the conditional for sanity, independent of any chain state.
+func (c *TxConditional) Validate() error {
+	if c.BlockNumberMin != nil && c.BlockNumberMax != nil && *c.BlockNumberMin > *c.BlockNumberMax {
+		return fmt.Errorf("block number minimum %d above maximum %d", *c.BlockNumberMin, *c.BlockNumberMax)
+	}
+	if c.TimestampMin != nil && c.TimestampMax != nil && *c.TimestampMin > *c.TimestampMax {
+		return fmt.Errorf("timestamp minimum %d above maximum %d", *c.TimestampMin, *c.TimestampMax)
+	}
+	for addr, account := range c.KnownAccounts {
+		if account.StorageRoot != nil && len(account.StorageSlots) > 0 {
+			return fmt.Errorf("account %x: both storage root and slots specified", addr)
+		}
+	}
+	if cost := c.Cost(); cost > maxConditionalCost {
+		return fmt.Errorf("conditional cost %d exceeds maximum %d", cost, maxConditionalCost)
+	}
+	return nil
+}
+
+// Check verifies the conditional for a block with the given number and timestamp,
+// built on top of the given state. Storage roots are read from the state as is,
+// so pending modifications need to be hashed beforehand.
+func (c *TxConditional) Check(number uint64, time uint64, statedb *state.StateDB) error {
+	if c.BlockNumberMax != nil && number > *c.BlockNumberMax {
+		return fmt.Errorf("%w: block number %d above maximum %d", ErrConditionExpired, number, *c.BlockNumberMax)
+	}
+	if c.TimestampMax != nil && time > *c.TimestampMax {
+		return fmt.Errorf("%w: timestamp %d above maximum %d", ErrConditionExpired, time, *c.TimestampMax)
+	}
+	for addr, account := range c.KnownAccounts {
+		if account.StorageRoot != nil {
+			root := statedb.GetStorageRoot(addr)
+			if root == (common.Hash{}) {
+				root = types.EmptyRootHash // non-existent account
+			}
+			if root != *account.StorageRoot {
+				return fmt.Errorf("%w: account %x storage root %x, want %x", ErrConditionViolated, addr, root, *account.StorageRoot)
+			}
+		}
+		for slot, want := range account.StorageSlots {
+			if have := statedb.GetState(addr, slot); have != want {
+				return fmt.Errorf("%w: account %x slot %x value %x, want %x", ErrConditionViolated, addr, slot, have, want)
+			}
+		}
+	}
+	if c.BlockNumberMin != nil && number < *c.BlockNumberMin {
+		return fmt.Errorf("%w: block number %d below minimum %d", ErrConditionNotMet, number, *c.BlockNumberMin)
+	}
+	if c.TimestampMin != nil && time < *c.TimestampMin {
+		return fmt.Errorf("%w: timestamp %d below minimum %d", ErrConditionNotMet, time, *c.TimestampMin)
+	}
+	return nil
+}
+
+// AddConditional inserts a transaction into the pool which is only valid as long
+// as the given conditions hold. The conditions are checked against the current
+// head first, rejecting the transaction if they are already expired or violated.
+func (p *TxPool) AddConditional(tx *types.Transaction, cond *TxConditional, local bool) error {
+	if err := cond.Validate(); err != nil {
+		return err
+	}
+	// Conditional transactions need to be evictable by their subpool
+	var remover Remover
+	for _, subpool := range p.subpools {
+		if subpool.Filter(tx) {
+			remover, _ = subpool.(Remover)
+			break
+		}
+	}
+	if remover == nil {
+		return ErrConditionalUnsupported
+	}
+	// Ensure the conditions hold on top of the current head
+	head := p.chain.CurrentBlock()
+	statedb, err := p.chain.StateAt(head.Root)
+	if err != nil {
+		return err
+	}
+	if err := cond.Check(head.Number.Uint64()+1, head.Time+1, statedb); err != nil && !errors.Is(err, ErrConditionNotMet) {
+		return err
+	}
+	// Track the conditional before insertion, so a reset racing with the add
+	// already checks it
+	hash := tx.Hash()
+
+	p.condLock.Lock()
+	if p.conditionals[hash] != nil {
+		p.condLock.Unlock()
+		return ErrAlreadyKnown
+	}
+	if p.conditionalCost+cond.Cost()+1 > conditionalBudget {
+		p.condLock.Unlock()
+		return ErrConditionalBudgetExceeded
+	}
+	p.trackConditional(hash, cond)
+	p.condLock.Unlock()
+
+	if err := p.Add([]*types.Transaction{tx}, local, true)[0]; err != nil {
+		p.condLock.Lock()
+		p.untrackConditional(hash)
+		p.condLock.Unlock()
+		return err
+	}
+	p.evicted.Remove(hash)
+	return nil
+}
+
+// Conditional returns the conditions of a pooled transaction, or nil if it is
+// not a conditional transaction.
+func (p *TxPool) Conditional(hash common.Hash) *TxConditional {
+	p.condLock.RLock()
+	defer p.condLock.RUnlock()
+
+	return p.conditionals[hash]
+}
+
+// EvictionReason returns the error a conditional transaction was evicted with,
+// or nil if it wasn't evicted (or it was forgotten since).
+func (p *TxPool) EvictionReason(hash common.Hash) error {
+	reason, _ := p.evicted.Get(hash)
+	return reason
+}
+
+// checkConditionals re-checks the conditions of all the tracked conditional
+// transactions on top of a new head, evicting the ones expired or violated.
+// Conditionals of transactions no longer in the pool are dropped.
+func (p *TxPool) checkConditionals(head *types.Header) {
+	p.condLock.Lock()
+	defer p.condLock.Unlock()
+
+	if len(p.conditionals) == 0 {
+		return
+	}
+	statedb, err := p.chain.StateAt(head.Root)
+	if err != nil {
+		log.Warn("Failed to check transaction conditionals", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	for hash, cond := range p.conditionals {
+		var pool SubPool
+		for _, subpool := range p.subpools {
+			if subpool.Has(hash) {
+				pool = subpool
+				break
+			}
+		}
+		if pool == nil {
+			// Included, replaced or dropped, stop tracking
+			p.untrackConditional(hash)
+			continue
+		}
+		err := cond.Check(head.Number.Uint64()+1, head.Time+1, statedb)
+		if err == nil || errors.Is(err, ErrConditionNotMet) {
+			continue
+		}
+		log.Debug("Evicting conditional transaction", "hash", hash, "err", err)
+		if remover, ok := pool.(Remover); ok {
+			remover.RemoveTx(hash, err)
+		}
+		p.untrackConditional(hash)
+		p.evicted.Add(hash, err)
+	}
+}
+
+// trackConditional starts tracking the conditional of a transaction, charging
+// its cost to the budget. The caller must hold p.condLock.
+func (p *TxPool) trackConditional(hash common.Hash, cond *TxConditional) {
+	p.conditionals[hash] = cond
+	p.conditionalCost += cond.Cost() + 1
+}
+
+// untrackConditional stops tracking the conditional of a transaction, returning
+// its cost to the budget. The caller must hold p.condLock.
+func (p *TxPool) untrackConditional(hash common.Hash) {
+	if cond := p.conditionals[hash]; cond != nil {
+		delete(p.conditionals, hash)
+		p.conditionalCost -= cond.Cost() + 1
+	}
+}
@@ -54,4 +54,25 @@ var (
 	// ErrFutureReplacePending is returned if a future transaction replaces a pending
 	// one. Future transactions should only be able to replace other future transactions.
 	ErrFutureReplacePending = errors.New("future transaction tries to replace pending")
+
+	// ErrConditionNotMet is returned if the block number or timestamp window of a
+	// conditional transaction has not been reached yet.
+	ErrConditionNotMet = errors.New("transaction conditions not met yet")
+
+	// ErrConditionExpired is returned if the block number or timestamp window of
+	// a conditional transaction has already passed.
+	ErrConditionExpired = errors.New("transaction conditions expired")
+
+	// ErrConditionViolated is returned if the state a conditional transaction
+	// depends on does not hold the expected values.
+	ErrConditionViolated = errors.New("transaction conditions violated")
+
+	// ErrConditionalUnsupported is returned if a conditional transaction is
+	// submitted to a subpool unable to evict it on a violated condition.
+	ErrConditionalUnsupported = errors.New("conditional transactions not supported for this type")
+
+	// ErrConditionalBudgetExceeded is returned if a conditional transaction is
+	// submitted while the conditionals tracked by the pool already use up the
+	// budget for re-checking them on every new head.
+	ErrConditionalBudgetExceeded = errors.New("conditional transaction budget exceeded")
 )
@@ -0,0 +1,183 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package txpool
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// DefaultHistoryLimit is the number of transactions for which the subpools
+	// remember the lifecycle events.
+	DefaultHistoryLimit = 16384
+
+	// maxTxEvents is the maximum number of lifecycle events remembered for a
+	// single transaction. Older events are discarded first.
+	maxTxEvents = 16
+
+	// dropEventBuffer is the number of drop events buffered for the subscribers
+	// before new ones are discarded.
+	dropEventBuffer = 1024
+)
+
+// droppedEventMeter counts the drop events that could not be delivered to the
+// subscribers because they were lagging behind.
+var droppedEventMeter = metrics.NewRegisteredMeter("txpool/history/dropped", nil)
+
+// TxEventKind is the type of a lifecycle event of a pooled transaction.
+type TxEventKind string
+
+const (
+	TxEventAdded    TxEventKind = "added"    // Accepted into the pool
+	TxEventPromoted TxEventKind = "promoted" // Became executable (pending)
+	TxEventDemoted  TxEventKind = "demoted"  // Moved back into the non-executable queue
+	TxEventReplaced TxEventKind = "replaced" // Replaced by a transaction with the same nonce
+	TxEventIncluded TxEventKind = "included" // Nonce consumed by the chain, usually by its inclusion
+	TxEventDropped  TxEventKind = "dropped"  // Removed from the pool
+	TxEventRejected TxEventKind = "rejected" // Refused entry into the pool
+)
+
+// TxEvent is a single entry in the lifecycle history of a transaction.
+type TxEvent struct {
+	Hash       common.Hash  `json:"hash"`
+	Kind       TxEventKind  `json:"kind"`
+	Time       time.Time    `json:"time"`
+	Reason     string       `json:"reason,omitempty"`
+	ReplacedBy *common.Hash `json:"replacedBy,omitempty"`
+}
+
+// History is a bounded record of the lifecycle events of the transactions seen
+// by a subpool, allowing to tell after the fact why a transaction is not (or no
+// longer) in the pool. Drop and replacement events are also fed to subscribers.
+type History struct {
+	txs  lru.BasicLRU[common.Hash, []TxEvent] // Recent lifecycle events by transaction hash
+	lock sync.Mutex                           // Lock protecting the recorded events
+
+	drops  chan TxEvent            // Drop events waiting for delivery to the subscribers
+	feed   event.Feed              // Event feed to send out drop events on
+	scope  event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
+	active atomic.Bool             // Whether the delivery loop is running
+	start  sync.Once               // Starts the delivery loop on the first subscription
+	quit   chan struct{}           // Quit channel to tear down the delivery loop
+}
+
+// NewHistory creates a lifecycle history tracking the events of up to limit
+// transactions.
+func NewHistory(limit int) *History {
+	return &History{
+		txs:   lru.NewBasicLRU[common.Hash, []TxEvent](limit),
+		drops: make(chan TxEvent, dropEventBuffer),
+		quit:  make(chan struct{}),
+	}
+}
+
+// Record adds a lifecycle event to the history of a transaction. The reason is
+// optional and should be set for drops and rejections.
+func (h *History) Record(hash common.Hash, kind TxEventKind, reason error) {
+	ev := TxEvent{
+		Hash: hash,
+		Kind: kind,
+		Time: time.Now(),
+	}
+	if reason != nil {
+		ev.Reason = reason.Error()
+	}
+	h.record(ev)
+}
+
+// RecordReplaced adds a replacement event to the history of a transaction,
+// referencing the transaction it was superseded by.
+func (h *History) RecordReplaced(hash common.Hash, by common.Hash) {
+	h.record(TxEvent{
+		Hash:       hash,
+		Kind:       TxEventReplaced,
+		Time:       time.Now(),
+		ReplacedBy: &by,
+	})
+}
+
+// record appends an event to the history of a transaction and queues it up for
+// the subscribers if it's a drop event.
+func (h *History) record(ev TxEvent) {
+	h.lock.Lock()
+	events, _ := h.txs.Get(ev.Hash)
+	if len(events) >= maxTxEvents {
+		events = append(events[:0:0], events[len(events)-maxTxEvents+1:]...)
+	}
+	h.txs.Add(ev.Hash, append(events, ev))
+	h.lock.Unlock()
+
+	// Notify any subscribers of removals without blocking the pool
+	if ev.Kind != TxEventDropped && ev.Kind != TxEventReplaced {
+		return
+	}
+	if !h.active.Load() {
+		return
+	}
+	select {
+	case h.drops <- ev:
+	default:
+		droppedEventMeter.Mark(1)
+	}
+}
+
+// Get retrieves the recorded lifecycle events of a transaction, oldest first.
+func (h *History) Get(hash common.Hash) []TxEvent {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	events, ok := h.txs.Peek(hash)
+	if !ok {
+		return nil
+	}
+	return append([]TxEvent(nil), events...)
+}
+
+// SubscribeDropped registers a subscription for transactions being dropped from
+// or replaced in the pool.
+func (h *History) SubscribeDropped(ch chan<- TxEvent) event.Subscription {
+	h.start.Do(func() {
+		h.active.Store(true)
+		go h.loop()
+	})
+	return h.scope.Track(h.feed.Subscribe(ch))
+}
+
+// loop delivers the queued drop events to the subscribers, decoupling the pool
+// from slow consumers.
+func (h *History) loop() {
+	for {
+		select {
+		case ev := <-h.drops:
+			h.feed.Send(ev)
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// Close terminates the drop event delivery and unsubscribes all subscribers.
+func (h *History) Close() {
+	close(h.quit)
+	h.scope.Close()
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -57,6 +58,10 @@ var (
 	// ErrTxPoolOverflow is returned if the transaction pool is full and can't accept
 	// another remote transaction.
 	ErrTxPoolOverflow = errors.New("txpool is full")
+
+	// ErrTxLifetimeExceeded is recorded as the drop reason of non-executable
+	// transactions evicted after the account was inactive for too long.
+	ErrTxLifetimeExceeded = errors.New("queued transaction lifetime exceeded")
 )
 
 var (
@@ -220,6 +225,7 @@ type LegacyPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *lookup                      // All transactions to allow lookups
 	priced  *pricedList                  // All transactions sorted by price
+	history *txpool.History              // Lifecycle events of the recently seen transactions
 
 	reqResetCh      chan *txpoolResetRequest
 	reqPromoteCh    chan *accountSet
@@ -252,6 +258,7 @@ func New(config Config, chain BlockChain) *LegacyPool {
 		queue:           make(map[common.Address]*list),
 		beats:           make(map[common.Address]time.Time),
 		all:             newLookup(),
+		history:         txpool.NewHistory(txpool.DefaultHistoryLimit),
 		reqResetCh:      make(chan *txpoolResetRequest),
 		reqPromoteCh:    make(chan *accountSet),
 		queueTxEventCh:  make(chan *types.Transaction),
@@ -378,6 +385,7 @@ func (pool *LegacyPool) loop() {
 					list := pool.queue[addr].Flatten()
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true, true)
+						pool.history.Record(tx.Hash(), txpool.TxEventDropped, ErrTxLifetimeExceeded)
 					}
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
@@ -406,6 +414,7 @@ func (pool *LegacyPool) Close() error {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	pool.history.Close()
 	log.Info("Transaction pool stopped")
 	return nil
 }
@@ -442,6 +451,7 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 		drop := pool.all.RemotesBelowTip(tip)
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false, true)
+			pool.history.Record(tx.Hash(), txpool.TxEventDropped, txpool.ErrUnderpriced)
 		}
 		pool.priced.Removed(len(drop))
 	}
@@ -658,6 +668,15 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		knownTxMeter.Mark(1)
 		return false, txpool.ErrAlreadyKnown
 	}
+	// Record the reason if the transaction is refused by any of the checks below.
+	//
+	// Note, `err` here is the named error return, same as for the reservation
+	// release further down.
+	defer func() {
+		if err != nil {
+			pool.history.Record(hash, txpool.TxEventRejected, err)
+		}
+	}()
 	// Make the local flag. If it's from local source or it's from the network but
 	// the sender is marked as local previously, treat it as the local transaction.
 	isLocal := local || pool.locals.containsTx(tx)
@@ -750,6 +769,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 
 			sender, _ := types.Sender(pool.signer, tx)
 			dropped := pool.removeTx(tx.Hash(), false, sender != from) // Don't unreserve the sender of the tx being added if last from the acc
+			pool.history.Record(tx.Hash(), txpool.TxEventDropped, txpool.ErrUnderpriced)
 
 			pool.changesSinceReorg += dropped
 		}
@@ -768,11 +788,14 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.history.RecordReplaced(old.Hash(), hash)
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
+		pool.history.Record(hash, txpool.TxEventAdded, nil)
+		pool.history.Record(hash, txpool.TxEventPromoted, nil)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
@@ -794,6 +817,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		localGauge.Inc(1)
 	}
 	pool.journalTx(from, tx)
+	pool.history.Record(hash, txpool.TxEventAdded, nil)
 
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
 	return replaced, nil
@@ -843,6 +867,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.history.RecordReplaced(old.Hash(), hash)
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -892,6 +917,7 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.history.Record(hash, txpool.TxEventDropped, txpool.ErrReplaceUnderpriced)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -899,12 +925,14 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.history.RecordReplaced(old.Hash(), hash)
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
+	pool.history.Record(hash, txpool.TxEventPromoted, nil)
 
 	// Successful promotion, bump the heartbeat
 	pool.beats[addr] = time.Now()
@@ -1043,6 +1071,17 @@ func (pool *LegacyPool) Status(hash common.Hash) txpool.TxStatus {
 	return txpool.TxStatusUnknown
 }
 
+// History retrieves the recorded lifecycle events of a transaction, oldest first.
+func (pool *LegacyPool) History(hash common.Hash) []txpool.TxEvent {
+	return pool.history.Get(hash)
+}
+
+// SubscribeDropped registers a subscription for transactions being dropped from
+// or replaced in the pool.
+func (pool *LegacyPool) SubscribeDropped(ch chan<- txpool.TxEvent) event.Subscription {
+	return pool.history.SubscribeDropped(ch)
+}
+
 // Get returns a transaction if it is contained in the pool and nil otherwise.
 func (pool *LegacyPool) Get(hash common.Hash) *types.Transaction {
 	tx := pool.get(hash)
@@ -1052,6 +1091,12 @@ func (pool *LegacyPool) Get(hash common.Hash) *types.Transaction {
 	return tx
 }
 
+// GetBlobs is not supported by the legacy transaction pool, it is just here to
+// implement the txpool.SubPool interface.
+func (pool *LegacyPool) GetBlobs(vhashes []common.Hash) ([]*kzg4844.Blob, []*kzg4844.Proof) {
+	return nil, nil
+}
+
 // get returns a transaction if it is contained in the pool and nil otherwise.
 func (pool *LegacyPool) get(hash common.Hash) *types.Transaction {
 	return pool.all.Get(hash)
@@ -1063,6 +1108,22 @@ func (pool *LegacyPool) Has(hash common.Hash) bool {
 	return pool.all.Get(hash) != nil
 }
 
+// RemoveTx removes a single transaction from the pool, moving all subsequent
+// transactions of the account back to the future queue. It implements the
+// txpool.Remover interface, used to evict failed conditional transactions.
+func (pool *LegacyPool) RemoveTx(hash common.Hash, reason error) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return false
+	}
+	// Only remote transactions are tracked by the price heap
+	pool.removeTx(hash, pool.all.GetRemote(hash) != nil, true)
+	pool.history.Record(hash, txpool.TxEventDropped, reason)
+	return true
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 //
@@ -1113,6 +1174,7 @@ func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bo
 			for _, tx := range invalids {
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(tx.Hash(), tx, false, false)
+				pool.history.Record(tx.Hash(), txpool.TxEventDemoted, nil)
 			}
 			// Update the account nonce if needed
 			pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1436,6 +1498,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range forwards {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.history.Record(hash, txpool.TxEventIncluded, nil)
 		}
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
@@ -1443,6 +1506,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 		for _, tx := range drops {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.history.Record(hash, txpool.TxEventDropped, unpayableReason(tx, gasLimit))
 		}
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
@@ -1465,6 +1529,7 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 			for _, tx := range caps {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
+				pool.history.Record(hash, txpool.TxEventDropped, txpool.ErrAccountLimitExceeded)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
 			queuedRateLimitMeter.Mark(int64(len(caps)))
@@ -1530,6 +1595,7 @@ func (pool *LegacyPool) truncatePending() {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
 						pool.all.Remove(hash)
+						pool.history.Record(hash, txpool.TxEventDropped, ErrTxPoolOverflow)
 
 						// Update the account nonce to the dropped transaction
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
@@ -1557,6 +1623,7 @@ func (pool *LegacyPool) truncatePending() {
 					// Drop the transaction from the global pools too
 					hash := tx.Hash()
 					pool.all.Remove(hash)
+					pool.history.Record(hash, txpool.TxEventDropped, ErrTxPoolOverflow)
 
 					// Update the account nonce to the dropped transaction
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
@@ -1604,6 +1671,7 @@ func (pool *LegacyPool) truncateQueue() {
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
 				pool.removeTx(tx.Hash(), true, true)
+				pool.history.Record(tx.Hash(), txpool.TxEventDropped, ErrTxPoolOverflow)
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
@@ -1613,6 +1681,7 @@ func (pool *LegacyPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true, true)
+			pool.history.Record(txs[i].Hash(), txpool.TxEventDropped, ErrTxPoolOverflow)
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1637,6 +1706,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 		for _, tx := range olds {
 			hash := tx.Hash()
 			pool.all.Remove(hash)
+			pool.history.Record(hash, txpool.TxEventIncluded, nil)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
@@ -1645,6 +1715,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
+			pool.history.Record(hash, txpool.TxEventDropped, unpayableReason(tx, gasLimit))
 		}
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
@@ -1654,6 +1725,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 
 			// Internal shuffle shouldn't touch the lookup set.
 			pool.enqueueTx(hash, tx, false, false)
+			pool.history.Record(hash, txpool.TxEventDemoted, nil)
 		}
 		pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
 		if pool.locals.contains(addr) {
@@ -1668,6 +1740,7 @@ func (pool *LegacyPool) demoteUnexecutables() {
 
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(hash, tx, false, false)
+				pool.history.Record(hash, txpool.TxEventDemoted, nil)
 			}
 			pendingGauge.Dec(int64(len(gapped)))
 		}
@@ -1681,6 +1754,15 @@ func (pool *LegacyPool) demoteUnexecutables() {
 	}
 }
 
+// unpayableReason returns the reason for dropping a transaction filtered out as
+// too costly: either exceeding the block gas limit or the sender's balance.
+func unpayableReason(tx *types.Transaction, gasLimit uint64) error {
+	if tx.Gas() > gasLimit {
+		return txpool.ErrGasLimit
+	}
+	return core.ErrInsufficientFunds
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -33,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -255,7 +257,7 @@ func (c *testChain) State() (*state.StateDB, error) {
 		c.statedb, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 		// simulate that the new head block included tx0 and tx1
 		c.statedb.SetNonce(c.address, 2)
-		c.statedb.SetBalance(c.address, new(big.Int).SetUint64(params.Ether))
+		c.statedb.SetBalance(c.address, new(big.Int).SetUint64(params.Ether), tracing.BalanceChangeUnspecified)
 		*c.trigger = false
 	}
 	return stdb, nil
@@ -275,7 +277,7 @@ func TestStateChangeDuringReset(t *testing.T) {
 	)
 
 	// setup pool with 2 transaction in it
-	statedb.SetBalance(address, new(big.Int).SetUint64(params.Ether))
+	statedb.SetBalance(address, new(big.Int).SetUint64(params.Ether), tracing.BalanceChangeUnspecified)
 	blockchain := &testChain{newTestBlockChain(params.TestChainConfig, 1000000000, statedb, new(event.Feed)), address, &trigger}
 
 	tx0 := transaction(0, 100000, key)
@@ -309,7 +311,7 @@ func TestStateChangeDuringReset(t *testing.T) {
 
 func testAddBalance(pool *LegacyPool, addr common.Address, amount *big.Int) {
 	pool.mu.Lock()
-	pool.currentState.AddBalance(addr, amount)
+	pool.currentState.AddBalance(addr, amount, tracing.BalanceChangeUnspecified)
 	pool.mu.Unlock()
 }
 
@@ -470,7 +472,7 @@ func TestChainFork(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	resetState := func() {
 		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-		statedb.AddBalance(addr, big.NewInt(100000000000000))
+		statedb.AddBalance(addr, big.NewInt(100000000000000), tracing.BalanceChangeUnspecified)
 
 		pool.chain = newTestBlockChain(pool.chainconfig, 1000000, statedb, new(event.Feed))
 		<-pool.requestReset(nil, nil)
@@ -499,7 +501,7 @@ func TestDoubleNonce(t *testing.T) {
 	addr := crypto.PubkeyToAddress(key.PublicKey)
 	resetState := func() {
 		statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-		statedb.AddBalance(addr, big.NewInt(100000000000000))
+		statedb.AddBalance(addr, big.NewInt(100000000000000), tracing.BalanceChangeUnspecified)
 
 		pool.chain = newTestBlockChain(pool.chainconfig, 1000000, statedb, new(event.Feed))
 		<-pool.requestReset(nil, nil)
@@ -2618,7 +2620,7 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		key, _ := crypto.GenerateKey()
 		account := crypto.PubkeyToAddress(key.PublicKey)
-		pool.currentState.AddBalance(account, big.NewInt(1000000))
+		pool.currentState.AddBalance(account, big.NewInt(1000000), tracing.BalanceChangeUnspecified)
 		tx := transaction(uint64(0), 100000, key)
 		batches[i] = tx
 	}
@@ -2628,3 +2630,238 @@ func BenchmarkMultiAccountBatchInsert(b *testing.B) {
 		pool.addRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// Tests that conditional transactions are only accepted if their conditions hold
+// and that they are evicted with a reason once the conditions are violated.
+func TestConditionalTransactions(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 10000000, statedb, new(event.Feed))
+
+	var (
+		key, _   = crypto.GenerateKey()
+		contract = common.Address{0xc0}
+		slot     = common.Hash{0x01}
+		one, two = common.Hash{0x01}, common.Hash{0x02}
+		zero     = uint64(0)
+		ten      = uint64(10)
+	)
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000), tracing.BalanceChangeUnspecified)
+	statedb.SetState(contract, slot, one)
+
+	pool := New(testTxPoolConfig, blockchain)
+	txs, err := txpool.New(new(big.Int).SetUint64(testTxPoolConfig.PriceLimit), blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer txs.Close()
+
+	slots := func(value common.Hash) *txpool.TxConditional {
+		return &txpool.TxConditional{KnownAccounts: map[common.Address]txpool.KnownAccount{
+			contract: {StorageSlots: map[common.Hash]common.Hash{slot: value}},
+		}}
+	}
+	// Conditionals failing sanity checks or already failing should be rejected
+	if err := txs.AddConditional(transaction(0, 100000, key), &txpool.TxConditional{BlockNumberMin: &ten, BlockNumberMax: &zero}, false); err == nil {
+		t.Errorf("invalid conditional accepted")
+	}
+	if err := txs.AddConditional(transaction(0, 100000, key), slots(two), false); !errors.Is(err, txpool.ErrConditionViolated) {
+		t.Errorf("violated conditional error mismatch: have %v, want %v", err, txpool.ErrConditionViolated)
+	}
+	if err := txs.AddConditional(transaction(0, 100000, key), &txpool.TxConditional{BlockNumberMax: &zero}, false); !errors.Is(err, txpool.ErrConditionExpired) {
+		t.Errorf("expired conditional error mismatch: have %v, want %v", err, txpool.ErrConditionExpired)
+	}
+	// Add a conditional transaction whose conditions hold, with a plain one on top
+	tx0, tx1 := transaction(0, 100000, key), transaction(1, 100000, key)
+	if err := txs.AddConditional(tx0, slots(one), false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	if err := txs.Add([]*types.Transaction{tx1}, false, true)[0]; err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if txs.Conditional(tx0.Hash()) == nil || txs.Conditional(tx1.Hash()) != nil {
+		t.Fatalf("conditional tracking mismatch")
+	}
+	if status := txs.Status(tx0.Hash()); status != txpool.TxStatusPending {
+		t.Fatalf("conditional transaction status mismatch: have %v, want %v", status, txpool.TxStatusPending)
+	}
+	// Violate the condition and move the chain forward, the transaction should be evicted
+	statedb.SetState(contract, slot, two)
+	blockchain.chainHeadFeed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{
+		ParentHash: blockchain.CurrentBlock().Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   blockchain.CurrentBlock().GasLimit,
+		BaseFee:    big.NewInt(1),
+	})})
+	for deadline := time.Now().Add(5 * time.Second); txs.Status(tx0.Hash()) != txpool.TxStatusEvicted; {
+		if time.Now().After(deadline) {
+			t.Fatalf("conditional transaction not evicted, status %v", txs.Status(tx0.Hash()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if reason := txs.EvictionReason(tx0.Hash()); !errors.Is(reason, txpool.ErrConditionViolated) {
+		t.Errorf("eviction reason mismatch: have %v, want %v", reason, txpool.ErrConditionViolated)
+	}
+	if txs.Conditional(tx0.Hash()) != nil {
+		t.Errorf("evicted conditional still tracked")
+	}
+	if events := txs.History(tx0.Hash()); len(events) == 0 || !strings.HasPrefix(events[len(events)-1].Reason, txpool.ErrConditionViolated.Error()) {
+		t.Errorf("eviction not recorded in history: %v", events)
+	}
+	// The subsequent transaction should be demoted due to the nonce gap
+	if status := txs.Status(tx1.Hash()); status != txpool.TxStatusQueued {
+		t.Errorf("subsequent transaction status mismatch: have %v, want %v", status, txpool.TxStatusQueued)
+	}
+}
+
+// Tests that the conditionals tracked by the pool are bounded by a total cost
+// budget, which is freed up again as conditional transactions leave the pool.
+func TestConditionalBudget(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := newTestBlockChain(params.TestChainConfig, 10000000, statedb, new(event.Feed))
+
+	pool := New(testTxPoolConfig, blockchain)
+	txs, err := txpool.New(new(big.Int).SetUint64(testTxPoolConfig.PriceLimit), blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer txs.Close()
+
+	// Every conditional references 999 unset slots, costing 1000 units
+	cond := &txpool.TxConditional{KnownAccounts: map[common.Address]txpool.KnownAccount{
+		{0xc0}: {StorageSlots: make(map[common.Hash]common.Hash)},
+	}}
+	for i := 0; i < 999; i++ {
+		cond.KnownAccounts[common.Address{0xc0}].StorageSlots[common.Hash{byte(i), byte(i >> 8)}] = common.Hash{}
+	}
+	keys := make([]*ecdsa.PrivateKey, 101)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		statedb.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000), tracing.BalanceChangeUnspecified)
+	}
+	for i := 0; i < 100; i++ {
+		if err := txs.AddConditional(transaction(0, 100000, keys[i]), cond, false); err != nil {
+			t.Fatalf("conditional transaction %d rejected: %v", i, err)
+		}
+	}
+	if err := txs.AddConditional(transaction(0, 100000, keys[100]), cond, false); !errors.Is(err, txpool.ErrConditionalBudgetExceeded) {
+		t.Fatalf("budget error mismatch: have %v, want %v", err, txpool.ErrConditionalBudgetExceeded)
+	}
+	// Adding an already tracked transaction doesn't stop tracking it
+	if err := txs.AddConditional(transaction(0, 100000, keys[0]), &txpool.TxConditional{}, false); !errors.Is(err, txpool.ErrAlreadyKnown) {
+		t.Fatalf("known error mismatch: have %v, want %v", err, txpool.ErrAlreadyKnown)
+	}
+	if txs.Conditional(transaction(0, 100000, keys[0]).Hash()) == nil {
+		t.Fatalf("conditional no longer tracked after duplicate submission")
+	}
+	// Remove a conditional transaction from the pool, freeing up its budget
+	pool.RemoveTx(transaction(0, 100000, keys[0]).Hash(), errors.New("test"))
+	blockchain.chainHeadFeed.Send(core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{
+		ParentHash: blockchain.CurrentBlock().Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   blockchain.CurrentBlock().GasLimit,
+		BaseFee:    big.NewInt(1),
+	})})
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		err := txs.AddConditional(transaction(0, 100000, keys[100]), cond, false)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("budget not freed up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that the lifecycle events of transactions are recorded along with the
+// reasons of their removal, and that removals are fed to the subscribers.
+func TestTransactionHistory(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000000))
+
+	drops := make(chan txpool.TxEvent, 16)
+	sub := pool.SubscribeDropped(drops)
+	defer sub.Unsubscribe()
+
+	var (
+		tx0     = pricedTransaction(0, 100000, big.NewInt(1), key)
+		tx1     = pricedTransaction(1, 100000, big.NewInt(1), key)
+		replace = pricedTransaction(0, 100000, big.NewInt(2), key)
+		cheap   = pricedTransaction(0, 100001, big.NewInt(2), key)
+		evict   = errors.New("evicted by test")
+	)
+	// Add a couple of executable transactions, replace the first one and try to
+	// replace it again without a price bump
+	if errs := pool.addRemotesSync([]*types.Transaction{tx0, tx1}); errs[0] != nil || errs[1] != nil {
+		t.Fatalf("failed to add transactions: %v", errs)
+	}
+	if err := pool.addRemoteSync(replace); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(cheap); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("underpriced replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	// Evict the replacement, demoting the next transaction, then mine its nonce
+	if !pool.RemoveTx(replace.Hash(), evict) {
+		t.Fatalf("failed to remove transaction")
+	}
+	testSetNonce(pool, addr, 2)
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer, addr))
+
+	tests := []struct {
+		tx     *types.Transaction
+		kinds  []txpool.TxEventKind
+		reason string
+	}{
+		{tx0, []txpool.TxEventKind{txpool.TxEventAdded, txpool.TxEventPromoted, txpool.TxEventReplaced}, ""},
+		{tx1, []txpool.TxEventKind{txpool.TxEventAdded, txpool.TxEventPromoted, txpool.TxEventDemoted, txpool.TxEventIncluded}, ""},
+		{replace, []txpool.TxEventKind{txpool.TxEventAdded, txpool.TxEventPromoted, txpool.TxEventDropped}, evict.Error()},
+		{cheap, []txpool.TxEventKind{txpool.TxEventRejected}, txpool.ErrReplaceUnderpriced.Error()},
+	}
+	for i, tt := range tests {
+		events := pool.History(tt.tx.Hash())
+		if len(events) != len(tt.kinds) {
+			t.Errorf("test %d: event count mismatch: have %d, want %d", i, len(events), len(tt.kinds))
+			continue
+		}
+		for j, event := range events {
+			if event.Hash != tt.tx.Hash() {
+				t.Errorf("test %d, event %d: hash mismatch: have %x, want %x", i, j, event.Hash, tt.tx.Hash())
+			}
+			if event.Kind != tt.kinds[j] {
+				t.Errorf("test %d, event %d: kind mismatch: have %s, want %s", i, j, event.Kind, tt.kinds[j])
+			}
+		}
+		if last := events[len(events)-1]; last.Reason != tt.reason {
+			t.Errorf("test %d: reason mismatch: have %q, want %q", i, last.Reason, tt.reason)
+		}
+	}
+	if events := pool.History(tx0.Hash()); events[2].ReplacedBy == nil || *events[2].ReplacedBy != replace.Hash() {
+		t.Errorf("replacement mismatch: have %v, want %x", events[2].ReplacedBy, replace.Hash())
+	}
+	// Ensure the removals were fed to the subscriber in order, but not the inclusion
+	for i, want := range []common.Hash{tx0.Hash(), replace.Hash()} {
+		select {
+		case event := <-drops:
+			if event.Hash != want {
+				t.Errorf("drop event %d: hash mismatch: have %x, want %x", i, event.Hash, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("drop event %d: timeout", i)
+		}
+	}
+	select {
+	case event := <-drops:
+		t.Errorf("unexpected drop event: %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/event"
 )
 
@@ -101,6 +102,11 @@ type SubPool interface {
 	// Get returns a transaction if it is contained in the pool, or nil otherwise.
 	Get(hash common.Hash) *types.Transaction
 
+	// GetBlobs retrieves the blobs and KZG proofs for the given versioned hashes.
+	// Pools not tracking blobs return nil, otherwise the results are aligned with
+	// the requested hashes, with nil entries for the unknown ones.
+	GetBlobs(vhashes []common.Hash) ([]*kzg4844.Blob, []*kzg4844.Proof)
+
 	// Add enqueues a batch of transactions into the pool if they are valid. Due
 	// to the large transaction churn, add may postpone fully integrating the tx
 	// to a later point to batch multiple ones together.
@@ -137,4 +143,12 @@ type SubPool interface {
 	// Status returns the known status (unknown/pending/queued) of a transaction
 	// identified by their hashes.
 	Status(hash common.Hash) TxStatus
+
+	// History retrieves the recorded lifecycle events (added, promoted, demoted,
+	// replaced, dropped, rejected) of a transaction, oldest first.
+	History(hash common.Hash) []TxEvent
+
+	// SubscribeDropped subscribes to events of transactions being dropped from
+	// or replaced in the pool.
+	SubscribeDropped(ch chan<- TxEvent) event.Subscription
 }
@@ -20,11 +20,15 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -38,6 +42,7 @@ const (
 	TxStatusQueued
 	TxStatusPending
 	TxStatusIncluded
+	TxStatusEvicted
 )
 
 var (
@@ -57,6 +62,9 @@ type BlockChain interface {
 
 	// SubscribeChainHeadEvent subscribes to new blocks being added to the chain.
 	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+
+	// StateAt returns a state database for a given root hash (generally the head).
+	StateAt(root common.Hash) (*state.StateDB, error)
 }
 
 // TxPool is an aggregator for various transaction specific pools, collectively
@@ -65,7 +73,13 @@ type BlockChain interface {
 // They exit the pool when they are included in the blockchain or evicted due to
 // resource constraints.
 type TxPool struct {
-	subpools []SubPool // List of subpools for specialized transaction handling
+	subpools []SubPool  // List of subpools for specialized transaction handling
+	chain    BlockChain // Chain to check the conditional transactions against
+
+	conditionals    map[common.Hash]*TxConditional // Conditions of the pooled conditional transactions
+	conditionalCost int                            // Total cost of the tracked conditionals
+	evicted         *lru.Cache[common.Hash, error] // Reasons of the recently evicted conditional transactions
+	condLock        sync.RWMutex                   // Lock protecting the conditionals
 
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
@@ -84,6 +98,9 @@ func New(gasTip *big.Int, chain BlockChain, subpools []SubPool) (*TxPool, error)
 
 	pool := &TxPool{
 		subpools:     subpools,
+		chain:        chain,
+		conditionals: make(map[common.Hash]*TxConditional),
+		evicted:      lru.NewCache[common.Hash, error](maxEvictedConditionals),
 		reservations: make(map[common.Address]SubPool),
 		quit:         make(chan chan error),
 	}
@@ -205,6 +222,7 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 					for _, subpool := range p.subpools {
 						subpool.Reset(oldHead, newHead)
 					}
+					p.checkConditionals(newHead)
 					resetDone <- newHead
 				}(oldHead, newHead)
 
@@ -260,6 +278,19 @@ func (p *TxPool) Get(hash common.Hash) *types.Transaction {
 	return nil
 }
 
+// GetBlobs retrieves the blobs and KZG proofs for the given versioned hashes,
+// returning nil entries for the ones not known by any subpool.
+func (p *TxPool) GetBlobs(vhashes []common.Hash) ([]*kzg4844.Blob, []*kzg4844.Proof) {
+	for _, subpool := range p.subpools {
+		// Only the blob pool tracks blobs, so the first meaningful response is
+		// the complete one; merging partial responses isn't needed.
+		if blobs, proofs := subpool.GetBlobs(vhashes); blobs != nil {
+			return blobs, proofs
+		}
+	}
+	return make([]*kzg4844.Blob, len(vhashes)), make([]*kzg4844.Proof, len(vhashes))
+}
+
 // Add enqueues a batch of transactions into the pool if they are valid. Due
 // to the large transaction churn, add may postpone fully integrating the tx
 // to a later point to batch multiple ones together.
@@ -405,13 +436,39 @@ func (p *TxPool) Locals() []common.Address {
 	return flat
 }
 
-// Status returns the known status (unknown/pending/queued) of a transaction
-// identified by its hash.
+// History retrieves the recorded lifecycle events of a transaction across all
+// the subpools, oldest first.
+func (p *TxPool) History(hash common.Hash) []TxEvent {
+	var events []TxEvent
+	for _, subpool := range p.subpools {
+		events = append(events, subpool.History(hash)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
+
+// SubscribeDropped registers a subscription for transactions being dropped from
+// or replaced in any of the subpools.
+func (p *TxPool) SubscribeDropped(ch chan<- TxEvent) event.Subscription {
+	subs := make([]event.Subscription, len(p.subpools))
+	for i, subpool := range p.subpools {
+		subs[i] = subpool.SubscribeDropped(ch)
+	}
+	return p.subs.Track(event.JoinSubscriptions(subs...))
+}
+
+// Status returns the known status (unknown/pending/queued/evicted) of a
+// transaction identified by its hash.
 func (p *TxPool) Status(hash common.Hash) TxStatus {
 	for _, subpool := range p.subpools {
 		if status := subpool.Status(hash); status != TxStatusUnknown {
 			return status
 		}
 	}
+	if p.evicted.Contains(hash) {
+		return TxStatusEvicted
+	}
 	return TxStatusUnknown
 }
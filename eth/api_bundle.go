@@ -0,0 +1,212 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BundleAPI provides an API to submit and simulate transaction bundles. Bundles
+// are handed to the miner directly, they never enter the transaction pool and
+// are not propagated to other peers. The API is only registered if bundles are
+// enabled in the miner configuration.
+type BundleAPI struct {
+	e *Ethereum
+}
+
+// NewBundleAPI creates a new BundleAPI instance.
+func NewBundleAPI(e *Ethereum) *BundleAPI {
+	return &BundleAPI{e}
+}
+
+// SendBundleArgs are the arguments of eth_sendBundle.
+type SendBundleArgs struct {
+	Txs               []hexutil.Bytes `json:"txs"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	MinTimestamp      *hexutil.Uint64 `json:"minTimestamp"`
+	MaxTimestamp      *hexutil.Uint64 `json:"maxTimestamp"`
+	RevertingTxHashes []common.Hash   `json:"revertingTxHashes"`
+}
+
+// SendBundleResult is the result of eth_sendBundle.
+type SendBundleResult struct {
+	BundleHash common.Hash `json:"bundleHash"`
+}
+
+// SendBundle submits a bundle for inclusion in the given block. The transactions
+// of the bundle are included together, in order, or not at all. The bundle is
+// simulated on submission, subject to the RPC gas cap and EVM timeout.
+func (api *BundleAPI) SendBundle(ctx context.Context, args SendBundleArgs) (*SendBundleResult, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.checkGasCap(txs); err != nil {
+		return nil, err
+	}
+	bundle := &miner.Bundle{
+		Txs:               txs,
+		BlockNumber:       uint64(args.BlockNumber),
+		RevertingTxHashes: args.RevertingTxHashes,
+	}
+	if args.MinTimestamp != nil {
+		bundle.MinTimestamp = uint64(*args.MinTimestamp)
+	}
+	if args.MaxTimestamp != nil {
+		bundle.MaxTimestamp = uint64(*args.MaxTimestamp)
+	}
+	ctx, cancel := api.withTimeout(ctx)
+	defer cancel()
+
+	if err := api.e.Miner().SendBundle(ctx, bundle); err != nil {
+		return nil, api.timeoutError(err)
+	}
+	return &SendBundleResult{BundleHash: bundle.Hash()}, nil
+}
+
+// CallBundleArgs are the arguments of eth_callBundle.
+type CallBundleArgs struct {
+	Txs              []hexutil.Bytes       `json:"txs"`
+	StateBlockNumber rpc.BlockNumberOrHash `json:"stateBlockNumber"`
+	Timestamp        *hexutil.Uint64       `json:"timestamp"`
+	Coinbase         *common.Address       `json:"coinbase"`
+}
+
+// CallBundleResult is the result of eth_callBundle.
+type CallBundleResult struct {
+	BundleHash       common.Hash          `json:"bundleHash"`
+	CoinbaseDiff     *hexutil.Big         `json:"coinbaseDiff"`
+	TotalGasUsed     hexutil.Uint64       `json:"totalGasUsed"`
+	StateBlockNumber hexutil.Uint64       `json:"stateBlockNumber"`
+	Results          []CallBundleTxResult `json:"results"`
+}
+
+// CallBundleTxResult is the outcome of a single transaction in eth_callBundle.
+type CallBundleTxResult struct {
+	TxHash   common.Hash    `json:"txHash"`
+	From     common.Address `json:"fromAddress"`
+	GasUsed  hexutil.Uint64 `json:"gasUsed"`
+	Reverted bool           `json:"reverted"`
+	Logs     []*types.Log   `json:"logs"`
+}
+
+// CallBundle simulates a bundle on top of the given block, as if it was included
+// first in the next block. Like eth_call, the execution is subject to the RPC gas
+// cap and EVM timeout.
+func (api *BundleAPI) CallBundle(ctx context.Context, args CallBundleArgs) (*CallBundleResult, error) {
+	txs, err := decodeBundleTxs(args.Txs)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.checkGasCap(txs); err != nil {
+		return nil, err
+	}
+	if args.StateBlockNumber == (rpc.BlockNumberOrHash{}) {
+		args.StateBlockNumber = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	parent, err := api.e.APIBackend.HeaderByNumberOrHash(ctx, args.StateBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("state block %v not found", args.StateBlockNumber)
+	}
+	var timestamp uint64
+	if args.Timestamp != nil {
+		timestamp = uint64(*args.Timestamp)
+	}
+	var coinbase common.Address
+	if args.Coinbase != nil {
+		coinbase = *args.Coinbase
+	}
+	ctx, cancel := api.withTimeout(ctx)
+	defer cancel()
+
+	result, err := api.e.Miner().CallBundle(ctx, &miner.Bundle{Txs: txs}, parent.Hash(), timestamp, coinbase)
+	if err != nil {
+		return nil, api.timeoutError(err)
+	}
+	out := &CallBundleResult{
+		BundleHash:       result.BundleHash,
+		CoinbaseDiff:     (*hexutil.Big)(result.CoinbaseDiff),
+		TotalGasUsed:     hexutil.Uint64(result.GasUsed),
+		StateBlockNumber: hexutil.Uint64(parent.Number.Uint64()),
+		Results:          make([]CallBundleTxResult, len(result.Txs)),
+	}
+	for i, tx := range result.Txs {
+		out.Results[i] = CallBundleTxResult{
+			TxHash:   tx.Hash,
+			From:     tx.From,
+			GasUsed:  hexutil.Uint64(tx.GasUsed),
+			Reverted: tx.Reverted,
+			Logs:     tx.Logs,
+		}
+	}
+	return out, nil
+}
+
+// checkGasCap ensures the summed gas limit of the bundle transactions is within
+// the RPC gas cap.
+func (api *BundleAPI) checkGasCap(txs types.Transactions) error {
+	gasCap := api.e.APIBackend.RPCGasCap()
+	if gasCap == 0 {
+		return nil
+	}
+	var gas uint64
+	for _, tx := range txs {
+		gas += tx.Gas()
+	}
+	if gas > gasCap {
+		return fmt.Errorf("bundle gas %d exceeds the RPC gas cap %d", gas, gasCap)
+	}
+	return nil
+}
+
+// withTimeout bounds the bundle execution by the RPC EVM timeout, if any.
+func (api *BundleAPI) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := api.e.APIBackend.RPCEVMTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// timeoutError replaces the error of an execution aborted by the EVM timeout.
+func (api *BundleAPI) timeoutError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("execution aborted (timeout = %v)", api.e.APIBackend.RPCEVMTimeout())
+	}
+	return err
+}
+
+func decodeBundleTxs(encoded []hexutil.Bytes) (types.Transactions, error) {
+	txs := make(types.Transactions, len(encoded))
+	for i, enc := range encoded {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		txs[i] = tx
+	}
+	return txs, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ConditionalAPI provides an API to submit transactions only valid as long as
+// a set of conditions on the chain hold, and to query why they were evicted.
+type ConditionalAPI struct {
+	e *Ethereum
+}
+
+// NewConditionalAPI creates a new ConditionalAPI instance.
+func NewConditionalAPI(e *Ethereum) *ConditionalAPI {
+	return &ConditionalAPI{e}
+}
+
+// KnownAccountArgs is the expected state of an account. It is encoded either as
+// the hash of the account's storage root, or as an object of storage slots to
+// their expected values.
+type KnownAccountArgs struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+// UnmarshalJSON decodes either a storage root or a set of storage slots.
+func (a *KnownAccountArgs) UnmarshalJSON(input []byte) error {
+	var root common.Hash
+	if err := json.Unmarshal(input, &root); err == nil {
+		a.StorageRoot, a.StorageSlots = &root, nil
+		return nil
+	}
+	var slots map[common.Hash]common.Hash
+	if err := json.Unmarshal(input, &slots); err != nil {
+		return errors.New("known account must be a storage root or a map of storage slots")
+	}
+	a.StorageRoot, a.StorageSlots = nil, slots
+	return nil
+}
+
+// MarshalJSON encodes either the storage root or the set of storage slots.
+func (a KnownAccountArgs) MarshalJSON() ([]byte, error) {
+	if a.StorageRoot != nil {
+		return json.Marshal(a.StorageRoot)
+	}
+	return json.Marshal(a.StorageSlots)
+}
+
+// TransactionConditionalArgs are the conditions of eth_sendRawTransactionConditional.
+type TransactionConditionalArgs struct {
+	KnownAccounts  map[common.Address]KnownAccountArgs `json:"knownAccounts"`
+	BlockNumberMin *hexutil.Uint64                     `json:"blockNumberMin"`
+	BlockNumberMax *hexutil.Uint64                     `json:"blockNumberMax"`
+	TimestampMin   *hexutil.Uint64                     `json:"timestampMin"`
+	TimestampMax   *hexutil.Uint64                     `json:"timestampMax"`
+}
+
+// toConditional converts the RPC arguments into the transaction pool format.
+func (args *TransactionConditionalArgs) toConditional() *txpool.TxConditional {
+	cond := &txpool.TxConditional{
+		KnownAccounts:  make(map[common.Address]txpool.KnownAccount, len(args.KnownAccounts)),
+		BlockNumberMin: (*uint64)(args.BlockNumberMin),
+		BlockNumberMax: (*uint64)(args.BlockNumberMax),
+		TimestampMin:   (*uint64)(args.TimestampMin),
+		TimestampMax:   (*uint64)(args.TimestampMax),
+	}
+	for addr, account := range args.KnownAccounts {
+		cond.KnownAccounts[addr] = txpool.KnownAccount{
+			StorageRoot:  account.StorageRoot,
+			StorageSlots: account.StorageSlots,
+		}
+	}
+	return cond
+}
+
+// SendRawTransactionConditional submits a signed transaction which may only be
+// included as long as the given conditions hold. The conditions are re-checked
+// on every new head and before inclusion, evicting the transaction once they
+// are expired or violated. Conditional transactions are not propagated to peers.
+//
+// The pool only tracks conditionals up to a total cost budget. Public endpoints
+// should additionally rate limit this method per client, e.g. with
+// --rpc.ratelimit eth_sendRawTransactionConditional=<rate>.
+func (api *ConditionalAPI) SendRawTransactionConditional(input hexutil.Bytes, args TransactionConditionalArgs) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if !api.e.APIBackend.UnprotectedAllowed() && !tx.Protected() {
+		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
+	}
+	// Conditional transactions are added as remote ones, since local ones would
+	// be journaled and resurrected without their conditions after a restart.
+	if err := api.e.TxPool().AddConditional(tx, args.toConditional(), false); err != nil {
+		return common.Hash{}, err
+	}
+	log.Info("Submitted conditional transaction", "hash", tx.Hash(), "nonce", tx.Nonce())
+	return tx.Hash(), nil
+}
+
+// ConditionalStatusResult is the result of eth_getConditionalTransactionStatus.
+type ConditionalStatusResult struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetConditionalTransactionStatus returns the status of a transaction in the pool
+// (unknown, queued, pending or evicted), along with the reason the transaction
+// was evicted for if its conditions failed.
+func (api *ConditionalAPI) GetConditionalTransactionStatus(hash common.Hash) *ConditionalStatusResult {
+	var res ConditionalStatusResult
+	switch api.e.TxPool().Status(hash) {
+	case txpool.TxStatusQueued:
+		res.Status = "queued"
+	case txpool.TxStatusPending:
+		res.Status = "pending"
+	case txpool.TxStatusEvicted:
+		res.Status = "evicted"
+		if reason := api.e.TxPool().EvictionReason(hash); reason != nil {
+			res.Reason = reason.Error()
+		}
+	default:
+		res.Status = "unknown"
+	}
+	return &res
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+)
+
+func TestTransactionConditionalArgsJSON(t *testing.T) {
+	input := `{
+		"knownAccounts": {
+			"0x00000000000000000000000000000000000000aa": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+			"0x00000000000000000000000000000000000000bb": {
+				"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"
+			}
+		},
+		"blockNumberMin": "0x10",
+		"timestampMax": "0x64"
+	}`
+	var args TransactionConditionalArgs
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatalf("failed to decode conditional: %v", err)
+	}
+	var (
+		root       = common.HexToHash("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+		minNumber  = uint64(16)
+		maxTime    = uint64(100)
+		haveCond   = args.toConditional()
+		expectCond = &txpool.TxConditional{
+			KnownAccounts: map[common.Address]txpool.KnownAccount{
+				common.HexToAddress("0xaa"): {StorageRoot: &root},
+				common.HexToAddress("0xbb"): {StorageSlots: map[common.Hash]common.Hash{{31: 0x01}: {31: 0x02}}},
+			},
+			BlockNumberMin: &minNumber,
+			TimestampMax:   &maxTime,
+		}
+	)
+	if !reflect.DeepEqual(haveCond, expectCond) {
+		t.Fatalf("conditional mismatch:\nhave %+v\nwant %+v", haveCond, expectCond)
+	}
+	// Ensure the known accounts survive a round trip
+	blob, err := json.Marshal(args)
+	if err != nil {
+		t.Fatalf("failed to encode conditional: %v", err)
+	}
+	var dec TransactionConditionalArgs
+	if err := json.Unmarshal(blob, &dec); err != nil {
+		t.Fatalf("failed to decode encoded conditional: %v", err)
+	}
+	if !reflect.DeepEqual(dec, args) {
+		t.Errorf("round trip mismatch:\nhave %+v\nwant %+v", dec, args)
+	}
+	// Reject known accounts of the wrong shape
+	if err := json.Unmarshal([]byte(`{"knownAccounts": {"0x00000000000000000000000000000000000000aa": 1}}`), &args); err == nil {
+		t.Errorf("invalid known account accepted")
+	}
+}
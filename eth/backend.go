@@ -18,6 +18,7 @@
 package eth
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -44,6 +45,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -201,6 +203,23 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			StateScheme:         scheme,
 		}
 	)
+	if config.VMTrace != "" {
+		var traceConfig json.RawMessage
+		if config.VMTraceJsonConfig != "" {
+			traceConfig = json.RawMessage(config.VMTraceJsonConfig)
+		}
+		t, err := tracers.LiveDirectory.New(config.VMTrace, traceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tracer %s: %v", config.VMTrace, err)
+		}
+		vmConfig.Tracer = t
+	}
+	// Prune the chain history before the chain is loaded if it's requested.
+	if config.HistoryPrune {
+		if err := pruneChainHistory(chainDb, chainConfig, config.HistoryCutoff); err != nil {
+			return nil, err
+		}
+	}
 	// Override the chain config with provided settings.
 	var overrides core.ChainOverrides
 	if config.OverrideCancun != nil {
@@ -283,6 +302,35 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	return eth, nil
 }
 
+// pruneChainHistory removes the block bodies and receipts below the configured
+// cutoff, defaulting to the last proof-of-work block. Requesting the default on
+// a chain without a merge transition is a configuration error. A chain which
+// is still before its merge, or hasn't frozen the blocks up to the cutoff yet,
+// is left untouched until a later startup.
+func pruneChainHistory(db ethdb.Database, config *params.ChainConfig, cutoff uint64) error {
+	if cutoff == 0 {
+		if config.TerminalTotalDifficulty == nil {
+			return errors.New("chain history pruning needs an explicit cutoff on chains without a merge transition")
+		}
+		var err error
+		if cutoff, err = core.MergeHistoryCutoff(db); err != nil {
+			if errors.Is(err, core.ErrNotMerged) {
+				log.Info("Skipping chain history pruning", "reason", err)
+				return nil
+			}
+			return fmt.Errorf("failed to find chain history cutoff: %w", err)
+		}
+	}
+	if err := core.PruneChainHistory(db, cutoff); err != nil {
+		if errors.Is(err, core.ErrHistoryNotFrozen) {
+			log.Info("Skipping chain history pruning", "reason", err)
+			return nil
+		}
+		return fmt.Errorf("failed to prune chain history: %w", err)
+	}
+	return nil
+}
+
 func makeExtraData(extra []byte) []byte {
 	if len(extra) == 0 {
 		// create default extradata
@@ -308,11 +356,21 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Private bundle submission is opt-in, it is not meant for public endpoints
+	if s.config.Miner.Bundles {
+		apis = append(apis, rpc.API{
+			Namespace: "eth",
+			Service:   NewBundleAPI(s),
+		})
+	}
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
 			Namespace: "eth",
 			Service:   NewEthereumAPI(s),
+		}, {
+			Namespace: "eth",
+			Service:   NewConditionalAPI(s),
 		}, {
 			Namespace: "miner",
 			Service:   NewMinerAPI(s),
@@ -510,15 +568,20 @@ func (s *Ethereum) Start() error {
 	s.shutdownTracker.Start()
 
 	// Figure out a max peers count based on the server limits
-	maxPeers := s.p2pServer.MaxPeers
+	maxPeers := s.p2pServer.PeerLimits().MaxPeers
+	lightPeers := 0
 	if s.config.LightServ > 0 {
-		if s.config.LightPeers >= s.p2pServer.MaxPeers {
-			return fmt.Errorf("invalid peer config: light peer count (%d) >= total peer count (%d)", s.config.LightPeers, s.p2pServer.MaxPeers)
+		if s.config.LightPeers >= maxPeers {
+			return fmt.Errorf("invalid peer config: light peer count (%d) >= total peer count (%d)", s.config.LightPeers, maxPeers)
 		}
-		maxPeers -= s.config.LightPeers
+		lightPeers = s.config.LightPeers
+	}
+	// The server limit can be changed through the admin API, so track it.
+	s.handler.peerLimit = func() int {
+		return s.p2pServer.PeerLimits().MaxPeers - lightPeers
 	}
 	// Start the networking layer and the light server if requested
-	s.handler.Start(maxPeers)
+	s.handler.Start(maxPeers - lightPeers)
 	return nil
 }
 
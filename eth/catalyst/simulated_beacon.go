@@ -19,6 +19,7 @@ package catalyst
 import (
 	"crypto/rand"
 	"errors"
+	"math/big"
 	"sync"
 	"time"
 
@@ -145,19 +146,31 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal) error {
 	c.feeRecipientLock.Unlock()
 
 	// Reset to CurrentBlock in case of the chain was rewound
-	if header := c.eth.BlockChain().CurrentBlock(); c.curForkchoiceState.HeadBlockHash != header.Hash() {
+	header := c.eth.BlockChain().CurrentBlock()
+	if c.curForkchoiceState.HeadBlockHash != header.Hash() {
 		finalizedHash := c.finalizedBlockHash(header.Number.Uint64())
 		c.setCurrentState(header.Hash(), *finalizedHash)
 	}
+	cancun := c.eth.BlockChain().Config().IsCancun(new(big.Int).Add(header.Number, big.NewInt(1)), tstamp)
 
 	var random [32]byte
 	rand.Read(random[:])
-	fcResponse, err := c.engineAPI.ForkchoiceUpdatedV2(c.curForkchoiceState, &engine.PayloadAttributes{
+	attributes := &engine.PayloadAttributes{
 		Timestamp:             tstamp,
 		SuggestedFeeRecipient: feeRecipient,
 		Withdrawals:           withdrawals,
 		Random:                random,
-	})
+	}
+	var (
+		fcResponse engine.ForkChoiceResponse
+		err        error
+	)
+	if cancun {
+		attributes.BeaconRoot = new(common.Hash)
+		fcResponse, err = c.engineAPI.ForkchoiceUpdatedV3(c.curForkchoiceState, attributes)
+	} else {
+		fcResponse, err = c.engineAPI.ForkchoiceUpdatedV2(c.curForkchoiceState, attributes)
+	}
 	if err != nil {
 		return err
 	}
@@ -183,7 +196,21 @@ func (c *SimulatedBeacon) sealBlock(withdrawals []*types.Withdrawal) error {
 	}
 
 	// Mark the payload as canon
-	if _, err = c.engineAPI.NewPayloadV2(*payload); err != nil {
+	if cancun {
+		// Collect the blob hashes the consensus layer would derive from the
+		// sidecars, in transaction order.
+		blobHashes := make([]common.Hash, 0)
+		for _, enc := range payload.Transactions {
+			var tx types.Transaction
+			if err := tx.UnmarshalBinary(enc); err != nil {
+				return err
+			}
+			blobHashes = append(blobHashes, tx.BlobHashes()...)
+		}
+		if _, err = c.engineAPI.NewPayloadV3(*payload, blobHashes, attributes.BeaconRoot); err != nil {
+			return err
+		}
+	} else if _, err = c.engineAPI.NewPayloadV2(*payload); err != nil {
 		return err
 	}
 	c.setCurrentState(payload.BlockHash, finalizedHash)
@@ -17,7 +17,9 @@
 package catalyst
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"math/big"
 	"testing"
 	"time"
@@ -26,17 +28,41 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
 )
 
 func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.Node, *eth.Ethereum, *SimulatedBeacon) {
 	t.Helper()
 
+	n, ethservice := newSimulatedBeaconEthService(t, genesis)
+
+	simBeacon, err := NewSimulatedBeacon(1, ethservice)
+	if err != nil {
+		t.Fatal("can't create simulated beacon:", err)
+	}
+
+	n.RegisterLifecycle(simBeacon)
+
+	if err := n.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+
+	ethservice.SetSynced()
+	return n, ethservice, simBeacon
+}
+
+// newSimulatedBeaconEthService creates a node with an eth service on top of the
+// given genesis, without starting it.
+func newSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.Node, *eth.Ethereum) {
+	t.Helper()
+
 	n, err := node.New(&node.Config{
 		P2P: p2p.Config{
 			ListenAddr:  "127.0.0.1:8545",
@@ -53,20 +79,7 @@ func startSimulatedBeaconEthService(t *testing.T, genesis *core.Genesis) (*node.
 	if err != nil {
 		t.Fatal("can't create eth service:", err)
 	}
-
-	simBeacon, err := NewSimulatedBeacon(1, ethservice)
-	if err != nil {
-		t.Fatal("can't create simulated beacon:", err)
-	}
-
-	n.RegisterLifecycle(simBeacon)
-
-	if err := n.Start(); err != nil {
-		t.Fatal("can't start node:", err)
-	}
-
-	ethservice.SetSynced()
-	return n, ethservice, simBeacon
+	return n, ethservice
 }
 
 // send 20 transactions, >10 withdrawals and ensure they are included in order
@@ -139,3 +152,103 @@ func TestSimulatedBeaconSendWithdrawals(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the blobs of pooled transactions can be retrieved through the
+// engine API until the transactions are included by the simulated beacon.
+func TestSimulatedBeaconGetBlobs(t *testing.T) {
+	var (
+		testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+	)
+	genesis := core.DeveloperGenesisBlock(10_000_000, testAddr)
+	config := *genesis.Config
+	config.CancunTime = new(uint64)
+	genesis.Config = &config
+
+	// Drive the simulated beacon by hand to control when the blob transaction
+	// gets included.
+	n, ethservice := newSimulatedBeaconEthService(t, genesis)
+	defer n.Close()
+
+	mock, err := NewSimulatedBeacon(0, ethservice)
+	if err != nil {
+		t.Fatal("can't create simulated beacon:", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	ethservice.SetSynced()
+
+	var (
+		blob      = kzg4844.Blob{0x00, 0x01}
+		commit, _ = kzg4844.BlobToCommitment(blob)
+		proof, _  = kzg4844.ComputeBlobProof(blob, commit)
+		hasher    = sha256.New()
+		vhash     common.Hash
+	)
+	hasher.Write(commit[:])
+	hasher.Sum(vhash[:0])
+	vhash[0] = params.BlobTxHashVersion
+
+	tx := types.MustSignNewTx(testKey, types.LatestSigner(&config), &types.BlobTx{
+		ChainID:    uint256.MustFromBig(config.ChainID),
+		Nonce:      0,
+		GasTipCap:  uint256.NewInt(params.GWei),
+		GasFeeCap:  uint256.NewInt(10 * params.GWei),
+		Gas:        params.TxGas,
+		To:         testAddr,
+		BlobFeeCap: uint256.NewInt(params.GWei),
+		BlobHashes: []common.Hash{vhash},
+		Sidecar: &types.BlobTxSidecar{
+			Blobs:       []kzg4844.Blob{blob},
+			Commitments: []kzg4844.Commitment{commit},
+			Proofs:      []kzg4844.Proof{proof},
+		},
+	})
+	if err := ethservice.APIBackend.SendTx(context.Background(), tx); err != nil {
+		t.Fatal("SendTx failed", err)
+	}
+	// Retrieve the blob while the transaction is pooled, mixing in an unknown one
+	res, err := mock.engineAPI.GetBlobsV1([]common.Hash{{0x01}, vhash})
+	if err != nil {
+		t.Fatalf("failed to retrieve blobs: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("result length mismatch: have %d, want %d", len(res), 2)
+	}
+	if res[0] != nil {
+		t.Errorf("unexpected blob for unknown versioned hash")
+	}
+	if res[1] == nil {
+		t.Fatalf("missing blob for pooled transaction")
+	}
+	if !bytes.Equal(res[1].Blob, blob[:]) || !bytes.Equal(res[1].Proof, proof[:]) {
+		t.Errorf("blob and proof mismatch")
+	}
+	// Include the transaction and ensure the blob is not served any more
+	if err := mock.sealBlock(make([]*types.Withdrawal, 0)); err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	block := ethservice.BlockChain().CurrentBlock()
+	if included := ethservice.BlockChain().GetBlock(block.Hash(), block.Number.Uint64()).Transactions(); len(included) != 1 || included[0].Hash() != tx.Hash() {
+		t.Fatalf("blob transaction not included")
+	}
+	// The pool is reset asynchronously on the new head, wait for it
+	for deadline := time.Now().Add(5 * time.Second); ethservice.TxPool().Has(tx.Hash()); {
+		if time.Now().After(deadline) {
+			t.Fatalf("blob transaction not removed from the pool")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	res, err = mock.engineAPI.GetBlobsV1([]common.Hash{vhash})
+	if err != nil {
+		t.Fatalf("failed to retrieve blobs: %v", err)
+	}
+	if res[0] != nil {
+		t.Errorf("blob of included transaction still served")
+	}
+	// Oversized requests should be rejected
+	if _, err := mock.engineAPI.GetBlobsV1(make([]common.Hash, 129)); err == nil {
+		t.Errorf("oversized request accepted")
+	}
+}
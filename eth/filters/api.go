@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -217,27 +218,69 @@ func (api *FilterAPI) NewBlockFilter() rpc.ID {
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+//
+// If a cursor is given, the canonical headers from the cursor block up to the
+// current head are sent first.
+func (api *FilterAPI) NewHeads(ctx context.Context, cursor *Cursor) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
-
-	rpcSub := notifier.CreateSubscription()
-
+	var from uint64
+	if cursor != nil {
+		var err error
+		if from, _, err = api.resolveCursor(ctx, cursor); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		rpcSub     = notifier.CreateSubscription()
+		headers    = make(chan *types.Header)
+		headersSub = api.events.SubscribeNewHeads(headers)
+	)
 	go func() {
-		headers := make(chan *types.Header)
-		headersSub := api.events.SubscribeNewHeads(headers)
-
+		defer headersSub.Unsubscribe()
+
+		// Replay the missed headers before the live ones. Live headers arriving
+		// in the meantime are queued, and dropped if they were replayed.
+		var (
+			replayed  chan *types.Header
+			replayErr chan error
+			queue     []*types.Header
+			seen      map[common.Hash]bool
+		)
+		if cursor != nil {
+			replayed, replayErr = make(chan *types.Header), make(chan error, 1)
+			seen = make(map[common.Hash]bool)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { replayErr <- api.replayHeaders(ctx, from, replayed) }()
+		}
 		for {
 			select {
-			case h := <-headers:
+			case h := <-replayed:
+				seen[h.Hash()] = true
 				notifier.Notify(rpcSub.ID, h)
+			case err := <-replayErr:
+				if err != nil {
+					log.Warn("Failed to replay headers", "from", from, "err", err)
+				}
+				for _, h := range queue {
+					if !seen[h.Hash()] {
+						notifier.Notify(rpcSub.ID, h)
+					}
+				}
+				replayed, replayErr, queue, seen = nil, nil, nil, nil
+			case h := <-headers:
+				if replayErr != nil {
+					queue = append(queue, h)
+				} else {
+					notifier.Notify(rpcSub.ID, h)
+				}
 			case <-rpcSub.Err():
-				headersSub.Unsubscribe()
 				return
 			case <-notifier.Closed():
-				headersSub.Unsubscribe()
 				return
 			}
 		}
@@ -247,12 +290,28 @@ func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+//
+// If a cursor is given, the matching logs of the blocks from that position up to
+// the current head are sent first. The fromBlock of the criteria doesn't cause a
+// replay, clients commonly send it for live subscriptions.
+func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria, cursor *Cursor) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
-
+	var (
+		from      uint64
+		abandoned []*types.Header
+	)
+	if cursor != nil {
+		if crit.FromBlock != nil {
+			return nil, errCursorAndFrom
+		}
+		var err error
+		if from, abandoned, err = api.resolveCursor(ctx, cursor); err != nil {
+			return nil, err
+		}
+	}
 	var (
 		rpcSub      = notifier.CreateSubscription()
 		matchedLogs = make(chan []*types.Log)
@@ -264,18 +323,59 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 	}
 
 	go func() {
+		defer logsSub.Unsubscribe()
+
+		notify := func(logs []*types.Log, skip map[common.Hash]bool) {
+			for _, l := range logs {
+				if !l.Removed && skip[l.BlockHash] {
+					continue
+				}
+				l := l
+				notifier.Notify(rpcSub.ID, &l)
+			}
+		}
+		// Replay the missed logs before the live ones. Live logs arriving in the
+		// meantime are queued, and dropped if their block was replayed.
+		var (
+			replayed  chan []*types.Log
+			replayErr chan error
+			queue     [][]*types.Log
+			seen      map[common.Hash]bool
+		)
+		if cursor != nil {
+			replayed, replayErr = make(chan []*types.Log), make(chan error, 1)
+			seen = make(map[common.Hash]bool)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { replayErr <- api.replayLogs(ctx, crit, abandoned, from, replayed) }()
+		}
 		for {
 			select {
+			case logs := <-replayed:
+				for _, l := range logs {
+					if !l.Removed {
+						seen[l.BlockHash] = true
+					}
+				}
+				notify(logs, nil)
+			case err := <-replayErr:
+				if err != nil {
+					log.Warn("Failed to replay logs", "from", from, "err", err)
+				}
+				for _, logs := range queue {
+					notify(logs, seen)
+				}
+				replayed, replayErr, queue, seen = nil, nil, nil, nil
 			case logs := <-matchedLogs:
-				for _, log := range logs {
-					log := log
-					notifier.Notify(rpcSub.ID, &log)
+				if replayErr != nil {
+					queue = append(queue, logs)
+				} else {
+					notify(logs, nil)
 				}
 			case <-rpcSub.Err(): // client send an unsubscribe request
-				logsSub.Unsubscribe()
 				return
 			case <-notifier.Closed(): // connection dropped
-				logsSub.Unsubscribe()
 				return
 			}
 		}
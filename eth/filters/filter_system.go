@@ -44,6 +44,7 @@ import (
 type Config struct {
 	LogCacheSize int           // maximum number of cached blocks (default: 32)
 	Timeout      time.Duration // how long filters stay active (default: 5min)
+	ReplayLimit  uint64        // maximum number of blocks replayed for subscription cursors (default: 10000)
 }
 
 func (cfg Config) withDefaults() Config {
@@ -53,6 +54,9 @@ func (cfg Config) withDefaults() Config {
 	if cfg.LogCacheSize == 0 {
 		cfg.LogCacheSize = 32
 	}
+	if cfg.ReplayLimit == 0 {
+		cfg.ReplayLimit = 10000
+	}
 	return cfg
 }
 
@@ -213,6 +217,7 @@ type EventSystem struct {
 	pendingLogsCh chan []*types.Log          // Channel to receive new log event
 	rmLogsCh      chan core.RemovedLogsEvent // Channel to receive removed log event
 	chainCh       chan core.ChainEvent       // Channel to receive new chain event
+	quit          chan struct{}              // Channel to stop the event loop
 }
 
 // NewEventSystem creates a new manager that listens for event on the given mux,
@@ -233,6 +238,7 @@ func NewEventSystem(sys *FilterSystem, lightMode bool) *EventSystem {
 		rmLogsCh:      make(chan core.RemovedLogsEvent, rmLogsChanSize),
 		pendingLogsCh: make(chan []*types.Log, logsChanSize),
 		chainCh:       make(chan core.ChainEvent, chainEvChanSize),
+		quit:          make(chan struct{}),
 	}
 
 	// Subscribe events
@@ -289,6 +295,12 @@ func (sub *Subscription) Unsubscribe() {
 	})
 }
 
+// Stop terminates the event loop. All subscriptions must have been unsubscribed
+// before, the event system can't be used afterwards.
+func (es *EventSystem) Stop() {
+	close(es.quit)
+}
+
 // subscribe installs the subscription in the event broadcast loop.
 func (es *EventSystem) subscribe(sub *subscription) *Subscription {
 	es.install <- sub
@@ -591,6 +603,8 @@ func (es *EventSystem) eventLoop() {
 			close(f.err)
 
 		// System stopped
+		case <-es.quit:
+			return
 		case <-es.txsSub.Err():
 			return
 		case <-es.logsSub.Err():
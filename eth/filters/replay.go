@@ -0,0 +1,173 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errUnknownCursor   = errors.New("unknown cursor block")
+	errCursorAndFrom   = errors.New("cannot specify both fromBlock and cursor")
+	errMissingAncestor = errors.New("missing ancestor of cursor block")
+	errCursorTooOld    = errors.New("cursor too far behind the current head")
+)
+
+// Cursor is the position a logs or newHeads subscription is resumed from. The
+// events of the blocks from BlockNumber up to the current head are replayed
+// before live delivery starts. Cursors lagging behind the head by more blocks
+// than the replay limit of the filter system are rejected.
+//
+// If BlockHash is set and that block has been reorged out of the chain since, the
+// logs of the abandoned blocks are replayed with removed set instead, followed
+// by the events of the canonical blocks after the common ancestor.
+type Cursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   *common.Hash   `json:"blockHash,omitempty"`
+}
+
+// resolveCursor returns the number of the first canonical block to replay and
+// the blocks abandoned since the cursor, in descending order.
+func (api *FilterAPI) resolveCursor(ctx context.Context, cursor *Cursor) (uint64, []*types.Header, error) {
+	var (
+		limit = api.sys.cfg.ReplayLimit
+		head  = api.sys.backend.CurrentHeader().Number.Uint64()
+	)
+	if uint64(cursor.BlockNumber)+limit < head {
+		return 0, nil, errCursorTooOld
+	}
+	if cursor.BlockHash == nil {
+		return uint64(cursor.BlockNumber), nil, nil
+	}
+	header, err := api.sys.backend.HeaderByHash(ctx, *cursor.BlockHash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if header == nil || header.Number.Uint64() != uint64(cursor.BlockNumber) {
+		return 0, nil, errUnknownCursor
+	}
+	var abandoned []*types.Header
+	for {
+		canon, err := api.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if err != nil {
+			return 0, nil, err
+		}
+		if canon != nil && canon.Hash() == header.Hash() {
+			break
+		}
+		if uint64(len(abandoned)) >= limit {
+			return 0, nil, errCursorTooOld
+		}
+		abandoned = append(abandoned, header)
+		if header, err = api.sys.backend.HeaderByHash(ctx, header.ParentHash); err != nil {
+			return 0, nil, err
+		}
+		if header == nil {
+			return 0, nil, errMissingAncestor
+		}
+	}
+	if len(abandoned) == 0 {
+		return uint64(cursor.BlockNumber), nil, nil
+	}
+	return header.Number.Uint64() + 1, abandoned, nil
+}
+
+// replayLogs sends the logs matching crit of the abandoned blocks, marked as
+// removed, followed by those of the canonical blocks from the given number up to
+// the current head. The canonical range is searched through the bloom index like
+// a log query. The logs of each block are sent as one batch.
+func (api *FilterAPI) replayLogs(ctx context.Context, crit FilterCriteria, abandoned []*types.Header, from uint64, out chan<- []*types.Log) error {
+	var (
+		filter = newFilter(api.sys, crit.Addresses, crit.Topics)
+		send   = func(logs []*types.Log) error {
+			select {
+			case out <- logs:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	)
+	for _, header := range abandoned {
+		logs, err := filter.blockLogs(ctx, header)
+		if err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			continue
+		}
+		removed := make([]*types.Log, len(logs))
+		for i, l := range logs {
+			// Copy the log not to modify cache elements.
+			cpy := *l
+			cpy.Removed = true
+			removed[i] = &cpy
+		}
+		if err := send(removed); err != nil {
+			return err
+		}
+	}
+	head := api.sys.backend.CurrentHeader().Number.Uint64()
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < head {
+		head = crit.ToBlock.Uint64()
+	}
+	if from > head {
+		return nil
+	}
+	logs, err := api.sys.NewRangeFilter(int64(from), int64(head), crit.Addresses, crit.Topics).Logs(ctx)
+	if err != nil {
+		return err
+	}
+	for len(logs) > 0 {
+		n := 1
+		for n < len(logs) && logs[n].BlockHash == logs[0].BlockHash {
+			n++
+		}
+		if err := send(logs[:n]); err != nil {
+			return err
+		}
+		logs = logs[n:]
+	}
+	return nil
+}
+
+// replayHeaders sends the canonical headers from the given number up to the
+// current head.
+func (api *FilterAPI) replayHeaders(ctx context.Context, from uint64, out chan<- *types.Header) error {
+	head := api.sys.backend.CurrentHeader().Number.Uint64()
+	for number := from; number <= head; number++ {
+		header, err := api.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return nil // chain was rewound during replay
+		}
+		select {
+		case out <- header:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
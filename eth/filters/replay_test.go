@@ -0,0 +1,172 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// TestSubscriptionReplay checks that logs and newHeads subscriptions replay the
+// events of past blocks, including removed logs of blocks reorged out since the
+// cursor, before live delivery.
+func TestSubscriptionReplay(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{ReplayLimit: 2})
+		api          = NewFilterAPI(sys, false)
+		signer       = types.HomesteadSigner{}
+
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		sideAddr = common.Address{0xff}
+	)
+	// Every block emits a single log from an address derived from its number.
+	logAt := func(nonce uint64, address common.Address) func(int, *core.BlockGen) {
+		return func(i int, b *core.BlockGen) {
+			receipt := &types.Receipt{Logs: []*types.Log{{Address: address}}}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			b.AddUncheckedReceipt(receipt)
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: nonce + uint64(i), To: &common.Address{}, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee()}), signer, key)
+			b.AddTx(tx)
+		}
+	}
+	var (
+		genDb, blocks, _ = core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 3, func(i int, b *core.BlockGen) {
+			logAt(0, common.Address{byte(i + 1)})(i, b)
+		})
+		side, _ = core.GenerateChain(genesis.Config, blocks[0], ethash.NewFaker(), genDb, 1, logAt(1, sideAddr))
+	)
+	write := func(block *types.Block, canonical bool) {
+		address := common.Address{byte(block.NumberU64())}
+		if !canonical {
+			address = sideAddr
+		}
+		receipt := &types.Receipt{Logs: []*types.Log{{Address: address}}}
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), []*types.Receipt{receipt})
+		if canonical {
+			rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			rawdb.WriteHeadBlockHash(db, block.Hash())
+		}
+	}
+	for _, block := range blocks {
+		write(block, true)
+	}
+	write(side[0], false)
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	type wantLog struct {
+		address common.Address
+		block   common.Hash
+		removed bool
+	}
+	checkLogs := func(name string, ch chan types.Log, want []wantLog) {
+		for i, w := range want {
+			select {
+			case l := <-ch:
+				if l.Address != w.address || l.BlockHash != w.block || l.Removed != w.removed {
+					t.Errorf("%s: log %d mismatch: have {%x %x %t}, want {%x %x %t}", name, i, l.Address, l.BlockHash, l.Removed, w.address, w.block, w.removed)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: timeout waiting for log %d", name, i)
+			}
+		}
+	}
+
+	// A fromBlock in the filter criteria doesn't replay past logs.
+	ch := make(chan types.Log)
+	sub, err := client.Subscribe(context.Background(), "eth", ch, "logs", map[string]interface{}{"fromBlock": "0x0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := &types.Log{Address: common.Address{0xaa}, Topics: []common.Hash{}, BlockHash: common.Hash{0xaa}}
+	backend.logsFeed.Send([]*types.Log{live})
+	checkLogs("fromBlock", ch, []wantLog{{live.Address, live.BlockHash, false}})
+	sub.Unsubscribe()
+
+	// Cursors lagging behind the head by more than the replay limit are rejected.
+	if _, err := client.Subscribe(context.Background(), "eth", make(chan types.Log), "logs", map[string]interface{}{}, &Cursor{BlockNumber: 0}); err == nil || err.Error() != errCursorTooOld.Error() {
+		t.Errorf("wrong error for stale cursor: %v", err)
+	}
+
+	// Replay from a cursor on a block that has been reorged out.
+	ch = make(chan types.Log)
+	cursor := &Cursor{BlockNumber: hexutil.Uint64(side[0].NumberU64()), BlockHash: new(common.Hash)}
+	*cursor.BlockHash = side[0].Hash()
+	sub, err = client.Subscribe(context.Background(), "eth", ch, "logs", map[string]interface{}{}, cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogs("cursor", ch, []wantLog{
+		{sideAddr, side[0].Hash(), true},
+		{common.Address{2}, blocks[1].Hash(), false},
+		{common.Address{3}, blocks[2].Hash(), false},
+	})
+	sub.Unsubscribe()
+
+	// A cursor cannot be combined with a fromBlock.
+	if _, err := client.Subscribe(context.Background(), "eth", make(chan types.Log), "logs", map[string]interface{}{"fromBlock": "0x1"}, cursor); err == nil || err.Error() != errCursorAndFrom.Error() {
+		t.Errorf("wrong error for cursor and fromBlock: %v", err)
+	}
+
+	// Replay headers from a canonical cursor, then switch to live delivery.
+	heads := make(chan *types.Header)
+	sub, err = client.Subscribe(context.Background(), "eth", heads, "newHeads", &Cursor{BlockNumber: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	for i, want := range []common.Hash{blocks[1].Hash(), blocks[2].Hash(), side[0].Hash()} {
+		if i == 2 {
+			backend.chainFeed.Send(core.ChainEvent{Block: side[0], Hash: side[0].Hash()})
+		}
+		select {
+		case h := <-heads:
+			if h.Hash() != want {
+				t.Errorf("header %d mismatch: have %x, want %x", i, h.Hash(), want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for header %d", i)
+		}
+	}
+}
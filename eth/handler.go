@@ -75,6 +75,11 @@ type txPool interface {
 	// The slice should be modifiable by the caller.
 	Pending(enforceTips bool) map[common.Address][]*txpool.LazyTransaction
 
+	// Conditional returns the conditions of a pooled conditional transaction,
+	// or nil for a plain one. Conditional transactions are never propagated,
+	// since remote nodes would not enforce their conditions.
+	Conditional(hash common.Hash) *txpool.TxConditional
+
 	// SubscribeTransactions subscribes to new transaction events. The subscriber
 	// can decide whether to receive notifications only for newly seen transactions
 	// or also for reorged out ones.
@@ -107,6 +112,9 @@ type handler struct {
 	chain    *core.BlockChain
 	maxPeers int
 
+	// peerLimit, if set, overrides maxPeers with a limit that can change at runtime.
+	peerLimit func() int
+
 	downloader   *downloader.Downloader
 	blockFetcher *fetcher.BlockFetcher
 	txFetcher    *fetcher.TxFetcher
@@ -360,7 +368,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 	}
 	// Ignore maxPeers if this is a trusted peer
 	if !peer.Peer.Info().Network.Trusted {
-		if reject || h.peers.len() >= h.maxPeers {
+		if reject || h.peers.len() >= h.maxPeerCount() {
 			return p2p.DiscTooManyPeers
 		}
 	}
@@ -507,6 +515,14 @@ func (h *handler) unregisterPeer(id string) {
 	}
 }
 
+// maxPeerCount returns the current limit of eth peers.
+func (h *handler) maxPeerCount() int {
+	if h.peerLimit != nil {
+		return h.peerLimit()
+	}
+	return h.maxPeers
+}
+
 func (h *handler) Start(maxPeers int) {
 	h.maxPeers = maxPeers
 
@@ -611,6 +627,9 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	)
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
+		if h.txpool.Conditional(tx.Hash()) != nil {
+			continue
+		}
 		peers := h.peers.peersWithoutTransaction(tx.Hash())
 
 		var numDirect int
@@ -91,6 +91,11 @@ func (p *testTxPool) Add(txs []*types.Transaction, local bool, sync bool) []erro
 	return make([]error, len(txs))
 }
 
+// Conditional returns nil, the test pool does not track conditional transactions.
+func (p *testTxPool) Conditional(hash common.Hash) *txpool.TxConditional {
+	return nil
+}
+
 // Pending returns all the transactions known to the pool
 func (p *testTxPool) Pending(enforceTips bool) map[common.Address][]*txpool.LazyTransaction {
 	p.lock.RLock()
@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -49,6 +50,10 @@ const (
 	// containing 200+ transactions nowadays, the practical limit will always
 	// be softResponseLimit.
 	maxReceiptsServe = 1024
+
+	// historyPrunedLogInterval is the minimum time between two reports about
+	// responses cut short by the local chain history pruning.
+	historyPrunedLogInterval = time.Minute
 )
 
 // Handler is a callback to invoke from an outside runner after the boilerplate
@@ -198,12 +203,19 @@ var eth68 = map[uint64]msgHandler{
 
 // handleMessage is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
-func handleMessage(backend Backend, peer *Peer) error {
+func handleMessage(backend Backend, peer *Peer) (err error) {
 	// Read the next message from the remote peer, and ensure it's fully consumed
 	msg, err := peer.rw.ReadMsg()
 	if err != nil {
 		return err
 	}
+	// Failures from here on are caused by the message contents rather than the
+	// connection, count them against the reputation of the peer.
+	defer func() {
+		if err != nil && !errors.Is(err, p2p.ErrShuttingDown) {
+			peer.Report(p2p.ReputationInvalidMessage)
+		}
+	}()
 	if msg.Size > maxMessageSize {
 		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
 	}
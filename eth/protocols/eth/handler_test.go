@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"errors"
 	"math"
 	"math/big"
 	"math/rand"
@@ -502,3 +503,63 @@ func testGetBlockReceipts(t *testing.T, protocol uint) {
 		t.Errorf("receipts mismatch: %v", err)
 	}
 }
+
+// Tests that body and receipt queries stop at the pruned chain history and
+// report it to the caller.
+func TestServicePrunedHistory(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{testAddr: {Balance: big.NewInt(100_000_000_000_000_000)}},
+		}
+		cutoff = uint64(16)
+	)
+	_, blocks, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 32, nil)
+
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	rawdb.WriteAncientBlocks(db, append([]*types.Block{gspec.ToBlock()}, blocks...), append([]types.Receipts{{}}, receipts...), big.NewInt(0))
+
+	if err := core.PruneChainHistory(db, cutoff); err != nil {
+		t.Fatalf("failed to prune chain history: %v", err)
+	}
+	chain, err := core.NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Request two retained blocks, then a pruned one followed by a retained one
+	query := []common.Hash{
+		blocks[cutoff].Hash(),
+		blocks[cutoff+1].Hash(),
+		blocks[cutoff-2].Hash(),
+		blocks[cutoff+2].Hash(),
+	}
+	bodies, err := ServiceGetBlockBodiesQuery(chain, query)
+	if !errors.Is(err, core.ErrHistoryPruned) {
+		t.Fatalf("body query error mismatch: have %v, want %v", err, core.ErrHistoryPruned)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("body count mismatch: have %d, want %d", len(bodies), 2)
+	}
+	results, err := ServiceGetReceiptsQuery(chain, query)
+	if !errors.Is(err, core.ErrHistoryPruned) {
+		t.Fatalf("receipt query error mismatch: have %v, want %v", err, core.ErrHistoryPruned)
+	}
+	if len(results) != 2 {
+		t.Fatalf("receipt count mismatch: have %d, want %d", len(results), 2)
+	}
+	// Retained blocks are served without an error
+	if _, err := ServiceGetBlockBodiesQuery(chain, query[:2]); err != nil {
+		t.Fatalf("failed to serve retained bodies: %v", err)
+	}
+	if _, err := ServiceGetReceiptsQuery(chain, query[:2]); err != nil {
+		t.Fatalf("failed to serve retained receipts: %v", err)
+	}
+}
@@ -19,11 +19,14 @@ package eth
 import (
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -207,13 +210,20 @@ func handleGetBlockBodies(backend Backend, msg Decoder, peer *Peer) error {
 	if err := msg.Decode(&query); err != nil {
 		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 	}
-	response := ServiceGetBlockBodiesQuery(backend.Chain(), query.GetBlockBodiesRequest)
+	response, err := ServiceGetBlockBodiesQuery(backend.Chain(), query.GetBlockBodiesRequest)
+	if err != nil {
+		logHistoryPruned(peer, err)
+	}
 	return peer.ReplyBlockBodiesRLP(query.RequestId, response)
 }
 
 // ServiceGetBlockBodiesQuery assembles the response to a body query. It is
 // exposed to allow external packages to test protocol behavior.
-func ServiceGetBlockBodiesQuery(chain *core.BlockChain, query GetBlockBodiesRequest) []rlp.RawValue {
+//
+// If a requested block is below the local history tail, the response is cut
+// off right before it and an error wrapping core.ErrHistoryPruned is returned
+// along with the bodies gathered so far.
+func ServiceGetBlockBodiesQuery(chain *core.BlockChain, query GetBlockBodiesRequest) ([]rlp.RawValue, error) {
 	// Gather blocks until the fetch or network limits is reached
 	var (
 		bytes  int
@@ -224,12 +234,15 @@ func ServiceGetBlockBodiesQuery(chain *core.BlockChain, query GetBlockBodiesRequ
 			lookups >= 2*maxBodiesServe {
 			break
 		}
+		if err := checkHistory(chain, hash); err != nil {
+			return bodies, err
+		}
 		if data := chain.GetBodyRLP(hash); len(data) != 0 {
 			bodies = append(bodies, data)
 			bytes += len(data)
 		}
 	}
-	return bodies
+	return bodies, nil
 }
 
 func handleGetReceipts(backend Backend, msg Decoder, peer *Peer) error {
@@ -238,13 +251,20 @@ func handleGetReceipts(backend Backend, msg Decoder, peer *Peer) error {
 	if err := msg.Decode(&query); err != nil {
 		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 	}
-	response := ServiceGetReceiptsQuery(backend.Chain(), query.GetReceiptsRequest)
+	response, err := ServiceGetReceiptsQuery(backend.Chain(), query.GetReceiptsRequest)
+	if err != nil {
+		logHistoryPruned(peer, err)
+	}
 	return peer.ReplyReceiptsRLP(query.RequestId, response)
 }
 
 // ServiceGetReceiptsQuery assembles the response to a receipt query. It is
 // exposed to allow external packages to test protocol behavior.
-func ServiceGetReceiptsQuery(chain *core.BlockChain, query GetReceiptsRequest) []rlp.RawValue {
+//
+// If a requested block is below the local history tail, the response is cut
+// off right before it and an error wrapping core.ErrHistoryPruned is returned
+// along with the receipts gathered so far.
+func ServiceGetReceiptsQuery(chain *core.BlockChain, query GetReceiptsRequest) ([]rlp.RawValue, error) {
 	// Gather state data until the fetch or network limits is reached
 	var (
 		bytes    int
@@ -255,6 +275,9 @@ func ServiceGetReceiptsQuery(chain *core.BlockChain, query GetReceiptsRequest) [
 			lookups >= 2*maxReceiptsServe {
 			break
 		}
+		if err := checkHistory(chain, hash); err != nil {
+			return receipts, err
+		}
 		// Retrieve the requested block's receipts
 		results := chain.GetReceiptsByHash(hash)
 		if results == nil {
@@ -270,7 +293,41 @@ func ServiceGetReceiptsQuery(chain *core.BlockChain, query GetReceiptsRequest) [
 			bytes += len(encoded)
 		}
 	}
-	return receipts
+	return receipts, nil
+}
+
+// checkHistory returns an error if the body and receipts of the block with the
+// given hash are unavailable due to the local chain history pruning.
+func checkHistory(chain *core.BlockChain, hash common.Hash) error {
+	if chain.HistoryTail() == 0 {
+		return nil
+	}
+	header := chain.GetHeaderByHash(hash)
+	if header == nil || !chain.HistoryPruned(header.Number.Uint64()) {
+		return nil
+	}
+	return fmt.Errorf("%w: block #%d below tail #%d", core.ErrHistoryPruned, header.Number, chain.HistoryTail())
+}
+
+// historyPrunedLogged is the time of the last report about a response cut
+// short by the chain history pruning, to avoid flooding the logs with them.
+var historyPrunedLogged atomic.Int64
+
+// logHistoryPruned reports that a response to the given peer was cut short as
+// the requested chain history is pruned locally. The peer only sees a partial
+// response, so the condition is surfaced to the operator at most once every
+// historyPrunedLogInterval.
+func logHistoryPruned(peer *Peer, err error) {
+	peer.Log().Debug("Truncated response at pruned chain history", "err", err)
+
+	var (
+		now  = time.Now().UnixNano()
+		last = historyPrunedLogged.Load()
+	)
+	if now-last < int64(historyPrunedLogInterval) || !historyPrunedLogged.CompareAndSwap(last, now) {
+		return
+	}
+	log.Info("Peers are requesting pruned chain history", "peer", peer.ID(), "err", err)
 }
 
 func handleNewBlockhashes(backend Backend, msg Decoder, peer *Peer) error {
@@ -298,10 +355,12 @@ func handleNewBlock(backend Backend, msg Decoder, peer *Peer) error {
 	}
 	if hash := types.CalcUncleHash(ann.Block.Uncles()); hash != ann.Block.UncleHash() {
 		log.Warn("Propagated block has invalid uncles", "have", hash, "exp", ann.Block.UncleHash())
+		peer.Report(p2p.ReputationInvalidBlock)
 		return nil // TODO(karalabe): return error eventually, but wait a few releases
 	}
 	if hash := types.DeriveSha(ann.Block.Transactions(), trie.NewStackTrie(nil)); hash != ann.Block.TxHash() {
 		log.Warn("Propagated block has invalid body", "have", hash, "exp", ann.Block.TxHash())
+		peer.Report(p2p.ReputationInvalidBlock)
 		return nil // TODO(karalabe): return error eventually, but wait a few releases
 	}
 	ann.Block.ReceivedAt = msg.Time()
@@ -71,6 +71,14 @@ func (p *Peer) Log() log.Logger {
 	return p.logger
 }
 
+// Report records an event affecting the reputation of the peer. It is a noop
+// for fake peers without a backing p2p connection.
+func (p *Peer) Report(ev p2p.ReputationEvent) {
+	if p.Peer != nil {
+		p.Peer.Report(ev)
+	}
+}
+
 // RequestAccountRange fetches a batch of accounts rooted in a specific account
 // trie, starting with the origin.
 func (p *Peer) RequestAccountRange(id uint64, root common.Hash, origin, limit common.Hash, bytes uint64) error {
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/msgrate"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -396,6 +397,14 @@ type SyncPeer interface {
 	Log() log.Logger
 }
 
+// reportPeer records an event affecting the reputation of a sync peer, if the
+// peer keeps track of it.
+func reportPeer(peer SyncPeer, ev p2p.ReputationEvent) {
+	if reporter, ok := peer.(interface{ Report(p2p.ReputationEvent) }); ok {
+		reporter.Report(ev)
+	}
+}
+
 // Syncer is an Ethereum account and storage trie syncer based on snapshots and
 // the  snap protocol. It's purpose is to download all the accounts and storage
 // slots from remote peers and reassemble chunks of the state trie, on top of
@@ -1048,6 +1057,7 @@ func (s *Syncer) assignAccountTasks(success chan *accountResponse, fail chan *ac
 		}
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Account range request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.ReputationTimeout)
 			s.rates.Update(idle, AccountRangeMsg, 0, 0)
 			s.scheduleRevertAccountRequest(req)
 		})
@@ -1159,6 +1169,7 @@ func (s *Syncer) assignBytecodeTasks(success chan *bytecodeResponse, fail chan *
 		}
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Bytecode request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.ReputationTimeout)
 			s.rates.Update(idle, ByteCodesMsg, 0, 0)
 			s.scheduleRevertBytecodeRequest(req)
 		})
@@ -1306,6 +1317,7 @@ func (s *Syncer) assignStorageTasks(success chan *storageResponse, fail chan *st
 		}
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Storage request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.ReputationTimeout)
 			s.rates.Update(idle, StorageRangesMsg, 0, 0)
 			s.scheduleRevertStorageRequest(req)
 		})
@@ -1443,6 +1455,7 @@ func (s *Syncer) assignTrienodeHealTasks(success chan *trienodeHealResponse, fai
 		}
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Trienode heal request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.ReputationTimeout)
 			s.rates.Update(idle, TrieNodesMsg, 0, 0)
 			s.scheduleRevertTrienodeHealRequest(req)
 		})
@@ -1559,6 +1572,7 @@ func (s *Syncer) assignBytecodeHealTasks(success chan *bytecodeHealResponse, fai
 		}
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Bytecode heal request timed out", "reqid", reqid)
+			reportPeer(peer, p2p.ReputationTimeout)
 			s.rates.Update(idle, ByteCodesMsg, 0, 0)
 			s.scheduleRevertBytecodeHealRequest(req)
 		})
@@ -2481,6 +2495,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 	if len(hashes) == 0 && len(accounts) == 0 && len(proof) == 0 {
 		logger.Debug("Peer rejected account range request", "root", s.root)
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.ReputationUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2520,6 +2535,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 		accounts: accs,
 		cont:     cont,
 	}
+	reportPeer(peer, p2p.ReputationGoodResponse)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2591,6 +2607,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 	if len(bytecodes) == 0 {
 		logger.Debug("Peer rejected bytecode request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.ReputationUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2631,6 +2648,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 		hashes: req.hashes,
 		codes:  codes,
 	}
+	reportPeer(peer, p2p.ReputationGoodResponse)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2719,6 +2737,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 	if len(hashes) == 0 && len(proof) == 0 {
 		logger.Debug("Peer rejected storage request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.ReputationUselessResponse)
 		s.lock.Unlock()
 		s.scheduleRevertStorageRequest(req) // reschedule request
 		return nil
@@ -2780,6 +2799,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 		slots:    slots,
 		cont:     cont,
 	}
+	reportPeer(peer, p2p.ReputationGoodResponse)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2838,6 +2858,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 	if len(trienodes) == 0 {
 		logger.Debug("Peer rejected trienode heal request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.ReputationUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2887,6 +2908,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 		hashes: req.hashes,
 		nodes:  nodes,
 	}
+	reportPeer(peer, p2p.ReputationGoodResponse)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
@@ -2945,6 +2967,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 	if len(bytecodes) == 0 {
 		logger.Debug("Peer rejected bytecode heal request")
 		s.statelessPeers[peer.ID()] = struct{}{}
+		reportPeer(peer, p2p.ReputationUselessResponse)
 		s.lock.Unlock()
 
 		// Signal this request as failed, and ready for rescheduling
@@ -2985,6 +3008,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 		hashes: req.hashes,
 		codes:  codes,
 	}
+	reportPeer(peer, p2p.ReputationGoodResponse)
 	select {
 	case req.deliver <- response:
 	case <-req.cancel:
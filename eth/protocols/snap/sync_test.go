@@ -157,8 +157,7 @@ func newTestPeer(id string, t *testing.T, term func()) *testPeer {
 		codeRequestHandler:    defaultCodeRequestHandler,
 		term:                  term,
 	}
-	//stderrHandler := log.StreamHandler(os.Stderr, log.TerminalFormat(true))
-	//peer.logger.SetHandler(stderrHandler)
+	//peer.logger = log.NewLogger(log.NewTerminalHandler(os.Stderr, true)).With("id", id)
 	return peer
 }
 
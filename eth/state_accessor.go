@@ -180,10 +180,14 @@ func (eth *Ethereum) pathState(block *types.Block) (*state.StateDB, func(), erro
 	if err == nil {
 		return statedb, noopReleaser, nil
 	}
-	// TODO historic state is not supported in path-based scheme.
-	// Fully archive node in pbss will be implemented by relying
-	// on state history, but needs more work on top.
-	return nil, nil, errors.New("historical state not available in path scheme yet")
+	// Fall back to the state histories if the state is not available anymore.
+	// The historical state is read-only and can't be committed, but it's fine
+	// for serving calls and tracing.
+	statedb, herr := state.NewHistoric(block.Root(), eth.blockchain.StateCache())
+	if herr != nil {
+		return nil, nil, fmt.Errorf("historical state %x is not available: %w (historical state: %w)", block.Root(), err, herr)
+	}
+	return statedb, noopReleaser, nil
 }
 
 // stateAtBlock retrieves the state database associated with a certain block.
@@ -38,6 +38,9 @@ func (h *handler) syncTransactions(p *eth.Peer) {
 	var hashes []common.Hash
 	for _, batch := range h.txpool.Pending(false) {
 		for _, tx := range batch {
+			if h.txpool.Conditional(tx.Hash) != nil {
+				continue
+			}
 			hashes = append(hashes, tx.Hash)
 		}
 	}
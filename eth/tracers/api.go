@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"runtime"
 	"sync"
@@ -65,6 +66,10 @@ const (
 	// for tracing. The creation of trace state will be paused if the unused
 	// trace states exceed this limit.
 	maximumPendingTraceStates = 128
+
+	// pseudoBlockInterval is the timestamp increment between the pseudo-blocks
+	// the bundles of TraceCallMany are executed in.
+	pseudoBlockInterval = 12
 )
 
 var errTxNotFound = errors.New("transaction not found")
@@ -865,25 +870,7 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // top of the provided block and returns them as a JSON object.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
 	// Try to retrieve the specified block
-	var (
-		err   error
-		block *types.Block
-	)
-	if hash, ok := blockNrOrHash.Hash(); ok {
-		block, err = api.blockByHash(ctx, hash)
-	} else if number, ok := blockNrOrHash.Number(); ok {
-		if number == rpc.PendingBlockNumber {
-			// We don't have access to the miner here. For tracing 'future' transactions,
-			// it can be done with block- and state-overrides instead, which offers
-			// more flexibility and stability than trying to trace on 'pending', since
-			// the contents of 'pending' is unstable and probably not a true representation
-			// of what the next actual block is likely to contain.
-			return nil, errors.New("tracing on top of pending is not supported")
-		}
-		block, err = api.blockByNumber(ctx, number)
-	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
-	}
+	block, err := api.callBlock(ctx, blockNrOrHash)
 	if err != nil {
 		return nil, err
 	}
@@ -919,6 +906,110 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
 }
 
+// Bundle is a batch of calls traced by TraceCallMany. The calls of a bundle are
+// executed sequentially in the same pseudo-block.
+type Bundle struct {
+	Transactions   []ethapi.TransactionArgs `json:"transactions"`
+	BlockOverrides *ethapi.BlockOverrides   `json:"blockOverrides"`
+	StateOverrides *ethapi.StateOverride    `json:"stateOverrides"`
+}
+
+// TraceCallMany lets you trace a series of dependent eth_calls. The bundles are
+// executed in order on top of the provided block, each one in its own pseudo-block
+// following the previous one, and every call observes the state changes made by
+// the calls preceding it. The returned traces are grouped per bundle.
+//
+// The block and state overrides of the config are applied before the first bundle,
+// the ones of an individual bundle before the calls of that bundle.
+func (api *API) TraceCallMany(ctx context.Context, bundles []Bundle, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([][]interface{}, error) {
+	if len(bundles) == 0 {
+		return nil, errors.New("empty bundle list")
+	}
+	// Try to retrieve the specified block
+	block, err := api.callBlock(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	// try to recompute the state
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, release, err := api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
+	// Apply the customization rules if required.
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+		traceConfig = &config.TraceConfig
+	}
+	var (
+		chainConfig = api.backend.ChainConfig()
+		results     = make([][]interface{}, len(bundles))
+	)
+	for i, bundle := range bundles {
+		// Every bundle after the first is executed in a new pseudo-block,
+		// unless its block overrides say otherwise.
+		bundleCtx := vmctx
+		bundleCtx.BlockNumber = new(big.Int).Add(vmctx.BlockNumber, big.NewInt(int64(i)))
+		bundleCtx.Time = vmctx.Time + uint64(i)*pseudoBlockInterval
+		bundle.BlockOverrides.Apply(&bundleCtx)
+
+		if err := bundle.StateOverrides.Apply(statedb); err != nil {
+			return nil, fmt.Errorf("bundle %d: %w", i, err)
+		}
+		results[i] = make([]interface{}, len(bundle.Transactions))
+		for j, args := range bundle.Transactions {
+			msg, err := args.ToMessage(api.backend.RPCGasCap(), bundleCtx.BaseFee)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			txctx := &Context{
+				BlockNumber: bundleCtx.BlockNumber,
+				TxIndex:     j,
+			}
+			res, err := api.traceTx(ctx, msg, txctx, bundleCtx, statedb, traceConfig)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d, call %d: %w", i, j, err)
+			}
+			results[i][j] = res
+			// Finalize the state so that the following calls observe
+			// the changes as if this call was an included transaction.
+			statedb.Finalise(chainConfig.IsEIP158(bundleCtx.BlockNumber))
+		}
+	}
+	return results, nil
+}
+
+// callBlock retrieves the block specified by the given number or hash for
+// tracing calls on top of it. Tracing on top of the pending block is not
+// supported.
+func (api *API) callBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return api.blockByHash(ctx, hash)
+	}
+	if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.PendingBlockNumber {
+			// We don't have access to the miner here. For tracing 'future' transactions,
+			// it can be done with block- and state-overrides instead, which offers
+			// more flexibility and stability than trying to trace on 'pending', since
+			// the contents of 'pending' is unstable and probably not a true representation
+			// of what the next actual block is likely to contain.
+			return nil, errors.New("tracing on top of pending is not supported")
+		}
+		return api.blockByNumber(ctx, number)
+	}
+	return nil, errors.New("invalid arguments; neither block nor hash specified")
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
@@ -320,6 +320,93 @@ func TestTraceCall(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	genBlocks := 10
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	var (
+		counter = common.Address{0xc0}
+		number  = common.Address{0xc1}
+		latest  = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	)
+	config := &TraceCallConfig{
+		StateOverrides: &ethapi.StateOverride{
+			// Increments slot 0 and returns the new value.
+			counter: ethapi.OverrideAccount{Code: newRPCBytes(common.FromHex("6000546001018060005560005260206000f3"))},
+		},
+	}
+	bundles := []Bundle{
+		{
+			Transactions: []ethapi.TransactionArgs{
+				{From: &accounts[0].addr, To: &counter},
+				{From: &accounts[0].addr, To: &counter},
+			},
+		}, {
+			StateOverrides: &ethapi.StateOverride{
+				// Returns the block number.
+				number: ethapi.OverrideAccount{Code: newRPCBytes(common.FromHex("4360005260206000f3"))},
+			},
+			Transactions: []ethapi.TransactionArgs{
+				{From: &accounts[0].addr, To: &counter},
+				{From: &accounts[0].addr, To: &number},
+			},
+		}, {
+			BlockOverrides: &ethapi.BlockOverrides{Number: (*hexutil.Big)(big.NewInt(100))},
+			Transactions: []ethapi.TransactionArgs{
+				{From: &accounts[0].addr, To: &number},
+			},
+		},
+	}
+	results, err := api.TraceCallMany(context.Background(), bundles, latest, config)
+	if err != nil {
+		t.Fatalf("failed to trace call bundles: %v", err)
+	}
+	want := [][]uint64{{1, 2}, {3, uint64(genBlocks + 1)}, {100}}
+	if len(results) != len(want) {
+		t.Fatalf("bundle count mismatch: have %d, want %d", len(results), len(want))
+	}
+	for i := range want {
+		if len(results[i]) != len(want[i]) {
+			t.Fatalf("bundle %d: trace count mismatch: have %d, want %d", i, len(results[i]), len(want[i]))
+		}
+		for j := range want[i] {
+			var have struct {
+				Failed      bool
+				ReturnValue string
+			}
+			resBytes, _ := json.Marshal(results[i][j])
+			json.Unmarshal(resBytes, &have)
+			if have.Failed {
+				t.Errorf("bundle %d, call %d: unexpected failure", i, j)
+			}
+			if value := new(big.Int).SetBytes(common.FromHex(have.ReturnValue)).Uint64(); value != want[i][j] {
+				t.Errorf("bundle %d, call %d: result mismatch: have %d, want %d", i, j, value, want[i][j])
+			}
+		}
+	}
+	// Tracing on top of pending is not supported
+	pending := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if _, err := api.TraceCallMany(context.Background(), bundles, pending, config); err == nil {
+		t.Fatal("expected error tracing on top of pending")
+	}
+}
+
 func TestTraceTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,156 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracetest
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// erc7562Frame is the subset of the erc7562Tracer output checked by the tests.
+type erc7562Frame struct {
+	Type          string `json:"type"`
+	AccessedSlots struct {
+		Reads  map[common.Hash][]common.Hash `json:"reads"`
+		Writes map[common.Hash]uint64        `json:"writes"`
+	} `json:"accessedSlots"`
+	ExtCodeAccessInfo []common.Address          `json:"extCodeAccessInfo"`
+	UsedOpcodes       map[hexutil.Uint64]uint64 `json:"usedOpcodes"`
+	ContractSize      map[common.Address]struct {
+		ContractSize int       `json:"contractSize"`
+		Opcode       vm.OpCode `json:"opcode"`
+	} `json:"contractSize"`
+	OutOfGas        bool            `json:"outOfGas"`
+	KeccakPreimages []hexutil.Bytes `json:"keccak"`
+	Calls           []erc7562Frame  `json:"calls"`
+}
+
+func TestErc7562Tracer(t *testing.T) {
+	var (
+		to     = common.HexToAddress("0x00000000000000000000000000000000deadbeef")
+		origin = common.HexToAddress("0x00000000000000000000000000000000feed")
+		target = common.HexToAddress("0xff")
+		slot   = common.HexToHash("0x01")
+		code   = []byte{
+			byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP), // read slot 1
+			byte(vm.PUSH1), 0x02, byte(vm.PUSH1), 0x01, byte(vm.SSTORE), // write slot 1
+			byte(vm.PUSH1), 0x01, byte(vm.SLOAD), byte(vm.POP), // read slot 1 after write
+			byte(vm.PUSH1), 0x20, byte(vm.PUSH1), 0x00, byte(vm.KECCAK256), byte(vm.POP),
+			byte(vm.PUSH1), 0xff, byte(vm.EXTCODESIZE), byte(vm.ISZERO), byte(vm.POP), // permitted
+			byte(vm.PUSH1), 0xff, byte(vm.EXTCODEHASH), byte(vm.POP),
+			byte(vm.TIMESTAMP), byte(vm.POP),
+			byte(vm.GAS), byte(vm.POP),
+			byte(vm.PUSH1), 0x0, byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1), byte(vm.DUP1),
+			byte(vm.PUSH1), 0xff, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), // GAS is permitted before CALL
+		}
+		txContext = vm.TxContext{
+			Origin:   origin,
+			GasPrice: big.NewInt(1),
+		}
+		context = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: new(big.Int).SetUint64(8000000),
+			Time:        5,
+			Difficulty:  big.NewInt(0x30000),
+			GasLimit:    uint64(6000000),
+		}
+	)
+	tracer, err := tracers.DefaultDirectory.New("erc7562Tracer", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	triedb, _, statedb := tests.MakePreState(rawdb.NewMemoryDatabase(),
+		core.GenesisAlloc{
+			to: core.GenesisAccount{
+				Code:    code,
+				Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x42")},
+			},
+			origin: core.GenesisAccount{
+				Balance: big.NewInt(500000000000000),
+			},
+		}, false, rawdb.HashScheme)
+	defer triedb.Close()
+
+	evm := vm.NewEVM(context, txContext, statedb, params.MainnetChainConfig, vm.Config{Tracer: tracer})
+	msg := &core.Message{
+		To:        &to,
+		From:      origin,
+		Value:     big.NewInt(0),
+		GasLimit:  80000,
+		GasPrice:  big.NewInt(0),
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+	}
+	st := core.NewStateTransition(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+	if _, err := st.TransitionDb(); err != nil {
+		t.Fatalf("failed to execute transaction: %v", err)
+	}
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("failed to retrieve trace result: %v", err)
+	}
+	var frame erc7562Frame
+	if err := json.Unmarshal(res, &frame); err != nil {
+		t.Fatalf("failed to unmarshal trace result: %v", err)
+	}
+	wantOpcodes := map[hexutil.Uint64]uint64{
+		hexutil.Uint64(vm.SLOAD):       2,
+		hexutil.Uint64(vm.SSTORE):      1,
+		hexutil.Uint64(vm.KECCAK256):   1,
+		hexutil.Uint64(vm.EXTCODEHASH): 1,
+		hexutil.Uint64(vm.TIMESTAMP):   1,
+		hexutil.Uint64(vm.GAS):         1,
+		hexutil.Uint64(vm.CALL):        1,
+		hexutil.Uint64(vm.STOP):        1,
+	}
+	if !reflect.DeepEqual(frame.UsedOpcodes, wantOpcodes) {
+		t.Errorf("used opcodes mismatch: have %v, want %v", frame.UsedOpcodes, wantOpcodes)
+	}
+	if reads := frame.AccessedSlots.Reads[slot]; len(reads) != 1 || reads[0] != common.HexToHash("0x42") {
+		t.Errorf("storage reads mismatch: have %v", frame.AccessedSlots.Reads)
+	}
+	if writes := frame.AccessedSlots.Writes[slot]; writes != 1 {
+		t.Errorf("storage writes mismatch: have %v", frame.AccessedSlots.Writes)
+	}
+	if len(frame.KeccakPreimages) != 1 || len(frame.KeccakPreimages[0]) != 32 {
+		t.Errorf("keccak preimages mismatch: have %v", frame.KeccakPreimages)
+	}
+	if len(frame.ExtCodeAccessInfo) != 1 || frame.ExtCodeAccessInfo[0] != target {
+		t.Errorf("code access mismatch: have %v", frame.ExtCodeAccessInfo)
+	}
+	if size, ok := frame.ContractSize[target]; !ok || size.ContractSize != 0 || size.Opcode != vm.EXTCODESIZE {
+		t.Errorf("contract size mismatch: have %v", frame.ContractSize)
+	}
+	if frame.OutOfGas {
+		t.Error("unexpected out-of-gas")
+	}
+	if len(frame.Calls) != 1 || frame.Calls[0].Type != "CALL" {
+		t.Errorf("inner calls mismatch: have %v", frame.Calls)
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("erc7562Tracer", newErc7562Tracer, false)
+}
+
+// accessedSlots contains the storage slots accessed by a call frame. The
+// persistent reads record the values of the slots before they are written
+// in the frame, the writes and the transient accesses are counted.
+type accessedSlots struct {
+	Reads           map[common.Hash][]common.Hash `json:"reads"`
+	Writes          map[common.Hash]uint64        `json:"writes"`
+	TransientReads  map[common.Hash]uint64        `json:"transientReads"`
+	TransientWrites map[common.Hash]uint64        `json:"transientWrites"`
+}
+
+// contractSizeWithOpcode is the code size of a contract accessed by a call
+// frame along with the opcode used for the access.
+type contractSizeWithOpcode struct {
+	ContractSize int       `json:"contractSize"`
+	Opcode       vm.OpCode `json:"opcode"`
+}
+
+// erc7562Frame is a call frame with the information needed to validate the
+// ERC-7562 rules of account abstraction.
+type erc7562Frame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+
+	AccessedSlots     accessedSlots                              `json:"accessedSlots"`
+	ExtCodeAccessInfo []common.Address                           `json:"extCodeAccessInfo"`
+	UsedOpcodes       map[hexutil.Uint64]uint64                  `json:"usedOpcodes"`
+	ContractSize      map[common.Address]*contractSizeWithOpcode `json:"contractSize"`
+	OutOfGas          bool                                       `json:"outOfGas"`
+	KeccakPreimages   []hexutil.Bytes                            `json:"keccak,omitempty"`
+	Calls             []*erc7562Frame                            `json:"calls,omitempty"`
+}
+
+func newErc7562Frame(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) *erc7562Frame {
+	return &erc7562Frame{
+		Type:  typ.String(),
+		From:  from,
+		To:    &to,
+		Gas:   hexutil.Uint64(gas),
+		Input: common.CopyBytes(input),
+		Value: (*hexutil.Big)(value),
+		AccessedSlots: accessedSlots{
+			Reads:           make(map[common.Hash][]common.Hash),
+			Writes:          make(map[common.Hash]uint64),
+			TransientReads:  make(map[common.Hash]uint64),
+			TransientWrites: make(map[common.Hash]uint64),
+		},
+		ExtCodeAccessInfo: make([]common.Address, 0),
+		UsedOpcodes:       make(map[hexutil.Uint64]uint64),
+		ContractSize:      make(map[common.Address]*contractSizeWithOpcode),
+	}
+}
+
+// processOutput fills the output related fields once the frame is finished.
+func (f *erc7562Frame) processOutput(output []byte, gasUsed uint64, err error) {
+	f.GasUsed = hexutil.Uint64(gasUsed)
+	if err == nil {
+		f.Output = common.CopyBytes(output)
+		return
+	}
+	f.Error = err.Error()
+	f.OutOfGas = errors.Is(err, vm.ErrOutOfGas)
+	if f.Type == vm.CREATE.String() || f.Type == vm.CREATE2.String() {
+		f.To = nil
+	}
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+	f.Output = common.CopyBytes(output)
+	if len(output) < 4 {
+		return
+	}
+	if unpacked, err := abi.UnpackRevert(output); err == nil {
+		f.RevertReason = unpacked
+	}
+}
+
+// addExtCodeAccess records an access to the code of the given address.
+func (f *erc7562Frame) addExtCodeAccess(addr common.Address) {
+	for _, accessed := range f.ExtCodeAccessInfo {
+		if accessed == addr {
+			return
+		}
+	}
+	f.ExtCodeAccessInfo = append(f.ExtCodeAccessInfo, addr)
+}
+
+type erc7562TracerConfig struct {
+	IgnoredOpcodes []hexutil.Uint64 `json:"ignoredOpcodes"` // Opcodes not to be counted, the default set is used if nil
+}
+
+// defaultIgnoredOpcodes returns the opcodes which are irrelevant for the
+// validation rules, they are not counted to keep the output compact.
+func defaultIgnoredOpcodes() []hexutil.Uint64 {
+	ignored := make([]hexutil.Uint64, 0, 64)
+
+	// PUSHx, DUPx and SWAPx opcodes have sequential codes
+	for op := vm.PUSH0; op <= vm.SWAP16; op++ {
+		ignored = append(ignored, hexutil.Uint64(op))
+	}
+	for _, op := range []vm.OpCode{
+		vm.POP, vm.ADD, vm.SUB, vm.MUL, vm.DIV, vm.EQ, vm.LT, vm.GT,
+		vm.SLT, vm.SGT, vm.SHL, vm.SHR, vm.AND, vm.OR, vm.NOT, vm.ISZERO,
+	} {
+		ignored = append(ignored, hexutil.Uint64(op))
+	}
+	return ignored
+}
+
+// erc7562Tracer is a native go tracer which collects the information needed
+// by ERC-4337 bundlers to validate the ERC-7562 rules of user operations: the
+// opcodes used, the storage accessed, the keccak preimages, the code accesses
+// and the out-of-gas failures of every call frame.
+type erc7562Tracer struct {
+	noopTracer
+	env       *vm.EVM
+	callstack []*erc7562Frame
+	ignored   map[vm.OpCode]bool
+	gasLimit  uint64
+
+	// The GAS and EXTCODESIZE opcodes are permitted when they are followed by
+	// a call and ISZERO respectively, they are resolved at the next step.
+	lastOp    vm.OpCode
+	lastFrame *erc7562Frame
+	lastAddr  common.Address
+
+	activePrecompiles []common.Address // Updated on CaptureStart based on given rules
+	interrupt         atomic.Bool      // Atomic flag to signal execution interruption
+	reason            error            // Textual reason for the interruption
+}
+
+// newErc7562Tracer returns a native go tracer which tracks the information
+// required for the ERC-7562 validation rules, and implements vm.EVMLogger.
+func newErc7562Tracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+	var config erc7562TracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.IgnoredOpcodes == nil {
+		config.IgnoredOpcodes = defaultIgnoredOpcodes()
+	}
+	ignored := make(map[vm.OpCode]bool, len(config.IgnoredOpcodes))
+	for _, op := range config.IgnoredOpcodes {
+		ignored[vm.OpCode(op)] = true
+	}
+	return &erc7562Tracer{ignored: ignored}, nil
+}
+
+// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
+func (t *erc7562Tracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.env = env
+
+	rules := env.ChainConfig().Rules(env.Context.BlockNumber, env.Context.Random != nil, env.Context.Time)
+	t.activePrecompiles = vm.ActivePrecompiles(rules)
+
+	typ := vm.CALL
+	if create {
+		typ = vm.CREATE
+	}
+	t.callstack = []*erc7562Frame{newErc7562Frame(typ, from, to, input, t.gasLimit, value)}
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *erc7562Tracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	t.resolveLastOp(vm.STOP)
+	t.callstack[0].processOutput(output, gasUsed, err)
+}
+
+// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
+func (t *erc7562Tracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	// skip if the previous op caused an error
+	if err != nil {
+		return
+	}
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	t.resolveLastOp(op)
+
+	var (
+		frame = t.callstack[len(t.callstack)-1]
+		stack = scope.Stack.Data()
+		addr  = scope.Contract.Address()
+	)
+	switch op {
+	case vm.GAS, vm.EXTCODESIZE:
+		// Resolved at the next step, see resolveLastOp
+	default:
+		if !t.ignored[op] {
+			frame.UsedOpcodes[hexutil.Uint64(op)]++
+		}
+	}
+	switch op {
+	case vm.SLOAD:
+		if len(stack) < 1 {
+			break
+		}
+		slot := common.Hash(stack[len(stack)-1].Bytes32())
+		if _, ok := frame.AccessedSlots.Writes[slot]; ok {
+			break
+		}
+		if _, ok := frame.AccessedSlots.Reads[slot]; ok {
+			break
+		}
+		frame.AccessedSlots.Reads[slot] = append(frame.AccessedSlots.Reads[slot], t.env.StateDB.GetState(addr, slot))
+
+	case vm.SSTORE:
+		if len(stack) < 1 {
+			break
+		}
+		frame.AccessedSlots.Writes[common.Hash(stack[len(stack)-1].Bytes32())]++
+
+	case vm.TLOAD:
+		if len(stack) < 1 {
+			break
+		}
+		frame.AccessedSlots.TransientReads[common.Hash(stack[len(stack)-1].Bytes32())]++
+
+	case vm.TSTORE:
+		if len(stack) < 1 {
+			break
+		}
+		frame.AccessedSlots.TransientWrites[common.Hash(stack[len(stack)-1].Bytes32())]++
+
+	case vm.KECCAK256:
+		if len(stack) < 2 {
+			break
+		}
+		offset, size := stack[len(stack)-1], stack[len(stack)-2]
+		preimage, err := tracers.GetMemoryCopyPadded(scope.Memory, int64(offset.Uint64()), int64(size.Uint64()))
+		if err != nil {
+			log.Warn("Failed to copy keccak preimage", "err", err, "tracer", "erc7562Tracer", "offset", offset, "size", size)
+			break
+		}
+		frame.KeccakPreimages = append(frame.KeccakPreimages, preimage)
+
+	case vm.EXTCODESIZE, vm.EXTCODEHASH, vm.EXTCODECOPY:
+		if len(stack) < 1 {
+			break
+		}
+		target := common.Address(stack[len(stack)-1].Bytes20())
+		t.recordContractSize(frame, target, op)
+		if op == vm.EXTCODESIZE {
+			t.lastAddr = target
+		} else {
+			frame.addExtCodeAccess(target)
+		}
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		if len(stack) < 2 {
+			break
+		}
+		t.recordContractSize(frame, common.Address(stack[len(stack)-2].Bytes20()), op)
+	}
+	t.lastOp, t.lastFrame = op, frame
+}
+
+// resolveLastOp records the opcode of the previous step if it's not permitted
+// by the opcode following it.
+func (t *erc7562Tracer) resolveLastOp(op vm.OpCode) {
+	switch t.lastOp {
+	case vm.GAS:
+		if !isCallOp(op) && !t.ignored[vm.GAS] {
+			t.lastFrame.UsedOpcodes[hexutil.Uint64(vm.GAS)]++
+		}
+	case vm.EXTCODESIZE:
+		if op != vm.ISZERO {
+			if !t.ignored[vm.EXTCODESIZE] {
+				t.lastFrame.UsedOpcodes[hexutil.Uint64(vm.EXTCODESIZE)]++
+			}
+			t.lastFrame.addExtCodeAccess(t.lastAddr)
+		}
+	}
+	t.lastOp, t.lastFrame = vm.STOP, nil
+}
+
+// recordContractSize records the code size of the contract accessed by the
+// frame, precompiles are skipped.
+func (t *erc7562Tracer) recordContractSize(frame *erc7562Frame, addr common.Address, op vm.OpCode) {
+	if t.isPrecompiled(addr) {
+		return
+	}
+	if _, ok := frame.ContractSize[addr]; ok {
+		return
+	}
+	frame.ContractSize[addr] = &contractSizeWithOpcode{
+		ContractSize: t.env.StateDB.GetCodeSize(addr),
+		Opcode:       op,
+	}
+}
+
+// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *erc7562Tracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	t.resolveLastOp(typ)
+	t.callstack = append(t.callstack, newErc7562Frame(typ, from, to, input, gas, value))
+}
+
+// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *erc7562Tracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+	t.resolveLastOp(vm.STOP)
+
+	// pop call
+	call := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+	size -= 1
+
+	call.processOutput(output, gasUsed, err)
+	t.callstack[size-1].Calls = append(t.callstack[size-1].Calls, call)
+}
+
+func (t *erc7562Tracer) CaptureTxStart(gasLimit uint64) {
+	t.gasLimit = gasLimit
+}
+
+func (t *erc7562Tracer) CaptureTxEnd(restGas uint64) {
+	if len(t.callstack) == 0 {
+		return
+	}
+	t.callstack[0].GasUsed = hexutil.Uint64(t.gasLimit - restGas)
+}
+
+// GetResult returns the json-encoded nested list of call frames, and any
+// error arising from the encoding or forceful termination (via `Stop`).
+func (t *erc7562Tracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(res), t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *erc7562Tracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
+
+// isPrecompiled returns whether the addr is a precompile.
+func (t *erc7562Tracer) isPrecompiled(addr common.Address) bool {
+	for _, p := range t.activePrecompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// isCallOp returns whether the opcode enters a new call frame.
+func isCallOp(op vm.OpCode) bool {
+	switch op {
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE, vm.CREATE2:
+		return true
+	}
+	return false
+}
@@ -166,6 +166,82 @@ func (ec *Client) CallContractWithBlockOverrides(ctx context.Context, msg ethere
 	return hex, err
 }
 
+// SimulateV1 executes a series of blocks, each containing an ordered list of message
+// calls, on top of the state of the given block. State changes made by a call are visible
+// to all calls following it, including the ones in later blocks. None of the changes are
+// persisted in the blockchain.
+//
+// blockNumber selects the block height the simulation is based on. It can be nil, in which
+// case the latest known block is used.
+//
+// If validation is set, the calls are subject to the same checks as real transactions,
+// e.g. nonces must match and the sender must be able to pay for the base fee.
+func (ec *Client) SimulateV1(ctx context.Context, blocks []SimulateBlock, validation bool, blockNumber *big.Int) ([]*SimulateBlockResult, error) {
+	type callError struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+		Data    string `json:"data,omitempty"`
+	}
+	type callResult struct {
+		ReturnValue hexutil.Bytes  `json:"returnData"`
+		Logs        []*types.Log   `json:"logs"`
+		GasUsed     hexutil.Uint64 `json:"gasUsed"`
+		Status      hexutil.Uint64 `json:"status"`
+		Error       *callError     `json:"error,omitempty"`
+	}
+	type blockResult struct {
+		Number    *hexutil.Big   `json:"number"`
+		Hash      common.Hash    `json:"hash"`
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+		GasLimit  hexutil.Uint64 `json:"gasLimit"`
+		GasUsed   hexutil.Uint64 `json:"gasUsed"`
+		Miner     common.Address `json:"miner"`
+		BaseFee   *hexutil.Big   `json:"baseFeePerGas"`
+		Calls     []callResult   `json:"calls"`
+	}
+	type simOpts struct {
+		BlockStateCalls []SimulateBlock `json:"blockStateCalls"`
+		Validation      bool            `json:"validation"`
+	}
+	var res []blockResult
+	opts := simOpts{BlockStateCalls: blocks, Validation: validation}
+	if err := ec.c.CallContext(ctx, &res, "eth_simulateV1", opts, toBlockNumArg(blockNumber)); err != nil {
+		return nil, err
+	}
+	// Turn hexutils back to normal datatypes
+	results := make([]*SimulateBlockResult, 0, len(res))
+	for _, b := range res {
+		calls := make([]SimulateCallResult, 0, len(b.Calls))
+		for _, c := range b.Calls {
+			call := SimulateCallResult{
+				ReturnValue: c.ReturnValue,
+				Logs:        c.Logs,
+				GasUsed:     uint64(c.GasUsed),
+				Status:      uint64(c.Status),
+			}
+			if c.Error != nil {
+				call.Error = &SimulateCallError{
+					Message: c.Error.Message,
+					Code:    c.Error.Code,
+					Data:    c.Error.Data,
+				}
+			}
+			calls = append(calls, call)
+		}
+		results = append(results, &SimulateBlockResult{
+			Number:       b.Number.ToInt(),
+			Hash:         b.Hash,
+			Timestamp:    uint64(b.Timestamp),
+			GasLimit:     uint64(b.GasLimit),
+			GasUsed:      uint64(b.GasUsed),
+			FeeRecipient: b.Miner,
+			BaseFee:      (*big.Int)(b.BaseFee),
+			Calls:        calls,
+		})
+	}
+	return results, nil
+}
+
 // GCStats retrieves the current garbage collection stats from a geth node.
 func (ec *Client) GCStats(ctx context.Context) (*debug.GCStats, error) {
 	var result debug.GCStats
@@ -333,3 +409,67 @@ func (o BlockOverrides) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(output)
 }
+
+// SimulateBlock is a block of message calls to be simulated by SimulateV1.
+type SimulateBlock struct {
+	// BlockOverrides specifies the header fields of the simulated block.
+	// Unset fields are derived from the parent block.
+	BlockOverrides *BlockOverrides
+
+	// StateOverrides specifies the account states to be overridden before
+	// the calls of the block are executed.
+	StateOverrides map[common.Address]OverrideAccount
+
+	// Calls is the ordered list of message calls executed in the block.
+	Calls []ethereum.CallMsg
+}
+
+func (b SimulateBlock) MarshalJSON() ([]byte, error) {
+	type block struct {
+		BlockOverrides *BlockOverrides                    `json:"blockOverrides,omitempty"`
+		StateOverrides map[common.Address]OverrideAccount `json:"stateOverrides,omitempty"`
+		Calls          []interface{}                      `json:"calls"`
+	}
+	output := block{
+		BlockOverrides: b.BlockOverrides,
+		StateOverrides: b.StateOverrides,
+		Calls:          make([]interface{}, len(b.Calls)),
+	}
+	for i, call := range b.Calls {
+		output.Calls[i] = toCallArg(call)
+	}
+	return json.Marshal(output)
+}
+
+// SimulateBlockResult is the result of a block simulated by SimulateV1.
+type SimulateBlockResult struct {
+	Number       *big.Int
+	Hash         common.Hash
+	Timestamp    uint64
+	GasLimit     uint64
+	GasUsed      uint64
+	FeeRecipient common.Address
+	BaseFee      *big.Int
+	Calls        []SimulateCallResult
+}
+
+// SimulateCallResult is the result of a single message call simulated by SimulateV1.
+type SimulateCallResult struct {
+	ReturnValue []byte
+	Logs        []*types.Log
+	GasUsed     uint64
+	Status      uint64
+	Error       *SimulateCallError
+}
+
+// SimulateCallError is the error of a failed simulated message call. For reverted
+// calls, Data contains the hex encoded revert data.
+type SimulateCallError struct {
+	Message string
+	Code    int
+	Data    string
+}
+
+func (e *SimulateCallError) Error() string {
+	return e.Message
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/json"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum"
@@ -134,6 +135,9 @@ func TestGethClient(t *testing.T) {
 		}, {
 			"TestCallContractWithBlockOverrides",
 			func(t *testing.T) { testCallContractWithBlockOverrides(t, client) },
+		}, {
+			"TestSimulateV1",
+			func(t *testing.T) { testSimulateV1(t, client) },
 		},
 		// The testaccesslist is a bit time-sensitive: the newTestBackend imports
 		// one block. The `testAcessList` fails if the miner has not yet created a
@@ -526,3 +530,52 @@ func testCallContractWithBlockOverrides(t *testing.T, client *rpc.Client) {
 		t.Fatalf("unexpected result: %x", res)
 	}
 }
+
+func testSimulateV1(t *testing.T, client *rpc.Client) {
+	ec := New(client)
+	counter := common.Address{0xc0}
+	blocks := []SimulateBlock{
+		{
+			StateOverrides: map[common.Address]OverrideAccount{
+				// Increments slot 0 and returns the new value.
+				counter: {Code: common.FromHex("0x6000546001018060005560005260206000f3")},
+			},
+			Calls: []ethereum.CallMsg{
+				{From: testAddr, To: &counter},
+				{From: testAddr, To: &counter},
+			},
+		}, {
+			BlockOverrides: &BlockOverrides{
+				Coinbase: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			},
+			Calls: []ethereum.CallMsg{
+				{From: testAddr, To: &counter},
+			},
+		},
+	}
+	res, err := ec.SimulateV1(context.Background(), blocks, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("unexpected number of blocks: %d", len(res))
+	}
+	if res[1].FeeRecipient != blocks[1].BlockOverrides.Coinbase {
+		t.Fatalf("unexpected fee recipient: %v", res[1].FeeRecipient)
+	}
+	if res[1].Number.Uint64() != res[0].Number.Uint64()+1 {
+		t.Fatalf("unexpected block numbers: %v, %v", res[0].Number, res[1].Number)
+	}
+	var have []uint64
+	for _, block := range res {
+		for _, call := range block.Calls {
+			if call.Error != nil {
+				t.Fatalf("unexpected call error: %v", call.Error)
+			}
+			have = append(have, new(big.Int).SetBytes(call.ReturnValue).Uint64())
+		}
+	}
+	if !reflect.DeepEqual(have, []uint64{1, 2, 3}) {
+		t.Fatalf("unexpected call results: %v", have)
+	}
+}
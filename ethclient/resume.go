@@ -0,0 +1,225 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// resumeWindow is the number of blocks behind the cursor for which delivered
+// events are remembered, to filter out those replayed again after resubscribing.
+const resumeWindow = 64
+
+// subscriptionCursor is the position a subscription is resumed from. It is the
+// client side of the cursor parameter accepted by eth_subscribe for logs and newHeads.
+type subscriptionCursor struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   *common.Hash   `json:"blockHash,omitempty"`
+}
+
+// resumeState tracks the events delivered on a resumable subscription.
+type resumeState struct {
+	mu     sync.Mutex
+	cursor *subscriptionCursor
+	seen   map[common.Hash]resumeMark // delivered blocks in the resume window
+}
+
+// resumeMark is the last delivered event of a block.
+type resumeMark struct {
+	number uint64
+	index  uint // log index, unused for headers
+}
+
+func newResumeState(cursor *subscriptionCursor) *resumeState {
+	return &resumeState{cursor: cursor, seen: make(map[common.Hash]resumeMark)}
+}
+
+// current returns the cursor to resubscribe with, or nil if nothing has been
+// delivered and no starting point is known.
+func (s *resumeState) current() *subscriptionCursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor
+}
+
+// advance moves the cursor to the given block, and forgets the blocks which fell
+// out of the resume window.
+func (s *resumeState) advance(hash common.Hash, number uint64) {
+	s.cursor = &subscriptionCursor{BlockNumber: hexutil.Uint64(number), BlockHash: &hash}
+	if len(s.seen) > 2*resumeWindow {
+		for h, mark := range s.seen {
+			if mark.number+resumeWindow < number {
+				delete(s.seen, h)
+			}
+		}
+	}
+}
+
+// deliverLog reports whether the log should be delivered, i.e. it was not
+// delivered before.
+func (s *resumeState) deliverLog(l *types.Log) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l.Removed {
+		delete(s.seen, l.BlockHash)
+		return true
+	}
+	if mark, ok := s.seen[l.BlockHash]; ok && l.Index <= mark.index {
+		return false
+	}
+	s.seen[l.BlockHash] = resumeMark{number: l.BlockNumber, index: l.Index}
+	s.advance(l.BlockHash, l.BlockNumber)
+	return true
+}
+
+// deliverHeader reports whether the header should be delivered, i.e. it was
+// not delivered before.
+func (s *resumeState) deliverHeader(h *types.Header) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := h.Hash()
+	if _, ok := s.seen[hash]; ok {
+		return false
+	}
+	s.seen[hash] = resumeMark{number: h.Number.Uint64()}
+	s.advance(hash, h.Number.Uint64())
+	return true
+}
+
+// SubscribeFilterLogsResumable subscribes to the results of a streaming filter
+// query like SubscribeFilterLogs, but the subscription is re-established when the
+// connection to the node is lost. On resubscription, the node replays the logs
+// missed in the meantime, including removed logs of blocks which have been
+// reorged out. Logs that were already delivered are not sent again.
+//
+// If the query has a FromBlock, the logs from that block on are delivered first,
+// provided the node is willing to replay that far back. The client must have been
+// created with a websocket or IPC connection, and the node must support
+// subscription cursors.
+func (ec *Client) SubscribeFilterLogsResumable(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if q.BlockHash != nil {
+		return nil, errors.New("cannot resume a subscription for a single block")
+	}
+	arg, err := toFilterArg(q)
+	if err != nil {
+		return nil, err
+	}
+	// The node only replays logs from a subscription cursor, the starting block
+	// of the query becomes the initial one. Without a starting block, the cursor
+	// anchors the subscription at the next block, so logs are not lost if the
+	// connection drops before any of them is delivered.
+	filter := arg.(map[string]interface{})
+	delete(filter, "fromBlock")
+
+	var from uint64
+	if q.FromBlock != nil && q.FromBlock.Sign() >= 0 {
+		from = q.FromBlock.Uint64()
+	} else {
+		head, err := ec.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		from = head + 1
+	}
+	state := newResumeState(&subscriptionCursor{BlockNumber: hexutil.Uint64(from)})
+
+	logs := make(chan types.Log)
+	sub, err := ec.c.SubscribeResumable(ctx, "eth", logs, func() []interface{} {
+		return []interface{}{"logs", filter, state.current()}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case l := <-logs:
+				if !state.deliverLog(&l) {
+					continue
+				}
+				select {
+				case ch <- l:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// SubscribeNewHeadResumable subscribes to notifications about the current
+// blockchain head like SubscribeNewHead, but the subscription is re-established
+// when the connection to the node is lost. On resubscription, the node replays
+// the canonical headers missed in the meantime.
+//
+// The client must have been created with a websocket or IPC connection, and the
+// node must support subscription cursors.
+func (ec *Client) SubscribeNewHeadResumable(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	head, err := ec.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := newResumeState(&subscriptionCursor{BlockNumber: hexutil.Uint64(head + 1)})
+
+	initial := true
+	headers := make(chan *types.Header)
+	sub, err := ec.c.SubscribeResumable(ctx, "eth", headers, func() []interface{} {
+		if initial {
+			initial = false
+			return []interface{}{"newHeads"}
+		}
+		return []interface{}{"newHeads", state.current()}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case h := <-headers:
+				if !state.deliverHeader(h) {
+					continue
+				}
+				select {
+				case ch <- h:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
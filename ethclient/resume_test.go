@@ -0,0 +1,72 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestResumeStateLogs(t *testing.T) {
+	var (
+		state = newResumeState(nil)
+		hashA = common.Hash{0xa}
+		hashB = common.Hash{0xb}
+	)
+	steps := []struct {
+		log     types.Log
+		deliver bool
+	}{
+		{types.Log{BlockHash: hashA, BlockNumber: 1, Index: 0}, true},
+		{types.Log{BlockHash: hashA, BlockNumber: 1, Index: 1}, true},
+		// replayed after resubscribing at the cursor
+		{types.Log{BlockHash: hashA, BlockNumber: 1, Index: 0}, false},
+		{types.Log{BlockHash: hashA, BlockNumber: 1, Index: 1}, false},
+		{types.Log{BlockHash: hashA, BlockNumber: 1, Index: 2}, true},
+		// reorg
+		{types.Log{BlockHash: hashA, BlockNumber: 1, Index: 0, Removed: true}, true},
+		{types.Log{BlockHash: hashB, BlockNumber: 1, Index: 0}, true},
+	}
+	for i, step := range steps {
+		if have := state.deliverLog(&step.log); have != step.deliver {
+			t.Fatalf("step %d: deliver mismatch: have %t, want %t", i, have, step.deliver)
+		}
+	}
+	cursor := state.current()
+	if cursor == nil || uint64(cursor.BlockNumber) != 1 || cursor.BlockHash == nil || *cursor.BlockHash != hashB {
+		t.Fatalf("wrong cursor: %+v", cursor)
+	}
+}
+
+func TestResumeStateHeaders(t *testing.T) {
+	state := newResumeState(nil)
+	for i := 0; i < 3*resumeWindow; i++ {
+		h := &types.Header{Number: big.NewInt(int64(i))}
+		if !state.deliverHeader(h) {
+			t.Fatalf("header %d not delivered", i)
+		}
+		if state.deliverHeader(h) {
+			t.Fatalf("header %d delivered twice", i)
+		}
+	}
+	if len(state.seen) > 2*resumeWindow+1 {
+		t.Fatalf("resume window not pruned: %d blocks remembered", len(state.seen))
+	}
+}
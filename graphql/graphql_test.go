@@ -40,6 +40,8 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/gorilla/websocket"
+	gqlTypes "github.com/graph-gophers/graphql-go/types"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -370,6 +372,135 @@ func TestGraphQLConcurrentResolvers(t *testing.T) {
 	}
 }
 
+func TestGraphQLSubscriptions(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		genesis = &core.Genesis{
+			Config:     params.AllEthashProtocolChanges,
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1048576),
+			Alloc: core.GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		stack  = createNode(t)
+	)
+	defer stack.Close()
+	newGQLService(t, stack, false, genesis, 1, func(i int, gen *core.BlockGen) {})
+
+	// start node
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	dialer := websocket.Dialer{Subprotocols: []string{wsSubprotocol}}
+	endpoint := "ws" + strings.TrimPrefix(stack.HTTPEndpoint(), "http") + "/graphql"
+
+	// Upgrades are subject to the virtual host checks of the HTTP handler stack.
+	if _, resp, err := dialer.Dial(endpoint, http.Header{"Host": {"evil.example.org"}}); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("upgrade from unknown virtual host not rejected: %v", err)
+	}
+	conn, _, err := dialer.Dial(endpoint, nil)
+	if err != nil {
+		t.Fatalf("could not dial graphql websocket: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var msg wsMessage
+	if err := conn.WriteJSON(wsMessage{Type: "connection_init"}); err != nil {
+		t.Fatalf("could not send init: %v", err)
+	}
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "connection_ack" {
+		t.Fatalf("connection not acknowledged: %v %+v", err, msg)
+	}
+	// Subscribe to pending transactions, then inject one through a mutation.
+	subscribe := func(id string, query string) {
+		payload, _ := json.Marshal(wsRequest{Query: query})
+		if err := conn.WriteJSON(wsMessage{ID: id, Type: "subscribe", Payload: payload}); err != nil {
+			t.Fatalf("could not subscribe: %v", err)
+		}
+	}
+	subscribe("1", "subscription { pendingTransactions { hash nonce from { address } } }")
+
+	tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{To: &addr, Gas: 21000, GasPrice: big.NewInt(params.InitialBaseFee)})
+	raw, _ := tx.MarshalBinary()
+	subscribe("2", fmt.Sprintf(`mutation { sendRawTransaction(data: "%#x") }`, raw))
+
+	var (
+		wantTx       = fmt.Sprintf(`{"pendingTransactions":{"hash":"%s","nonce":"0x0","from":{"address":"%s"}}}`, tx.Hash().Hex(), strings.ToLower(addr.Hex()))
+		wantMutation = fmt.Sprintf(`{"sendRawTransaction":"%s"}`, tx.Hash().Hex())
+		seenTx       bool
+		seenMutation bool
+		completed    bool
+	)
+	for !seenTx || !seenMutation || !completed {
+		var msg struct {
+			ID      string          `json:"id"`
+			Type    string          `json:"type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("could not read message: %v", err)
+		}
+		var result struct {
+			Data json.RawMessage `json:"data"`
+		}
+		json.Unmarshal(msg.Payload, &result)
+		switch {
+		case msg.ID == "1" && msg.Type == "next":
+			if have := string(result.Data); have != wantTx {
+				t.Fatalf("unexpected subscription result\nhave: %s\nwant: %s", have, wantTx)
+			}
+			seenTx = true
+		case msg.ID == "2" && msg.Type == "next":
+			if have := string(result.Data); have != wantMutation {
+				t.Fatalf("unexpected mutation result\nhave: %s\nwant: %s", have, wantMutation)
+			}
+			seenMutation = true
+		case msg.ID == "2" && msg.Type == "complete":
+			completed = true
+		default:
+			t.Fatalf("unexpected message: %s %s %s", msg.ID, msg.Type, msg.Payload)
+		}
+	}
+	// Stopping the node closes the connection along with its subscription.
+	if err := stack.Close(); err != nil {
+		t.Fatalf("could not stop node: %v", err)
+	}
+	if err := conn.ReadJSON(&msg); err == nil {
+		t.Fatalf("connection still open after stopping the node: %+v", msg)
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	for i, tt := range []struct {
+		doc  string
+		name string
+		want gqlTypes.OperationType
+	}{
+		{doc: `{ block { number } }`, want: opQuery},
+		{doc: `query { block { number } }`, want: opQuery},
+		{doc: `mutation { sendRawTransaction(data: "0x") }`, want: opMutation},
+		{doc: `subscription { newBlock { number } }`, want: opSubscription},
+		{doc: `subscription Heads { newBlock { ...fields } } fragment fields on Block { number }`, want: opSubscription},
+		{doc: `fragment fields on Block { number } subscription Heads { newBlock { ...fields } }`, want: opSubscription},
+		{doc: "# subscription { newBlock }\n{ block(hash: \"}\") { number } }", want: opQuery},
+		{doc: `subscription Logs($f: FilterCriteria = {topics: [["0x01"]]}) @live(q: "{") { logs(filter: $f) { index } }`, want: opSubscription},
+		{doc: `query Q($s: String = """ \""" } """) { block { number } } subscription S { newBlock { number } }`, name: "S", want: opSubscription},
+		{doc: `subscription A { newBlock { number } } subscription B { pendingTransactions { hash } }`, name: "B", want: opSubscription},
+		{doc: `subscription A { newBlock { number } } subscription B { pendingTransactions { hash } }`, want: opQuery},
+		{doc: `subscription A { newBlock { number } }`, name: "B", want: opQuery},
+		{doc: `subscription { newBlock { number }`, want: opSubscription},
+		{doc: `subscription`, want: opQuery},
+	} {
+		if have := operationType(tt.doc, tt.name); have != tt.want {
+			t.Errorf("testcase #%d: operation type mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
 func TestWithdrawals(t *testing.T) {
 	var (
 		key, _ = crypto.GenerateKey()
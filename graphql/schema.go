@@ -17,6 +17,32 @@
 package graphql
 
 const schema string = `
+    schema {
+        query: Query
+        mutation: Mutation
+    }
+` + schemaTypes
+
+// subscriptionSchema is served to websocket subscribers. graphql-go resolves every
+// root operation against the same resolver, so the logs subscription can't share a
+// schema with the logs query. A query root is mandatory, the subscription schema
+// has a minimal one; queries and mutations sent over a websocket are answered by
+// the main schema instead.
+const subscriptionSchema string = `
+    schema {
+        query: SubscriptionQuery
+        subscription: Subscription
+    }
+
+    # SubscriptionQuery is the query root of the subscription schema. Queries sent
+    # to the websocket endpoint are answered by the main schema.
+    type SubscriptionQuery {
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+` + schemaTypes
+
+const schemaTypes string = `
     # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
     scalar Bytes32
     # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
@@ -33,11 +59,6 @@ const schema string = `
     # 0x-prefixed hexadecimal.
     scalar Long
 
-    schema {
-        query: Query
-        mutation: Mutation
-    }
-
     # Account is an Ethereum account at a particular block.
     type Account {
         # Address is the address owning the account.
@@ -391,4 +412,16 @@ const schema string = `
         # SendRawTransaction sends an RLP-encoded transaction to the network.
         sendRawTransaction(data: Bytes!): Bytes32!
     }
+
+    type Subscription {
+        # NewBlock delivers the header of every block which becomes the head of
+        # the canonical chain. Reorganisations may redeliver a block number.
+        newBlock: Block!
+        # Logs delivers log entries matching the provided filter as new blocks are
+        # imported. If no filter is supplied, all logs are delivered.
+        logs(filter: FilterCriteria): Log!
+        # PendingTransactions delivers transactions as they enter the pending
+        # state of the transaction pool.
+        pendingTransactions: Transaction!
+    }
 `
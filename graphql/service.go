@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gorilla/websocket"
 	"github.com/graph-gophers/graphql-go"
 	gqlErrors "github.com/graph-gophers/graphql-go/errors"
 )
@@ -111,7 +112,8 @@ func New(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterS
 	return err
 }
 
-// newHandler returns a new `http.Handler` that will answer GraphQL queries.
+// newHandler returns a new `http.Handler` that will answer GraphQL queries, and
+// subscriptions over websocket connections using the graphql-transport-ws protocol.
 // It additionally exports an interactive query browser on the / endpoint.
 func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.FilterSystem, cors, vhosts []string) (*handler, error) {
 	q := Resolver{backend, filterSystem}
@@ -120,13 +122,49 @@ func newHandler(stack *node.Node, backend ethapi.Backend, filterSystem *filters.
 	if err != nil {
 		return nil, err
 	}
+	subResolver := &subscriptionResolver{r: &q}
+	subs, err := graphql.ParseSchema(subscriptionSchema, subResolver)
+	if err != nil {
+		return nil, err
+	}
 	h := handler{Schema: s}
-	handler := node.NewHTTPHandlerStack(h, cors, vhosts, nil)
+	ws := newWSHandler(s, subs, cors)
+
+	// Websocket upgrades pass through the same host and CORS checks as plain
+	// HTTP requests before being taken over by the websocket handler.
+	handler := node.NewHTTPHandlerStack(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), cors, vhosts, nil)
 
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui/", GraphiQL{})
 	stack.RegisterHandler("GraphQL", "/graphql", handler)
 	stack.RegisterHandler("GraphQL", "/graphql/", handler)
+	stack.RegisterLifecycle(&service{ws: ws, subscriptions: subResolver})
 
 	return &h, nil
 }
+
+// service ends the websocket connections and their subscriptions when the node
+// stops. They are not affected by the shutdown of the HTTP server.
+type service struct {
+	ws            *wsHandler
+	subscriptions *subscriptionResolver
+}
+
+// Start implements node.Lifecycle.
+func (s *service) Start() error {
+	return nil
+}
+
+// Stop implements node.Lifecycle, closing the websocket connections and stopping
+// the event system feeding the subscriptions.
+func (s *service) Stop() error {
+	s.ws.stop()
+	s.subscriptions.stop()
+	return nil
+}
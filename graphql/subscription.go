@@ -0,0 +1,222 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errServiceStopped is returned to subscribers once the GraphQL service stopped.
+var errServiceStopped = errors.New("GraphQL service stopped")
+
+// subscriptionResolver is the root resolver of the subscription schema. Events
+// are sourced from an event system which is only started once the first client
+// subscribes, and stopped along with the GraphQL service.
+type subscriptionResolver struct {
+	r *Resolver
+
+	mu      sync.Mutex
+	events  *filters.EventSystem
+	stopped bool
+	wg      sync.WaitGroup // Running subscription loops
+}
+
+// ChainID implements the query root of the subscription schema.
+func (s *subscriptionResolver) ChainID(ctx context.Context) (hexutil.Big, error) {
+	return s.r.ChainID(ctx)
+}
+
+// track returns the event system to subscribe to, registering a subscription
+// loop which must call s.wg.Done once it's finished.
+func (s *subscriptionResolver) track() (*filters.EventSystem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return nil, errServiceStopped
+	}
+	if s.events == nil {
+		s.events = filters.NewEventSystem(s.r.filterSystem, false)
+	}
+	s.wg.Add(1)
+	return s.events, nil
+}
+
+// stop waits for the subscription loops to end, which they do once their
+// subscribers are gone, and terminates the event system.
+func (s *subscriptionResolver) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	if s.events != nil {
+		s.events.Stop()
+	}
+}
+
+// NewBlock delivers the blocks which become the head of the canonical chain.
+func (s *subscriptionResolver) NewBlock(ctx context.Context) (<-chan *Block, error) {
+	events, err := s.track()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		headers = make(chan *types.Header)
+		sub     = events.SubscribeNewHeads(headers)
+		results = make(chan *Block)
+	)
+	go func() {
+		defer s.wg.Done()
+		defer close(results)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case header := <-headers:
+				hash := header.Hash()
+				numberOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+				block := &Block{
+					r:            s.r,
+					numberOrHash: &numberOrHash,
+					hash:         hash,
+					header:       header,
+				}
+				if !deliver(ctx, results, block) {
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+// Logs delivers the log entries matching the filter criteria, as they are
+// included in newly imported blocks.
+func (s *subscriptionResolver) Logs(ctx context.Context, args struct{ Filter *FilterCriteria }) (<-chan *Log, error) {
+	var crit ethereum.FilterQuery
+	if args.Filter != nil {
+		if args.Filter.FromBlock != nil {
+			crit.FromBlock = big.NewInt(int64(*args.Filter.FromBlock))
+		}
+		if args.Filter.ToBlock != nil {
+			crit.ToBlock = big.NewInt(int64(*args.Filter.ToBlock))
+		}
+		if args.Filter.Addresses != nil {
+			crit.Addresses = *args.Filter.Addresses
+		}
+		if args.Filter.Topics != nil {
+			crit.Topics = *args.Filter.Topics
+		}
+	}
+	events, err := s.track()
+	if err != nil {
+		return nil, err
+	}
+	matches := make(chan []*types.Log)
+	sub, err := events.SubscribeLogs(crit, matches)
+	if err != nil {
+		s.wg.Done()
+		return nil, err
+	}
+	results := make(chan *Log)
+	go func() {
+		defer s.wg.Done()
+		defer close(results)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case logs := <-matches:
+				for _, log := range logs {
+					entry := &Log{
+						r:           s.r,
+						transaction: &Transaction{r: s.r, hash: log.TxHash},
+						log:         log,
+					}
+					if !deliver(ctx, results, entry) {
+						return
+					}
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+// PendingTransactions delivers the transactions entering the pending state of
+// the transaction pool.
+func (s *subscriptionResolver) PendingTransactions(ctx context.Context) (<-chan *Transaction, error) {
+	events, err := s.track()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		pending = make(chan []*types.Transaction)
+		sub     = events.SubscribePendingTxs(pending)
+		results = make(chan *Transaction)
+	)
+	go func() {
+		defer s.wg.Done()
+		defer close(results)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case txs := <-pending:
+				for _, tx := range txs {
+					if !deliver(ctx, results, &Transaction{r: s.r, hash: tx.Hash(), tx: tx}) {
+						return
+					}
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results, nil
+}
+
+// deliver sends a subscription result to the GraphQL executor, returning false
+// if the subscription was torn down in the meantime.
+func deliver[T any](ctx context.Context, results chan<- T, result T) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
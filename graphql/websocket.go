@@ -0,0 +1,526 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+const (
+	wsSubprotocol  = "graphql-transport-ws"
+	wsInitTimeout  = 10 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	wsReadLimit    = 1024 * 1024
+)
+
+// Close codes defined by the graphql-transport-ws protocol.
+const (
+	wsCloseBadRequest       = 4400
+	wsCloseUnauthorized     = 4401
+	wsCloseNotAcceptable    = 4406
+	wsCloseInitTimeout      = 4408
+	wsCloseSubscriberExists = 4409
+	wsCloseTooManyInits     = 4429
+)
+
+// wsMessage is the envelope of all graphql-transport-ws protocol messages.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsRequest is the payload of a subscribe message.
+type wsRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// wsHandler serves GraphQL over websocket connections, speaking the
+// graphql-transport-ws protocol of the graphql-ws library.
+type wsHandler struct {
+	schema        *graphql.Schema // Schema answering queries and mutations
+	subscriptions *graphql.Schema // Schema answering subscriptions
+	upgrader      websocket.Upgrader
+
+	mu      sync.Mutex
+	conns   map[*wsConn]struct{} // Open connections, closed when the handler stops
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func newWSHandler(schema, subscriptions *graphql.Schema, origins []string) *wsHandler {
+	return &wsHandler{
+		schema:        schema,
+		subscriptions: subscriptions,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{wsSubprotocol},
+			CheckOrigin:  wsOriginValidator(origins),
+		},
+		conns: make(map[*wsConn]struct{}),
+	}
+}
+
+// wsOriginValidator checks the origin of websocket upgrade requests against the
+// CORS origins configured for the GraphQL endpoint. Requests without an Origin
+// header don't originate from browsers and are always accepted.
+func wsOriginValidator(allowed []string) func(*http.Request) bool {
+	origins := make(map[string]struct{}, len(allowed))
+	for _, origin := range allowed {
+		origins[strings.ToLower(origin)] = struct{}{}
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if _, ok := origins["*"]; ok {
+			return true
+		}
+		if _, ok := origins[strings.ToLower(origin)]; ok {
+			return true
+		}
+		log.Warn("Rejected GraphQL websocket connection", "origin", origin)
+		return false
+	}
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL websocket upgrade failed", "err", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &wsConn{
+		handler: h,
+		conn:    conn,
+		ctx:     ctx,
+		cancel:  cancel,
+		ops:     make(map[string]*wsOperation),
+	}
+	// Hijacked connections are not closed by the HTTP server, the handler has to
+	// keep track of them to close them when the service stops.
+	h.mu.Lock()
+	if h.stopped {
+		h.mu.Unlock()
+		cancel()
+		conn.Close()
+		return
+	}
+	h.conns[c] = struct{}{}
+	h.wg.Add(1)
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.conns, c)
+		h.mu.Unlock()
+		h.wg.Done()
+	}()
+	c.serve()
+}
+
+// stop closes all connections and waits for their operations to end.
+func (h *wsHandler) stop() {
+	h.mu.Lock()
+	h.stopped = true
+	for c := range h.conns {
+		c.cancel()
+		c.conn.Close()
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+}
+
+// wsConn is a single graphql-transport-ws connection.
+type wsConn struct {
+	handler *wsHandler
+	conn    *websocket.Conn
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	wmu sync.Mutex // Serialises writes to conn
+
+	mu  sync.Mutex
+	ops map[string]*wsOperation // Running operations, by client assigned id
+	wg  sync.WaitGroup
+}
+
+// wsOperation is a query, mutation or subscription running on a connection.
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+// serve reads and handles client messages until the connection is closed.
+func (c *wsConn) serve() {
+	defer func() {
+		c.cancel()
+		c.wg.Wait()
+		c.conn.Close()
+	}()
+	if c.conn.Subprotocol() != wsSubprotocol {
+		c.close(wsCloseNotAcceptable, "Subprotocol not acceptable")
+		return
+	}
+	c.conn.SetReadLimit(wsReadLimit)
+	c.conn.SetReadDeadline(time.Now().Add(wsInitTimeout))
+
+	var acked bool
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if !acked && errors.As(err, &netErr) && netErr.Timeout() {
+				c.close(wsCloseInitTimeout, "Connection initialisation timeout")
+			}
+			return
+		}
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+		switch msg.Type {
+		case "connection_init":
+			if acked {
+				c.close(wsCloseTooManyInits, "Too many initialisation requests")
+				return
+			}
+			acked = true
+			c.conn.SetReadDeadline(time.Time{})
+			c.send(&wsMessage{Type: "connection_ack"})
+
+		case "ping":
+			c.send(&wsMessage{Type: "pong"})
+
+		case "pong":
+
+		case "subscribe":
+			if !acked {
+				c.close(wsCloseUnauthorized, "Unauthorized")
+				return
+			}
+			var req wsRequest
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &req) != nil {
+				c.close(wsCloseBadRequest, "Invalid message received")
+				return
+			}
+			if !c.start(msg.ID, &req) {
+				c.close(wsCloseSubscriberExists, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+
+		case "complete":
+			c.stop(msg.ID, nil)
+
+		default:
+			c.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+	}
+}
+
+// start launches the operation requested by the client under the given id,
+// returning false if the id is already in use.
+func (c *wsConn) start(id string, req *wsRequest) bool {
+	c.mu.Lock()
+	if _, ok := c.ops[id]; ok {
+		c.mu.Unlock()
+		return false
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	op := &wsOperation{cancel: cancel}
+	c.ops[id] = op
+	c.mu.Unlock()
+
+	// Queries and mutations are answered by the main schema, subscriptions by the
+	// subscription schema. Subscriptions are installed before returning, so that
+	// they observe all events caused by later messages.
+	if operationType(req.Query, req.OperationName) != opSubscription {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			defer c.stop(id, op)
+			c.exec(ctx, cancel, id, req)
+		}()
+		return true
+	}
+	c.subscribe(ctx, cancel, id, op, req)
+	return true
+}
+
+// subscribe installs a subscription and streams its events to the client.
+func (c *wsConn) subscribe(ctx context.Context, cancel context.CancelFunc, id string, op *wsOperation, req *wsRequest) {
+	results, err := c.handler.subscriptions.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		c.sendPayload(id, "error", []map[string]string{{"message": err.Error()}})
+		c.stop(id, op)
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer c.stop(id, op)
+		c.stream(ctx, cancel, id, results)
+	}()
+}
+
+// stop cancels the operation running under the given id. If op is non-nil, the
+// operation is only stopped if the id still belongs to it.
+func (c *wsConn) stop(id string, op *wsOperation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if running, ok := c.ops[id]; ok && (op == nil || op == running) {
+		running.cancel()
+		delete(c.ops, id)
+	}
+}
+
+// exec executes a query or mutation, sending its result to the client.
+func (c *wsConn) exec(ctx context.Context, cancel context.CancelFunc, id string, req *wsRequest) {
+	response := c.handler.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	if c.result(id, response) && ctx.Err() == nil {
+		c.send(&wsMessage{ID: id, Type: "complete"})
+	}
+}
+
+// stream forwards the results of a subscription to the client.
+func (c *wsConn) stream(ctx context.Context, cancel context.CancelFunc, id string, results <-chan interface{}) {
+	first := true
+	for result := range results {
+		response := result.(*graphql.Response)
+		if !first {
+			// Failures resolving individual events don't end the subscription.
+			c.sendPayload(id, "next", response)
+			continue
+		}
+		first = false
+		if !c.result(id, response) {
+			// The executor only stops once the context is done, keep draining.
+			cancel()
+			for range results {
+			}
+			return
+		}
+	}
+	// The client is not told about the end of operations it cancelled itself.
+	if ctx.Err() == nil {
+		c.send(&wsMessage{ID: id, Type: "complete"})
+	}
+}
+
+// result delivers an operation result to the client. A response without data
+// signals the failure of the whole operation, in which case false is returned.
+func (c *wsConn) result(id string, response *graphql.Response) bool {
+	if response.Data == nil && len(response.Errors) > 0 {
+		c.sendPayload(id, "error", response.Errors)
+		return false
+	}
+	c.sendPayload(id, "next", response)
+	return true
+}
+
+func (c *wsConn) sendPayload(id string, typ string, payload interface{}) {
+	blob, err := json.Marshal(payload)
+	if err != nil {
+		log.Warn("Failed to encode GraphQL websocket message", "type", typ, "err", err)
+		return
+	}
+	c.send(&wsMessage{ID: id, Type: typ, Payload: blob})
+}
+
+func (c *wsConn) send(msg *wsMessage) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		log.Debug("Failed to send GraphQL websocket message", "type", msg.Type, "err", err)
+	}
+}
+
+// close terminates the connection with a graphql-transport-ws close code.
+func (c *wsConn) close(code int, reason string) {
+	deadline := time.Now().Add(wsWriteTimeout)
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), deadline)
+}
+
+// Operation types of GraphQL documents.
+const (
+	opQuery        types.OperationType = "QUERY"
+	opMutation     types.OperationType = "MUTATION"
+	opSubscription types.OperationType = "SUBSCRIPTION"
+)
+
+// operationTypes maps the keywords introducing operations to their types.
+var operationTypes = map[string]types.OperationType{
+	"query":        opQuery,
+	"mutation":     opMutation,
+	"subscription": opSubscription,
+}
+
+// operationType returns the type of the operation selected by a request. The
+// top level definitions of the document are scanned for operations, skipping
+// over their variables, directives and selection sets. Documents which can't be
+// understood are treated as queries, leaving it to the executor to reject them.
+func operationType(document, operationName string) types.OperationType {
+	type operation struct {
+		typ  types.OperationType
+		name string
+	}
+	var (
+		lex = &docLexer{src: document}
+		ops []operation
+	)
+	for tok := lex.next(); tok != ""; tok = lex.next() {
+		op := operation{typ: opQuery}
+		if tok != "{" { // Query shorthand, consisting only of a selection set
+			typ, ok := operationTypes[tok]
+			if ok {
+				op.typ = typ
+				if tok = lex.next(); isName(tok) {
+					op.name, tok = tok, lex.next()
+				}
+			}
+			if !lex.skipUntilSelection(tok) {
+				return opQuery
+			}
+			if !ok {
+				// Fragment definitions, or anything the executor will reject
+				lex.skipGroup()
+				continue
+			}
+		}
+		lex.skipGroup()
+		ops = append(ops, op)
+	}
+	for _, op := range ops {
+		if (operationName == "" && len(ops) == 1) || (operationName != "" && op.name == operationName) {
+			return op.typ
+		}
+	}
+	return opQuery
+}
+
+// docLexer splits a GraphQL document into the tokens needed to find the top
+// level definitions. Names and punctuators are returned as they are, string and
+// number values as a placeholder.
+type docLexer struct {
+	src string
+	pos int
+}
+
+// next returns the next token of the document, or the empty string at its end.
+func (l *docLexer) next() string {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.pos += 3
+			for l.pos < len(l.src) && !strings.HasPrefix(l.src[l.pos:], `"""`) {
+				if strings.HasPrefix(l.src[l.pos:], `\"""`) {
+					l.pos += 3
+				}
+				l.pos++
+			}
+			l.pos += 3
+			return `"`
+
+		case c == '"':
+			for l.pos++; l.pos < len(l.src) && l.src[l.pos] != '"' && l.src[l.pos] != '\n'; l.pos++ {
+				if l.src[l.pos] == '\\' {
+					l.pos++
+				}
+			}
+			l.pos++
+			return `"`
+
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-':
+			start := l.pos
+			for l.pos < len(l.src) {
+				c := l.src[l.pos]
+				if c != '_' && c != '-' && c != '.' && c != '+' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+					break
+				}
+				l.pos++
+			}
+			return l.src[start:l.pos]
+
+		default:
+			l.pos++
+			return string(c)
+		}
+	}
+	l.pos = len(l.src)
+	return ""
+}
+
+// skipUntilSelection skips the tokens preceding the selection set of the current
+// definition, starting with tok. It returns false if there is no selection set.
+func (l *docLexer) skipUntilSelection(tok string) bool {
+	for ; tok != "{"; tok = l.next() {
+		switch tok {
+		case "":
+			return false
+		case "(", "[":
+			l.skipGroup()
+		}
+	}
+	return true
+}
+
+// skipGroup skips the tokens up to the bracket closing the one just read.
+func (l *docLexer) skipGroup() {
+	for depth := 1; depth > 0; {
+		switch l.next() {
+		case "":
+			return
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			depth--
+		}
+	}
+}
+
+// isName reports whether a token is a GraphQL name.
+func isName(tok string) bool {
+	return tok != "" && (tok[0] == '_' || tok[0] >= 'a' && tok[0] <= 'z' || tok[0] >= 'A' && tok[0] <= 'Z')
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// zeroHashes caches the roots of all-zero subtrees for each depth of the
+// accumulator merkle tree.
+var zeroHashes = func() [][32]byte {
+	hashes := make([][32]byte, accumulatorDepth+1)
+	for i := 1; i <= accumulatorDepth; i++ {
+		hashes[i] = sha256.Sum256(append(hashes[i-1][:], hashes[i-1][:]...))
+	}
+	return hashes
+}()
+
+// accumulatorDepth is the depth of the merkle tree backing an accumulator
+// with room for MaxEra1Size header records.
+const accumulatorDepth = 13
+
+// ComputeAccumulator calculates the SSZ hash tree root of the Era1
+// accumulator of header records.
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, errors.New("must have equal number hashes as td values")
+	}
+	if len(hashes) > MaxEra1Size {
+		return common.Hash{}, fmt.Errorf("too many records: have %d, max %d", len(hashes), MaxEra1Size)
+	}
+	layer := make([][32]byte, len(hashes))
+	for i := range hashes {
+		rec, err := headerRecordRoot(hashes[i], tds[i])
+		if err != nil {
+			return common.Hash{}, err
+		}
+		layer[i] = rec
+	}
+	// Merkleize the records, padding the list up to its limit with zero leaves.
+	for depth := 0; depth < accumulatorDepth; depth++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[depth])
+		}
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+	root := zeroHashes[accumulatorDepth]
+	if len(layer) > 0 {
+		root = layer[0]
+	}
+	// Mix in the length of the list.
+	var length [32]byte
+	big.NewInt(int64(len(hashes))).FillBytes(length[:])
+	reverseOrder(length[:])
+	return sha256.Sum256(append(root[:], length[:]...)), nil
+}
+
+// headerRecordRoot computes the hash tree root of a single header record,
+// i.e. the container { block-hash: Bytes32, total-difficulty: Uint256 }.
+func headerRecordRoot(hash common.Hash, td *big.Int) ([32]byte, error) {
+	if td.Sign() < 0 || td.BitLen() > 256 {
+		return [32]byte{}, fmt.Errorf("total difficulty out of range: %v", td)
+	}
+	var buf [64]byte
+	copy(buf[:32], hash[:])
+	td.FillBytes(buf[32:])
+	reverseOrder(buf[32:])
+	return sha256.Sum256(buf[:]), nil
+}
+
+// reverseOrder reverses b in place, converting between big and little endian.
+func reverseOrder(b []byte) []byte {
+	for i := 0; i < len(b)/2; i++ {
+		b[i], b[len(b)-i-1] = b[len(b)-i-1], b[i]
+	}
+	return b
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testchain struct {
+	headers  [][]byte
+	bodies   [][]byte
+	receipts [][]byte
+	tds      []*big.Int
+}
+
+func TestEra1Builder(t *testing.T) {
+	// Get temp directory.
+	f, err := os.CreateTemp("", "era1-test")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer f.Close()
+
+	var (
+		builder = NewBuilder(f)
+		chain   = testchain{}
+	)
+	for i := 0; i < 128; i++ {
+		chain.headers = append(chain.headers, []byte{byte('h'), byte(i)})
+		chain.bodies = append(chain.bodies, []byte{byte('b'), byte(i)})
+		chain.receipts = append(chain.receipts, []byte{byte('r'), byte(i)})
+		chain.tds = append(chain.tds, big.NewInt(int64(i)))
+	}
+	// Write blocks to Era1.
+	for i := 0; i < len(chain.headers); i++ {
+		var (
+			header   = chain.headers[i]
+			body     = chain.bodies[i]
+			receipts = chain.receipts[i]
+			hash     = common.Hash{byte(i)}
+			td       = chain.tds[i]
+		)
+		if err = builder.AddRLP(header, body, receipts, uint64(i), hash, td, big.NewInt(1)); err != nil {
+			t.Fatalf("error adding entry: %v", err)
+		}
+	}
+	// Finalize Era1.
+	if _, err := builder.Finalize(); err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	// Verify Era1 contents.
+	e, err := Open(f.Name())
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	defer e.Close()
+	it, err := NewRawIterator(e)
+	if err != nil {
+		t.Fatalf("failed to make iterator: %s", err)
+	}
+	for i := uint64(0); i < uint64(len(chain.headers)); i++ {
+		if !it.Next() {
+			t.Fatalf("expected more entries")
+		}
+		if it.Error() != nil {
+			t.Fatalf("unexpected error %v", it.Error())
+		}
+		// Check headers.
+		header, err := io.ReadAll(it.Header)
+		if err != nil {
+			t.Fatalf("error reading header: %v", err)
+		}
+		if !bytes.Equal(header, chain.headers[i]) {
+			t.Fatalf("mismatched header: want %s, got %s", chain.headers[i], header)
+		}
+		// Check bodies.
+		body, err := io.ReadAll(it.Body)
+		if err != nil {
+			t.Fatalf("error reading body: %v", err)
+		}
+		if !bytes.Equal(body, chain.bodies[i]) {
+			t.Fatalf("mismatched body: want %s, got %s", chain.bodies[i], body)
+		}
+		// Check receipts.
+		receipts, err := io.ReadAll(it.Receipts)
+		if err != nil {
+			t.Fatalf("error reading receipts: %v", err)
+		}
+		if !bytes.Equal(receipts, chain.receipts[i]) {
+			t.Fatalf("mismatched receipts: want %s, got %s", chain.receipts[i], receipts)
+		}
+		// Check total difficulty.
+		rawTd, err := io.ReadAll(it.TotalDifficulty)
+		if err != nil {
+			t.Fatalf("error reading td: %v", err)
+		}
+		td := new(big.Int).SetBytes(reverseOrder(rawTd))
+		if td.Cmp(chain.tds[i]) != 0 {
+			t.Fatalf("mismatched tds: want %s, got %s", chain.tds[i], td)
+		}
+	}
+	if it.Next() {
+		t.Fatalf("unexpected extra entry")
+	}
+	if e.Start() != 0 || e.Count() != uint64(len(chain.headers)) {
+		t.Fatalf("wrong metadata: start %d, count %d", e.Start(), e.Count())
+	}
+}
+
+func TestEra1BlocksAndAccumulator(t *testing.T) {
+	var (
+		buf     = bytes.NewBuffer(nil)
+		builder = NewBuilder(buf)
+		blocks  []*types.Block
+		hashes  []common.Hash
+		tds     []*big.Int
+		td      = big.NewInt(100)
+	)
+	for i := uint64(10); i < 20; i++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(i), Difficulty: big.NewInt(int64(i)), Extra: []byte("test")}
+		block := types.NewBlockWithHeader(header)
+		td = new(big.Int).Add(td, header.Difficulty)
+
+		receipts := types.Receipts{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: i, Logs: []*types.Log{}}}
+		if err := builder.Add(block, receipts, td); err != nil {
+			t.Fatalf("error adding block %d: %v", i, err)
+		}
+		blocks = append(blocks, block)
+		hashes = append(hashes, block.Hash())
+		tds = append(tds, td)
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("error finalizing era1: %v", err)
+	}
+	if want, err := ComputeAccumulator(hashes, tds); err != nil || root != want {
+		t.Fatalf("accumulator mismatch: have %x, want %x (err %v)", root, want, err)
+	}
+	e, err := From(nopCloser{bytes.NewReader(buf.Bytes())})
+	if err != nil {
+		t.Fatalf("failed to open era: %v", err)
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("stored accumulator mismatch: have %x, want %x (err %v)", have, root, err)
+	}
+	if have, err := e.InitialTD(); err != nil || have.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("initial td mismatch: have %v, want 100 (err %v)", have, err)
+	}
+	for _, want := range blocks {
+		have, err := e.GetBlockByNumber(want.NumberU64())
+		if err != nil {
+			t.Fatalf("error reading block %d: %v", want.NumberU64(), err)
+		}
+		if have.Hash() != want.Hash() {
+			t.Fatalf("block %d hash mismatch", want.NumberU64())
+		}
+	}
+	if _, err := e.GetBlockByNumber(20); err == nil {
+		t.Fatal("expected out-of-bounds error")
+	}
+	it, err := NewIterator(e)
+	if err != nil {
+		t.Fatalf("failed to make iterator: %v", err)
+	}
+	for i := 0; it.Next(); i++ {
+		block, receipts, err := it.BlockAndReceipts()
+		if err != nil {
+			t.Fatalf("error reading entry %d: %v", i, err)
+		}
+		if block.Hash() != hashes[i] {
+			t.Fatalf("block %d hash mismatch", i)
+		}
+		if len(receipts) != 1 || receipts[0].CumulativeGasUsed != block.NumberU64() {
+			t.Fatalf("block %d receipts mismatch", i)
+		}
+		td, err := it.TotalDifficulty()
+		if err != nil || td.Cmp(tds[i]) != 0 {
+			t.Fatalf("block %d td mismatch: have %v, want %v", i, td, tds[i])
+		}
+	}
+	if it.Error() != nil {
+		t.Fatalf("iterator error: %v", it.Error())
+	}
+}
+
+func TestComputeAccumulator(t *testing.T) {
+	record := func(i int) common.Hash { return common.Hash{byte(i)} }
+	for i, tt := range []struct {
+		hashes []common.Hash
+		tds    []*big.Int
+		want   common.Hash
+	}{
+		{ // empty list, i.e. hash_tree_root of an empty list limited to 8192 records
+			want: common.HexToHash("0x4a8c3a07c8d23adc5bac61157555c3c784d53d9bc110c1370809bd23cd93777d"),
+		},
+		{
+			hashes: []common.Hash{record(1)},
+			tds:    []*big.Int{big.NewInt(1)},
+			want:   common.HexToHash("0x387ac3ea741d196f5cf2fdbce980c49d4edf42e543b62289b3c0c77d06cf5986"),
+		},
+		{
+			hashes: []common.Hash{record(0), record(1), record(2)},
+			tds:    []*big.Int{big.NewInt(0), big.NewInt(7), big.NewInt(14)},
+			want:   common.HexToHash("0xfd12e24765c284eeb874e45c0d8d659a47c0c5f3ab496b497c9fd5282e077a56"),
+		},
+	} {
+		have, err := ComputeAccumulator(tt.hashes, tt.tds)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if have != tt.want {
+			t.Fatalf("test %d: accumulator mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+	if _, err := ComputeAccumulator([]common.Hash{{1}}, nil); err == nil {
+		t.Fatal("expected error for mismatched input lengths")
+	}
+}
+
+type nopCloser struct{ *bytes.Reader }
+
+func (nopCloser) Close() error { return nil }
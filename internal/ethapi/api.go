@@ -37,6 +37,8 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -210,6 +212,13 @@ func (s *TxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCT
 	return content
 }
 
+// TxPoolTxStatus is the status of a single transaction as seen by the pool, along
+// with the last recorded lifecycle event explaining how it got there.
+type TxPoolTxStatus struct {
+	Status string          `json:"status"`
+	Event  *txpool.TxEvent `json:"event,omitempty"`
+}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (s *TxPoolAPI) Status() map[string]hexutil.Uint {
 	pending, queue := s.b.Stats()
@@ -219,6 +228,71 @@ func (s *TxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// TxStatus returns the status of a single transaction: pending, queued or evicted
+// while pooled, otherwise the way it left the pool (included, dropped, replaced or
+// rejected) if still remembered, or unknown.
+func (s *TxPoolAPI) TxStatus(hash common.Hash) *TxPoolTxStatus {
+	var res TxPoolTxStatus
+	if events := s.b.TxPoolHistory(hash); len(events) > 0 {
+		res.Event = &events[len(events)-1]
+	}
+	switch s.b.TxPoolStatus(hash) {
+	case txpool.TxStatusPending:
+		res.Status = "pending"
+	case txpool.TxStatusQueued:
+		res.Status = "queued"
+	case txpool.TxStatusIncluded:
+		res.Status = "included"
+	case txpool.TxStatusEvicted:
+		res.Status = "evicted"
+	default:
+		res.Status = "unknown"
+		if res.Event != nil {
+			res.Status = string(res.Event.Kind)
+		}
+	}
+	return &res
+}
+
+// History returns the recorded lifecycle events (added, promoted, demoted,
+// replaced, included, dropped, rejected) of a transaction, oldest first. The
+// pool only remembers a bounded number of recently seen transactions.
+func (s *TxPoolAPI) History(hash common.Hash) []txpool.TxEvent {
+	events := s.b.TxPoolHistory(hash)
+	if events == nil {
+		events = []txpool.TxEvent{}
+	}
+	return events
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction is dropped from or replaced in the transaction pool.
+func (s *TxPoolAPI) DroppedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan txpool.TxEvent, 128)
+		dropSub := s.b.SubscribeTxPoolDropEvent(events)
+		defer dropSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, ev)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -960,7 +1034,7 @@ func (diff *StateOverride) Apply(state *state.StateDB) error {
 		}
 		// Override account balance.
 		if account.Balance != nil {
-			state.SetBalance(addr, (*big.Int)(*account.Balance))
+			state.SetBalance(addr, (*big.Int)(*account.Balance), tracing.BalanceChangeUnspecified)
 		}
 		if account.State != nil && account.StateDiff != nil {
 			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
@@ -1026,6 +1100,38 @@ func (diff *BlockOverrides) Apply(blockCtx *vm.BlockContext) {
 	}
 }
 
+// MakeHeader returns a new header object with the overridden fields.
+// Note: MakeHeader ignores BlobBaseFee if set. That's because the header
+// has no such field.
+func (diff *BlockOverrides) MakeHeader(header *types.Header) *types.Header {
+	if diff == nil {
+		return header
+	}
+	h := types.CopyHeader(header)
+	if diff.Number != nil {
+		h.Number = diff.Number.ToInt()
+	}
+	if diff.Difficulty != nil {
+		h.Difficulty = diff.Difficulty.ToInt()
+	}
+	if diff.Time != nil {
+		h.Time = uint64(*diff.Time)
+	}
+	if diff.GasLimit != nil {
+		h.GasLimit = uint64(*diff.GasLimit)
+	}
+	if diff.Coinbase != nil {
+		h.Coinbase = *diff.Coinbase
+	}
+	if diff.Random != nil {
+		h.MixDigest = *diff.Random
+	}
+	if diff.BaseFee != nil {
+		h.BaseFee = diff.BaseFee.ToInt()
+	}
+	return h
+}
+
 // ChainContextBackend provides methods required to implement ChainContext.
 type ChainContextBackend interface {
 	Engine() consensus.Engine
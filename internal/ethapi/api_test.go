@@ -40,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -579,6 +580,13 @@ func (b testBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transactio
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) TxPoolStatus(hash common.Hash) txpool.TxStatus { panic("implement me") }
+func (b testBackend) TxPoolHistory(hash common.Hash) []txpool.TxEvent {
+	panic("implement me")
+}
+func (b testBackend) SubscribeTxPoolDropEvent(events chan<- txpool.TxEvent) event.Subscription {
+	panic("implement me")
+}
 func (b testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
 func (b testBackend) Engine() consensus.Engine         { return b.chain.Engine() }
 func (b testBackend) GetLogs(ctx context.Context, blockHash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -870,6 +878,135 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+	// Initialize test accounts
+	var (
+		accounts = newAccounts(3)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		genBlocks = 10
+		signer    = types.HomesteadSigner{}
+
+		counter  = common.Address{0xc0}
+		reverter = common.Address{0xc1}
+		logger   = common.Address{0xc2}
+	)
+	api := NewBlockChainAPI(newTestBackend(t, genBlocks, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: uint64(i), To: &accounts[1].addr, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee(), Data: nil}), signer, accounts[0].key)
+		b.AddTx(tx)
+	}))
+	var (
+		latest = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		value  = (*hexutil.Big)(big.NewInt(1000))
+		code   = StateOverride{
+			// Increments slot 0 and returns the new value.
+			counter: OverrideAccount{Code: hex2Bytes("6000546001018060005560005260206000f3")},
+			// Reverts without data.
+			reverter: OverrideAccount{Code: hex2Bytes("60006000fd")},
+			// Emits an empty LOG0.
+			logger: OverrideAccount{Code: hex2Bytes("60006000a000")},
+		}
+	)
+	results, err := api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{
+				StateOverrides: &code,
+				Calls: []TransactionArgs{
+					{From: &accounts[2].addr, To: &counter},
+					{From: &accounts[2].addr, To: &counter},
+					// accounts[2] can only pay for the last call after the transfer preceding it.
+					{From: &accounts[0].addr, To: &accounts[2].addr, Value: (*hexutil.Big)(big.NewInt(20000))},
+					{From: &accounts[2].addr, To: &accounts[0].addr, Value: value},
+				},
+			}, {
+				// Skip a few blocks, the gap must be filled with empty blocks.
+				BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(int64(genBlocks + 4)))},
+				Calls: []TransactionArgs{
+					{From: &accounts[2].addr, To: &counter},
+					{From: &accounts[2].addr, To: &reverter},
+					{From: &accounts[2].addr, To: &logger},
+				},
+			},
+		},
+	}, &latest)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("block count mismatch: have %d, want %d", len(results), 4)
+	}
+	var parent common.Hash
+	for i, res := range results {
+		number := res["number"].(*hexutil.Big).ToInt().Uint64()
+		if want := uint64(genBlocks + i + 1); number != want {
+			t.Errorf("block %d: number mismatch: have %d, want %d", i, number, want)
+		}
+		if i > 0 && res["parentHash"].(common.Hash) != parent {
+			t.Errorf("block %d: parent hash mismatch", i)
+		}
+		parent = res["hash"].(common.Hash)
+	}
+	first := results[0]["calls"].([]simCallResult)
+	for i, want := range []uint64{1, 2} {
+		if have := new(big.Int).SetBytes(first[i].ReturnValue).Uint64(); have != want {
+			t.Errorf("call %d: counter mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	for i, call := range first {
+		if call.Status != hexutil.Uint64(types.ReceiptStatusSuccessful) {
+			t.Errorf("call %d: unexpected failure: %v", i, call.Error)
+		}
+	}
+	if have := len(results[1]["calls"].([]simCallResult)); have != 0 {
+		t.Errorf("filler block has %d calls", have)
+	}
+	last := results[3]["calls"].([]simCallResult)
+	if have := new(big.Int).SetBytes(last[0].ReturnValue).Uint64(); have != 3 {
+		t.Errorf("counter mismatch across blocks: have %d, want %d", have, 3)
+	}
+	if last[1].Status != hexutil.Uint64(types.ReceiptStatusFailed) || last[1].Error == nil || last[1].Error.Code != 3 {
+		t.Errorf("expected revert error, have %+v", last[1].Error)
+	}
+	if len(last[2].Logs) != 1 {
+		t.Fatalf("log count mismatch: have %d, want %d", len(last[2].Logs), 1)
+	}
+	if have, want := last[2].Logs[0].BlockHash, results[3]["hash"].(common.Hash); have != want {
+		t.Errorf("log block hash mismatch: have %x, want %x", have, want)
+	}
+	var gasUsed uint64
+	for _, call := range last {
+		gasUsed += uint64(call.GasUsed)
+	}
+	if have := uint64(results[3]["gasUsed"].(hexutil.Uint64)); have != gasUsed {
+		t.Errorf("block gas used mismatch: have %d, want %d", have, gasUsed)
+	}
+
+	// Block numbers must be increasing
+	_, err = api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(int64(genBlocks + 2)))}},
+			{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(int64(genBlocks + 1)))}},
+		},
+	}, &latest)
+	if err == nil {
+		t.Fatal("expected error for out of order blocks")
+	}
+	// Insufficient funds abort the simulation
+	_, err = api.SimulateV1(context.Background(), simOpts{
+		BlockStateCalls: []simBlock{
+			{Calls: []TransactionArgs{{From: &accounts[2].addr, To: &accounts[0].addr, Value: value}}},
+		},
+	}, &latest)
+	if err == nil {
+		t.Fatal("expected error for insufficient funds")
+	}
+}
+
 type Account struct {
 	key  *ecdsa.PrivateKey
 	addr common.Address
@@ -0,0 +1,478 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks that can be simulated
+	// in a single request, including the empty blocks filling number gaps.
+	maxSimulateBlocks = 256
+
+	// timestampIncrement is the default increment between block timestamps.
+	timestampIncrement = 12
+)
+
+// Error codes returned by eth_simulateV1 in addition to the standard ones.
+const (
+	errCodeInvalidParams      = -32602
+	errCodeVMError            = -32015
+	errCodeBlockNumberInvalid = -38020
+	errCodeBlockTimestamp     = -38021
+	errCodeBlockGasLimit      = -38015
+	errCodeClientLimit        = -38026
+)
+
+// simBlock is a batch of calls to be simulated sequentially on top of the
+// state produced by the previous simulated block.
+type simBlock struct {
+	BlockOverrides *BlockOverrides
+	StateOverrides *StateOverride
+	Calls          []TransactionArgs
+}
+
+// simOpts are the inputs to eth_simulateV1.
+type simOpts struct {
+	BlockStateCalls        []simBlock
+	Validation             bool
+	ReturnFullTransactions bool
+}
+
+// simCallResult is the result of a simulated call.
+type simCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnData"`
+	Logs        []*types.Log   `json:"logs"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Status      hexutil.Uint64 `json:"status"`
+	Error       *callError     `json:"error,omitempty"`
+}
+
+// callError is the error of a single simulated call. Unlike request level
+// errors it does not abort the simulation.
+type callError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+	Data    string `json:"data,omitempty"`
+}
+
+// simError is a request level error aborting the whole simulation.
+type simError struct {
+	message string
+	code    int
+}
+
+func (e *simError) Error() string  { return e.message }
+func (e *simError) ErrorCode() int { return e.code }
+
+// simulator is a stateful object that simulates a series of blocks.
+// It is not safe for concurrent use.
+type simulator struct {
+	b           Backend
+	state       *state.StateDB
+	base        *types.Header
+	chainConfig *params.ChainConfig
+	gp          *core.GasPool
+	validate    bool
+	fullTx      bool
+}
+
+// SimulateV1 executes a series of blocks, each containing an ordered list of
+// calls, on top of the given base block. Each block may override block header
+// fields and account state, and observes all state changes made by the blocks
+// and calls preceding it.
+//
+// Note, this function doesn't make any changes in the state/blockchain and is
+// useful to execute and retrieve values of dependent calls.
+func (s *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &simError{message: "empty input", code: errCodeInvalidParams}
+	} else if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &simError{message: "too many blocks", code: errCodeClientLimit}
+	}
+	if blockNrOrHash == nil {
+		latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &latest
+	}
+	state, base, err := s.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	gasCap := s.b.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = math.MaxUint64
+	}
+	sim := &simulator{
+		b:           s.b,
+		state:       state,
+		base:        base,
+		chainConfig: s.b.ChainConfig(),
+		gp:          new(core.GasPool).AddGas(gasCap),
+		validate:    opts.Validation,
+		fullTx:      opts.ReturnFullTransactions,
+	}
+	return sim.execute(ctx, opts.BlockStateCalls, s.b.RPCEVMTimeout())
+}
+
+// execute runs the simulation of a series of blocks.
+func (sim *simulator) execute(ctx context.Context, blocks []simBlock, timeout time.Duration) ([]map[string]interface{}, error) {
+	// Setup context so it may be cancelled before the calls completed
+	// or, in case of unmetered gas, setup a context with a timeout.
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	// Make sure the context is cancelled when the call has completed
+	// this makes sure resources are cleaned up.
+	defer cancel()
+
+	blocks, err := sim.sanitizeChain(blocks)
+	if err != nil {
+		return nil, err
+	}
+	headers, err := sim.makeHeaders(blocks)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		results = make([]map[string]interface{}, len(blocks))
+		parent  = sim.base
+	)
+	for i, block := range blocks {
+		result, header, err := sim.processBlock(ctx, &block, headers[i], parent, headers[:i], timeout)
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = header
+		results[i] = result
+		parent = header
+	}
+	return results, nil
+}
+
+// processBlock executes the calls of a single simulated block, assembles the
+// resulting block and returns its RPC representation.
+func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header, parent *types.Header, headers []*types.Header, timeout time.Duration) (map[string]interface{}, *types.Header, error) {
+	// Set header fields that depend only on parent block.
+	header.ParentHash = parent.Hash()
+	if sim.chainConfig.IsLondon(header.Number) {
+		// In non-validation mode the base fee is set to 0 if it is not
+		// overridden, avoiding the edge case of gasPrice < baseFee.
+		if header.BaseFee == nil {
+			if sim.validate {
+				header.BaseFee = eip1559.CalcBaseFee(sim.chainConfig, parent)
+			} else {
+				header.BaseFee = big.NewInt(0)
+			}
+		}
+	}
+	if sim.chainConfig.IsCancun(header.Number, header.Time) {
+		var excess uint64
+		if sim.chainConfig.IsCancun(parent.Number, parent.Time) && parent.ExcessBlobGas != nil && parent.BlobGasUsed != nil {
+			excess = eip4844.CalcExcessBlobGas(*parent.ExcessBlobGas, *parent.BlobGasUsed)
+		}
+		header.ExcessBlobGas = &excess
+	}
+	blockContext := core.NewEVMBlockContext(header, sim.newSimChainContext(ctx, headers), nil)
+	if block.BlockOverrides != nil && block.BlockOverrides.BlobBaseFee != nil {
+		blockContext.BlobBaseFee = block.BlockOverrides.BlobBaseFee.ToInt()
+	}
+	// State overrides are applied prior to the execution of the block.
+	if err := block.StateOverrides.Apply(sim.state); err != nil {
+		return nil, nil, err
+	}
+	var (
+		gasUsed, blobGasUsed uint64
+		txes                 = make([]*types.Transaction, len(block.Calls))
+		callResults          = make([]simCallResult, len(block.Calls))
+		receipts             = make([]*types.Receipt, len(block.Calls))
+		senders              = make([]common.Address, len(block.Calls))
+		vmConfig             = &vm.Config{NoBaseFee: !sim.validate}
+		evm                  = vm.NewEVM(blockContext, vm.TxContext{GasPrice: new(big.Int)}, sim.state, sim.chainConfig, *vmConfig)
+	)
+	if header.ParentBeaconRoot != nil {
+		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, evm, sim.state)
+	}
+	// Cancel the evm when the context is done. Even if the EVM has
+	// finished, cancelling may be done (repeatedly).
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	for i, call := range block.Calls {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if err := sim.sanitizeCall(&call, header, &gasUsed); err != nil {
+			return nil, nil, err
+		}
+		tx := call.toTransaction()
+		txes[i], senders[i] = tx, call.from()
+
+		msg, err := call.ToMessage(sim.gp.Gas(), header.BaseFee)
+		if err != nil {
+			return nil, nil, err
+		}
+		msg.Nonce = uint64(*call.Nonce)
+		msg.SkipAccountChecks = !sim.validate
+
+		sim.state.SetTxContext(tx.Hash(), i)
+		evm.Reset(core.NewEVMTxContext(msg), sim.state)
+		result, err := core.ApplyMessage(evm, msg, sim.gp)
+		if evm.Cancelled() {
+			return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		if err != nil {
+			return nil, nil, &simError{message: fmt.Sprintf("err: %v (supplied gas %d)", err, msg.GasLimit), code: errCodeInvalidParams}
+		}
+		// Update the state with pending changes.
+		var root []byte
+		if sim.chainConfig.IsByzantium(header.Number) {
+			sim.state.Finalise(true)
+		} else {
+			root = sim.state.IntermediateRoot(sim.chainConfig.IsEIP158(header.Number)).Bytes()
+		}
+		gasUsed += result.UsedGas
+		receipts[i] = core.MakeReceipt(evm, result, sim.state, header.Number, common.Hash{}, tx, gasUsed, root)
+		blobGasUsed += receipts[i].BlobGasUsed
+
+		callRes := simCallResult{ReturnValue: result.Return(), Logs: receipts[i].Logs, GasUsed: hexutil.Uint64(result.UsedGas)}
+		if result.Failed() {
+			callRes.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+			if errors.Is(result.Err, vm.ErrExecutionReverted) {
+				revertErr := newRevertError(result)
+				callRes.Error = &callError{Message: revertErr.Error(), Code: revertErr.ErrorCode(), Data: revertErr.reason}
+			} else {
+				callRes.Error = &callError{Message: result.Err.Error(), Code: errCodeVMError}
+			}
+		} else {
+			callRes.Status = hexutil.Uint64(types.ReceiptStatusSuccessful)
+		}
+		callResults[i] = callRes
+	}
+	// Assemble the block. Consensus engine rewards are intentionally not
+	// applied, the simulated blocks are not meant to be sealed.
+	header.Root = sim.state.IntermediateRoot(sim.chainConfig.IsEIP158(header.Number))
+	header.GasUsed = gasUsed
+	if sim.chainConfig.IsCancun(header.Number, header.Time) {
+		header.BlobGasUsed = &blobGasUsed
+	}
+	var withdrawals types.Withdrawals
+	if sim.chainConfig.IsShanghai(header.Number, header.Time) {
+		withdrawals = make(types.Withdrawals, 0)
+	}
+	b := types.NewBlockWithWithdrawals(header, txes, nil, receipts, withdrawals, trie.NewStackTrie(nil))
+	repairLogs(callResults, b.Hash())
+
+	fields := RPCMarshalBlock(b, true, sim.fullTx, sim.chainConfig)
+	if sim.fullTx {
+		// The simulated transactions are unsigned, patch up the senders.
+		for i, tx := range fields["transactions"].([]interface{}) {
+			tx.(*RPCTransaction).From = senders[i]
+		}
+	}
+	fields["calls"] = callResults
+	return fields, b.Header(), nil
+}
+
+// repairLogs updates the block hash in the logs present in the result of
+// a simulated block. This is needed as the logs are collected before the
+// block hash is known.
+func repairLogs(calls []simCallResult, hash common.Hash) {
+	for i := range calls {
+		for j := range calls[i].Logs {
+			calls[i].Logs[j].BlockHash = hash
+		}
+	}
+}
+
+// sanitizeCall fills in the default values of a call that are required to
+// turn it into a transaction, and checks it fits within the block gas limit.
+func (sim *simulator) sanitizeCall(call *TransactionArgs, header *types.Header, gasUsed *uint64) error {
+	if call.Nonce == nil {
+		nonce := sim.state.GetNonce(call.from())
+		call.Nonce = (*hexutil.Uint64)(&nonce)
+	}
+	// Let the call run wild unless explicitly specified.
+	if call.Gas == nil {
+		remaining := header.GasLimit - *gasUsed
+		call.Gas = (*hexutil.Uint64)(&remaining)
+	}
+	if *gasUsed+uint64(*call.Gas) > header.GasLimit {
+		return &simError{message: fmt.Sprintf("block gas limit reached: %d >= %d", *gasUsed, header.GasLimit), code: errCodeBlockGasLimit}
+	}
+	if call.Data != nil && call.Input != nil && !bytes.Equal(*call.Data, *call.Input) {
+		return &simError{message: `both "data" and "input" are set and not equal. Please use "input" to pass transaction call data`, code: errCodeInvalidParams}
+	}
+	if call.Value == nil {
+		call.Value = new(hexutil.Big)
+	}
+	if call.ChainID == nil {
+		call.ChainID = (*hexutil.Big)(sim.chainConfig.ChainID)
+	}
+	return nil
+}
+
+// sanitizeChain checks the chain integrity. Specifically it checks that
+// block numbers and timestamps are strictly increasing, setting default
+// values when necessary. Gaps in block numbers are filled with empty blocks.
+// Note: It modifies the block's override object.
+func (sim *simulator) sanitizeChain(blocks []simBlock) ([]simBlock, error) {
+	var (
+		res           = make([]simBlock, 0, len(blocks))
+		base          = sim.base
+		prevNumber    = base.Number
+		prevTimestamp = base.Time
+	)
+	for _, block := range blocks {
+		if block.BlockOverrides == nil {
+			block.BlockOverrides = new(BlockOverrides)
+		}
+		if block.BlockOverrides.Number == nil {
+			n := new(big.Int).Add(prevNumber, big.NewInt(1))
+			block.BlockOverrides.Number = (*hexutil.Big)(n)
+		}
+		diff := new(big.Int).Sub(block.BlockOverrides.Number.ToInt(), prevNumber)
+		if diff.Sign() <= 0 {
+			return nil, &simError{message: fmt.Sprintf("block numbers must be in order: %d <= %d", block.BlockOverrides.Number.ToInt().Uint64(), prevNumber), code: errCodeBlockNumberInvalid}
+		}
+		if total := new(big.Int).Sub(block.BlockOverrides.Number.ToInt(), base.Number); total.Cmp(big.NewInt(maxSimulateBlocks)) > 0 {
+			return nil, &simError{message: "too many blocks", code: errCodeClientLimit}
+		}
+		if diff.Cmp(big.NewInt(1)) > 0 {
+			// Fill the gap with empty blocks.
+			gap := new(big.Int).Sub(diff, big.NewInt(1))
+			// Assign block number to the empty blocks.
+			for i := uint64(0); i < gap.Uint64(); i++ {
+				n := new(big.Int).Add(prevNumber, big.NewInt(int64(i+1)))
+				t := prevTimestamp + timestampIncrement
+				b := simBlock{BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(n), Time: (*hexutil.Uint64)(&t)}}
+				prevTimestamp = t
+				res = append(res, b)
+			}
+		}
+		// Only append block after filling a potential gap.
+		prevNumber = block.BlockOverrides.Number.ToInt()
+		var t uint64
+		if block.BlockOverrides.Time == nil {
+			t = prevTimestamp + timestampIncrement
+			block.BlockOverrides.Time = (*hexutil.Uint64)(&t)
+		} else {
+			t = uint64(*block.BlockOverrides.Time)
+			if t <= prevTimestamp {
+				return nil, &simError{message: fmt.Sprintf("block timestamps must be in order: %d <= %d", t, prevTimestamp), code: errCodeBlockTimestamp}
+			}
+		}
+		prevTimestamp = t
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// makeHeaders makes header object with preliminary fields based on a simulated
+// block. Some fields have to be filled post-execution.
+// It assumes blocks are in order and numbers have been validated.
+func (sim *simulator) makeHeaders(blocks []simBlock) ([]*types.Header, error) {
+	var (
+		res    = make([]*types.Header, len(blocks))
+		base   = sim.base
+		header = base
+	)
+	for bi, block := range blocks {
+		if block.BlockOverrides == nil || block.BlockOverrides.Number == nil {
+			return nil, errors.New("empty block number")
+		}
+		overrides := block.BlockOverrides
+
+		var withdrawalsHash *common.Hash
+		if sim.chainConfig.IsShanghai(overrides.Number.ToInt(), (uint64)(*overrides.Time)) {
+			withdrawalsHash = &types.EmptyWithdrawalsHash
+		}
+		var parentBeaconRoot *common.Hash
+		if sim.chainConfig.IsCancun(overrides.Number.ToInt(), (uint64)(*overrides.Time)) {
+			parentBeaconRoot = &common.Hash{}
+		}
+		difficulty := new(big.Int)
+		if header.Difficulty.Sign() != 0 {
+			difficulty.Set(header.Difficulty)
+		}
+		header = overrides.MakeHeader(&types.Header{
+			UncleHash:        types.EmptyUncleHash,
+			ReceiptHash:      types.EmptyReceiptsHash,
+			TxHash:           types.EmptyTxsHash,
+			Coinbase:         header.Coinbase,
+			Difficulty:       difficulty,
+			GasLimit:         header.GasLimit,
+			WithdrawalsHash:  withdrawalsHash,
+			ParentBeaconRoot: parentBeaconRoot,
+		})
+		res[bi] = header
+	}
+	return res, nil
+}
+
+// simChainContext is a core.ChainContext that resolves the headers of the
+// already simulated blocks before falling back to the canonical chain.
+type simChainContext struct {
+	*ChainContext
+	base    *types.Header
+	headers []*types.Header
+}
+
+func (sim *simulator) newSimChainContext(ctx context.Context, headers []*types.Header) *simChainContext {
+	return &simChainContext{
+		ChainContext: NewChainContext(ctx, sim.b),
+		base:         sim.base,
+		headers:      headers,
+	}
+}
+
+func (context *simChainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if number > context.base.Number.Uint64() {
+		for _, header := range context.headers {
+			if header.Number.Uint64() == number && header.Hash() == hash {
+				return header
+			}
+		}
+		return nil
+	}
+	if number == context.base.Number.Uint64() && hash == context.base.Hash() {
+		return context.base
+	}
+	return context.ChainContext.GetHeader(hash, number)
+}
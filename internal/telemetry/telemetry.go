@@ -0,0 +1,135 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package telemetry exports the OpenTelemetry spans recorded by the node.
+//
+// Instrumented packages record their spans through the global tracer provider of
+// the otel package, which discards them unless Setup installed an exporting one.
+// Tracing is therefore free of any cost beyond a few no-op calls when disabled.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// shutdownTimeout is the time allowed for exporting the buffered spans when the
+// node shuts down.
+const shutdownTimeout = 5 * time.Second
+
+// Config contains the settings of the span exporter.
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP collector. Spans are posted to the
+	// standard /v1/traces path unless the URL contains a path.
+	Endpoint string
+
+	// ServiceName and InstanceID identify the node in the exported spans.
+	ServiceName string
+	InstanceID  string
+
+	// SampleRatio is the fraction of traces recorded. Traces started by remote
+	// callers follow the sampling decision of the caller.
+	SampleRatio float64
+}
+
+// Provider is the tracer provider installed by Setup. It implements the node
+// lifecycle so the buffered spans are flushed when the node stops.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// Setup creates a tracer provider exporting spans to the configured collector and
+// installs it as the global provider, along with the W3C trace context propagator.
+func Setup(config Config) (*Provider, error) {
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("invalid sample ratio %v, want value in [0, 1]", config.SampleRatio)
+	}
+	options, err := exporterOptions(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", config.ServiceName),
+		attribute.String("service.version", params.VersionWithMeta),
+	}
+	if config.InstanceID != "" {
+		attrs = append(attrs, attribute.String("service.instance.id", config.InstanceID))
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attrs...)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warn("OpenTelemetry error", "err", err)
+	}))
+	log.Info("Enabled OpenTelemetry tracing", "endpoint", config.Endpoint, "ratio", config.SampleRatio)
+	return &Provider{provider: provider}, nil
+}
+
+// exporterOptions converts the collector URL into exporter options.
+func exporterOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collector endpoint: %v", err)
+	}
+	if u.Host == "" {
+		return nil, errors.New("invalid collector endpoint: missing host")
+	}
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	switch u.Scheme {
+	case "http":
+		options = append(options, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("invalid collector endpoint: unsupported scheme %q", u.Scheme)
+	}
+	if u.Path != "" && u.Path != "/" {
+		options = append(options, otlptracehttp.WithURLPath(u.Path))
+	}
+	return options, nil
+}
+
+// Start implements node.Lifecycle. The provider is already running.
+func (p *Provider) Start() error {
+	return nil
+}
+
+// Stop implements node.Lifecycle, exporting the buffered spans and shutting down
+// the exporter.
+func (p *Provider) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return p.provider.Shutdown(ctx)
+}
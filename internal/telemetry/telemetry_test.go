@@ -0,0 +1,152 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an OTLP/HTTP collector stub recording the spans it receives.
+type collector struct {
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req collectortrace.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	c.mu.Unlock()
+
+	resp, _ := proto.Marshal(&collectortrace.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Write(resp)
+}
+
+func (c *collector) span(name string) *tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, span := range c.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+type testService struct{}
+
+func (testService) Echo(s string) string { return s }
+
+// Tests that RPC calls are exported as spans, continuing the trace of the caller.
+func TestRPCSpans(t *testing.T) {
+	var (
+		stub      = new(collector)
+		collector = httptest.NewServer(stub)
+	)
+	defer collector.Close()
+
+	provider, err := Setup(Config{Endpoint: collector.URL, ServiceName: "test", SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", testService{}); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+	client, err := rpc.DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "caller")
+	var result string
+	if err := client.CallContext(ctx, &result, "test_echo", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CallContext(ctx, &result, "test_missing"); err == nil {
+		t.Fatal("call of missing method succeeded")
+	}
+	span.End()
+	if err := provider.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	caller, echo, missing := stub.span("caller"), stub.span("test_echo"), stub.span("test_missing")
+	if caller == nil || echo == nil || missing == nil {
+		t.Fatalf("spans missing: caller %v, echo %v, missing %v", caller, echo, missing)
+	}
+	for _, s := range []*tracepb.Span{echo, missing} {
+		if string(s.TraceId) != string(caller.TraceId) || string(s.ParentSpanId) != string(caller.SpanId) {
+			t.Errorf("span %s is not a child of the caller span", s.Name)
+		}
+		if s.Kind != tracepb.Span_SPAN_KIND_SERVER {
+			t.Errorf("span %s has wrong kind %v", s.Name, s.Kind)
+		}
+	}
+	if echo.Status.GetCode() == tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("successful call recorded as failed")
+	}
+	if missing.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR {
+		t.Errorf("failed call not recorded as failed")
+	}
+}
+
+func TestExporterOptions(t *testing.T) {
+	for _, endpoint := range []string{"http://localhost:4318", "https://collector:443/otlp/v1/traces"} {
+		if _, err := exporterOptions(endpoint); err != nil {
+			t.Errorf("endpoint %s rejected: %v", endpoint, err)
+		}
+	}
+	for _, endpoint := range []string{"localhost:4318", "ftp://collector", "http://"} {
+		if _, err := exporterOptions(endpoint); err == nil {
+			t.Errorf("endpoint %s accepted", endpoint)
+		}
+	}
+}
@@ -18,6 +18,9 @@
 package testlog
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"sync"
 	"testing"
 
@@ -25,18 +28,18 @@ import (
 )
 
 // Handler returns a log handler which logs to the unit test log of t.
-func Handler(t *testing.T, level log.Lvl) log.Handler {
-	return log.LvlFilterHandler(level, &handler{t, log.TerminalFormat(false)})
+func Handler(t *testing.T, level slog.Level) slog.Handler {
+	return log.NewTerminalHandlerWithLevel(&testWriter{t}, level, false)
 }
 
-type handler struct {
-	t   *testing.T
-	fmt log.Format
+// testWriter forwards every formatted record straight to the unit test log.
+type testWriter struct {
+	t *testing.T
 }
 
-func (h *handler) Log(r *log.Record) error {
-	h.t.Logf("%s", h.fmt.Format(r))
-	return nil
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.t.Logf("%s", bytes.TrimSuffix(p, []byte{'\n'}))
+	return len(p), nil
 }
 
 // logger implements log.Logger such that all output goes to the unit test log via
@@ -50,26 +53,50 @@ type logger struct {
 	h  *bufHandler
 }
 
+// bufHandler collects the formatted records until the logger flushes them into
+// the test log from within the helper-marked logging method.
 type bufHandler struct {
-	buf []*log.Record
-	fmt log.Format
+	buf [][]byte
 }
 
-func (h *bufHandler) Log(r *log.Record) error {
-	h.buf = append(h.buf, r)
-	return nil
+func (h *bufHandler) Write(p []byte) (int, error) {
+	h.buf = append(h.buf, bytes.TrimSuffix(bytes.Clone(p), []byte{'\n'}))
+	return len(p), nil
 }
 
 // Logger returns a logger which logs to the unit test log of t.
-func Logger(t *testing.T, level log.Lvl) log.Logger {
-	l := &logger{
+func Logger(t *testing.T, level slog.Level) log.Logger {
+	h := new(bufHandler)
+	return &logger{
 		t:  t,
-		l:  log.New(),
+		l:  log.NewLogger(log.NewTerminalHandlerWithLevel(h, level, false)),
 		mu: new(sync.Mutex),
-		h:  &bufHandler{fmt: log.TerminalFormat(false)},
+		h:  h,
 	}
-	l.l.SetHandler(log.LvlFilterHandler(level, l.h))
-	return l
+}
+
+func (l *logger) Handler() slog.Handler {
+	return l.l.Handler()
+}
+
+func (l *logger) Write(level slog.Level, msg string, ctx ...interface{}) {
+	l.t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.l.Write(level, msg, ctx...)
+	l.flush()
+}
+
+func (l *logger) Log(level slog.Level, msg string, ctx ...interface{}) {
+	l.t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.l.Log(level, msg, ctx...)
+	l.flush()
+}
+
+func (l *logger) Enabled(ctx context.Context, level slog.Level) bool {
+	return l.l.Enabled(ctx, level)
 }
 
 func (l *logger) Trace(msg string, ctx ...interface{}) {
@@ -124,19 +151,15 @@ func (l *logger) New(ctx ...interface{}) log.Logger {
 	return &logger{l.t, l.l.New(ctx...), l.mu, l.h}
 }
 
-func (l *logger) GetHandler() log.Handler {
-	return l.l.GetHandler()
-}
-
-func (l *logger) SetHandler(h log.Handler) {
-	l.l.SetHandler(h)
+func (l *logger) With(ctx ...interface{}) log.Logger {
+	return &logger{l.t, l.l.With(ctx...), l.mu, l.h}
 }
 
 // flush writes all buffered messages and clears the buffer.
 func (l *logger) flush() {
 	l.t.Helper()
-	for _, r := range l.h.buf {
-		l.t.Logf("%s", l.h.fmt.Format(r))
+	for _, line := range l.h.buf {
+		l.t.Logf("%s", line)
 	}
 	l.h.buf = nil
 }
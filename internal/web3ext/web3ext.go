@@ -190,6 +190,46 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'resetReputation',
+			call: 'admin_resetReputation',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'banNetwork',
+			call: 'admin_banNetwork',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unbanNetwork',
+			call: 'admin_unbanNetwork',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setMaxPeers',
+			call: 'admin_setMaxPeers',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setDialRatio',
+			call: 'admin_setDialRatio',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setProtocolQuota',
+			call: 'admin_setProtocolQuota',
+			params: 2
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -200,6 +240,18 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'reputations',
+			getter: 'admin_reputations'
+		}),
+		new web3._extend.Property({
+			name: 'banList',
+			getter: 'admin_banList'
+		}),
+		new web3._extend.Property({
+			name: 'peerLimits',
+			getter: 'admin_peerLimits'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
@@ -437,6 +489,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceCallMany',
+			call: 'debug_traceCallMany',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -540,6 +598,32 @@ web3._extend({
 			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter, null],
 			outputFormatter: web3._extend.utils.toDecimal
 		}),
+		new web3._extend.Method({
+			name: 'sendBundle',
+			call: 'eth_sendBundle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'eth_callBundle',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sendRawTransactionConditional',
+			call: 'eth_sendRawTransactionConditional',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getConditionalTransactionStatus',
+			call: 'eth_getConditionalTransactionStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'simulateV1',
+			call: 'eth_simulateV1',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'submitTransaction',
 			call: 'eth_submitTransaction',
@@ -804,6 +888,16 @@ web3._extend({
 			call: 'txpool_contentFrom',
 			params: 1,
 		}),
+		new web3._extend.Method({
+			name: 'txStatus',
+			call: 'txpool_txStatus',
+			params: 1,
+		}),
+		new web3._extend.Method({
+			name: 'history',
+			call: 'txpool_history',
+			params: 1,
+		}),
 	]
 });
 `
@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -235,6 +236,24 @@ func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
 
+func (b *LesApiBackend) TxPoolStatus(hash common.Hash) txpool.TxStatus {
+	if b.eth.txPool.GetTransaction(hash) != nil {
+		return txpool.TxStatusPending
+	}
+	return txpool.TxStatusUnknown
+}
+
+func (b *LesApiBackend) TxPoolHistory(hash common.Hash) []txpool.TxEvent {
+	return nil // The light pool does not track transaction lifecycles
+}
+
+func (b *LesApiBackend) SubscribeTxPoolDropEvent(ch chan<- txpool.TxEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainEvent(ch)
 }
@@ -17,8 +17,11 @@
 package log
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -36,43 +39,68 @@ var errTraceSyntax = errors.New("expect file.go:234")
 // GlogHandler is a log handler that mimics the filtering features of Google's
 // glog logger: setting global log levels; overriding with callsite pattern
 // matches; and requesting backtraces at certain positions.
+//
+// Handlers derived through WithAttrs and WithGroup share the filtering state
+// and the origin handler of their parent, so changing the verbosity, vmodule
+// patterns or the origin affects every logger created from it.
 type GlogHandler struct {
-	origin Handler // The origin handler this wraps
+	*glogState
+
+	derive  func(slog.Handler) slog.Handler // Attributes and groups to apply to the origin, nil at the root
+	derived atomic.Pointer[glogDerived]     // Cached origin with the derivation applied
+}
+
+// glogState is the filtering configuration shared by a GlogHandler and all the
+// handlers derived from it.
+type glogState struct {
+	origin atomic.Pointer[glogOrigin] // The origin handler this wraps
+
+	level     atomic.Int32 // Current log level, atomically accessible
+	override  atomic.Bool  // Flag whether overrides are used, atomically accessible
+	backtrace atomic.Bool  // Flag whether backtrace location is set
+
+	patterns  []pattern              // Current list of patterns to override with
+	siteCache map[uintptr]slog.Level // Cache of callsite pattern evaluations
+	location  string                 // file:line location where to do a stackdump at
+	lock      sync.RWMutex           // Lock protecting the override pattern list
+}
 
-	level     atomic.Uint32 // Current log level, atomically accessible
-	override  atomic.Bool   // Flag whether overrides are used, atomically accessible
-	backtrace atomic.Bool   // Flag whether backtrace location is set
+// glogOrigin boxes the wrapped handler so it can be swapped atomically.
+type glogOrigin struct {
+	handler slog.Handler
+}
 
-	patterns  []pattern       // Current list of patterns to override with
-	siteCache map[uintptr]Lvl // Cache of callsite pattern evaluations
-	location  string          // file:line location where to do a stackdump at
-	lock      sync.RWMutex    // Lock protecting the override pattern list
+// glogDerived caches the handler derived from a specific origin.
+type glogDerived struct {
+	origin  *glogOrigin
+	handler slog.Handler
 }
 
 // NewGlogHandler creates a new log handler with filtering functionality similar
-// to Google's glog logger. The returned handler implements Handler.
-func NewGlogHandler(h Handler) *GlogHandler {
-	return &GlogHandler{
-		origin: h,
-	}
+// to Google's glog logger. The returned handler implements slog.Handler.
+func NewGlogHandler(h slog.Handler) *GlogHandler {
+	g := &GlogHandler{glogState: new(glogState)}
+	g.origin.Store(&glogOrigin{h})
+	g.level.Store(int32(LevelCrit))
+	return g
 }
 
 // SetHandler updates the handler to write records to the specified sub-handler.
-func (h *GlogHandler) SetHandler(nh Handler) {
-	h.origin = nh
+func (h *GlogHandler) SetHandler(nh slog.Handler) {
+	h.origin.Store(&glogOrigin{nh})
 }
 
 // pattern contains a filter for the Vmodule option, holding a verbosity level
 // and a file pattern to match.
 type pattern struct {
 	pattern *regexp.Regexp
-	level   Lvl
+	level   slog.Level
 }
 
 // Verbosity sets the glog verbosity ceiling. The verbosity of individual packages
 // and source files can be raised using Vmodule.
-func (h *GlogHandler) Verbosity(level Lvl) {
-	h.level.Store(uint32(level))
+func (h *GlogHandler) Verbosity(level slog.Level) {
+	h.level.Store(int32(level))
 }
 
 // Vmodule sets the glog verbosity pattern.
@@ -130,19 +158,15 @@ func (h *GlogHandler) Vmodule(ruleset string) error {
 		matcher = matcher + "$"
 
 		re, _ := regexp.Compile(matcher)
-		filter = append(filter, pattern{re, Lvl(level)})
+		filter = append(filter, pattern{re, FromLegacyLevel(level)})
 	}
 	// Swap out the vmodule pattern for the new filter system
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
 	h.patterns = filter
-	h.siteCache = make(map[uintptr]Lvl)
+	h.siteCache = make(map[uintptr]slog.Level)
 	h.override.Store(len(filter) != 0)
-	// Enable location storage (globally)
-	if len(h.patterns) > 0 {
-		stackEnabled.Store(true)
-	}
 	return nil
 }
 
@@ -175,35 +199,88 @@ func (h *GlogHandler) BacktraceAt(location string) error {
 
 	h.location = location
 	h.backtrace.Store(len(location) > 0)
-	// Enable location storage (globally)
-	stackEnabled.Store(true)
 	return nil
 }
 
-// Log implements Handler.Log, filtering a log record through the global, local
-// and backtrace filters, finally emitting it if either allow it through.
-func (h *GlogHandler) Log(r *Record) error {
+// Enabled implements slog.Handler, reporting whether the handler handles records
+// at the given level. As callsite overrides and backtraces can only be evaluated
+// on the record itself, all levels are enabled while either is configured.
+func (h *GlogHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	if !h.override.Load() && !h.backtrace.Load() && lvl < slog.Level(h.level.Load()) {
+		return false
+	}
+	return h.handler().Enabled(ctx, lvl)
+}
+
+// WithAttrs implements slog.Handler, returning a handler which shares the
+// filtering state of h but adds the given attributes to all records.
+func (h *GlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.extend(func(origin slog.Handler) slog.Handler {
+		return origin.WithAttrs(attrs)
+	})
+}
+
+// WithGroup implements slog.Handler, returning a handler which shares the
+// filtering state of h but qualifies all subsequent attributes with name.
+func (h *GlogHandler) WithGroup(name string) slog.Handler {
+	return h.extend(func(origin slog.Handler) slog.Handler {
+		return origin.WithGroup(name)
+	})
+}
+
+// extend creates a handler sharing the state of h, applying fn on top of the
+// derivations of h to the origin handler.
+func (h *GlogHandler) extend(fn func(slog.Handler) slog.Handler) *GlogHandler {
+	derive := fn
+	if parent := h.derive; parent != nil {
+		derive = func(origin slog.Handler) slog.Handler {
+			return fn(parent(origin))
+		}
+	}
+	return &GlogHandler{glogState: h.glogState, derive: derive}
+}
+
+// handler returns the origin handler with the attributes and groups of h
+// applied, rederiving it if the origin was swapped out since the last call.
+func (h *GlogHandler) handler() slog.Handler {
+	origin := h.origin.Load()
+	if h.derive == nil {
+		return origin.handler
+	}
+	if cached := h.derived.Load(); cached != nil && cached.origin == origin {
+		return cached.handler
+	}
+	derived := &glogDerived{origin: origin, handler: h.derive(origin.handler)}
+	h.derived.Store(derived)
+	return derived.handler
+}
+
+// Handle implements slog.Handler, filtering a log record through the global,
+// local and backtrace filters, finally emitting it if either allow it through.
+func (h *GlogHandler) Handle(ctx context.Context, r slog.Record) error {
 	// If backtracing is requested, check whether this is the callsite
 	if h.backtrace.Load() {
 		// Everything below here is slow. Although we could cache the call sites the
 		// same way as for vmodule, backtracing is so rare it's not worth the extra
 		// complexity.
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+
 		h.lock.RLock()
-		match := h.location == r.Call.String()
+		match := h.location == fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
 		h.lock.RUnlock()
 
 		if match {
 			// Callsite matched, raise the log level to info and gather the stacks
-			r.Lvl = LvlInfo
+			r.Level = LevelInfo
 
 			buf := make([]byte, 1024*1024)
 			buf = buf[:runtime.Stack(buf, true)]
-			r.Msg += "\n\n" + string(buf)
+			r.Message += "\n\n" + string(buf)
 		}
 	}
 	// If the global log level allows, fast track logging
-	if h.level.Load() >= uint32(r.Lvl) {
-		return h.origin.Log(r)
+	if slog.Level(h.level.Load()) <= r.Level {
+		return h.handler().Handle(ctx, r)
 	}
 	// If no local overrides are present, fast track skipping
 	if !h.override.Load() {
@@ -211,26 +288,30 @@ func (h *GlogHandler) Log(r *Record) error {
 	}
 	// Check callsite cache for previously calculated log levels
 	h.lock.RLock()
-	lvl, ok := h.siteCache[r.Call.Frame().PC]
+	lvl, ok := h.siteCache[r.PC]
 	h.lock.RUnlock()
 
 	// If we didn't cache the callsite yet, calculate it
 	if !ok {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file := pkgFilePath(frame)
+
 		h.lock.Lock()
 		for _, rule := range h.patterns {
-			if rule.pattern.MatchString(fmt.Sprintf("%+s", r.Call)) {
-				h.siteCache[r.Call.Frame().PC], lvl, ok = rule.level, rule.level, true
+			if rule.pattern.MatchString(file) {
+				h.siteCache[r.PC], lvl, ok = rule.level, rule.level, true
 				break
 			}
 		}
 		// If no rule matched, remember to drop log the next time
 		if !ok {
-			h.siteCache[r.Call.Frame().PC] = 0
+			lvl = LevelCrit
+			h.siteCache[r.PC] = lvl
 		}
 		h.lock.Unlock()
 	}
-	if lvl >= r.Lvl {
-		return h.origin.Log(r)
+	if lvl <= r.Level {
+		return h.handler().Handle(ctx, r)
 	}
 	return nil
 }
@@ -2,6 +2,8 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
+	"log/slog"
 	"os"
 	"strings"
 	"testing"
@@ -10,17 +12,13 @@ import (
 // TestLoggingWithTrace checks that if BackTraceAt is set, then the
 // gloghandler is capable of spitting out a stacktrace
 func TestLoggingWithTrace(t *testing.T) {
-	defer stackEnabled.Store(stackEnabled.Load())
 	out := new(bytes.Buffer)
-	logger := New()
-	{
-		glog := NewGlogHandler(StreamHandler(out, TerminalFormat(false)))
-		glog.Verbosity(LvlTrace)
-		if err := glog.BacktraceAt("logger_test.go:24"); err != nil {
-			t.Fatal(err)
-		}
-		logger.SetHandler(glog)
+	glog := NewGlogHandler(NewTerminalHandlerWithLevel(out, LevelTrace, false))
+	glog.Verbosity(LevelTrace)
+	if err := glog.BacktraceAt("logger_test.go:22"); err != nil {
+		t.Fatal(err)
 	}
+	logger := NewLogger(glog)
 	logger.Trace("a message", "foo", "bar") // Will be bumped to INFO
 	have := out.String()
 	if !strings.HasPrefix(have, "INFO") {
@@ -37,16 +35,12 @@ func TestLoggingWithTrace(t *testing.T) {
 
 // TestLoggingWithVmodule checks that vmodule works.
 func TestLoggingWithVmodule(t *testing.T) {
-	defer stackEnabled.Store(stackEnabled.Load())
 	out := new(bytes.Buffer)
-	logger := New()
-	{
-		glog := NewGlogHandler(StreamHandler(out, TerminalFormat(false)))
-		glog.Verbosity(LvlCrit)
-		logger.SetHandler(glog)
-		logger.Warn("This should not be seen", "ignored", "true")
-		glog.Vmodule("logger_test.go=5")
-	}
+	glog := NewGlogHandler(NewTerminalHandlerWithLevel(out, LevelTrace, false))
+	glog.Verbosity(LevelCrit)
+	logger := NewLogger(glog)
+	logger.Warn("This should not be seen", "ignored", "true")
+	glog.Vmodule("logger_test.go=5")
 	logger.Trace("a message", "foo", "bar")
 	have := out.String()
 	// The timestamp is locale-dependent, so we want to trim that off
@@ -58,8 +52,73 @@ func TestLoggingWithVmodule(t *testing.T) {
 	}
 }
 
+// TestGlogDerivedLoggers checks that loggers derived from a glog handled logger
+// follow verbosity and origin changes made on the root handler.
+func TestGlogDerivedLoggers(t *testing.T) {
+	var (
+		out1 = new(bytes.Buffer)
+		out2 = new(bytes.Buffer)
+		glog = NewGlogHandler(NewTerminalHandler(out1, false))
+	)
+	glog.Verbosity(LevelInfo)
+	child := NewLogger(glog).New("peer", "a")
+
+	child.Debug("hidden")
+	if out1.Len() != 0 {
+		t.Fatalf("debug log emitted at info verbosity: %q", out1.String())
+	}
+	glog.Verbosity(LevelDebug)
+	child.Debug("shown")
+	if have := out1.String(); !strings.Contains(have, "shown") || !strings.Contains(have, "peer=a") {
+		t.Fatalf("debug log missing after verbosity change: %q", have)
+	}
+	glog.SetHandler(NewTerminalHandler(out2, false))
+	child.Info("swapped")
+	if have := out2.String(); !strings.Contains(have, "swapped") || !strings.Contains(have, "peer=a") {
+		t.Fatalf("log missing after handler swap: %q", have)
+	}
+}
+
+func TestTerminalHandlerWithAttrs(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger := NewLogger(NewTerminalHandlerWithLevel(out, LevelTrace, false)).With("baz", "bat")
+	logger.Trace("a message", "foo", "bar", "lazy", Lazy{Fn: func() int { return 42 }})
+	have := out.String()
+	// The timestamp is locale-dependent, so we want to trim that off
+	// "INFO [01-01|00:00:00.000] a messag ..." -> "a messag..."
+	have = strings.Split(have, "]")[1]
+	want := " a message                                baz=bat foo=bar lazy=42\n"
+	if have != want {
+		t.Errorf("\nhave: %q\nwant: %q\n", have, want)
+	}
+}
+
+func TestJSONHandler(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger := NewLogger(JSONHandler(out))
+	logger.Debug("hi there", "number", 1)
+
+	var have map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &have); err != nil {
+		t.Fatalf("invalid json output %q: %v", out.String(), err)
+	}
+	if have["lvl"] != "dbug" || have["msg"] != "hi there" || have["number"] != float64(1) {
+		t.Errorf("unexpected json record: %v", have)
+	}
+	if _, ok := have["t"]; !ok {
+		t.Errorf("missing timestamp in json record: %v", have)
+	}
+
+	out.Reset()
+	logger = NewLogger(JSONHandlerWithLevel(out, slog.LevelInfo))
+	logger.Debug("hi there")
+	if out.Len() != 0 {
+		t.Errorf("debug record emitted by info level handler: %q", out.String())
+	}
+}
+
 func BenchmarkTraceLogging(b *testing.B) {
-	Root().SetHandler(LvlFilterHandler(LvlInfo, StreamHandler(os.Stderr, TerminalFormat(true))))
+	SetDefault(NewLogger(NewTerminalHandlerWithLevel(os.Stderr, LevelInfo, true)))
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		Trace("a message", "v", i)
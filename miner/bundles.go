@@ -0,0 +1,569 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// maxBundles is the maximum number of bundles held by the bundle store.
+	maxBundles = 1024
+
+	// maxBundlesPerSender is the maximum number of bundles held per sender. The
+	// sender of a bundle is the sender of its first transaction.
+	maxBundlesPerSender = 16
+
+	// maxBundleTxs is the maximum number of transactions in a single bundle.
+	maxBundleTxs = 64
+
+	// maxBundleFutureBlocks is how far ahead of the chain head a bundle may target.
+	maxBundleFutureBlocks = 64
+
+	// maxBundleTxSize is the maximum size of a single bundle transaction.
+	maxBundleTxSize = 128 * 1024
+)
+
+var (
+	errEmptyBundle        = errors.New("bundle has no transactions")
+	errBundleTooLarge     = fmt.Errorf("bundle has more than %d transactions", maxBundleTxs)
+	errBundleBlobTx       = errors.New("blob transactions are not supported in bundles")
+	errBundleStale        = errors.New("bundle targets a past block")
+	errBundleTooFar       = errors.New("bundle targets a block too far in the future")
+	errBundleStoreFull    = errors.New("bundle store is full of more valuable bundles")
+	errBundleSenderLimit  = fmt.Errorf("sender has %d pending bundles", maxBundlesPerSender)
+	errBundleKnown        = errors.New("bundle already known")
+	errBundleReverted     = errors.New("bundle transaction reverted")
+	errBundleUnprofitable = errors.New("bundle does not pay the fee recipient")
+)
+
+// Bundle is a list of transactions which must be included in a block together, in
+// the given order, or not at all. Bundles are submitted privately to the miner and
+// never enter the transaction pool.
+type Bundle struct {
+	Txs          types.Transactions
+	BlockNumber  uint64 // Number of the block the bundle is valid for
+	MinTimestamp uint64 // Minimum block timestamp, zero if unrestricted
+	MaxTimestamp uint64 // Maximum block timestamp, zero if unrestricted
+
+	// RevertingTxHashes are the transactions which are allowed to revert without
+	// invalidating the bundle.
+	RevertingTxHashes []common.Hash
+}
+
+// Hash returns the bundle hash, which is the hash of the concatenated hashes of
+// the bundle transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// validFor reports whether the bundle may be included in the given block.
+func (b *Bundle) validFor(number, timestamp uint64) bool {
+	if b.BlockNumber != number {
+		return false
+	}
+	if b.MinTimestamp != 0 && timestamp < b.MinTimestamp {
+		return false
+	}
+	if b.MaxTimestamp != 0 && timestamp > b.MaxTimestamp {
+		return false
+	}
+	return true
+}
+
+// mayRevert reports whether the given transaction is allowed to revert.
+func (b *Bundle) mayRevert(hash common.Hash) bool {
+	return slices.Contains(b.RevertingTxHashes, hash)
+}
+
+// BundleResult is the outcome of executing a bundle.
+type BundleResult struct {
+	BundleHash   common.Hash
+	GasUsed      uint64
+	CoinbaseDiff *big.Int // Balance change of the fee recipient, the bundle's profit
+	Txs          []BundleTxResult
+}
+
+// BundleTxResult is the outcome of executing a single bundle transaction.
+type BundleTxResult struct {
+	Hash     common.Hash
+	From     common.Address
+	GasUsed  uint64
+	Reverted bool
+	Logs     []*types.Log
+}
+
+// reverted returns the first transaction which reverted without being allowed to.
+func (r *BundleResult) reverted(bundle *Bundle) *BundleTxResult {
+	for i := range r.Txs {
+		if r.Txs[i].Reverted && !bundle.mayRevert(r.Txs[i].Hash) {
+			return &r.Txs[i]
+		}
+	}
+	return nil
+}
+
+// bundleStore holds the bundles submitted to the miner until the block they
+// target has passed. When the store is full, the bundle with the lowest value is
+// evicted in favour of a more valuable one.
+type bundleStore struct {
+	mu      sync.Mutex
+	bundles map[common.Hash]*storedBundle
+	senders map[common.Address]int // number of stored bundles per sender
+
+	// Simulation results of the pending bundles, valid for simParent and simCoinbase.
+	sims        map[common.Hash]*bundleSimulation
+	simParent   common.Hash
+	simCoinbase common.Address
+}
+
+// storedBundle is a bundle along with its sender and value, which is the profit
+// of the fee recipient when the bundle was simulated on submission.
+type storedBundle struct {
+	bundle *Bundle
+	sender common.Address
+	value  *big.Int
+}
+
+// bundleSimulation is the outcome of simulating a bundle on its own on top of
+// the parent state of a block.
+type bundleSimulation struct {
+	result *BundleResult
+	err    error
+}
+
+func newBundleStore() *bundleStore {
+	return &bundleStore{
+		bundles: make(map[common.Hash]*storedBundle),
+		senders: make(map[common.Address]int),
+		sims:    make(map[common.Hash]*bundleSimulation),
+	}
+}
+
+// validateBundle checks the bundle and its transactions against the current chain head.
+func validateBundle(bundle *Bundle, head *types.Header, config *params.ChainConfig) error {
+	switch {
+	case len(bundle.Txs) == 0:
+		return errEmptyBundle
+	case len(bundle.Txs) > maxBundleTxs:
+		return errBundleTooLarge
+	case bundle.BlockNumber <= head.Number.Uint64():
+		return errBundleStale
+	case bundle.BlockNumber > head.Number.Uint64()+maxBundleFutureBlocks:
+		return errBundleTooFar
+	}
+	opts := &txpool.ValidationOptions{
+		Config:  config,
+		Accept:  1<<types.LegacyTxType | 1<<types.AccessListTxType | 1<<types.DynamicFeeTxType,
+		MaxSize: maxBundleTxSize,
+		MinTip:  new(big.Int),
+	}
+	signer := types.MakeSigner(config, head.Number, head.Time)
+	for _, tx := range bundle.Txs {
+		if tx.Type() == types.BlobTxType {
+			return errBundleBlobTx
+		}
+		if err := txpool.ValidateTransaction(tx, head, signer, opts); err != nil {
+			return fmt.Errorf("transaction %v: %w", tx.Hash(), err)
+		}
+	}
+	return nil
+}
+
+// add stores a validated bundle. The head is the number of the current chain head.
+func (s *bundleStore) add(bundle *Bundle, sender common.Address, value *big.Int, head uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(head)
+	hash := bundle.Hash()
+	if s.bundles[hash] != nil {
+		return errBundleKnown
+	}
+	if s.senders[sender] >= maxBundlesPerSender {
+		return errBundleSenderLimit
+	}
+	if len(s.bundles) >= maxBundles {
+		var (
+			cheapest     *storedBundle
+			cheapestHash common.Hash
+		)
+		for h, stored := range s.bundles {
+			if cheapest == nil || stored.value.Cmp(cheapest.value) < 0 {
+				cheapest, cheapestHash = stored, h
+			}
+		}
+		if cheapest.value.Cmp(value) >= 0 {
+			return errBundleStoreFull
+		}
+		log.Trace("Evicting bundle", "hash", cheapestHash, "value", cheapest.value)
+		s.remove(cheapestHash)
+	}
+	s.bundles[hash] = &storedBundle{bundle: bundle, sender: sender, value: value}
+	s.senders[sender]++
+	return nil
+}
+
+// pending returns the bundles which may be included in the given block.
+func (s *bundleStore) pending(number, timestamp uint64) []*Bundle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(number - 1)
+	var bundles []*Bundle
+	for _, stored := range s.bundles {
+		if stored.bundle.validFor(number, timestamp) {
+			bundles = append(bundles, stored.bundle)
+		}
+	}
+	return bundles
+}
+
+// simulation returns the cached simulation of a bundle on top of the given parent
+// block, with the given fee recipient.
+func (s *bundleStore) simulation(parent common.Hash, coinbase common.Address, hash common.Hash) *bundleSimulation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if parent != s.simParent || coinbase != s.simCoinbase {
+		return nil
+	}
+	return s.sims[hash]
+}
+
+// setSimulation caches the simulation of a bundle. Simulations of other parent
+// blocks or fee recipients are dropped.
+func (s *bundleStore) setSimulation(parent common.Hash, coinbase common.Address, hash common.Hash, sim *bundleSimulation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if parent != s.simParent || coinbase != s.simCoinbase {
+		s.sims = make(map[common.Hash]*bundleSimulation)
+		s.simParent, s.simCoinbase = parent, coinbase
+	}
+	s.sims[hash] = sim
+}
+
+// prune drops the bundles targeting blocks up to the given head. The caller
+// must hold s.mu.
+func (s *bundleStore) prune(head uint64) {
+	for hash, stored := range s.bundles {
+		if stored.bundle.BlockNumber <= head {
+			s.remove(hash)
+		}
+	}
+}
+
+// remove drops a bundle from the store. The caller must hold s.mu.
+func (s *bundleStore) remove(hash common.Hash) {
+	stored := s.bundles[hash]
+	delete(s.bundles, hash)
+	delete(s.sims, hash)
+	if s.senders[stored.sender]--; s.senders[stored.sender] == 0 {
+		delete(s.senders, stored.sender)
+	}
+}
+
+// addBundle validates a submitted bundle and adds it to the store. The bundle is
+// simulated on top of the current head to determine its value for eviction.
+// Bundles which fail the simulation are still accepted, as they may depend on
+// state changes of the blocks before the one they target, but they are the
+// first to be evicted. Simulations of submitted bundles run one at a time, the
+// caller bounds both the wait and the execution through ctx.
+func (w *worker) addBundle(ctx context.Context, bundle *Bundle) error {
+	head := w.chain.CurrentBlock()
+	if err := validateBundle(bundle, head, w.chainConfig); err != nil {
+		return err
+	}
+	sender, err := types.Sender(types.MakeSigner(w.chainConfig, head.Number, head.Time), bundle.Txs[0])
+	if err != nil {
+		return err
+	}
+	select {
+	case w.bundleSims <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	result, err := w.callBundle(ctx, bundle, head.Hash(), 0, common.Address{})
+	<-w.bundleSims
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	value := new(big.Int)
+	if err == nil && checkBundleResult(bundle, result) == nil {
+		value = result.CoinbaseDiff
+	}
+	return w.bundles.add(bundle, sender, value, head.Number.Uint64())
+}
+
+// envCheckpoint records the parts of an environment modified by executing
+// transactions, so a partially applied bundle can be rolled back.
+type envCheckpoint struct {
+	snapshot int
+	gas      uint64
+	gasUsed  uint64
+	tcount   int
+	txs      int
+}
+
+func (env *environment) checkpoint() envCheckpoint {
+	return envCheckpoint{
+		snapshot: env.state.Snapshot(),
+		gas:      env.gasPool.Gas(),
+		gasUsed:  env.header.GasUsed,
+		tcount:   env.tcount,
+		txs:      len(env.txs),
+	}
+}
+
+func (env *environment) revert(cp envCheckpoint) {
+	env.state.RevertToSnapshot(cp.snapshot)
+	env.gasPool.SetGas(cp.gas)
+	env.header.GasUsed = cp.gasUsed
+	env.tcount = cp.tcount
+	env.txs = env.txs[:cp.txs]
+	env.receipts = env.receipts[:cp.txs]
+}
+
+// executeBundle applies all bundle transactions on top of the environment. An
+// error is returned if a transaction can't be applied at all, reverting
+// transactions are reported in the result. The caller is responsible for rolling
+// the environment back if the bundle is not acceptable. The execution is aborted
+// when ctx is cancelled.
+func (w *worker) executeBundle(ctx context.Context, env *environment, bundle *Bundle) (*BundleResult, error) {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	result := &BundleResult{BundleHash: bundle.Hash()}
+	balance := env.state.GetBalance(env.coinbase)
+	for _, tx := range bundle.Txs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		from, err := types.Sender(env.signer, tx)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %v: %w", tx.Hash(), err)
+		}
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+		logs, err := w.commitBundleTransaction(ctx, env, tx)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %v: %w", tx.Hash(), err)
+		}
+		env.tcount++
+		receipt := env.receipts[len(env.receipts)-1]
+		result.GasUsed += receipt.GasUsed
+		result.Txs = append(result.Txs, BundleTxResult{
+			Hash:     tx.Hash(),
+			From:     from,
+			GasUsed:  receipt.GasUsed,
+			Reverted: receipt.Status == types.ReceiptStatusFailed,
+			Logs:     logs,
+		})
+	}
+	result.CoinbaseDiff = new(big.Int).Sub(env.state.GetBalance(env.coinbase), balance)
+	return result, nil
+}
+
+// commitBundleTransaction applies a bundle transaction like commitTransaction,
+// but aborts the EVM execution when ctx is cancelled.
+func (w *worker) commitBundleTransaction(ctx context.Context, env *environment, tx *types.Transaction) ([]*types.Log, error) {
+	if ctx.Done() == nil {
+		return w.commitTransaction(env, tx)
+	}
+	msg, err := core.TransactionToMessage(tx, env.signer, env.header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		snap    = env.state.Snapshot()
+		gp      = env.gasPool.Gas()
+		gasUsed = env.header.GasUsed
+		vmenv   = vm.NewEVM(core.NewEVMBlockContext(env.header, w.chain, &env.coinbase), vm.TxContext{}, env.state, w.chainConfig, *w.chain.GetVMConfig())
+		done    = make(chan struct{})
+	)
+	go func() {
+		select {
+		case <-ctx.Done():
+			vmenv.Cancel()
+		case <-done:
+		}
+	}()
+	receipt, err := core.ApplyTransactionWithEVM(msg, w.chainConfig, env.gasPool, env.state, env.header.Number, env.header.Hash(), tx, &env.header.GasUsed, vmenv)
+	close(done)
+	if err == nil && vmenv.Cancelled() {
+		err = ctx.Err()
+	}
+	if err != nil {
+		env.state.RevertToSnapshot(snap)
+		env.gasPool.SetGas(gp)
+		env.header.GasUsed = gasUsed
+		return nil, err
+	}
+	env.txs = append(env.txs, tx)
+	env.receipts = append(env.receipts, receipt)
+	return receipt.Logs, nil
+}
+
+// simulatedBundle is a bundle which was successfully executed against the parent
+// state of the block being built.
+type simulatedBundle struct {
+	bundle *Bundle
+	result *BundleResult
+}
+
+// commitBundles merges the pending bundles of the block into the environment,
+// ahead of any public transactions. Every bundle is first simulated on its own,
+// discarding the ones that fail or revert, then the bundles are applied in the
+// order of their profit, skipping those conflicting with an earlier one.
+// Simulations are cached per parent block and fee recipient, so rebuilding the
+// block only simulates newly submitted bundles.
+func (w *worker) commitBundles(env *environment, interrupt *atomic.Int32) error {
+	bundles := w.bundles.pending(env.header.Number.Uint64(), env.header.Time)
+	if len(bundles) == 0 {
+		return nil
+	}
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	var simulated []*simulatedBundle
+	for _, bundle := range bundles {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		hash := bundle.Hash()
+		sim := w.bundles.simulation(env.header.ParentHash, env.coinbase, hash)
+		if sim == nil {
+			result, err := w.simulateBundle(env, bundle)
+			sim = &bundleSimulation{result: result, err: err}
+			w.bundles.setSimulation(env.header.ParentHash, env.coinbase, hash, sim)
+		}
+		if sim.err != nil {
+			log.Debug("Discarding bundle", "hash", hash, "err", sim.err)
+			continue
+		}
+		simulated = append(simulated, &simulatedBundle{bundle: bundle, result: sim.result})
+	}
+	sort.SliceStable(simulated, func(i, j int) bool {
+		if cmp := simulated[i].result.CoinbaseDiff.Cmp(simulated[j].result.CoinbaseDiff); cmp != 0 {
+			return cmp > 0
+		}
+		return simulated[i].result.GasUsed < simulated[j].result.GasUsed
+	})
+	included := make(map[common.Hash]bool)
+	for _, sim := range simulated {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		if slices.ContainsFunc(sim.bundle.Txs, func(tx *types.Transaction) bool { return included[tx.Hash()] }) {
+			log.Trace("Skipping bundle sharing transactions with an included one", "hash", sim.result.BundleHash)
+			continue
+		}
+		if env.gasPool.Gas() < sim.result.GasUsed {
+			log.Trace("Not enough gas left for bundle", "hash", sim.result.BundleHash, "left", env.gasPool.Gas(), "needed", sim.result.GasUsed)
+			continue
+		}
+		cp := env.checkpoint()
+		result, err := w.executeBundle(context.Background(), env, sim.bundle)
+		if err == nil {
+			err = checkBundleResult(sim.bundle, result)
+		}
+		if err != nil {
+			// The bundle conflicts with the state changes of a more profitable one.
+			log.Debug("Bundle conflicts with included bundles", "hash", sim.result.BundleHash, "err", err)
+			env.revert(cp)
+			continue
+		}
+		for _, tx := range sim.bundle.Txs {
+			included[tx.Hash()] = true
+		}
+		log.Debug("Included bundle", "hash", result.BundleHash, "txs", len(result.Txs), "profit", result.CoinbaseDiff)
+	}
+	return nil
+}
+
+// simulateBundle executes the bundle against a copy of the environment.
+func (w *worker) simulateBundle(env *environment, bundle *Bundle) (*BundleResult, error) {
+	sim := env.copy()
+	defer sim.discard()
+
+	result, err := w.executeBundle(context.Background(), sim, bundle)
+	if err != nil {
+		return nil, err
+	}
+	return result, checkBundleResult(bundle, result)
+}
+
+// checkBundleResult checks whether an executed bundle may be included.
+func checkBundleResult(bundle *Bundle, result *BundleResult) error {
+	if tx := result.reverted(bundle); tx != nil {
+		return fmt.Errorf("%w: %v", errBundleReverted, tx.Hash)
+	}
+	if result.CoinbaseDiff.Sign() <= 0 {
+		return errBundleUnprofitable
+	}
+	return nil
+}
+
+// callBundle executes a bundle on top of the given parent block without including
+// it anywhere. If no coinbase is given, the etherbase is used as fee recipient.
+// The execution is aborted when ctx is cancelled.
+func (w *worker) callBundle(ctx context.Context, bundle *Bundle, parent common.Hash, timestamp uint64, coinbase common.Address) (*BundleResult, error) {
+	for _, tx := range bundle.Txs {
+		if tx.Type() == types.BlobTxType {
+			return nil, errBundleBlobTx
+		}
+	}
+	if coinbase == (common.Address{}) {
+		coinbase = w.etherbase()
+	}
+	env, err := w.prepareWork(&generateParams{
+		timestamp:  timestamp,
+		parentHash: parent,
+		coinbase:   coinbase,
+		noTxs:      true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer env.discard()
+
+	return w.executeBundle(ctx, env, bundle)
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package miner
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// revertingCode is contract init code which reverts immediately.
+var revertingCode = common.FromHex("0x60006000fd")
+
+func newBundleTx(t *testing.T, nonce uint64, to *common.Address, value *big.Int, tip int64, data []byte) *types.Transaction {
+	gas := params.TxGas
+	if to == nil {
+		gas = 100000
+	}
+	tx, err := types.SignNewTx(testBankKey, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(100 * params.GWei),
+		Gas:       gas,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestValidateBundle(t *testing.T) {
+	var (
+		head = &types.Header{Number: big.NewInt(10), GasLimit: params.GenesisGasLimit}
+		tx   = newBundleTx(t, 0, &testUserAddress, big.NewInt(1), params.GWei, nil)
+		blob = types.NewTx(&types.BlobTx{
+			ChainID:    uint256.MustFromBig(params.TestChainConfig.ChainID),
+			GasTipCap:  uint256.NewInt(1),
+			GasFeeCap:  uint256.NewInt(1),
+			BlobFeeCap: uint256.NewInt(1),
+			BlobHashes: []common.Hash{{0x01}},
+		})
+		unsigned = types.NewTx(&types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(1),
+			Gas:       params.TxGas,
+			To:        &testUserAddress,
+		})
+	)
+	noGas, _ := types.SignNewTx(testBankKey, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       params.TxGas - 1,
+		To:        &testUserAddress,
+	})
+	tests := []struct {
+		bundle *Bundle
+		err    error
+	}{
+		{&Bundle{BlockNumber: 11}, errEmptyBundle},
+		{&Bundle{Txs: make(types.Transactions, maxBundleTxs+1), BlockNumber: 11}, errBundleTooLarge},
+		{&Bundle{Txs: types.Transactions{tx}, BlockNumber: 10}, errBundleStale},
+		{&Bundle{Txs: types.Transactions{tx}, BlockNumber: 10 + maxBundleFutureBlocks + 1}, errBundleTooFar},
+		{&Bundle{Txs: types.Transactions{blob}, BlockNumber: 11}, errBundleBlobTx},
+		{&Bundle{Txs: types.Transactions{tx, unsigned}, BlockNumber: 11}, txpool.ErrInvalidSender},
+		{&Bundle{Txs: types.Transactions{noGas}, BlockNumber: 11}, core.ErrIntrinsicGas},
+		{&Bundle{Txs: types.Transactions{tx, tx}, BlockNumber: 12, MinTimestamp: 100, MaxTimestamp: 200}, nil},
+	}
+	for i, test := range tests {
+		if err := validateBundle(test.bundle, head, params.TestChainConfig); !errors.Is(err, test.err) {
+			t.Errorf("test %d: wrong error %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestBundleStore(t *testing.T) {
+	var (
+		store  = newBundleStore()
+		sender = common.Address{0x01}
+		tx     = newBundleTx(t, 0, &testUserAddress, big.NewInt(1), params.GWei, nil)
+	)
+	tests := []struct {
+		bundle *Bundle
+		err    error
+	}{
+		{&Bundle{Txs: types.Transactions{tx}, BlockNumber: 11}, nil},
+		{&Bundle{Txs: types.Transactions{tx}, BlockNumber: 11}, errBundleKnown},
+		{&Bundle{Txs: types.Transactions{tx, tx}, BlockNumber: 12, MinTimestamp: 100, MaxTimestamp: 200}, nil},
+	}
+	for i, test := range tests {
+		if err := store.add(test.bundle, sender, new(big.Int), 10); !errors.Is(err, test.err) {
+			t.Errorf("test %d: wrong error %v, want %v", i, err, test.err)
+		}
+	}
+	if n := len(store.pending(11, 0)); n != 1 {
+		t.Errorf("wrong number of bundles for block 11: %d", n)
+	}
+	for _, time := range []uint64{99, 201} {
+		if n := len(store.pending(12, time)); n != 0 {
+			t.Errorf("bundle returned outside its timestamp range (time %d)", time)
+		}
+	}
+	// Requesting the bundles of block 12 drops the ones of block 11.
+	if n := len(store.pending(12, 150)); n != 1 {
+		t.Errorf("wrong number of bundles for block 12: %d", n)
+	}
+	if n := len(store.bundles); n != 1 || store.senders[sender] != 1 {
+		t.Errorf("stale bundles not pruned, have %d", n)
+	}
+}
+
+// newBundle creates a bundle with a unique hash, the transaction isn't signed.
+func newBundle(nonce uint64, block uint64) *Bundle {
+	tx := types.NewTx(&types.LegacyTx{Nonce: nonce, Gas: params.TxGas, To: &testUserAddress})
+	return &Bundle{Txs: types.Transactions{tx}, BlockNumber: block}
+}
+
+func TestBundleStoreLimits(t *testing.T) {
+	store := newBundleStore()
+
+	// Every sender can only have a limited number of bundles.
+	sender := common.Address{0x01}
+	for i := 0; i < maxBundlesPerSender; i++ {
+		if err := store.add(newBundle(uint64(i), 11), sender, big.NewInt(1), 10); err != nil {
+			t.Fatalf("bundle %d rejected: %v", i, err)
+		}
+	}
+	if err := store.add(newBundle(maxBundlesPerSender, 11), sender, big.NewInt(1), 10); !errors.Is(err, errBundleSenderLimit) {
+		t.Fatalf("wrong error for bundle exceeding the sender limit: %v", err)
+	}
+	// Fill up the store. Bundles are valued by the index of their sender.
+	for i := maxBundlesPerSender; i < maxBundles; i++ {
+		sender := common.Address{0x02, byte(i), byte(i >> 8)}
+		if err := store.add(newBundle(uint64(i), 11), sender, big.NewInt(int64(i)), 10); err != nil {
+			t.Fatalf("bundle %d rejected: %v", i, err)
+		}
+	}
+	// A full store only accepts bundles more valuable than the cheapest one, which
+	// is evicted then.
+	if err := store.add(newBundle(maxBundles, 11), common.Address{0x03}, big.NewInt(1), 10); !errors.Is(err, errBundleStoreFull) {
+		t.Fatalf("wrong error for cheap bundle in full store: %v", err)
+	}
+	if err := store.add(newBundle(maxBundles, 11), common.Address{0x03}, big.NewInt(2), 10); err != nil {
+		t.Fatalf("valuable bundle rejected: %v", err)
+	}
+	if len(store.bundles) != maxBundles || store.senders[sender] != maxBundlesPerSender-1 {
+		t.Fatalf("cheapest bundle not evicted: %d bundles, %d of sender", len(store.bundles), store.senders[sender])
+	}
+}
+
+func TestBuildPayloadWithBundles(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	w, b := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), db, 0)
+	defer w.close()
+
+	// The first bundle funds the user account, which then pays back the bank. It
+	// only works in order, and conflicts with the pool transaction of the bank.
+	fund := newBundleTx(t, 0, &testUserAddress, big.NewInt(params.Ether/10), 10*params.GWei, nil)
+	refund, _ := types.SignNewTx(testUserKey, types.LatestSigner(params.TestChainConfig), &types.DynamicFeeTx{
+		ChainID:   params.TestChainConfig.ChainID,
+		Nonce:     0,
+		GasTipCap: big.NewInt(10 * params.GWei),
+		GasFeeCap: big.NewInt(100 * params.GWei),
+		Gas:       params.TxGas,
+		To:        &testBankAddress,
+		Value:     big.NewInt(1000),
+	})
+	// The second one reverts but is allowed to, it pays less than the first one
+	// and can't be included after it.
+	revert := newBundleTx(t, 0, nil, nil, params.GWei, revertingCode)
+	// The third one reverts without being allowed to.
+	revert2 := newBundleTx(t, 1, nil, nil, 100*params.GWei, revertingCode)
+
+	bundles := []*Bundle{
+		{Txs: types.Transactions{fund, refund}, BlockNumber: 1},
+		{Txs: types.Transactions{revert}, BlockNumber: 1, RevertingTxHashes: []common.Hash{revert.Hash()}},
+		{Txs: types.Transactions{fund, revert2}, BlockNumber: 1},
+	}
+	for i, bundle := range bundles {
+		if err := w.addBundle(context.Background(), bundle); err != nil {
+			t.Fatalf("bundle %d rejected: %v", i, err)
+		}
+	}
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:       b.chain.CurrentBlock().Hash(),
+		Timestamp:    uint64(time.Now().Unix()),
+		FeeRecipient: common.HexToAddress("0xdeadbeef"),
+	})
+	if err != nil {
+		t.Fatalf("failed to build payload: %v", err)
+	}
+	txs := payload.ResolveFull().ExecutionPayload.Transactions
+
+	// The bundle simulations are kept for rebuilding the payload.
+	if n := len(w.bundles.sims); n != len(bundles) {
+		t.Errorf("wrong number of cached simulations: %d", n)
+	}
+	want := types.Transactions{fund, refund}
+	if len(txs) != len(want) {
+		t.Fatalf("wrong number of transactions: %d, want %d", len(txs), len(want))
+	}
+	for i, enc := range txs {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			t.Fatal(err)
+		}
+		if tx.Hash() != want[i].Hash() {
+			t.Errorf("transaction %d: have %v, want %v", i, tx.Hash(), want[i].Hash())
+		}
+	}
+	// Bundle transactions must not leak into the pool.
+	for _, tx := range []*types.Transaction{fund, refund, revert, revert2} {
+		if b.txPool.Has(tx.Hash()) {
+			t.Errorf("bundle transaction %v added to the pool", tx.Hash())
+		}
+	}
+}
+
+func TestCallBundle(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	w, b := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), db, 0)
+	defer w.close()
+
+	fund := newBundleTx(t, 0, &testUserAddress, big.NewInt(1000), params.GWei, nil)
+	revert := newBundleTx(t, 1, nil, nil, params.GWei, revertingCode)
+	coinbase := common.HexToAddress("0xdeadbeef")
+	result, err := w.callBundle(context.Background(), &Bundle{Txs: types.Transactions{fund, revert}}, b.chain.CurrentBlock().Hash(), 0, coinbase)
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	if len(result.Txs) != 2 || result.Txs[0].Reverted || !result.Txs[1].Reverted {
+		t.Fatalf("wrong transaction results: %+v", result.Txs)
+	}
+	if result.GasUsed != result.Txs[0].GasUsed+result.Txs[1].GasUsed || result.Txs[0].GasUsed != params.TxGas {
+		t.Errorf("wrong gas used %d", result.GasUsed)
+	}
+	if want := new(big.Int).SetUint64(result.GasUsed * params.GWei); result.CoinbaseDiff.Cmp(want) != 0 {
+		t.Errorf("wrong coinbase diff %v, want %v", result.CoinbaseDiff, want)
+	}
+	// A transaction which can't be applied fails the call.
+	if _, err := w.callBundle(context.Background(), &Bundle{Txs: types.Transactions{revert}}, b.chain.CurrentBlock().Hash(), 0, coinbase); err == nil {
+		t.Error("expected nonce error")
+	}
+	// The execution is aborted when the context is cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.callBundle(ctx, &Bundle{Txs: types.Transactions{fund}}, b.chain.CurrentBlock().Hash(), 0, coinbase); !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error for cancelled call: %v", err)
+	}
+	// So is the simulation of a submitted bundle, which is not stored then.
+	if err := w.addBundle(ctx, &Bundle{Txs: types.Transactions{fund}, BlockNumber: 1}); !errors.Is(err, context.Canceled) {
+		t.Errorf("wrong error for cancelled submission: %v", err)
+	}
+	if n := len(w.bundles.bundles); n != 0 {
+		t.Errorf("cancelled bundle stored, %d bundles", n)
+	}
+}
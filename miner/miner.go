@@ -18,6 +18,7 @@
 package miner
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"sync"
@@ -53,6 +54,12 @@ type Config struct {
 	Recommit  time.Duration  // The time interval for miner to re-create mining work.
 
 	NewPayloadTimeout time.Duration // The maximum time allowance for creating a new payload
+
+	Bundles bool `toml:",omitempty"` // Whether private bundles are accepted via eth_sendBundle
+
+	TxOrdering        string           `toml:",omitempty"` // Name of the built-in transaction ordering policy
+	PriorityAddresses []common.Address `toml:",omitempty"` // Senders included first by the priority ordering policy
+	OrderingPolicy    TxOrderingPolicy `toml:"-"`          // Custom ordering policy, overrides TxOrdering if set
 }
 
 // DefaultConfig contains default settings for miner.
@@ -240,6 +247,23 @@ func (miner *Miner) SubscribePendingLogs(ch chan<- []*types.Log) event.Subscript
 	return miner.worker.pendingLogsFeed.Subscribe(ch)
 }
 
+// SendBundle submits a bundle for inclusion in the block it targets. Bundles are
+// kept by the miner only, they are never added to the transaction pool. They are
+// only included in payloads built for the consensus client, never in the pending
+// block. The bundle is simulated on submission, which is aborted when ctx is
+// cancelled.
+func (miner *Miner) SendBundle(ctx context.Context, bundle *Bundle) error {
+	return miner.worker.addBundle(ctx, bundle)
+}
+
+// CallBundle executes a bundle on top of the given parent block and returns the
+// outcome without including it anywhere. If timestamp is not after the parent's,
+// the parent timestamp plus one is used. A zero coinbase selects the etherbase.
+// The execution is aborted when ctx is cancelled.
+func (miner *Miner) CallBundle(ctx context.Context, bundle *Bundle, parent common.Hash, timestamp uint64, coinbase common.Address) (*BundleResult, error) {
+	return miner.worker.callBundle(ctx, bundle, parent, timestamp, coinbase)
+}
+
 // BuildPayload builds the payload according to the provided parameters.
 func (miner *Miner) BuildPayload(args *BuildPayloadArgs) (*Payload, error) {
 	return miner.worker.buildPayload(args)
@@ -17,7 +17,9 @@
 package miner
 
 import (
+	"bytes"
 	"container/heap"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -26,11 +28,83 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// Names of the built-in transaction ordering policies.
+const (
+	TxOrderingPrice    = "price"    // Highest effective tip first, ties broken by arrival (default)
+	TxOrderingFIFO     = "fifo"     // Strictly by arrival time, regardless of the tip
+	TxOrderingFair     = "fair"     // Round-robin across senders, by tip within a round
+	TxOrderingPriority = "priority" // Whitelisted senders first, then everyone else by tip
+)
+
+// TxOrdering is an iterator over a set of pending transactions, yielding them
+// in the order they should be included into a block. Implementations must
+// honour the nonce order of the transactions from any single account.
+type TxOrdering interface {
+	// Peek returns the next transaction to include, or nil if the set is exhausted.
+	Peek() *txpool.LazyTransaction
+
+	// Shift replaces the current head with the next transaction from the same
+	// account. It is called when the head was included (or skipped but subsequent
+	// transactions of the account may still be valid).
+	Shift()
+
+	// Pop removes the current head, *not* replacing it with the next one from
+	// the same account. It is called when the head cannot be executed and hence
+	// all subsequent ones from the same account should be discarded.
+	Pop()
+}
+
+// TxOrderingPolicy creates the transaction ordering used by the worker when
+// filling a block with pending transactions.
+type TxOrderingPolicy interface {
+	// NewOrdering creates an ordering over the given per-account, nonce-sorted
+	// transaction lists. The input map is reowned by the ordering, the caller
+	// should not interact with it any more after providing it.
+	NewOrdering(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering
+}
+
+// TxOrderingFunc is an adapter to allow the use of ordinary functions as
+// transaction ordering policies.
+type TxOrderingFunc func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering
+
+// NewOrdering implements TxOrderingPolicy, calling f.
+func (f TxOrderingFunc) NewOrdering(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering {
+	return f(signer, txs, baseFee)
+}
+
+// NewTxOrderingPolicy returns the built-in ordering policy with the given name.
+// The priority addresses are only used by the priority policy, which requires
+// at least one of them.
+func NewTxOrderingPolicy(name string, priority []common.Address) (TxOrderingPolicy, error) {
+	switch name {
+	case "", TxOrderingPrice:
+		return TxOrderingFunc(func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering {
+			return newOrderedTransactions(signer, txs, baseFee, byPriceAndTime)
+		}), nil
+	case TxOrderingFIFO:
+		return TxOrderingFunc(func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering {
+			return newOrderedTransactions(signer, txs, baseFee, byTime)
+		}), nil
+	case TxOrderingFair:
+		return TxOrderingFunc(func(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering {
+			return newOrderedTransactions(signer, txs, baseFee, byRoundAndPrice)
+		}), nil
+	case TxOrderingPriority:
+		if len(priority) == 0 {
+			return nil, fmt.Errorf("ordering policy %q requires priority addresses", name)
+		}
+		return newPriorityPolicy(priority), nil
+	default:
+		return nil, fmt.Errorf("unknown transaction ordering policy %q", name)
+	}
+}
+
 // txWithMinerFee wraps a transaction with its gas price or effective miner gasTipCap
 type txWithMinerFee struct {
-	tx   *txpool.LazyTransaction
-	from common.Address
-	fees *big.Int
+	tx    *txpool.LazyTransaction
+	from  common.Address
+	fees  *big.Int
+	round int // Number of transactions already yielded from the same account
 }
 
 // newTxWithMinerFee creates a wrapped transaction, calculating the effective
@@ -51,66 +125,92 @@ func newTxWithMinerFee(tx *txpool.LazyTransaction, from common.Address, baseFee
 	}, nil
 }
 
-// txByPriceAndTime implements both the sort and the heap interface, making it useful
-// for all at once sorting as well as individually adding and removing elements.
-type txByPriceAndTime []*txWithMinerFee
+// txLess reports whether transaction a should be included before b.
+type txLess func(a, b *txWithMinerFee) bool
 
-func (s txByPriceAndTime) Len() int { return len(s) }
-func (s txByPriceAndTime) Less(i, j int) bool {
-	// If the prices are equal, use the time the transaction was first seen for
-	// deterministic sorting
-	cmp := s[i].fees.Cmp(s[j].fees)
+// byPriceAndTime orders by effective tip, using the time the transaction was
+// first seen for deterministic sorting if the prices are equal.
+func byPriceAndTime(a, b *txWithMinerFee) bool {
+	cmp := a.fees.Cmp(b.fees)
 	if cmp == 0 {
-		return s[i].tx.Time.Before(s[j].tx.Time)
+		return a.tx.Time.Before(b.tx.Time)
 	}
 	return cmp > 0
 }
-func (s txByPriceAndTime) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-func (s *txByPriceAndTime) Push(x interface{}) {
-	*s = append(*s, x.(*txWithMinerFee))
+// byTime orders by the time the transaction was first seen, using the hash for
+// deterministic sorting if the times are equal.
+func byTime(a, b *txWithMinerFee) bool {
+	if !a.tx.Time.Equal(b.tx.Time) {
+		return a.tx.Time.Before(b.tx.Time)
+	}
+	return bytes.Compare(a.tx.Hash[:], b.tx.Hash[:]) < 0
+}
+
+// byRoundAndPrice orders by the number of transactions already included from
+// the sender, so every account gets a slot before any gets a second one.
+func byRoundAndPrice(a, b *txWithMinerFee) bool {
+	if a.round != b.round {
+		return a.round < b.round
+	}
+	return byPriceAndTime(a, b)
+}
+
+// txHeads implements both the sort and the heap interface over the account
+// heads, ordered by a configurable comparator.
+type txHeads struct {
+	list []*txWithMinerFee
+	less txLess
 }
 
-func (s *txByPriceAndTime) Pop() interface{} {
-	old := *s
+func (s *txHeads) Len() int           { return len(s.list) }
+func (s *txHeads) Less(i, j int) bool { return s.less(s.list[i], s.list[j]) }
+func (s *txHeads) Swap(i, j int)      { s.list[i], s.list[j] = s.list[j], s.list[i] }
+
+func (s *txHeads) Push(x interface{}) {
+	s.list = append(s.list, x.(*txWithMinerFee))
+}
+
+func (s *txHeads) Pop() interface{} {
+	old := s.list
 	n := len(old)
 	x := old[n-1]
 	old[n-1] = nil
-	*s = old[0 : n-1]
+	s.list = old[0 : n-1]
 	return x
 }
 
-// transactionsByPriceAndNonce represents a set of transactions that can return
-// transactions in a profit-maximizing sorted order, while supporting removing
-// entire batches of transactions for non-executable accounts.
-type transactionsByPriceAndNonce struct {
+// orderedTransactions represents a set of transactions that can return
+// transactions in a sorted order, while supporting removing entire batches of
+// transactions for non-executable accounts.
+type orderedTransactions struct {
 	txs     map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
-	heads   txByPriceAndTime                             // Next transaction for each unique account (price heap)
+	heads   *txHeads                                     // Next transaction for each unique account
 	signer  types.Signer                                 // Signer for the set of transactions
 	baseFee *big.Int                                     // Current base fee
 }
 
-// newTransactionsByPriceAndNonce creates a transaction set that can retrieve
-// price sorted transactions in a nonce-honouring way.
+// newOrderedTransactions creates a transaction set that can retrieve transactions
+// sorted by the given comparator in a nonce-honouring way.
 //
 // Note, the input map is reowned so the caller should not interact any more with
 // if after providing it to the constructor.
-func newTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) *transactionsByPriceAndNonce {
-	// Initialize a price and received time based heap with the head transactions
-	heads := make(txByPriceAndTime, 0, len(txs))
+func newOrderedTransactions(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int, less txLess) *orderedTransactions {
+	// Initialize a heap with the head transactions
+	heads := &txHeads{list: make([]*txWithMinerFee, 0, len(txs)), less: less}
 	for from, accTxs := range txs {
 		wrapped, err := newTxWithMinerFee(accTxs[0], from, baseFee)
 		if err != nil {
 			delete(txs, from)
 			continue
 		}
-		heads = append(heads, wrapped)
+		heads.list = append(heads.list, wrapped)
 		txs[from] = accTxs[1:]
 	}
-	heap.Init(&heads)
+	heap.Init(heads)
 
 	// Assemble and return the transaction set
-	return &transactionsByPriceAndNonce{
+	return &orderedTransactions{
 		txs:     txs,
 		heads:   heads,
 		signer:  signer,
@@ -118,30 +218,106 @@ func newTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address]
 	}
 }
 
-// Peek returns the next transaction by price.
-func (t *transactionsByPriceAndNonce) Peek() *txpool.LazyTransaction {
-	if len(t.heads) == 0 {
+// newTransactionsByPriceAndNonce creates a transaction set that can retrieve
+// price sorted transactions in a nonce-honouring way.
+func newTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) *orderedTransactions {
+	return newOrderedTransactions(signer, txs, baseFee, byPriceAndTime)
+}
+
+// Peek returns the next transaction in order.
+func (t *orderedTransactions) Peek() *txpool.LazyTransaction {
+	if len(t.heads.list) == 0 {
 		return nil
 	}
-	return t.heads[0].tx
+	return t.heads.list[0].tx
 }
 
 // Shift replaces the current best head with the next one from the same account.
-func (t *transactionsByPriceAndNonce) Shift() {
-	acc := t.heads[0].from
-	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.baseFee); err == nil {
-			t.heads[0], t.txs[acc] = wrapped, txs[1:]
-			heap.Fix(&t.heads, 0)
+func (t *orderedTransactions) Shift() {
+	head := t.heads.list[0]
+	if txs, ok := t.txs[head.from]; ok && len(txs) > 0 {
+		if wrapped, err := newTxWithMinerFee(txs[0], head.from, t.baseFee); err == nil {
+			wrapped.round = head.round + 1
+			t.heads.list[0], t.txs[head.from] = wrapped, txs[1:]
+			heap.Fix(t.heads, 0)
 			return
 		}
 	}
-	heap.Pop(&t.heads)
+	heap.Pop(t.heads)
 }
 
 // Pop removes the best transaction, *not* replacing it with the next one from
 // the same account. This should be used when a transaction cannot be executed
 // and hence all subsequent ones should be discarded from the same account.
-func (t *transactionsByPriceAndNonce) Pop() {
-	heap.Pop(&t.heads)
+func (t *orderedTransactions) Pop() {
+	heap.Pop(t.heads)
+}
+
+// priorityPolicy includes the transactions of a set of whitelisted senders
+// ahead of everyone else, ordering both groups by price.
+type priorityPolicy struct {
+	accounts map[common.Address]struct{}
+}
+
+func newPriorityPolicy(accounts []common.Address) *priorityPolicy {
+	p := &priorityPolicy{accounts: make(map[common.Address]struct{}, len(accounts))}
+	for _, addr := range accounts {
+		p.accounts[addr] = struct{}{}
+	}
+	return p
+}
+
+// NewOrdering implements TxOrderingPolicy, splitting the whitelisted senders
+// out of the pending set.
+func (p *priorityPolicy) NewOrdering(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering {
+	prio := make(map[common.Address][]*txpool.LazyTransaction)
+	for addr := range p.accounts {
+		if accTxs, ok := txs[addr]; ok {
+			prio[addr] = accTxs
+			delete(txs, addr)
+		}
+	}
+	return &chainedOrdering{orderings: []TxOrdering{
+		newOrderedTransactions(signer, prio, baseFee, byPriceAndTime),
+		newOrderedTransactions(signer, txs, baseFee, byPriceAndTime),
+	}}
+}
+
+// chainedOrdering exhausts a list of orderings one after the other.
+type chainedOrdering struct {
+	orderings []TxOrdering
+}
+
+// current drops the exhausted orderings from the front of the chain and returns
+// the active one, or nil if all of them are exhausted.
+func (c *chainedOrdering) current() TxOrdering {
+	for len(c.orderings) > 0 {
+		if c.orderings[0].Peek() != nil {
+			return c.orderings[0]
+		}
+		c.orderings = c.orderings[1:]
+	}
+	return nil
+}
+
+// Peek implements TxOrdering.
+func (c *chainedOrdering) Peek() *txpool.LazyTransaction {
+	if cur := c.current(); cur != nil {
+		return cur.Peek()
+	}
+	return nil
+}
+
+// Shift implements TxOrdering.
+func (c *chainedOrdering) Shift() {
+	if cur := c.current(); cur != nil {
+		cur.Shift()
+	}
+}
+
+// Pop implements TxOrdering.
+func (c *chainedOrdering) Pop() {
+	if cur := c.current(); cur != nil {
+		cur.Pop()
+	}
 }
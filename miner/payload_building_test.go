@@ -17,7 +17,10 @@
 package miner
 
 import (
+	"bytes"
+	"math/big"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -25,7 +28,9 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -81,6 +86,188 @@ func TestBuildPayload(t *testing.T) {
 	}
 }
 
+// bySenderOrdering is a custom ordering used in the tests, including all the
+// transactions of a sender before moving on to the next, by address.
+type bySenderOrdering struct {
+	senders []common.Address
+	txs     map[common.Address][]*txpool.LazyTransaction
+}
+
+func newBySenderOrdering(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) TxOrdering {
+	o := &bySenderOrdering{txs: txs}
+	for addr := range txs {
+		o.senders = append(o.senders, addr)
+	}
+	sort.Slice(o.senders, func(i, j int) bool {
+		return bytes.Compare(o.senders[i][:], o.senders[j][:]) < 0
+	})
+	return o
+}
+
+func (o *bySenderOrdering) Peek() *txpool.LazyTransaction {
+	if len(o.senders) == 0 {
+		return nil
+	}
+	return o.txs[o.senders[0]][0]
+}
+
+func (o *bySenderOrdering) Shift() {
+	if txs := o.txs[o.senders[0]][1:]; len(txs) > 0 {
+		o.txs[o.senders[0]] = txs
+		return
+	}
+	o.Pop()
+}
+
+func (o *bySenderOrdering) Pop() {
+	o.senders = o.senders[1:]
+}
+
+func TestBuildPayloadOrdering(t *testing.T) {
+	var (
+		signer  = types.LatestSigner(params.TestChainConfig)
+		senders = make([]common.Address, len(testSenderKeys))
+		start   = time.Now()
+	)
+	for i, key := range testSenderKeys {
+		senders[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	// Create the transactions from three senders: a cheap early one, an
+	// expensive late one and a single one with a medium tip arriving last.
+	type txDef struct {
+		sender int
+		nonce  uint64
+		tip    int64
+	}
+	defs := []txDef{{0, 0, 1}, {0, 1, 1}, {1, 0, 3}, {1, 1, 3}, {2, 0, 2}}
+
+	txs := make([]*types.Transaction, len(defs))
+	for i, def := range defs {
+		txs[i] = types.MustSignNewTx(testSenderKeys[def.sender], signer, &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			Nonce:     def.nonce,
+			To:        &testUserAddress,
+			Value:     big.NewInt(1000),
+			Gas:       params.TxGas,
+			GasTipCap: big.NewInt(def.tip * params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+		})
+		txs[i].SetTime(start.Add(time.Duration(i) * time.Second))
+	}
+	// The custom ordering includes the senders by address, so the expectation
+	// depends on the generated keys.
+	sorted := []int{0, 1, 2, 3, 4}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(senders[defs[sorted[i]].sender][:], senders[defs[sorted[j]].sender][:]) < 0
+	})
+	tests := []struct {
+		name   string
+		config Config
+		want   []int
+	}{
+		{"price", Config{TxOrdering: TxOrderingPrice}, []int{2, 3, 4, 0, 1}},
+		{"default", Config{}, []int{2, 3, 4, 0, 1}},
+		{"fifo", Config{TxOrdering: TxOrderingFIFO}, []int{0, 1, 2, 3, 4}},
+		{"fair", Config{TxOrdering: TxOrderingFair}, []int{2, 4, 0, 3, 1}},
+		{"priority", Config{TxOrdering: TxOrderingPriority, PriorityAddresses: []common.Address{senders[2]}}, []int{4, 2, 3, 0, 1}},
+		{"invalid", Config{TxOrdering: "unknown"}, []int{2, 3, 4, 0, 1}},
+		{"custom", Config{TxOrdering: TxOrderingFIFO, OrderingPolicy: TxOrderingFunc(newBySenderOrdering)}, sorted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := *testConfig
+			config.TxOrdering, config.PriorityAddresses, config.OrderingPolicy = tt.config.TxOrdering, tt.config.PriorityAddresses, tt.config.OrderingPolicy
+
+			w, b := newTestWorkerWithConfig(t, &config, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+			defer w.close()
+
+			for _, err := range b.txPool.Add(txs, false, true) {
+				if err != nil {
+					t.Fatalf("failed to add transaction: %v", err)
+				}
+			}
+			payload, err := w.buildPayload(&BuildPayloadArgs{
+				Parent:       b.chain.CurrentBlock().Hash(),
+				Timestamp:    uint64(time.Now().Unix()),
+				FeeRecipient: common.HexToAddress("0xdeadbeef"),
+			})
+			if err != nil {
+				t.Fatalf("failed to build payload: %v", err)
+			}
+			included := payload.ResolveFull().ExecutionPayload.Transactions
+			if len(included) != len(tt.want) {
+				t.Fatalf("transaction count mismatch: have %d, want %d", len(included), len(tt.want))
+			}
+			for i, enc := range included {
+				var tx types.Transaction
+				if err := tx.UnmarshalBinary(enc); err != nil {
+					t.Fatalf("failed to decode transaction %d: %v", i, err)
+				}
+				if want := txs[tt.want[i]].Hash(); tx.Hash() != want {
+					t.Errorf("transaction %d mismatch: have %x, want %x (tx %d)", i, tx.Hash(), want, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Tests that the conditions of conditional transactions are checked against the
+// block being built before inclusion.
+func TestBuildPayloadConditional(t *testing.T) {
+	w, b := newTestWorkerWithConfig(t, testConfig, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	defer w.close()
+
+	signer := types.LatestSigner(params.TestChainConfig)
+	txs := make([]*types.Transaction, len(testSenderKeys))
+	for i, key := range testSenderKeys {
+		txs[i] = types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   params.TestChainConfig.ChainID,
+			To:        &testUserAddress,
+			Value:     big.NewInt(1000),
+			Gas:       params.TxGas,
+			GasTipCap: big.NewInt(params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+		})
+	}
+	// A transaction not yet valid, one depending on an unchanged storage root
+	// and a plain one
+	future := uint64(5)
+	if err := b.txPool.AddConditional(txs[0], &txpool.TxConditional{BlockNumberMin: &future}, false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	known := &txpool.TxConditional{KnownAccounts: map[common.Address]txpool.KnownAccount{
+		testUserAddress: {StorageRoot: &types.EmptyRootHash},
+	}}
+	if err := b.txPool.AddConditional(txs[1], known, false); err != nil {
+		t.Fatalf("failed to add conditional transaction: %v", err)
+	}
+	if err := b.txPool.Add(txs[2:], false, true)[0]; err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	payload, err := w.buildPayload(&BuildPayloadArgs{
+		Parent:       b.chain.CurrentBlock().Hash(),
+		Timestamp:    uint64(time.Now().Unix()),
+		FeeRecipient: common.HexToAddress("0xdeadbeef"),
+	})
+	if err != nil {
+		t.Fatalf("failed to build payload: %v", err)
+	}
+	included := make(map[common.Hash]bool)
+	for _, enc := range payload.ResolveFull().ExecutionPayload.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("failed to decode transaction: %v", err)
+		}
+		included[tx.Hash()] = true
+	}
+	if len(included) != 2 || !included[txs[1].Hash()] || !included[txs[2].Hash()] {
+		t.Errorf("included transaction set mismatch: have %v", included)
+	}
+	if status := b.txPool.Status(txs[0].Hash()); status != txpool.TxStatusPending {
+		t.Errorf("not yet valid transaction status mismatch: have %v, want %v", status, txpool.TxStatusPending)
+	}
+}
+
 func TestPayloadId(t *testing.T) {
 	ids := make(map[string]int)
 	for i, tt := range []*BuildPayloadArgs{
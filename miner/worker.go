@@ -214,6 +214,10 @@ type worker struct {
 	snapshotReceipts types.Receipts
 	snapshotState    *state.StateDB
 
+	bundles    *bundleStore     // Privately submitted bundles, merged ahead of pool transactions
+	bundleSims chan struct{}    // Semaphore serialising the simulations of submitted bundles
+	ordering   TxOrderingPolicy // Policy ordering the pool transactions within a block
+
 	// atomic status counters
 	running atomic.Bool  // The indicator whether the consensus engine is running or not.
 	newTxs  atomic.Int32 // New arrival transaction count since last sealing work submitting.
@@ -262,6 +266,8 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		exitCh:             make(chan struct{}),
 		resubmitIntervalCh: make(chan time.Duration),
 		resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
+		bundles:            newBundleStore(),
+		bundleSims:         make(chan struct{}, 1),
 	}
 	// Subscribe for transaction insertion events (whether from network or resurrects)
 	worker.txsSub = eth.TxPool().SubscribeTransactions(worker.txsCh, true)
@@ -287,6 +293,17 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	}
 	worker.newpayloadTimeout = newpayloadTimeout
 
+	// Resolve the transaction ordering policy, falling back to price ordering.
+	worker.ordering = worker.config.OrderingPolicy
+	if worker.ordering == nil {
+		ordering, err := NewTxOrderingPolicy(worker.config.TxOrdering, worker.config.PriorityAddresses)
+		if err != nil {
+			log.Warn("Sanitizing transaction ordering policy", "provided", worker.config.TxOrdering, "updated", TxOrderingPrice, "err", err)
+			ordering, _ = NewTxOrderingPolicy(TxOrderingPrice, nil)
+		}
+		worker.ordering = ordering
+	}
+
 	worker.wg.Add(4)
 	go worker.mainLoop()
 	go worker.newWorkLoop(recommit)
@@ -552,7 +569,7 @@ func (w *worker) mainLoop() {
 						BlobGas:   tx.BlobGas(),
 					})
 				}
-				txset := newTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
+				txset := w.ordering.NewOrdering(w.current.signer, txs, w.current.header.BaseFee)
 				tcount := w.current.tcount
 				w.commitTransactions(w.current, txset, nil)
 
@@ -792,7 +809,7 @@ func (w *worker) applyTransaction(env *environment, tx *types.Transaction) (*typ
 	return receipt, err
 }
 
-func (w *worker) commitTransactions(env *environment, txs *transactionsByPriceAndNonce, interrupt *atomic.Int32) error {
+func (w *worker) commitTransactions(env *environment, txs TxOrdering, interrupt *atomic.Int32) error {
 	gasLimit := env.header.GasLimit
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(gasLimit)
@@ -845,6 +862,18 @@ func (w *worker) commitTransactions(env *environment, txs *transactionsByPriceAn
 			txs.Pop()
 			continue
 		}
+		// Check the conditions of conditional transactions against the block being
+		// built, skipping the account if they don't hold (yet).
+		if cond := w.eth.TxPool().Conditional(ltx.Hash); cond != nil {
+			if cond.HasStorageRoots() {
+				env.state.IntermediateRoot(w.chainConfig.IsEIP158(env.header.Number))
+			}
+			if err := cond.Check(env.header.Number.Uint64(), env.header.Time, env.state); err != nil {
+				log.Trace("Ignoring conditional transaction", "hash", ltx.Hash, "err", err)
+				txs.Pop()
+				continue
+			}
+		}
 		// Start executing the transaction
 		env.state.SetTxContext(tx.Hash(), env.tcount)
 
@@ -982,8 +1011,8 @@ func (w *worker) prepareWork(genParams *generateParams) (*environment, error) {
 }
 
 // fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
+// into the given sealing block. The transaction ordering is determined by the
+// configured ordering policy.
 func (w *worker) fillTransactions(interrupt *atomic.Int32, env *environment) error {
 	pending := w.eth.TxPool().Pending(true)
 
@@ -998,13 +1027,13 @@ func (w *worker) fillTransactions(interrupt *atomic.Int32, env *environment) err
 
 	// Fill the block with all available pending transactions.
 	if len(localTxs) > 0 {
-		txs := newTransactionsByPriceAndNonce(env.signer, localTxs, env.header.BaseFee)
+		txs := w.ordering.NewOrdering(env.signer, localTxs, env.header.BaseFee)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
 	}
 	if len(remoteTxs) > 0 {
-		txs := newTransactionsByPriceAndNonce(env.signer, remoteTxs, env.header.BaseFee)
+		txs := w.ordering.NewOrdering(env.signer, remoteTxs, env.header.BaseFee)
 		if err := w.commitTransactions(env, txs, interrupt); err != nil {
 			return err
 		}
@@ -1027,7 +1056,12 @@ func (w *worker) generateWork(params *generateParams) *newPayloadResult {
 		})
 		defer timer.Stop()
 
-		err := w.fillTransactions(interrupt, work)
+		// Bundles are only merged into payloads, not into the blocks built by
+		// commitWork, which also serve as the publicly visible pending block.
+		err := w.commitBundles(work, interrupt)
+		if err == nil {
+			err = w.fillTransactions(interrupt, work)
+		}
 		if errors.Is(err, errBlockInterruptedByTimeout) {
 			log.Warn("Block building is interrupted", "allowance", common.PrettyDuration(w.newpayloadTimeout))
 		}
@@ -17,6 +17,7 @@
 package miner
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"sync/atomic"
 	"testing"
@@ -62,6 +63,9 @@ var (
 	testUserKey, _  = crypto.GenerateKey()
 	testUserAddress = crypto.PubkeyToAddress(testUserKey.PublicKey)
 
+	// Additional funded senders for tests needing multiple accounts
+	testSenderKeys = []*ecdsa.PrivateKey{newTestKey(), newTestKey(), newTestKey()}
+
 	// Test transactions
 	pendingTxs []*types.Transaction
 	newTxs     []*types.Transaction
@@ -72,6 +76,11 @@ var (
 	}
 )
 
+func newTestKey() *ecdsa.PrivateKey {
+	key, _ := crypto.GenerateKey()
+	return key
+}
+
 func init() {
 	testTxPoolConfig = legacypool.DefaultConfig
 	testTxPoolConfig.Journal = ""
@@ -118,6 +127,9 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 		Config: chainConfig,
 		Alloc:  core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}},
 	}
+	for _, key := range testSenderKeys {
+		gspec.Alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: testBankFunds}
+	}
 	switch e := engine.(type) {
 	case *clique.Clique:
 		gspec.ExtraData = make([]byte, 32+common.AddressLength+crypto.SignatureLength)
@@ -159,9 +171,16 @@ func (b *testWorkerBackend) newRandomTx(creation bool) *types.Transaction {
 }
 
 func newTestWorker(t *testing.T, chainConfig *params.ChainConfig, engine consensus.Engine, db ethdb.Database, blocks int) (*worker, *testWorkerBackend) {
-	backend := newTestWorkerBackend(t, chainConfig, engine, db, blocks)
+	w, backend := newTestWorkerWithConfig(t, testConfig, chainConfig, engine, db, blocks)
 	backend.txPool.Add(pendingTxs, true, false)
-	w := newWorker(testConfig, chainConfig, engine, backend, new(event.TypeMux), nil, false)
+	return w, backend
+}
+
+// newTestWorkerWithConfig creates a worker with the given config on top of an
+// empty transaction pool.
+func newTestWorkerWithConfig(t *testing.T, config *Config, chainConfig *params.ChainConfig, engine consensus.Engine, db ethdb.Database, blocks int) (*worker, *testWorkerBackend) {
+	backend := newTestWorkerBackend(t, chainConfig, engine, db, blocks)
+	w := newWorker(config, chainConfig, engine, backend, new(event.TypeMux), nil, false)
 	w.setEtherbase(testBankAddress)
 	return w, backend
 }
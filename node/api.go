@@ -19,6 +19,7 @@ package node
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -179,6 +180,7 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			limiter:                api.node.rpcLimiter,
 		},
 	}
 	if cors != nil {
@@ -257,6 +259,7 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			limiter:                api.node.rpcLimiter,
 		},
 	}
 	if apis != nil {
@@ -315,6 +318,162 @@ func (api *adminAPI) NodeInfo() (*p2p.NodeInfo, error) {
 	return server.NodeInfo(), nil
 }
 
+// Reputations retrieves the score and ban status of all nodes with a non-neutral
+// reputation.
+func (api *adminAPI) Reputations() ([]*p2p.ReputationInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Reputations(), nil
+}
+
+// ResetReputation clears the reputation of a node, lifting any ban. The node can
+// be given as an enode URL or as a hex node ID.
+func (api *adminAPI) ResetReputation(node string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	server.ResetReputation(id)
+	return true, nil
+}
+
+// parseNodeID parses a node given as an enode URL or as a hex node ID.
+func parseNodeID(node string) (enode.ID, error) {
+	id, err := enode.ParseID(node)
+	if err != nil {
+		n, perr := enode.Parse(enode.ValidSchemes, node)
+		if perr != nil {
+			return enode.ID{}, fmt.Errorf("invalid node: %v", perr)
+		}
+		id = n.ID()
+	}
+	return id, nil
+}
+
+// BanPeer bans a node, given as an enode URL or hex node ID, until the ban is
+// lifted through UnbanPeer. The ban is kept across restarts.
+func (api *adminAPI) BanPeer(node string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if err := server.BanNode(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanPeer lifts the ban of a node. It returns false if the node wasn't banned.
+func (api *adminAPI) UnbanPeer(node string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	return server.UnbanNode(id), nil
+}
+
+// BanNetwork bans all nodes in an IP network given in CIDR notation, e.g.
+// "10.0.0.0/8". The ban is kept across restarts.
+func (api *adminAPI) BanNetwork(cidr string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, fmt.Errorf("invalid network: %v", err)
+	}
+	if err := server.BanNetwork(n); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnbanNetwork lifts the ban of an IP network. It returns false if the network
+// wasn't banned.
+func (api *adminAPI) UnbanNetwork(cidr string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, fmt.Errorf("invalid network: %v", err)
+	}
+	return server.UnbanNetwork(n), nil
+}
+
+// BanList retrieves the banned nodes and networks.
+func (api *adminAPI) BanList() (*p2p.BanList, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.BanList(), nil
+}
+
+// PeerLimits retrieves the peer limits of the node.
+func (api *adminAPI) PeerLimits() (*p2p.PeerLimits, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeerLimits(), nil
+}
+
+// SetMaxPeers changes the maximum number of peers. The new limit is kept across
+// restarts, overriding the configured value.
+func (api *adminAPI) SetMaxPeers(n int) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if err := server.SetMaxPeers(n); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetDialRatio changes the ratio of inbound to dialed connections. The new ratio
+// is kept across restarts, overriding the configured value.
+func (api *adminAPI) SetDialRatio(r int) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if err := server.SetDialRatio(r); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetProtocolQuota reserves n peer slots for peers supporting the given
+// subprotocol. A quota of zero removes the reservation.
+func (api *adminAPI) SetProtocolQuota(protocol string, n int) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if err := server.SetProtocolQuota(protocol, n); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // Datadir retrieves the current data directory the node is using.
 func (api *adminAPI) Datadir() string {
 	return api.node.DataDir()
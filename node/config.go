@@ -176,6 +176,23 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// GRPCHost is the host interface on which to start the gRPC gateway. If this
+	// field is empty, no gRPC endpoint will be started.
+	GRPCHost string
+
+	// GRPCPort is the TCP port number on which to start the gRPC gateway. The
+	// default zero value is valid and will pick a port number randomly.
+	GRPCPort int `toml:",omitempty"`
+
+	// GRPCModules is a list of API modules to expose via the gRPC gateway. If the
+	// module list is empty, the gateway shares the allowlist of HTTPModules.
+	GRPCModules []string `toml:",omitempty"`
+
+	// GRPCAuth requires calls to the gRPC gateway to carry a JWT token signed with
+	// the secret configured by JWTSecret. Authenticated APIs are also exposed by
+	// the gateway in this mode.
+	GRPCAuth bool `toml:",omitempty"`
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
@@ -204,6 +221,10 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// RPCLimits configures the rate limits and concurrency caps applied to
+	// calls arriving over the HTTP and WebSocket endpoints.
+	RPCLimits rpc.LimitConfig `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
@@ -34,6 +34,8 @@ const (
 	DefaultWSPort   = 8546        // Default TCP port for the websocket RPC server
 	DefaultAuthHost = "localhost" // Default host interface for the authenticated apis
 	DefaultAuthPort = 8551        // Default port for the authenticated apis
+	DefaultGRPCHost = "localhost" // Default host interface for the gRPC gateway
+	DefaultGRPCPort = 8547        // Default TCP port for the gRPC gateway
 )
 
 const (
@@ -63,6 +65,7 @@ var DefaultConfig = Config{
 	HTTPTimeouts:         rpc.DefaultHTTPTimeouts,
 	WSPort:               DefaultWSPort,
 	WSModules:            []string{"net", "web3"},
+	GRPCPort:             DefaultGRPCPort,
 	BatchRequestLimit:    1000,
 	BatchResponseMaxSize: 25 * 1000 * 1000,
 	GraphQLVirtualHosts:  []string{"localhost"},
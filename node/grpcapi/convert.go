@@ -0,0 +1,371 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package grpcapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// This file converts between the JSON encoding of the eth namespace and the
+// protobuf messages.
+
+// rpcHeader is the JSON encoding of a header. Unlike types.Header it accepts the
+// missing fields of pending blocks.
+type rpcHeader struct {
+	Hash             *common.Hash      `json:"hash"`
+	ParentHash       common.Hash       `json:"parentHash"`
+	UncleHash        common.Hash       `json:"sha3Uncles"`
+	Coinbase         *common.Address   `json:"miner"`
+	Root             common.Hash       `json:"stateRoot"`
+	TxHash           common.Hash       `json:"transactionsRoot"`
+	ReceiptHash      common.Hash       `json:"receiptsRoot"`
+	Bloom            types.Bloom       `json:"logsBloom"`
+	Difficulty       *hexutil.Big      `json:"difficulty"`
+	Number           *hexutil.Big      `json:"number"`
+	GasLimit         hexutil.Uint64    `json:"gasLimit"`
+	GasUsed          hexutil.Uint64    `json:"gasUsed"`
+	Time             hexutil.Uint64    `json:"timestamp"`
+	Extra            hexutil.Bytes     `json:"extraData"`
+	MixDigest        common.Hash       `json:"mixHash"`
+	Nonce            *types.BlockNonce `json:"nonce"`
+	BaseFee          *hexutil.Big      `json:"baseFeePerGas"`
+	WithdrawalsHash  *common.Hash      `json:"withdrawalsRoot"`
+	BlobGasUsed      *hexutil.Uint64   `json:"blobGasUsed"`
+	ExcessBlobGas    *hexutil.Uint64   `json:"excessBlobGas"`
+	ParentBeaconRoot *common.Hash      `json:"parentBeaconBlockRoot"`
+}
+
+type rpcBlock struct {
+	rpcHeader
+	Transactions []json.RawMessage   `json:"transactions"`
+	Uncles       []common.Hash       `json:"uncles"`
+	Withdrawals  []*types.Withdrawal `json:"withdrawals"`
+	Size         hexutil.Uint64      `json:"size"`
+}
+
+type rpcTransaction struct {
+	tx *types.Transaction
+	txExtraInfo
+}
+
+type txExtraInfo struct {
+	BlockNumber      *hexutil.Big    `json:"blockNumber"`
+	BlockHash        *common.Hash    `json:"blockHash"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
+	From             *common.Address `json:"from"`
+}
+
+func (tx *rpcTransaction) UnmarshalJSON(msg []byte) error {
+	if err := json.Unmarshal(msg, &tx.tx); err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, &tx.txExtraInfo)
+}
+
+type rpcReceipt struct {
+	types.Receipt
+	receiptExtraInfo
+}
+
+type receiptExtraInfo struct {
+	From *common.Address `json:"from"`
+	To   *common.Address `json:"to"`
+}
+
+func (r *rpcReceipt) UnmarshalJSON(msg []byte) error {
+	if err := json.Unmarshal(msg, &r.Receipt); err != nil {
+		return err
+	}
+	return json.Unmarshal(msg, &r.receiptExtraInfo)
+}
+
+func hashBytes(h *common.Hash) []byte {
+	if h == nil {
+		return nil
+	}
+	return h.Bytes()
+}
+
+func addressBytes(a *common.Address) []byte {
+	if a == nil {
+		return nil
+	}
+	return a.Bytes()
+}
+
+func bigBytes(b *big.Int) []byte {
+	if b == nil {
+		return nil
+	}
+	return b.Bytes()
+}
+
+func uint64Value(v *hexutil.Uint64) uint64 {
+	if v == nil {
+		return 0
+	}
+	return uint64(*v)
+}
+
+func newHeader(h *rpcHeader) *Header {
+	out := &Header{
+		Hash:             hashBytes(h.Hash),
+		ParentHash:       h.ParentHash.Bytes(),
+		UncleHash:        h.UncleHash.Bytes(),
+		Coinbase:         addressBytes(h.Coinbase),
+		Root:             h.Root.Bytes(),
+		TxHash:           h.TxHash.Bytes(),
+		ReceiptHash:      h.ReceiptHash.Bytes(),
+		Bloom:            h.Bloom.Bytes(),
+		Difficulty:       bigBytes((*big.Int)(h.Difficulty)),
+		GasLimit:         uint64(h.GasLimit),
+		GasUsed:          uint64(h.GasUsed),
+		Time:             uint64(h.Time),
+		Extra:            h.Extra,
+		MixDigest:        h.MixDigest.Bytes(),
+		BaseFee:          bigBytes((*big.Int)(h.BaseFee)),
+		WithdrawalsHash:  hashBytes(h.WithdrawalsHash),
+		BlobGasUsed:      uint64Value(h.BlobGasUsed),
+		ExcessBlobGas:    uint64Value(h.ExcessBlobGas),
+		ParentBeaconRoot: hashBytes(h.ParentBeaconRoot),
+	}
+	if h.Number != nil {
+		out.Number = (*big.Int)(h.Number).Uint64()
+	}
+	if h.Nonce != nil {
+		out.Nonce = h.Nonce.Uint64()
+	}
+	return out
+}
+
+func newTransaction(tx *rpcTransaction) (*Transaction, error) {
+	raw, err := tx.tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	out := &Transaction{
+		Hash:      tx.tx.Hash().Bytes(),
+		Type:      uint32(tx.tx.Type()),
+		From:      addressBytes(tx.From),
+		To:        addressBytes(tx.tx.To()),
+		Nonce:     tx.tx.Nonce(),
+		Value:     bigBytes(tx.tx.Value()),
+		Gas:       tx.tx.Gas(),
+		GasPrice:  bigBytes(tx.tx.GasPrice()),
+		GasFeeCap: bigBytes(tx.tx.GasFeeCap()),
+		GasTipCap: bigBytes(tx.tx.GasTipCap()),
+		Input:     tx.tx.Data(),
+		ChainId:   bigBytes(tx.tx.ChainId()),
+		BlockHash: hashBytes(tx.BlockHash),
+		Raw:       raw,
+	}
+	if tx.BlockNumber != nil {
+		out.BlockNumber = (*big.Int)(tx.BlockNumber).Uint64()
+	}
+	out.TransactionIndex = uint64Value(tx.TransactionIndex)
+	return out, nil
+}
+
+func newBlock(b *rpcBlock, fullTx bool) (*Block, error) {
+	out := &Block{
+		Header: newHeader(&b.rpcHeader),
+		Size:   uint64(b.Size),
+	}
+	for _, raw := range b.Transactions {
+		if fullTx {
+			var tx rpcTransaction
+			if err := json.Unmarshal(raw, &tx); err != nil {
+				return nil, err
+			}
+			ptx, err := newTransaction(&tx)
+			if err != nil {
+				return nil, err
+			}
+			out.Transactions = append(out.Transactions, ptx)
+			out.TransactionHashes = append(out.TransactionHashes, ptx.Hash)
+		} else {
+			var hash common.Hash
+			if err := json.Unmarshal(raw, &hash); err != nil {
+				return nil, err
+			}
+			out.TransactionHashes = append(out.TransactionHashes, hash.Bytes())
+		}
+	}
+	for _, uncle := range b.Uncles {
+		out.Uncles = append(out.Uncles, uncle.Bytes())
+	}
+	for _, w := range b.Withdrawals {
+		out.Withdrawals = append(out.Withdrawals, &Withdrawal{
+			Index:     w.Index,
+			Validator: w.Validator,
+			Address:   w.Address.Bytes(),
+			Amount:    w.Amount,
+		})
+	}
+	return out, nil
+}
+
+func newLog(l *types.Log) *Log {
+	out := &Log{
+		Address:          l.Address.Bytes(),
+		Data:             l.Data,
+		BlockNumber:      l.BlockNumber,
+		TransactionHash:  l.TxHash.Bytes(),
+		TransactionIndex: uint64(l.TxIndex),
+		BlockHash:        l.BlockHash.Bytes(),
+		LogIndex:         uint64(l.Index),
+		Removed:          l.Removed,
+	}
+	for _, topic := range l.Topics {
+		out.Topics = append(out.Topics, topic.Bytes())
+	}
+	return out
+}
+
+func newReceipt(r *rpcReceipt) *Receipt {
+	out := &Receipt{
+		TransactionHash:   r.TxHash.Bytes(),
+		TransactionIndex:  uint64(r.TransactionIndex),
+		BlockHash:         r.BlockHash.Bytes(),
+		BlockNumber:       bigUint64(r.BlockNumber),
+		From:              addressBytes(r.From),
+		To:                addressBytes(r.To),
+		Type:              uint32(r.Type),
+		Status:            r.Status,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		GasUsed:           r.GasUsed,
+		EffectiveGasPrice: bigBytes(r.EffectiveGasPrice),
+		LogsBloom:         r.Bloom.Bytes(),
+		BlobGasUsed:       r.BlobGasUsed,
+		BlobGasPrice:      bigBytes(r.BlobGasPrice),
+	}
+	if r.ContractAddress != (common.Address{}) {
+		out.ContractAddress = r.ContractAddress.Bytes()
+	}
+	for _, l := range r.Logs {
+		out.Logs = append(out.Logs, newLog(l))
+	}
+	return out
+}
+
+func bigUint64(b *big.Int) uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.Uint64()
+}
+
+var (
+	errInvalidHash    = errors.New("invalid hash length")
+	errInvalidAddress = errors.New("invalid address length")
+)
+
+func toHash(b []byte) (common.Hash, error) {
+	if len(b) != common.HashLength {
+		return common.Hash{}, errInvalidHash
+	}
+	return common.BytesToHash(b), nil
+}
+
+// toBlockNumber converts a block number or tag selector.
+func toBlockNumber(id *BlockId) (rpc.BlockNumber, error) {
+	switch sel := id.GetId().(type) {
+	case nil:
+		return rpc.LatestBlockNumber, nil
+	case *BlockId_Number:
+		if sel.Number > math.MaxInt64 {
+			return 0, fmt.Errorf("block number %d too large", sel.Number)
+		}
+		return rpc.BlockNumber(sel.Number), nil
+	case *BlockId_Tag:
+		switch sel.Tag {
+		case BlockTag_BLOCK_TAG_LATEST:
+			return rpc.LatestBlockNumber, nil
+		case BlockTag_BLOCK_TAG_PENDING:
+			return rpc.PendingBlockNumber, nil
+		case BlockTag_BLOCK_TAG_SAFE:
+			return rpc.SafeBlockNumber, nil
+		case BlockTag_BLOCK_TAG_FINALIZED:
+			return rpc.FinalizedBlockNumber, nil
+		case BlockTag_BLOCK_TAG_EARLIEST:
+			return rpc.EarliestBlockNumber, nil
+		}
+		return 0, fmt.Errorf("unknown block tag %v", sel.Tag)
+	default:
+		return 0, errors.New("block must be selected by number or tag")
+	}
+}
+
+// toFilterArg converts a log filter into the eth_getLogs filter object. The
+// block range is omitted for subscriptions.
+func toFilterArg(f *LogFilter, withRange bool) (interface{}, error) {
+	arg := make(map[string]interface{})
+	addresses := make([]common.Address, len(f.Addresses))
+	for i, a := range f.Addresses {
+		if len(a) != common.AddressLength {
+			return nil, errInvalidAddress
+		}
+		addresses[i] = common.BytesToAddress(a)
+	}
+	arg["address"] = addresses
+	topics := make([][]common.Hash, len(f.Topics))
+	for i, set := range f.Topics {
+		for _, t := range set.Topics {
+			hash, err := toHash(t)
+			if err != nil {
+				return nil, err
+			}
+			topics[i] = append(topics[i], hash)
+		}
+	}
+	arg["topics"] = topics
+	if !withRange {
+		if len(f.BlockHash) > 0 || f.FromBlock != nil || f.ToBlock != nil {
+			return nil, errors.New("block range not supported for subscriptions")
+		}
+		return arg, nil
+	}
+	if len(f.BlockHash) > 0 {
+		if f.FromBlock != nil || f.ToBlock != nil {
+			return nil, errors.New("cannot specify both block hash and block range")
+		}
+		hash, err := toHash(f.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		arg["blockHash"] = hash
+		return arg, nil
+	}
+	from, err := toBlockNumber(f.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := toBlockNumber(f.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	arg["fromBlock"], arg["toBlock"] = from, to
+	return arg, nil
+}
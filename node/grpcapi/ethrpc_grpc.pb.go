@@ -0,0 +1,601 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: ethrpc.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	RPC_Call_FullMethodName      = "/ethrpc.v1.RPC/Call"
+	RPC_Subscribe_FullMethodName = "/ethrpc.v1.RPC/Subscribe"
+)
+
+// RPCClient is the client API for RPC service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RPCClient interface {
+	Call(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*CallResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (RPC_SubscribeClient, error)
+}
+
+type rPCClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRPCClient(cc grpc.ClientConnInterface) RPCClient {
+	return &rPCClient{cc}
+}
+
+func (c *rPCClient) Call(ctx context.Context, in *CallRequest, opts ...grpc.CallOption) (*CallResponse, error) {
+	out := new(CallResponse)
+	err := c.cc.Invoke(ctx, RPC_Call_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rPCClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (RPC_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RPC_ServiceDesc.Streams[0], RPC_Subscribe_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rPCSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RPC_SubscribeClient interface {
+	Recv() (*SubscriptionEvent, error)
+	grpc.ClientStream
+}
+
+type rPCSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *rPCSubscribeClient) Recv() (*SubscriptionEvent, error) {
+	m := new(SubscriptionEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RPCServer is the server API for RPC service.
+// All implementations should embed UnimplementedRPCServer
+// for forward compatibility
+type RPCServer interface {
+	Call(context.Context, *CallRequest) (*CallResponse, error)
+	Subscribe(*SubscribeRequest, RPC_SubscribeServer) error
+}
+
+// UnimplementedRPCServer should be embedded to have forward compatible implementations.
+type UnimplementedRPCServer struct {
+}
+
+func (UnimplementedRPCServer) Call(context.Context, *CallRequest) (*CallResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Call not implemented")
+}
+func (UnimplementedRPCServer) Subscribe(*SubscribeRequest, RPC_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+
+// UnsafeRPCServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RPCServer will
+// result in compilation errors.
+type UnsafeRPCServer interface {
+	mustEmbedUnimplementedRPCServer()
+}
+
+func RegisterRPCServer(s grpc.ServiceRegistrar, srv RPCServer) {
+	s.RegisterService(&RPC_ServiceDesc, srv)
+}
+
+func _RPC_Call_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RPCServer).Call(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RPC_Call_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RPCServer).Call(ctx, req.(*CallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RPC_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RPCServer).Subscribe(m, &rPCSubscribeServer{stream})
+}
+
+type RPC_SubscribeServer interface {
+	Send(*SubscriptionEvent) error
+	grpc.ServerStream
+}
+
+type rPCSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *rPCSubscribeServer) Send(m *SubscriptionEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RPC_ServiceDesc is the grpc.ServiceDesc for RPC service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RPC_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ethrpc.v1.RPC",
+	HandlerType: (*RPCServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Call",
+			Handler:    _RPC_Call_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _RPC_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ethrpc.proto",
+}
+
+const (
+	Eth_BlockNumber_FullMethodName                  = "/ethrpc.v1.Eth/BlockNumber"
+	Eth_GetBlock_FullMethodName                     = "/ethrpc.v1.Eth/GetBlock"
+	Eth_GetTransaction_FullMethodName               = "/ethrpc.v1.Eth/GetTransaction"
+	Eth_GetReceipt_FullMethodName                   = "/ethrpc.v1.Eth/GetReceipt"
+	Eth_GetLogs_FullMethodName                      = "/ethrpc.v1.Eth/GetLogs"
+	Eth_SubscribeNewHeads_FullMethodName            = "/ethrpc.v1.Eth/SubscribeNewHeads"
+	Eth_SubscribeLogs_FullMethodName                = "/ethrpc.v1.Eth/SubscribeLogs"
+	Eth_SubscribePendingTransactions_FullMethodName = "/ethrpc.v1.Eth/SubscribePendingTransactions"
+)
+
+// EthClient is the client API for Eth service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EthClient interface {
+	BlockNumber(ctx context.Context, in *BlockNumberRequest, opts ...grpc.CallOption) (*BlockNumberResponse, error)
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	GetReceipt(ctx context.Context, in *GetReceiptRequest, opts ...grpc.CallOption) (*Receipt, error)
+	GetLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (*GetLogsResponse, error)
+	SubscribeNewHeads(ctx context.Context, in *SubscribeNewHeadsRequest, opts ...grpc.CallOption) (Eth_SubscribeNewHeadsClient, error)
+	SubscribeLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Eth_SubscribeLogsClient, error)
+	SubscribePendingTransactions(ctx context.Context, in *SubscribePendingTransactionsRequest, opts ...grpc.CallOption) (Eth_SubscribePendingTransactionsClient, error)
+}
+
+type ethClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEthClient(cc grpc.ClientConnInterface) EthClient {
+	return &ethClient{cc}
+}
+
+func (c *ethClient) BlockNumber(ctx context.Context, in *BlockNumberRequest, opts ...grpc.CallOption) (*BlockNumberResponse, error) {
+	out := new(BlockNumberResponse)
+	err := c.cc.Invoke(ctx, Eth_BlockNumber_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Eth_GetBlock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, Eth_GetTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetReceipt(ctx context.Context, in *GetReceiptRequest, opts ...grpc.CallOption) (*Receipt, error) {
+	out := new(Receipt)
+	err := c.cc.Invoke(ctx, Eth_GetReceipt_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) GetLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (*GetLogsResponse, error) {
+	out := new(GetLogsResponse)
+	err := c.cc.Invoke(ctx, Eth_GetLogs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ethClient) SubscribeNewHeads(ctx context.Context, in *SubscribeNewHeadsRequest, opts ...grpc.CallOption) (Eth_SubscribeNewHeadsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Eth_ServiceDesc.Streams[0], Eth_SubscribeNewHeads_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethSubscribeNewHeadsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Eth_SubscribeNewHeadsClient interface {
+	Recv() (*Header, error)
+	grpc.ClientStream
+}
+
+type ethSubscribeNewHeadsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethSubscribeNewHeadsClient) Recv() (*Header, error) {
+	m := new(Header)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ethClient) SubscribeLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Eth_SubscribeLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Eth_ServiceDesc.Streams[1], Eth_SubscribeLogs_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethSubscribeLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Eth_SubscribeLogsClient interface {
+	Recv() (*Log, error)
+	grpc.ClientStream
+}
+
+type ethSubscribeLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethSubscribeLogsClient) Recv() (*Log, error) {
+	m := new(Log)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *ethClient) SubscribePendingTransactions(ctx context.Context, in *SubscribePendingTransactionsRequest, opts ...grpc.CallOption) (Eth_SubscribePendingTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Eth_ServiceDesc.Streams[2], Eth_SubscribePendingTransactions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ethSubscribePendingTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Eth_SubscribePendingTransactionsClient interface {
+	Recv() (*Transaction, error)
+	grpc.ClientStream
+}
+
+type ethSubscribePendingTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *ethSubscribePendingTransactionsClient) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EthServer is the server API for Eth service.
+// All implementations should embed UnimplementedEthServer
+// for forward compatibility
+type EthServer interface {
+	BlockNumber(context.Context, *BlockNumberRequest) (*BlockNumberResponse, error)
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	GetReceipt(context.Context, *GetReceiptRequest) (*Receipt, error)
+	GetLogs(context.Context, *LogFilter) (*GetLogsResponse, error)
+	SubscribeNewHeads(*SubscribeNewHeadsRequest, Eth_SubscribeNewHeadsServer) error
+	SubscribeLogs(*LogFilter, Eth_SubscribeLogsServer) error
+	SubscribePendingTransactions(*SubscribePendingTransactionsRequest, Eth_SubscribePendingTransactionsServer) error
+}
+
+// UnimplementedEthServer should be embedded to have forward compatible implementations.
+type UnimplementedEthServer struct {
+}
+
+func (UnimplementedEthServer) BlockNumber(context.Context, *BlockNumberRequest) (*BlockNumberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockNumber not implemented")
+}
+func (UnimplementedEthServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedEthServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedEthServer) GetReceipt(context.Context, *GetReceiptRequest) (*Receipt, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReceipt not implemented")
+}
+func (UnimplementedEthServer) GetLogs(context.Context, *LogFilter) (*GetLogsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLogs not implemented")
+}
+func (UnimplementedEthServer) SubscribeNewHeads(*SubscribeNewHeadsRequest, Eth_SubscribeNewHeadsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeNewHeads not implemented")
+}
+func (UnimplementedEthServer) SubscribeLogs(*LogFilter, Eth_SubscribeLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeLogs not implemented")
+}
+func (UnimplementedEthServer) SubscribePendingTransactions(*SubscribePendingTransactionsRequest, Eth_SubscribePendingTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribePendingTransactions not implemented")
+}
+
+// UnsafeEthServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EthServer will
+// result in compilation errors.
+type UnsafeEthServer interface {
+	mustEmbedUnimplementedEthServer()
+}
+
+func RegisterEthServer(s grpc.ServiceRegistrar, srv EthServer) {
+	s.RegisterService(&Eth_ServiceDesc, srv)
+}
+
+func _Eth_BlockNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockNumberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).BlockNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_BlockNumber_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).BlockNumber(ctx, req.(*BlockNumberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReceiptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetReceipt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetReceipt(ctx, req.(*GetReceiptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_GetLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogFilter)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EthServer).GetLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Eth_GetLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EthServer).GetLogs(ctx, req.(*LogFilter))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Eth_SubscribeNewHeads_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeNewHeadsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthServer).SubscribeNewHeads(m, &ethSubscribeNewHeadsServer{stream})
+}
+
+type Eth_SubscribeNewHeadsServer interface {
+	Send(*Header) error
+	grpc.ServerStream
+}
+
+type ethSubscribeNewHeadsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethSubscribeNewHeadsServer) Send(m *Header) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Eth_SubscribeLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthServer).SubscribeLogs(m, &ethSubscribeLogsServer{stream})
+}
+
+type Eth_SubscribeLogsServer interface {
+	Send(*Log) error
+	grpc.ServerStream
+}
+
+type ethSubscribeLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethSubscribeLogsServer) Send(m *Log) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Eth_SubscribePendingTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribePendingTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EthServer).SubscribePendingTransactions(m, &ethSubscribePendingTransactionsServer{stream})
+}
+
+type Eth_SubscribePendingTransactionsServer interface {
+	Send(*Transaction) error
+	grpc.ServerStream
+}
+
+type ethSubscribePendingTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *ethSubscribePendingTransactionsServer) Send(m *Transaction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Eth_ServiceDesc is the grpc.ServiceDesc for Eth service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Eth_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ethrpc.v1.Eth",
+	HandlerType: (*EthServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "BlockNumber",
+			Handler:    _Eth_BlockNumber_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _Eth_GetBlock_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Eth_GetTransaction_Handler,
+		},
+		{
+			MethodName: "GetReceipt",
+			Handler:    _Eth_GetReceipt_Handler,
+		},
+		{
+			MethodName: "GetLogs",
+			Handler:    _Eth_GetLogs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeNewHeads",
+			Handler:       _Eth_SubscribeNewHeads_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeLogs",
+			Handler:       _Eth_SubscribeLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribePendingTransactions",
+			Handler:       _Eth_SubscribePendingTransactions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ethrpc.proto",
+}
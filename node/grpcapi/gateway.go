@@ -0,0 +1,369 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// JSON-RPC error codes which are mapped to gRPC status codes.
+const (
+	errcodeMethodNotFound  = -32601
+	errcodeInvalidParams   = -32602
+	errcodeTimeout         = -32002
+	errcodeLimitExceeded   = -32005
+	errcodeNoNotifications = -32001
+)
+
+// Gateway serves the RPC and Eth services by forwarding calls to a JSON-RPC
+// client, which is usually attached in-process to the node's RPC server.
+type Gateway struct {
+	client  *rpc.Client
+	limiter rpc.Limiter
+}
+
+// NewGateway creates a gateway forwarding calls to the given client. As all calls
+// share the client, the limiter (if not nil) is applied by the gateway itself, on
+// behalf of the gRPC client making the call.
+func NewGateway(client *rpc.Client, limiter rpc.Limiter) *Gateway {
+	return &Gateway{client: client, limiter: limiter}
+}
+
+// Register registers the gateway's services on a gRPC server.
+func (g *Gateway) Register(s grpc.ServiceRegistrar) {
+	RegisterRPCServer(s, g)
+	RegisterEthServer(s, g)
+}
+
+// Call implements RPCServer.
+func (g *Gateway) Call(ctx context.Context, req *CallRequest) (*CallResponse, error) {
+	args, err := decodeParams(req.Params)
+	if err != nil {
+		return nil, err
+	}
+	var result json.RawMessage
+	if err := g.call(ctx, &result, req.Method, args...); err != nil {
+		return nil, toStatus(err)
+	}
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return &CallResponse{Result: result}, nil
+}
+
+// Subscribe implements RPCServer.
+func (g *Gateway) Subscribe(req *SubscribeRequest, stream RPC_SubscribeServer) error {
+	args, err := decodeParams(req.Params)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return status.Error(codes.InvalidArgument, "missing subscription name")
+	}
+	return g.subscribe(stream.Context(), req.Namespace, args, func(msg json.RawMessage) error {
+		return stream.Send(&SubscriptionEvent{Result: msg})
+	})
+}
+
+// BlockNumber implements EthServer.
+func (g *Gateway) BlockNumber(ctx context.Context, req *BlockNumberRequest) (*BlockNumberResponse, error) {
+	var number hexutil.Uint64
+	if err := g.call(ctx, &number, "eth_blockNumber"); err != nil {
+		return nil, toStatus(err)
+	}
+	return &BlockNumberResponse{Number: uint64(number)}, nil
+}
+
+// GetBlock implements EthServer.
+func (g *Gateway) GetBlock(ctx context.Context, req *GetBlockRequest) (*Block, error) {
+	var (
+		raw json.RawMessage
+		err error
+	)
+	if hash := req.GetBlock().GetHash(); hash != nil {
+		h, herr := toHash(hash)
+		if herr != nil {
+			return nil, status.Error(codes.InvalidArgument, herr.Error())
+		}
+		err = g.call(ctx, &raw, "eth_getBlockByHash", h, req.FullTransactions)
+	} else {
+		number, nerr := toBlockNumber(req.Block)
+		if nerr != nil {
+			return nil, status.Error(codes.InvalidArgument, nerr.Error())
+		}
+		err = g.call(ctx, &raw, "eth_getBlockByNumber", number, req.FullTransactions)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	if isNull(raw) {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+	var block rpcBlock
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out, err := newBlock(&block, req.FullTransactions)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+// GetTransaction implements EthServer.
+func (g *Gateway) GetTransaction(ctx context.Context, req *GetTransactionRequest) (*Transaction, error) {
+	hash, err := toHash(req.Hash)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var raw json.RawMessage
+	if err := g.call(ctx, &raw, "eth_getTransactionByHash", hash); err != nil {
+		return nil, toStatus(err)
+	}
+	if isNull(raw) {
+		return nil, status.Error(codes.NotFound, "transaction not found")
+	}
+	return decodeTransaction(raw)
+}
+
+// GetReceipt implements EthServer.
+func (g *Gateway) GetReceipt(ctx context.Context, req *GetReceiptRequest) (*Receipt, error) {
+	hash, err := toHash(req.Hash)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var raw json.RawMessage
+	if err := g.call(ctx, &raw, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, toStatus(err)
+	}
+	if isNull(raw) {
+		return nil, status.Error(codes.NotFound, "receipt not found")
+	}
+	var receipt rpcReceipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newReceipt(&receipt), nil
+}
+
+// GetLogs implements EthServer.
+func (g *Gateway) GetLogs(ctx context.Context, req *LogFilter) (*GetLogsResponse, error) {
+	arg, err := toFilterArg(req, true)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var logs []*types.Log
+	if err := g.call(ctx, &logs, "eth_getLogs", arg); err != nil {
+		return nil, toStatus(err)
+	}
+	out := &GetLogsResponse{Logs: make([]*Log, len(logs))}
+	for i, l := range logs {
+		out.Logs[i] = newLog(l)
+	}
+	return out, nil
+}
+
+// SubscribeNewHeads implements EthServer.
+func (g *Gateway) SubscribeNewHeads(req *SubscribeNewHeadsRequest, stream Eth_SubscribeNewHeadsServer) error {
+	return g.subscribe(stream.Context(), "eth", []interface{}{"newHeads"}, func(msg json.RawMessage) error {
+		var header rpcHeader
+		if err := json.Unmarshal(msg, &header); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return stream.Send(newHeader(&header))
+	})
+}
+
+// SubscribeLogs implements EthServer.
+func (g *Gateway) SubscribeLogs(req *LogFilter, stream Eth_SubscribeLogsServer) error {
+	arg, err := toFilterArg(req, false)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return g.subscribe(stream.Context(), "eth", []interface{}{"logs", arg}, func(msg json.RawMessage) error {
+		var l types.Log
+		if err := json.Unmarshal(msg, &l); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return stream.Send(newLog(&l))
+	})
+}
+
+// SubscribePendingTransactions implements EthServer.
+func (g *Gateway) SubscribePendingTransactions(req *SubscribePendingTransactionsRequest, stream Eth_SubscribePendingTransactionsServer) error {
+	args := []interface{}{"newPendingTransactions"}
+	if req.FullTransactions {
+		args = append(args, true)
+	}
+	return g.subscribe(stream.Context(), "eth", args, func(msg json.RawMessage) error {
+		if !req.FullTransactions {
+			var hash common.Hash
+			if err := json.Unmarshal(msg, &hash); err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			return stream.Send(&Transaction{Hash: hash.Bytes()})
+		}
+		tx, err := decodeTransaction(msg)
+		if err != nil {
+			return err
+		}
+		return stream.Send(tx)
+	})
+}
+
+// subscribe creates a JSON-RPC subscription and hands its notifications to send
+// until the subscription fails or the context is canceled.
+func (g *Gateway) subscribe(ctx context.Context, namespace string, args []interface{}, send func(json.RawMessage) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan json.RawMessage)
+	release, err := g.acquire(ctx, namespace+"_subscribe")
+	if err != nil {
+		return toStatus(err)
+	}
+	sub, err := g.client.Subscribe(ctx, namespace, ch, args...)
+	release()
+	if err != nil {
+		return toStatus(err)
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case msg := <-ch:
+			if err := send(msg); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			if err == nil {
+				return status.Error(codes.Unavailable, "subscription closed")
+			}
+			return toStatus(err)
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// call forwards a method call to the client once admitted by the limiter.
+func (g *Gateway) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	release, err := g.acquire(ctx, method)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return g.client.CallContext(ctx, result, method, args...)
+}
+
+// acquire admits a call of the given method in the limiter, attributing it to the
+// gRPC client the context belongs to.
+func (g *Gateway) acquire(ctx context.Context, method string) (func(), error) {
+	if g.limiter == nil {
+		return func() {}, nil
+	}
+	return g.limiter.Acquire(peerContext(ctx), method)
+}
+
+// peerContext records the connection details and metadata of the gRPC client in
+// the context, in the form the RPC limiter expects them.
+func peerContext(ctx context.Context) context.Context {
+	info := rpc.PeerInfo{Transport: "grpc"}
+	if p, ok := peer.FromContext(ctx); ok {
+		info.RemoteAddr = p.Addr.String()
+	}
+	header := make(http.Header)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+	}
+	info.HTTP.UserAgent = header.Get("User-Agent")
+	return rpc.WithPeerInfo(ctx, info, header)
+}
+
+func decodeTransaction(raw json.RawMessage) (*Transaction, error) {
+	var tx rpcTransaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out, err := newTransaction(&tx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+// decodeParams splits a JSON parameter array into its elements.
+func decodeParams(params []byte) ([]interface{}, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	var elems []json.RawMessage
+	if err := json.Unmarshal(params, &elems); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "params must be a JSON array")
+	}
+	args := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		args[i] = elem
+	}
+	return args, nil
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// toStatus converts an error returned by the JSON-RPC client into a gRPC status.
+func toStatus(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code := codes.Unknown
+	switch rpcErr.ErrorCode() {
+	case errcodeMethodNotFound, errcodeNoNotifications:
+		code = codes.Unimplemented
+	case errcodeInvalidParams:
+		code = codes.InvalidArgument
+	case errcodeTimeout:
+		code = codes.DeadlineExceeded
+	case errcodeLimitExceeded:
+		code = codes.ResourceExhausted
+	}
+	return status.Error(code, err.Error())
+}
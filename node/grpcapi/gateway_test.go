@@ -0,0 +1,372 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testChainID = big.NewInt(1337)
+)
+
+// testEthAPI serves the parts of the eth namespace used by the gateway from a
+// single block.
+type testEthAPI struct {
+	block *types.Block
+	logs  []*types.Log
+}
+
+func newTestEthAPI() *testEthAPI {
+	signer := types.LatestSignerForChainID(testChainID)
+	to := common.HexToAddress("0x0102")
+	tx := types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     5,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(100),
+	})
+	header := &types.Header{
+		ParentHash: common.HexToHash("0x01"),
+		Number:     big.NewInt(10),
+		GasLimit:   30_000_000,
+		GasUsed:    21000,
+		Time:       1000,
+		Difficulty: big.NewInt(0),
+		BaseFee:    big.NewInt(7),
+		Extra:      []byte("test"),
+	}
+	block := types.NewBlock(header, []*types.Transaction{tx}, nil, nil, trie.NewStackTrie(nil))
+	logs := []*types.Log{{
+		Address:     to,
+		Topics:      []common.Hash{common.HexToHash("0xaa")},
+		Data:        []byte{1, 2, 3},
+		BlockNumber: 10,
+		TxHash:      tx.Hash(),
+		BlockHash:   block.Hash(),
+	}}
+	return &testEthAPI{block: block, logs: logs}
+}
+
+func (api *testEthAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.block.NumberU64())
+}
+
+func (api *testEthAPI) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) map[string]interface{} {
+	if number != rpc.LatestBlockNumber && number.Int64() != int64(api.block.NumberU64()) {
+		return nil
+	}
+	return ethapi.RPCMarshalBlock(api.block, true, fullTx, params.AllEthashProtocolChanges)
+}
+
+func (api *testEthAPI) GetBlockByHash(hash common.Hash, fullTx bool) map[string]interface{} {
+	if hash != api.block.Hash() {
+		return nil
+	}
+	return ethapi.RPCMarshalBlock(api.block, true, fullTx, params.AllEthashProtocolChanges)
+}
+
+func (api *testEthAPI) GetTransactionByHash(hash common.Hash) interface{} {
+	if hash != api.block.Transactions()[0].Hash() {
+		return nil
+	}
+	fields := ethapi.RPCMarshalBlock(api.block, true, true, params.AllEthashProtocolChanges)
+	return fields["transactions"].([]interface{})[0]
+}
+
+func (api *testEthAPI) GetTransactionReceipt(hash common.Hash) (map[string]interface{}, error) {
+	tx := api.block.Transactions()[0]
+	if hash != tx.Hash() {
+		return nil, nil
+	}
+	receipt := &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 21000,
+		GasUsed:           21000,
+		Logs:              api.logs,
+		TxHash:            tx.Hash(),
+		EffectiveGasPrice: big.NewInt(8),
+		BlockHash:         api.block.Hash(),
+		BlockNumber:       api.block.Number(),
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	enc, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		return nil, err
+	}
+	fields["from"], fields["to"] = testAddr, tx.To()
+	return fields, nil
+}
+
+func (api *testEthAPI) GetLogs(crit map[string]interface{}) ([]*types.Log, error) {
+	return api.logs, nil
+}
+
+func (api *testEthAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < 3; i++ {
+			header := types.CopyHeader(api.block.Header())
+			header.Number = big.NewInt(int64(i))
+			notifier.Notify(sub.ID, ethapi.RPCMarshalHeader(header))
+		}
+	}()
+	return sub, nil
+}
+
+// startGateway runs a gateway in front of the test API and connects to it.
+func startGateway(t *testing.T, api *testEthAPI) *grpc.ClientConn {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(srv)
+	server := grpc.NewServer()
+	NewGateway(client, nil).Register(server)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		client.Close()
+		server.Stop()
+		srv.Stop()
+	})
+	return conn
+}
+
+func TestGatewayGetBlock(t *testing.T) {
+	api := newTestEthAPI()
+	eth := NewEthClient(startGateway(t, api))
+	ctx := context.Background()
+
+	number, err := eth.BlockNumber(ctx, &BlockNumberRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number.Number != 10 {
+		t.Fatalf("wrong block number %d", number.Number)
+	}
+
+	selectors := []*BlockId{
+		nil,
+		{Id: &BlockId_Number{Number: 10}},
+		{Id: &BlockId_Hash{Hash: api.block.Hash().Bytes()}},
+	}
+	for _, sel := range selectors {
+		block, err := eth.GetBlock(ctx, &GetBlockRequest{Block: sel, FullTransactions: true})
+		if err != nil {
+			t.Fatalf("selector %v: %v", sel, err)
+		}
+		if common.BytesToHash(block.Header.Hash) != api.block.Hash() {
+			t.Errorf("selector %v: wrong block hash %x", sel, block.Header.Hash)
+		}
+		if block.Header.Number != 10 || block.Header.GasUsed != 21000 || new(big.Int).SetBytes(block.Header.BaseFee).Int64() != 7 {
+			t.Errorf("selector %v: wrong header %v", sel, block.Header)
+		}
+		if len(block.Transactions) != 1 {
+			t.Fatalf("selector %v: wrong transaction count %d", sel, len(block.Transactions))
+		}
+		ptx := block.Transactions[0]
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(ptx.Raw); err != nil {
+			t.Fatalf("selector %v: invalid raw transaction: %v", sel, err)
+		}
+		if tx.Hash() != api.block.Transactions()[0].Hash() || common.BytesToHash(ptx.Hash) != tx.Hash() {
+			t.Errorf("selector %v: wrong transaction %x", sel, ptx.Hash)
+		}
+		if common.BytesToAddress(ptx.From) != testAddr || ptx.Nonce != 5 || ptx.Type != types.DynamicFeeTxType {
+			t.Errorf("selector %v: wrong transaction fields %v", sel, ptx)
+		}
+	}
+
+	_, err = eth.GetBlock(ctx, &GetBlockRequest{Block: &BlockId{Id: &BlockId_Number{Number: 11}}})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for missing block, got %v", err)
+	}
+	_, err = eth.GetBlock(ctx, &GetBlockRequest{Block: &BlockId{Id: &BlockId_Hash{Hash: []byte{1}}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for short hash, got %v", err)
+	}
+}
+
+func TestGatewayReceiptsAndLogs(t *testing.T) {
+	api := newTestEthAPI()
+	eth := NewEthClient(startGateway(t, api))
+	ctx := context.Background()
+	txhash := api.block.Transactions()[0].Hash()
+
+	tx, err := eth.GetTransaction(ctx, &GetTransactionRequest{Hash: txhash.Bytes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.BytesToHash(tx.BlockHash) != api.block.Hash() || tx.BlockNumber != 10 {
+		t.Errorf("wrong transaction inclusion %x/%d", tx.BlockHash, tx.BlockNumber)
+	}
+	receipt, err := eth.GetReceipt(ctx, &GetReceiptRequest{Hash: txhash.Bytes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful || receipt.GasUsed != 21000 || len(receipt.Logs) != 1 {
+		t.Errorf("wrong receipt %v", receipt)
+	}
+	if common.BytesToAddress(receipt.From) != testAddr {
+		t.Errorf("wrong receipt sender %x", receipt.From)
+	}
+	_, err = eth.GetReceipt(ctx, &GetReceiptRequest{Hash: make([]byte, 32)})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for missing receipt, got %v", err)
+	}
+
+	logs, err := eth.GetLogs(ctx, &LogFilter{
+		FromBlock: &BlockId{Id: &BlockId_Number{Number: 0}},
+		Topics:    []*Topics{{}, {Topics: [][]byte{make([]byte, 32)}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs.Logs) != 1 || common.BytesToHash(logs.Logs[0].Topics[0]) != api.logs[0].Topics[0] {
+		t.Errorf("wrong logs %v", logs.Logs)
+	}
+	_, err = eth.GetLogs(ctx, &LogFilter{BlockHash: make([]byte, 32), ToBlock: &BlockId{}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for block hash with range, got %v", err)
+	}
+}
+
+func TestGatewayCall(t *testing.T) {
+	conn := startGateway(t, newTestEthAPI())
+	rpcClient := NewRPCClient(conn)
+	ctx := context.Background()
+
+	resp, err := rpcClient.Call(ctx, &CallRequest{Method: "eth_getBlockByNumber", Params: []byte(`["0xa", false]`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block struct {
+		Number       hexutil.Uint64 `json:"number"`
+		Transactions []common.Hash  `json:"transactions"`
+	}
+	if err := json.Unmarshal(resp.Result, &block); err != nil {
+		t.Fatal(err)
+	}
+	if block.Number != 10 || len(block.Transactions) != 1 {
+		t.Errorf("wrong result %s", resp.Result)
+	}
+
+	resp, err = rpcClient.Call(ctx, &CallRequest{Method: "eth_getBlockByNumber", Params: []byte(`["0xb", false]`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Result) != "null" {
+		t.Errorf("expected null result, got %s", resp.Result)
+	}
+
+	_, err = rpcClient.Call(ctx, &CallRequest{Method: "debug_traceTransaction"})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented for unknown method, got %v", err)
+	}
+	_, err = rpcClient.Call(ctx, &CallRequest{Method: "eth_blockNumber", Params: []byte(`{}`)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for non-array params, got %v", err)
+	}
+	_, err = rpcClient.Call(ctx, &CallRequest{Method: "eth_getBlockByNumber", Params: []byte(`["foo"]`)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for bad params, got %v", err)
+	}
+}
+
+func TestGatewaySubscribe(t *testing.T) {
+	conn := startGateway(t, newTestEthAPI())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	heads, err := NewEthClient(conn).SubscribeNewHeads(ctx, &SubscribeNewHeadsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		head, err := heads.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.Number != i {
+			t.Fatalf("wrong head %d, want %d", head.Number, i)
+		}
+	}
+
+	events, err := NewRPCClient(conn).Subscribe(ctx, &SubscribeRequest{Namespace: "eth", Params: []byte(`["newHeads"]`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := events.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var head struct {
+		Number hexutil.Uint64 `json:"number"`
+	}
+	if err := json.Unmarshal(ev.Result, &head); err != nil || head.Number != 0 {
+		t.Fatalf("wrong notification %s (%v)", ev.Result, err)
+	}
+
+	unknown, err := NewRPCClient(conn).Subscribe(ctx, &SubscribeRequest{Namespace: "eth", Params: []byte(`["syncing"]`)})
+	if err == nil {
+		_, err = unknown.Recv()
+	}
+	if err == nil {
+		t.Fatal("expected error for unknown subscription")
+	}
+}
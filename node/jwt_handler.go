@@ -17,17 +17,24 @@
 package node
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const jwtExpiryTimeout = 60 * time.Second
 
 type jwtHandler struct {
-	keyFunc func(token *jwt.Token) (interface{}, error)
+	keyFunc jwt.Keyfunc
 	next    http.Handler
 }
 
@@ -43,38 +50,100 @@ func newJWTHandler(secret []byte, next http.Handler) http.Handler {
 
 // ServeHTTP implements http.Handler
 func (handler *jwtHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
-	var (
-		strToken string
-		claims   jwt.RegisteredClaims
-	)
+	var strToken string
 	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
 		strToken = strings.TrimPrefix(auth, "Bearer ")
 	}
-	if len(strToken) == 0 {
-		http.Error(out, "missing token", http.StatusUnauthorized)
+	claims, err := validateJWT(strToken, handler.keyFunc)
+	if err != nil {
+		http.Error(out, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	if claims.Subject != "" {
+		r = r.WithContext(rpc.WithAuthSubject(r.Context(), claims.Subject))
+	}
+	handler.next.ServeHTTP(out, r)
+}
+
+// validateJWT checks a bearer token against the rules of the authenticated API,
+// returning its claims if it is valid.
+func validateJWT(strToken string, keyFunc jwt.Keyfunc) (*jwt.RegisteredClaims, error) {
+	if len(strToken) == 0 {
+		return nil, errors.New("missing token")
+	}
 	// We explicitly set only HS256 allowed, and also disables the
 	// claim-check: the RegisteredClaims internally requires 'iat' to
 	// be no later than 'now', but we allow for a bit of drift.
-	token, err := jwt.ParseWithClaims(strToken, &claims, handler.keyFunc,
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(strToken, &claims, keyFunc,
 		jwt.WithValidMethods([]string{"HS256"}),
 		jwt.WithoutClaimsValidation())
 
 	switch {
 	case err != nil:
-		http.Error(out, err.Error(), http.StatusUnauthorized)
+		return nil, err
 	case !token.Valid:
-		http.Error(out, "invalid token", http.StatusUnauthorized)
+		return nil, errors.New("invalid token")
 	case !claims.VerifyExpiresAt(time.Now(), false): // optional
-		http.Error(out, "token is expired", http.StatusUnauthorized)
+		return nil, errors.New("token is expired")
 	case claims.IssuedAt == nil:
-		http.Error(out, "missing issued-at", http.StatusUnauthorized)
+		return nil, errors.New("missing issued-at")
 	case time.Since(claims.IssuedAt.Time) > jwtExpiryTimeout:
-		http.Error(out, "stale token", http.StatusUnauthorized)
+		return nil, errors.New("stale token")
 	case time.Until(claims.IssuedAt.Time) > jwtExpiryTimeout:
-		http.Error(out, "future token", http.StatusUnauthorized)
-	default:
-		handler.next.ServeHTTP(out, r)
+		return nil, errors.New("future token")
 	}
+	return &claims, nil
+}
+
+// newJWTInterceptors creates the gRPC server options enforcing jwt authentication
+// on unary and streaming calls. The token is read from the authorization metadata.
+func newJWTInterceptors(secret []byte) []grpc.ServerOption {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}
+	check := func(ctx context.Context) (context.Context, error) {
+		var strToken string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if auth := md.Get("authorization"); len(auth) > 0 && strings.HasPrefix(auth[0], "Bearer ") {
+				strToken = strings.TrimPrefix(auth[0], "Bearer ")
+			}
+		}
+		claims, err := validateJWT(strToken, keyFunc)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if claims.Subject != "" {
+			ctx = rpc.WithAuthSubject(ctx, claims.Subject)
+		}
+		return ctx, nil
+	}
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := check(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := check(stream.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &authServerStream{stream, ctx})
+		}),
+	}
+}
+
+// authServerStream is a gRPC server stream carrying the context of its verified
+// credentials.
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream.
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
 }
@@ -62,7 +62,9 @@ type Node struct {
 	httpAuth      *httpServer //
 	wsAuth        *httpServer //
 	ipc           *ipcServer  // Stores information about the ipc http server
+	grpc          *grpcServer // gRPC gateway to the RPC APIs
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	rpcLimiter    rpc.Limiter // Limiter shared by the HTTP and WS endpoints, nil if unlimited
 
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
@@ -101,11 +103,20 @@ func New(conf *Config) (*Node, error) {
 	if strings.HasSuffix(conf.Name, ".ipc") {
 		return nil, errors.New(`Config.Name cannot end in ".ipc"`)
 	}
+	var limiter rpc.Limiter
+	if len(conf.RPCLimits.Rules) > 0 {
+		rl, err := rpc.NewRateLimiter(conf.RPCLimits)
+		if err != nil {
+			return nil, err
+		}
+		limiter = rl
+	}
 	server := rpc.NewServer()
 	server.SetBatchLimits(conf.BatchRequestLimit, conf.BatchResponseMaxSize)
 	node := &Node{
 		config:        conf,
 		inprocHandler: server,
+		rpcLimiter:    limiter,
 		eventmux:      new(event.TypeMux),
 		log:           conf.Logger,
 		stop:          make(chan struct{}),
@@ -153,6 +164,7 @@ func New(conf *Config) (*Node, error) {
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.wsAuth = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
+	node.grpc = newGRPCServer(node.log)
 
 	return node, nil
 }
@@ -407,6 +419,7 @@ func (n *Node) startRPC() error {
 	rpcConfig := rpcEndpointConfig{
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
+		limiter:                n.rpcLimiter,
 	}
 
 	initHttp := func(server *httpServer, port int) error {
@@ -497,22 +510,45 @@ func (n *Node) startRPC() error {
 		}
 	}
 	// Configure authenticated API
-	if len(openAPIs) != len(allAPIs) {
-		jwtSecret, err := n.obtainJWTSecret(n.config.JWTSecret)
+	var jwtSecret []byte
+	if len(openAPIs) != len(allAPIs) || n.config.GRPCAuth {
+		secret, err := n.obtainJWTSecret(n.config.JWTSecret)
 		if err != nil {
 			return err
 		}
+		jwtSecret = secret
+	}
+	if len(openAPIs) != len(allAPIs) {
 		if err := initAuth(n.config.AuthPort, jwtSecret); err != nil {
 			return err
 		}
 	}
+	// Configure the gRPC gateway. Unless configured otherwise, it exposes the same
+	// modules as the HTTP endpoint. With authentication enabled it shares the JWT
+	// secret of the authenticated API and may expose the same modules.
+	if n.config.GRPCHost != "" {
+		if err := n.grpc.setListenAddr(n.config.GRPCHost, n.config.GRPCPort); err != nil {
+			return err
+		}
+		modules := n.config.GRPCModules
+		if len(modules) == 0 {
+			modules = n.config.HTTPModules
+		}
+		apis, config := openAPIs, grpcConfig{Modules: modules, rpcEndpointConfig: rpcConfig}
+		if n.config.GRPCAuth {
+			apis, config.jwtSecret = allAPIs, jwtSecret
+		}
+		if err := n.grpc.enable(apis, config); err != nil {
+			return err
+		}
+	}
 	// Start the servers
 	for _, server := range servers {
 		if err := server.start(); err != nil {
 			return err
 		}
 	}
-	return nil
+	return n.grpc.start()
 }
 
 func (n *Node) wsServerForPort(port int, authenticated bool) *httpServer {
@@ -532,6 +568,7 @@ func (n *Node) stopRPC() {
 	n.httpAuth.stop()
 	n.wsAuth.stop()
 	n.ipc.stop()
+	n.grpc.stop()
 	n.stopInProc()
 }
 
@@ -694,6 +731,11 @@ func (n *Node) HTTPAuthEndpoint() string {
 	return "http://" + n.httpAuth.listenAddr()
 }
 
+// GRPCEndpoint returns the listening address of the gRPC gateway.
+func (n *Node) GRPCEndpoint() string {
+	return n.grpc.listenAddr()
+}
+
 // WSAuthEndpoint returns the current authenticated JSON-RPC over WebSocket endpoint.
 func (n *Node) WSAuthEndpoint() string {
 	if n.httpAuth.wsAllowed() {
@@ -27,8 +27,14 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/node/grpcapi"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type helloRPC string
@@ -235,3 +241,169 @@ func offsetTimeAuth(secret [32]byte, offset time.Duration) rpc.HTTPAuth {
 		return nil
 	}
 }
+
+// TestGRPCAuth checks that the gRPC gateway enforces the module allowlist and
+// shares JWT authentication with the authenticated RPC endpoints.
+func TestGRPCAuth(t *testing.T) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		t.Fatalf("failed to create jwt secret: %v", err)
+	}
+	jwtPath := path.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(jwtPath, []byte(hexutil.Encode(secret[:])), 0600); err != nil {
+		t.Fatalf("failed to prepare jwt secret file: %v", err)
+	}
+	node, err := New(&Config{
+		GRPCHost:    "127.0.0.1",
+		GRPCModules: []string{"engine"},
+		GRPCAuth:    true,
+		JWTSecret:   jwtPath,
+	})
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{
+		{Namespace: "engine", Service: helloRPC("hello engine"), Authenticated: true},
+		{Namespace: "eth", Service: helloRPC("hello eth")},
+	})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	conn, err := grpc.Dial(node.GRPCEndpoint(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial grpc endpoint: %v", err)
+	}
+	defer conn.Close()
+	client := grpcapi.NewRPCClient(conn)
+
+	call := func(method string, auth rpc.HTTPAuth) (string, error) {
+		ctx := context.Background()
+		if auth != nil {
+			header := make(http.Header)
+			if err := auth(header); err != nil {
+				t.Fatal(err)
+			}
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", header.Get("Authorization"))
+		}
+		resp, err := client.Call(ctx, &grpcapi.CallRequest{Method: method})
+		if err != nil {
+			return "", err
+		}
+		return string(resp.Result), nil
+	}
+	var otherSecret [32]byte
+	crand.Read(otherSecret[:])
+
+	if _, err := call("engine_helloWorld", nil); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected missing token to be rejected, got %v", err)
+	}
+	if _, err := call("engine_helloWorld", NewJWTAuth(otherSecret)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected bad token to be rejected, got %v", err)
+	}
+	if _, err := call("engine_helloWorld", offsetTimeAuth(secret, -time.Minute)); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected stale token to be rejected, got %v", err)
+	}
+	if res, err := call("engine_helloWorld", NewJWTAuth(secret)); err != nil || res != `"hello engine"` {
+		t.Errorf("call failed: %v %s", err, res)
+	}
+	// The eth module is not in the allowlist.
+	if _, err := call("eth_helloWorld", NewJWTAuth(secret)); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected module outside allowlist to be unavailable, got %v", err)
+	}
+}
+
+// TestGRPCLimits checks that the gRPC gateway applies the RPC limits to every
+// gRPC client separately, keyed by the subject of their JWT.
+func TestGRPCLimits(t *testing.T) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		t.Fatalf("failed to create jwt secret: %v", err)
+	}
+	jwtPath := path.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(jwtPath, []byte(hexutil.Encode(secret[:])), 0600); err != nil {
+		t.Fatalf("failed to prepare jwt secret file: %v", err)
+	}
+	node, err := New(&Config{
+		GRPCHost:    "127.0.0.1",
+		GRPCModules: []string{"eth"},
+		GRPCAuth:    true,
+		JWTSecret:   jwtPath,
+		RPCLimits: rpc.LimitConfig{
+			ClientKey: "jwt",
+			Rules:     []rpc.LimitRule{{Pattern: "eth_*", Rate: 0.001, Burst: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{{Namespace: "eth", Service: helloRPC("hello eth")}})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	call := func(subject string) error {
+		conn, err := grpc.Dial(node.GRPCEndpoint(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("failed to dial grpc endpoint: %v", err)
+		}
+		defer conn.Close()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iat": &jwt.NumericDate{Time: time.Now()},
+			"sub": subject,
+		})
+		s, err := token.SignedString(secret[:])
+		if err != nil {
+			t.Fatalf("failed to create JWT token: %v", err)
+		}
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+s)
+		_, err = grpcapi.NewRPCClient(conn).Call(ctx, &grpcapi.CallRequest{Method: "eth_helloWorld"})
+		return err
+	}
+	if err := call("alice"); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	if err := call("alice"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected second call of the same client to be limited, got %v", err)
+	}
+	if err := call("bob"); err != nil {
+		t.Errorf("call of another client was limited: %v", err)
+	}
+}
+
+// TestGRPCDefaultModules checks that the gRPC gateway shares the module allowlist
+// of the HTTP endpoint unless configured otherwise.
+func TestGRPCDefaultModules(t *testing.T) {
+	node, err := New(&Config{
+		GRPCHost:    "127.0.0.1",
+		HTTPModules: []string{"eth"},
+	})
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{
+		{Namespace: "eth", Service: helloRPC("hello eth")},
+		{Namespace: "debug", Service: helloRPC("hello debug")},
+	})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	conn, err := grpc.Dial(node.GRPCEndpoint(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial grpc endpoint: %v", err)
+	}
+	defer conn.Close()
+	client := grpcapi.NewRPCClient(conn)
+
+	if _, err := client.Call(context.Background(), &grpcapi.CallRequest{Method: "eth_helloWorld"}); err != nil {
+		t.Errorf("call of HTTP module failed: %v", err)
+	}
+	if _, err := client.Call(context.Background(), &grpcapi.CallRequest{Method: "debug_helloWorld"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected module outside the HTTP allowlist to be unavailable, got %v", err)
+	}
+}
@@ -31,8 +31,10 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node/grpcapi"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/rs/cors"
+	"google.golang.org/grpc"
 )
 
 // httpConfig is the JSON-RPC/HTTP configuration.
@@ -56,6 +58,7 @@ type rpcEndpointConfig struct {
 	jwtSecret              []byte // optional JWT secret
 	batchItemLimit         int
 	batchResponseSizeLimit int
+	limiter                rpc.Limiter // optional per-method and per-client limits
 }
 
 type rpcHandler struct {
@@ -195,12 +198,11 @@ func (h *httpServer) start() error {
 }
 
 func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// check if ws request and serve if ws enabled
+	// check if ws request and serve if ws enabled. Websocket requests to other
+	// paths are left to the handlers registered on the mux.
 	ws := h.wsHandler.Load().(*rpcHandler)
-	if ws != nil && isWebsocket(r) {
-		if checkPath(r, h.wsConfig.prefix) {
-			ws.ServeHTTP(w, r)
-		}
+	if ws != nil && isWebsocket(r) && checkPath(r, h.wsConfig.prefix) {
+		ws.ServeHTTP(w, r)
 		return
 	}
 
@@ -302,6 +304,8 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	}
 
 	// Create RPC server and handler.
+	// The limiter is applied by the gateway, all calls reach the server through
+	// the same in-process client.
 	srv := rpc.NewServer()
 	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
@@ -334,6 +338,8 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 		return fmt.Errorf("JSON-RPC over WebSocket is already enabled")
 	}
 	// Create RPC server and handler.
+	// The limiter is applied by the gateway, all calls reach the server through
+	// the same in-process client.
 	srv := rpc.NewServer()
 	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
 	if err := RegisterApis(apis, config.Modules, srv); err != nil {
@@ -564,7 +570,8 @@ func (w *gzipResponseWriter) close() {
 
 func newGzipHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		// Websocket upgrades hijack the connection, which the gzip writer can't.
+		if isWebsocket(r) || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -621,6 +628,127 @@ func (is *ipcServer) stop() error {
 	return err
 }
 
+// grpcConfig is the gRPC gateway configuration.
+type grpcConfig struct {
+	Modules []string
+	rpcEndpointConfig
+}
+
+// grpcServer serves the gRPC gateway. Calls are forwarded to a dedicated RPC server
+// holding the allowed modules.
+type grpcServer struct {
+	log log.Logger
+
+	mu        sync.Mutex
+	server    *grpc.Server
+	listener  net.Listener // non-nil when server is running
+	config    grpcConfig
+	rpcServer *rpc.Server // non-nil when the gateway is enabled
+	client    *rpc.Client // in-process client of rpcServer used by the gateway
+
+	// These are set by setListenAddr.
+	endpoint string
+	host     string
+	port     int
+}
+
+func newGRPCServer(log log.Logger) *grpcServer {
+	return &grpcServer{log: log}
+}
+
+// setListenAddr configures the listening address of the server.
+// The address can only be set while the server isn't running.
+func (g *grpcServer) setListenAddr(host string, port int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.listener != nil && (host != g.host || port != g.port) {
+		return fmt.Errorf("gRPC server already running on %s", g.endpoint)
+	}
+	g.host, g.port = host, port
+	g.endpoint = net.JoinHostPort(host, fmt.Sprintf("%d", port))
+	return nil
+}
+
+// listenAddr returns the listening address of the server.
+func (g *grpcServer) listenAddr() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.listener != nil {
+		return g.listener.Addr().String()
+	}
+	return g.endpoint
+}
+
+// enable registers the allowed APIs on the gateway.
+func (g *grpcServer) enable(apis []rpc.API, config grpcConfig) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.rpcServer != nil {
+		return fmt.Errorf("gRPC gateway is already enabled")
+	}
+	// The limiter is applied by the gateway, all calls reach the server through
+	// the same in-process client.
+	srv := rpc.NewServer()
+	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
+	if err := RegisterApis(apis, config.Modules, srv); err != nil {
+		return err
+	}
+	g.config, g.rpcServer = config, srv
+	return nil
+}
+
+// start starts the gRPC server if it is enabled and not already running.
+func (g *grpcServer) start() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.endpoint == "" || g.rpcServer == nil || g.listener != nil {
+		return nil // already running or not configured
+	}
+	listener, err := net.Listen("tcp", g.endpoint)
+	if err != nil {
+		g.rpcServer.Stop()
+		g.rpcServer = nil
+		return err
+	}
+	var opts []grpc.ServerOption
+	if g.config.jwtSecret != nil {
+		opts = newJWTInterceptors(g.config.jwtSecret)
+	}
+	g.server = grpc.NewServer(opts...)
+	g.client = rpc.DialInProc(g.rpcServer)
+	grpcapi.NewGateway(g.client, g.config.limiter).Register(g.server)
+	g.listener = listener
+	go g.server.Serve(listener)
+
+	g.log.Info("gRPC server started", "endpoint", listener.Addr(),
+		"auth", g.config.jwtSecret != nil, "modules", strings.Join(g.config.Modules, ","))
+	return nil
+}
+
+// stop shuts down the gRPC server and disables the gateway.
+func (g *grpcServer) stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.rpcServer == nil {
+		return
+	}
+	if g.listener != nil {
+		// Closing the client first ends all subscriptions, so streaming calls
+		// return and the graceful shutdown doesn't block on them.
+		g.client.Close()
+		g.server.GracefulStop()
+		g.log.Info("gRPC server stopped", "endpoint", g.listener.Addr())
+		g.server, g.listener, g.client = nil, nil, nil
+	}
+	g.rpcServer.Stop()
+	g.rpcServer = nil
+}
+
 // RegisterApis checks the given modules' availability, generates an allowlist based on the allowed modules,
 // and then registers all of the APIs exposed by the services.
 func RegisterApis(apis []rpc.API, modules []string, srv *rpc.Server) error {
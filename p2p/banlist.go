@@ -0,0 +1,160 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package p2p
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"golang.org/x/exp/slices"
+)
+
+// BanList is the set of banned nodes and networks, as returned by Server.BanList.
+type BanList struct {
+	Nodes    []string `json:"nodes"`
+	Networks []string `json:"networks"`
+}
+
+// banList holds nodes and IP networks which were banned by the operator. Unlike
+// reputation bans, these bans don't expire and also apply to trusted nodes. The
+// list is persisted in the node database.
+type banList struct {
+	db    *enode.DB
+	mu    sync.RWMutex
+	nodes map[enode.ID]struct{}
+	nets  []*net.IPNet
+}
+
+func newBanList(db *enode.DB) *banList {
+	b := &banList{db: db, nodes: make(map[enode.ID]struct{}), nets: db.BannedNetworks()}
+	for _, id := range db.BannedNodes() {
+		b.nodes[id] = struct{}{}
+	}
+	return b
+}
+
+// banNode adds a node to the list. The ban is not applied if it can't be persisted.
+func (b *banList) banNode(id enode.ID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.db.BanNode(id); err != nil {
+		return fmt.Errorf("can't store node ban: %w", err)
+	}
+	b.nodes[id] = struct{}{}
+	return nil
+}
+
+// unbanNode lifts the ban of a node. It returns false if the node wasn't banned.
+func (b *banList) unbanNode(id enode.ID) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.nodes[id]; !ok {
+		return false
+	}
+	delete(b.nodes, id)
+	if err := b.db.UnbanNode(id); err != nil {
+		log.Warn("Failed to remove node ban from database", "id", id, "err", err)
+	}
+	return true
+}
+
+// banNetwork adds a network to the list. The ban is not applied if it can't be persisted.
+func (b *banList) banNetwork(n *net.IPNet) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.indexOfNetwork(n) >= 0 {
+		return nil
+	}
+	if err := b.db.BanNetwork(n); err != nil {
+		return fmt.Errorf("can't store network ban: %w", err)
+	}
+	b.nets = append(b.nets, n)
+	return nil
+}
+
+// unbanNetwork lifts the ban of a network. It returns false if the network wasn't banned.
+func (b *banList) unbanNetwork(n *net.IPNet) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	i := b.indexOfNetwork(n)
+	if i < 0 {
+		return false
+	}
+	b.nets = append(b.nets[:i], b.nets[i+1:]...)
+	if err := b.db.UnbanNetwork(n); err != nil {
+		log.Warn("Failed to remove network ban from database", "net", n, "err", err)
+	}
+	return true
+}
+
+func (b *banList) indexOfNetwork(n *net.IPNet) int {
+	return slices.IndexFunc(b.nets, func(bn *net.IPNet) bool {
+		return bn.String() == n.String()
+	})
+}
+
+// containsID reports whether the node is banned.
+func (b *banList) containsID(id enode.ID) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	_, ok := b.nodes[id]
+	return ok
+}
+
+// containsIP reports whether the IP is in a banned network.
+func (b *banList) containsIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, n := range b.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether the node is banned by ID or by any of its IPs.
+func (b *banList) contains(n *enode.Node) bool {
+	return b.containsID(n.ID()) || b.containsIP(n.IP()) || b.containsIP(n.IPv6())
+}
+
+func (b *banList) list() *BanList {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	list := &BanList{Nodes: make([]string, 0, len(b.nodes)), Networks: make([]string, 0, len(b.nets))}
+	for id := range b.nodes {
+		list.Nodes = append(list.Nodes, id.String())
+	}
+	for _, n := range b.nets {
+		list.Networks = append(list.Networks, n.String())
+	}
+	slices.Sort(list.Nodes)
+	slices.Sort(list.Networks)
+	return list
+}
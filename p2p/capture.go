@@ -0,0 +1,138 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/capture"
+)
+
+// setupCapture creates the capture file.
+func (srv *Server) setupCapture() error {
+	f, err := os.Create(srv.CaptureFile)
+	if err != nil {
+		return err
+	}
+	w, err := capture.NewWriter(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	srv.captureFile, srv.capture = f, w
+	srv.log.Info("Capturing p2p messages", "file", srv.CaptureFile)
+	return nil
+}
+
+// writeCapture appends a record to the capture file.
+func (srv *Server) writeCapture(r *capture.Record) {
+	if err := srv.capture.Write(r); err != nil {
+		srv.captureErr.Do(func() {
+			srv.log.Warn("Failed to write p2p message capture", "err", err)
+		})
+	}
+}
+
+// captureConnect records the connection of a peer.
+func (srv *Server) captureConnect(p *Peer) {
+	r := &capture.Record{
+		Time:    time.Now(),
+		Type:    capture.Connect,
+		Peer:    p.ID(),
+		Inbound: p.Inbound(),
+		Node:    p.Node().String(),
+		Name:    p.Fullname(),
+	}
+	for _, c := range p.Caps() {
+		r.Caps = append(r.Caps, capture.Cap{Name: c.Name, Version: c.Version})
+	}
+	srv.writeCapture(r)
+}
+
+// captureDisconnect records the disconnection of a peer.
+func (srv *Server) captureDisconnect(p *Peer, err error) {
+	srv.writeCapture(&capture.Record{
+		Time:   time.Now(),
+		Type:   capture.Disconnect,
+		Peer:   p.ID(),
+		Reason: err.Error(),
+	})
+}
+
+// msgCapturer wraps a MsgReadWriter and records all messages sent or received
+// through it.
+type msgCapturer struct {
+	MsgReadWriter
+
+	write func(*capture.Record)
+	peer  *Peer
+	proto Protocol
+}
+
+// ReadMsg reads a message from the underlying MsgReadWriter and records it.
+func (c *msgCapturer) ReadMsg() (Msg, error) {
+	msg, err := c.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	payload, err := io.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	c.record(msg.Code, payload, true)
+	return msg, nil
+}
+
+// WriteMsg writes a message to the underlying MsgReadWriter and records it.
+func (c *msgCapturer) WriteMsg(msg Msg) error {
+	payload, err := io.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	if err := c.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	c.record(msg.Code, payload, false)
+	return nil
+}
+
+func (c *msgCapturer) record(code uint64, payload []byte, inbound bool) {
+	c.write(&capture.Record{
+		Time:     time.Now(),
+		Type:     capture.Message,
+		Peer:     c.peer.ID(),
+		Inbound:  inbound,
+		Protocol: c.proto.Name,
+		Version:  c.proto.Version,
+		Code:     code,
+		Payload:  payload,
+	})
+}
+
+// Close closes the underlying MsgReadWriter if it implements the io.Closer
+// interface.
+func (c *msgCapturer) Close() error {
+	if v, ok := c.MsgReadWriter.(io.Closer); ok {
+		return v.Close()
+	}
+	return nil
+}
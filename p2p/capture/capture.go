@@ -0,0 +1,208 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package capture implements the file format of devp2p message captures.
+//
+// A capture file starts with a header identifying the format, followed by a
+// sequence of RLP-encoded records. Records describe the connection of a peer,
+// a subprotocol message sent to or received from it, or its disconnection.
+package capture
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	magic   = "devp2p-capture"
+	version = 1
+)
+
+var errInvalidHeader = errors.New("not a devp2p capture file")
+
+// Type is the kind of a capture record.
+type Type uint8
+
+const (
+	Connect    Type = iota // peer connected
+	Message                // subprotocol message sent or received
+	Disconnect             // peer disconnected
+)
+
+func (t Type) String() string {
+	switch t {
+	case Connect:
+		return "connect"
+	case Message:
+		return "message"
+	case Disconnect:
+		return "disconnect"
+	default:
+		return fmt.Sprintf("unknown(%d)", t)
+	}
+}
+
+// Cap is a subprotocol capability announced by a peer.
+type Cap struct {
+	Name    string
+	Version uint
+}
+
+func (c Cap) String() string {
+	return fmt.Sprintf("%s/%d", c.Name, c.Version)
+}
+
+// Record is an entry of a capture file.
+type Record struct {
+	Time    time.Time
+	Type    Type
+	Peer    enode.ID
+	Inbound bool // Connect: the peer dialed us, Message: received from the peer
+
+	// Connect records contain the node record, client name and capabilities
+	// of the peer.
+	Node string
+	Name string
+	Caps []Cap
+
+	// Message records contain the protocol, the code relative to the protocol
+	// offset and the RLP payload of the message.
+	Protocol string
+	Version  uint
+	Code     uint64
+	Payload  []byte
+
+	// Disconnect records contain the reason of the disconnection.
+	Reason string
+}
+
+// encRecord is the RLP encoding of a record.
+type encRecord struct {
+	Time     uint64 // unix nanoseconds
+	Type     Type
+	Peer     enode.ID
+	Inbound  bool
+	Node     string
+	Name     string
+	Caps     []Cap
+	Protocol string
+	Version  uint
+	Code     uint64
+	Payload  []byte
+	Reason   string
+}
+
+type header struct {
+	Magic   string
+	Version uint
+}
+
+// Writer writes records to a capture file. It is safe for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error // first write error, the capture ends there
+}
+
+// NewWriter writes the capture header to w and returns a writer for the records.
+func NewWriter(w io.Writer) (*Writer, error) {
+	if err := rlp.Encode(w, &header{magic, version}); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Write appends a record. Once a write fails, all further writes return the same
+// error, so the capture is not corrupted by partial records.
+func (w *Writer) Write(r *Record) error {
+	enc, err := rlp.EncodeToBytes(&encRecord{
+		Time:     uint64(r.Time.UnixNano()),
+		Type:     r.Type,
+		Peer:     r.Peer,
+		Inbound:  r.Inbound,
+		Node:     r.Node,
+		Name:     r.Name,
+		Caps:     r.Caps,
+		Protocol: r.Protocol,
+		Version:  r.Version,
+		Code:     r.Code,
+		Payload:  r.Payload,
+		Reason:   r.Reason,
+	})
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	_, w.err = w.w.Write(enc)
+	return w.err
+}
+
+// Reader reads the records of a capture file.
+type Reader struct {
+	s *rlp.Stream
+}
+
+// NewReader checks the capture header of r and returns a reader for the records.
+func NewReader(r io.Reader) (*Reader, error) {
+	s := rlp.NewStream(bufio.NewReader(r), 0)
+	var h header
+	if err := s.Decode(&h); err != nil || h.Magic != magic {
+		return nil, errInvalidHeader
+	}
+	if h.Version != version {
+		return nil, fmt.Errorf("unsupported capture version %d", h.Version)
+	}
+	return &Reader{s: s}, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the capture.
+func (r *Reader) Next() (*Record, error) {
+	var enc encRecord
+	if err := r.s.Decode(&enc); err != nil {
+		return nil, err
+	}
+	if len(enc.Caps) == 0 {
+		enc.Caps = nil
+	}
+	if len(enc.Payload) == 0 {
+		enc.Payload = nil
+	}
+	return &Record{
+		Time:     time.Unix(0, int64(enc.Time)),
+		Type:     enc.Type,
+		Peer:     enc.Peer,
+		Inbound:  enc.Inbound,
+		Node:     enc.Node,
+		Name:     enc.Name,
+		Caps:     enc.Caps,
+		Protocol: enc.Protocol,
+		Version:  enc.Version,
+		Code:     enc.Code,
+		Payload:  enc.Payload,
+		Reason:   enc.Reason,
+	}, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package capture
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestCaptureRoundtrip(t *testing.T) {
+	var (
+		buf  bytes.Buffer
+		now  = time.Unix(0, time.Now().UnixNano())
+		recs = []*Record{
+			{Time: now, Type: Connect, Peer: enode.ID{1}, Inbound: true, Node: "enr:-test", Name: "geth", Caps: []Cap{{"eth", 68}, {"snap", 1}}},
+			{Time: now.Add(time.Millisecond), Type: Message, Peer: enode.ID{1}, Inbound: true, Protocol: "eth", Version: 68, Code: 3, Payload: []byte{0xc1, 0x01}},
+			{Time: now.Add(2 * time.Millisecond), Type: Disconnect, Peer: enode.ID{1}, Reason: "too many peers"},
+		}
+	)
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range recs {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range recs {
+		have, err := r.Next()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Fatalf("record %d mismatch:\nhave %+v\nwant %+v", i, have, want)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected EOF at end of capture, got %v", err)
+	}
+}
+
+func TestCaptureInvalidHeader(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte{0xc2, 0x80, 0x80})); err != errInvalidHeader {
+		t.Fatalf("wrong error for invalid header: %v", err)
+	}
+}
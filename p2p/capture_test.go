@@ -0,0 +1,132 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/capture"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestServerCapture(t *testing.T) {
+	var (
+		file      = filepath.Join(t.TempDir(), "capture")
+		received1 = make(chan string, 2)
+		received2 = make(chan string, 2)
+	)
+	srv1 := &Server{Config: Config{
+		Name:        "srv1",
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		NoDiscovery: true,
+		CaptureFile: file,
+		Protocols:   quicTestProtocols(received1),
+		PrivateKey:  newkey(),
+		Logger:      testlog.Logger(t, log.LvlTrace).New("server", "srv1"),
+	}}
+	srv2 := &Server{Config: Config{
+		Name:        "srv2",
+		MaxPeers:    10,
+		NoDiscovery: true,
+		Protocols:   quicTestProtocols(received2),
+		PrivateKey:  newkey(),
+		Logger:      testlog.Logger(t, log.LvlTrace).New("server", "srv2"),
+	}}
+	if err := srv1.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	if err := srv2.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv2.Stop()
+	if !syncAddPeer(srv2, srv1.Self()) {
+		t.Fatal("peer not connected")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received1:
+		case <-time.After(2 * time.Second):
+			t.Fatal("greeting not received")
+		}
+	}
+	srv1.Stop()
+
+	// Check the capture contains the session.
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := capture.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		peer     = srv2.Self().ID()
+		types    []capture.Type
+		messages = make(map[string]bool)
+	)
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if rec.Peer != peer {
+			t.Fatalf("record of wrong peer %v", rec.Peer)
+		}
+		types = append(types, rec.Type)
+		switch rec.Type {
+		case capture.Connect:
+			if rec.Name != "srv2" || len(rec.Caps) != 2 || !rec.Inbound {
+				t.Errorf("wrong connect record: %+v", rec)
+			}
+		case capture.Message:
+			var greeting string
+			if err := rlp.DecodeBytes(rec.Payload, &greeting); err != nil {
+				t.Fatalf("invalid payload: %v", err)
+			}
+			if rec.Code != 2 || rec.Version != 1 || !strings.HasPrefix(greeting, rec.Protocol+" from ") {
+				t.Errorf("wrong message record: %+v", rec)
+			}
+			dir := "out"
+			if rec.Inbound {
+				dir = "in"
+			}
+			messages[rec.Protocol+" "+dir] = true
+		case capture.Disconnect:
+			if rec.Reason != DiscQuitting.Error() {
+				t.Errorf("wrong disconnect reason %q", rec.Reason)
+			}
+		}
+	}
+	if len(types) != 6 || types[0] != capture.Connect || types[5] != capture.Disconnect {
+		t.Fatalf("wrong records in capture: %v", types)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("missing messages in capture: %v", messages)
+	}
+}
@@ -60,17 +60,58 @@ type nodeResolver interface {
 
 // tcpDialer implements NodeDialer using real TCP connections.
 type tcpDialer struct {
-	d *net.Dialer
+	d     *net.Dialer
+	allow func(net.IP) bool // reports whether an endpoint may be dialed, nil allows all
 }
 
+// Dial connects to the IPv4 endpoint of dest, and falls back to its IPv6 endpoint
+// if that fails or dest has no IPv4 endpoint.
 func (t tcpDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
-	return t.d.DialContext(ctx, "tcp", nodeAddr(dest).String())
+	addrs := nodeAddrs(dest)
+	if len(addrs) == 0 {
+		return nil, errNoPort
+	}
+	if t.allow != nil {
+		allowed := addrs[:0]
+		for _, addr := range addrs {
+			if t.allow(addr.IP) {
+				allowed = append(allowed, addr)
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, errNetRestrict
+		}
+		addrs = allowed
+	}
+	var err error
+	for _, addr := range addrs {
+		var fd net.Conn
+		if fd, err = t.d.DialContext(ctx, "tcp", addr.String()); err == nil {
+			return fd, nil
+		}
+	}
+	return nil, err
 }
 
 func nodeAddr(n *enode.Node) net.Addr {
+	if addrs := nodeAddrs(n); len(addrs) > 0 {
+		return addrs[0]
+	}
 	return &net.TCPAddr{IP: n.IP(), Port: n.TCP()}
 }
 
+// nodeAddrs returns the TCP endpoints of n, IPv4 first.
+func nodeAddrs(n *enode.Node) []*net.TCPAddr {
+	var addrs []*net.TCPAddr
+	if addr := n.TCPEndpoint(false); addr != nil {
+		addrs = append(addrs, addr)
+	}
+	if addr := n.TCPEndpoint(true); addr != nil {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // checkDial errors:
 var (
 	errSelf             = errors.New("is self")
@@ -78,7 +119,8 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
-	errNoPort           = errors.New("node does not provide TCP port")
+	errNoPort           = errors.New("node does not provide TCP or QUIC port")
+	errBanned           = errors.New("node is banned")
 )
 
 // dialer creates outbound connections and submits them into Server.
@@ -102,6 +144,7 @@ type dialScheduler struct {
 	remStaticCh chan *enode.Node
 	addPeerCh   chan *conn
 	remPeerCh   chan *conn
+	setMaxCh    chan int
 
 	// Everything below here belongs to loop and
 	// should only be accessed by code on the loop goroutine.
@@ -132,6 +175,8 @@ type dialConfig struct {
 	maxDialPeers   int              // maximum number of dialed peers
 	maxActiveDials int              // maximum number of active dials
 	netRestrict    *netutil.Netlist // IP netrestrict list, disabled if nil
+	reputation     *reputationStore // node reputations, disabled if nil
+	bans           *banList         // operator bans, disabled if nil
 	resolver       nodeResolver
 	dialer         NodeDialer
 	log            log.Logger
@@ -173,6 +218,7 @@ func newDialScheduler(config dialConfig, it enode.Iterator, setupFunc dialSetupF
 		remStaticCh:  make(chan *enode.Node),
 		addPeerCh:    make(chan *conn),
 		remPeerCh:    make(chan *conn),
+		setMaxCh:     make(chan int),
 	}
 	d.lastStatsLog = d.clock.Now()
 	d.ctx, d.cancel = context.WithCancel(context.Background())
@@ -220,6 +266,14 @@ func (d *dialScheduler) peerRemoved(c *conn) {
 	}
 }
 
+// setMaxDialPeers changes the maximum number of dialed peers.
+func (d *dialScheduler) setMaxDialPeers(n int) {
+	select {
+	case d.setMaxCh <- n:
+	case <-d.ctx.Done():
+	}
+}
+
 // loop is the main loop of the dialer.
 func (d *dialScheduler) loop(it enode.Iterator) {
 	var (
@@ -297,6 +351,9 @@ loop:
 				}
 			}
 
+		case n := <-d.setMaxCh:
+			d.maxDialPeers = n
+
 		case <-d.historyTimer.C():
 			d.expireHistory()
 
@@ -375,7 +432,7 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if n.ID() == d.self {
 		return errSelf
 	}
-	if n.IP() != nil && n.TCP() == 0 {
+	if n.IP() != nil && len(nodeAddrs(n)) == 0 && quicEndpoint(n) == nil {
 		// This check can trigger if a non-TCP node is found
 		// by discovery. If there is no IP, the node is a static
 		// node and the actual endpoint will be resolved later in dialTask.
@@ -387,12 +444,25 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if _, ok := d.peers[n.ID()]; ok {
 		return errAlreadyConnected
 	}
-	if d.netRestrict != nil && !d.netRestrict.Contains(n.IP()) {
+	if d.netRestrict != nil && !d.netRestrict.Contains(n.IP()) && !d.netRestrict.Contains(n.IPv6()) {
 		return errNetRestrict
 	}
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
+	if d.bans != nil && d.bans.contains(n) {
+		// Check again later because the ban may be lifted.
+		d.history.add(string(n.ID().Bytes()), d.clock.Now().Add(dialHistoryExpiration))
+		return errBanned
+	}
+	if d.reputation != nil {
+		if ban := d.reputation.bannedFor(n.ID()); ban > 0 {
+			// Keep the node in history until the ban ends, which also
+			// returns banned static nodes to the pool afterwards.
+			d.history.add(string(n.ID().Bytes()), d.clock.Now().Add(ban))
+			return errBanned
+		}
+	}
 	return nil
 }
 
@@ -439,7 +509,11 @@ func (d *dialScheduler) removeFromStaticPool(idx int) {
 func (d *dialScheduler) startDial(task *dialTask) {
 	d.log.Trace("Starting p2p dial", "id", task.dest.ID(), "ip", task.dest.IP(), "flag", task.flags)
 	hkey := string(task.dest.ID().Bytes())
-	d.history.add(hkey, d.clock.Now().Add(dialHistoryExpiration))
+	exp := dialHistoryExpiration
+	if d.reputation != nil {
+		exp += d.reputation.dialPenalty(task.dest.ID())
+	}
+	d.history.add(hkey, d.clock.Now().Add(exp))
 	d.dialing[task.dest.ID()] = task
 	go func() {
 		task.run(d)
@@ -36,6 +36,13 @@ type UDPConn interface {
 	LocalAddr() net.Addr
 }
 
+// ipv6Only reports whether c is bound to a specific IPv6 address, which means
+// that it can't reach IPv4 hosts.
+func ipv6Only(c UDPConn) bool {
+	addr, ok := c.LocalAddr().(*net.UDPAddr)
+	return ok && addr.IP.To4() == nil && len(addr.IP) == net.IPv6len && !addr.IP.IsUnspecified()
+}
+
 // Config holds settings for the discovery listener.
 type Config struct {
 	// These settings are required and configure the UDP listener:
@@ -52,6 +59,10 @@ type Config struct {
 	PingInterval    time.Duration // speed of node liveness check
 	RefreshInterval time.Duration // used in bucket refresh
 
+	// IsBanned reports nodes which must be kept out of the table, e.g. because
+	// they misbehaved as peers. It is called for every node added to the table.
+	IsBanned func(enode.ID) bool
+
 	// The options below are useful in very specific cases, like in unit tests.
 	V5ProtocolID *[6]byte
 	Log          log.Logger         // if set, log messages go here
@@ -89,9 +89,46 @@ func unwrapNodes(ns []*node) []*enode.Node {
 }
 
 func (n *node) addr() *net.UDPAddr {
+	return nodeAddr(&n.Node, false)
+}
+
+// nodeAddr returns the UDP endpoint at which n is contacted. For nodes with
+// endpoints in both address families, the IPv4 endpoint is chosen unless ipv6
+// is set.
+func nodeAddr(n *enode.Node, ipv6 bool) *net.UDPAddr {
+	if addr := n.UDPEndpoint(ipv6); addr != nil {
+		return addr
+	}
+	if addr := n.UDPEndpoint(!ipv6); addr != nil {
+		return addr
+	}
 	return &net.UDPAddr{IP: n.IP(), Port: n.UDP()}
 }
 
+// mergeEndpoints combines two sightings of a discv4 node through different
+// address families, so the table keeps both endpoints. It returns nil if n
+// should simply replace old, e.g. when either node is backed by a signed record.
+func mergeEndpoints(old, n *node) *node {
+	if !enode.IsV4Compat(&old.Node) || !enode.IsV4Compat(&n.Node) {
+		return nil
+	}
+	var (
+		ip4, tcp, udp   = n.IPv4(), n.TCP(), n.UDP()
+		ip6, tcp6, udp6 = n.IPv6(), n.TCP6(), n.UDP6()
+	)
+	if ip4 == nil {
+		ip4, tcp, udp = old.IPv4(), old.TCP(), old.UDP()
+	}
+	if ip6 == nil {
+		ip6, tcp6, udp6 = old.IPv6(), old.TCP6(), old.UDP6()
+	}
+	if ip4 == nil || ip6 == nil {
+		return nil
+	}
+	merged := enode.NewV4DualStack(n.Pubkey(), ip4, tcp, udp, ip6, tcp6, udp6)
+	return &node{Node: *merged, addedAt: old.addedAt, livenessChecks: n.livenessChecks}
+}
+
 func (n *node) String() string {
 	return n.Node.String()
 }
@@ -488,13 +488,20 @@ func (tab *Table) bucketAtDistance(d int) *bucket {
 	return tab.buckets[d-bucketMinDistance-1]
 }
 
+// isBanned reports whether the node is banned due to bad behaviour as a peer.
+// Banned nodes are kept out of the table, so they are neither dialed nor
+// handed out to other nodes.
+func (tab *Table) isBanned(id enode.ID) bool {
+	return tab.cfg.IsBanned != nil && tab.cfg.IsBanned(id)
+}
+
 // addSeenNode adds a node which may or may not be live to the end of a bucket. If the
 // bucket has space available, adding the node succeeds immediately. Otherwise, the node is
 // added to the replacements list.
 //
 // The caller must not hold tab.mutex.
 func (tab *Table) addSeenNode(n *node) {
-	if n.ID() == tab.self().ID() {
+	if n.ID() == tab.self().ID() || tab.isBanned(n.ID()) {
 		return
 	}
 
@@ -538,7 +545,7 @@ func (tab *Table) addVerifiedNode(n *node) {
 	if !tab.isInitDone() {
 		return
 	}
-	if n.ID() == tab.self().ID() {
+	if n.ID() == tab.self().ID() || tab.isBanned(n.ID()) {
 		return
 	}
 
@@ -645,6 +652,10 @@ func (tab *Table) replace(b *bucket, last *node) *node {
 func (tab *Table) bumpInBucket(b *bucket, n *node) bool {
 	for i := range b.entries {
 		if b.entries[i].ID() == n.ID() {
+			if merged := mergeEndpoints(b.entries[i], n); merged != nil {
+				// The node was seen through the other address family.
+				n = merged
+			}
 			if !n.IP().Equal(b.entries[i].IP()) {
 				// Endpoint has changed, ensure that the new IP fits into table limits.
 				tab.removeIP(b, b.entries[i].IP())
@@ -302,6 +302,30 @@ func TestTable_addVerifiedNode(t *testing.T) {
 	checkIPLimitInvariant(t, tab)
 }
 
+// This test checks that nodes banned due to bad peer behaviour are not added.
+func TestTable_addBannedNode(t *testing.T) {
+	var (
+		transport = newPingRecorder()
+		n1        = nodeAtDistance(transport.Self().ID(), 256, net.IP{88, 77, 66, 1})
+		n2        = nodeAtDistance(transport.Self().ID(), 256, net.IP{88, 77, 66, 2})
+		cfg       = Config{IsBanned: func(id enode.ID) bool { return id == n1.ID() }}
+	)
+	db, _ := enode.OpenDB("")
+	tab, _ := newTable(transport, db, cfg)
+	go tab.loop()
+	<-tab.initDone
+	defer db.Close()
+	defer tab.close()
+
+	tab.addSeenNode(n1)
+	tab.addVerifiedNode(n1)
+	tab.addSeenNode(n2)
+
+	if bcontent := []*node{n2}; !reflect.DeepEqual(tab.bucket(n1.ID()).entries, bcontent) {
+		t.Fatalf("wrong bucket content: %v", tab.bucket(n1.ID()).entries)
+	}
+}
+
 func TestTable_addSeenNode(t *testing.T) {
 	tab, db := newTestTable(newPingRecorder())
 	<-tab.initDone
@@ -333,6 +357,38 @@ func TestTable_addSeenNode(t *testing.T) {
 	checkIPLimitInvariant(t, tab)
 }
 
+// This test checks that a discv4 node seen through both address families is
+// stored with both endpoints.
+func TestTable_addVerifiedNodeDualStack(t *testing.T) {
+	tab, db := newTestTable(newPingRecorder())
+	<-tab.initDone
+	defer db.Close()
+	defer tab.close()
+
+	var (
+		key = newkey()
+		ip4 = net.IP{88, 77, 66, 1}
+		ip6 = net.ParseIP("2001:db8::1")
+		n4  = wrapNode(enode.NewV4(&key.PublicKey, ip4, 30303, 30303))
+		n6  = wrapNode(enode.NewV4(&key.PublicKey, ip6, 30304, 30305))
+	)
+	tab.addVerifiedNode(n4)
+	tab.addVerifiedNode(n6)
+
+	entries := tab.bucket(n4.ID()).entries
+	if len(entries) != 1 {
+		t.Fatalf("wrong number of bucket entries: %d", len(entries))
+	}
+	n := entries[0]
+	if !n.IPv4().Equal(ip4) || n.TCP() != 30303 || n.UDP() != 30303 {
+		t.Errorf("wrong IPv4 endpoint: %v tcp %d udp %d", n.IPv4(), n.TCP(), n.UDP())
+	}
+	if !n.IPv6().Equal(ip6) || n.TCP6() != 30304 || n.UDP6() != 30305 {
+		t.Errorf("wrong IPv6 endpoint: %v tcp %d udp %d", n.IPv6(), n.TCP6(), n.UDP6())
+	}
+	checkIPLimitInvariant(t, tab)
+}
+
 // This test checks that ENR updates happen during revalidation. If a node in the table
 // announces a new sequence number, the new record should be pulled.
 func TestTable_revalidateSyncRecord(t *testing.T) {
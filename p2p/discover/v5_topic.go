@@ -0,0 +1,566 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package discover
+
+import (
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	topicAdLifetime       = 15 * time.Minute // how long an ad stays in the topic table
+	topicQueueLimit       = 100              // max ads per topic
+	topicTableLimit       = 5000             // max ads across all topics
+	topicQueryResultLimit = 16               // max ads in a TOPICQUERY response
+	ticketGracePeriod     = 10 * time.Second // how long a ticket stays usable after its wait time
+
+	topicRegistrarCount   = 8               // number of nodes an ad is placed at
+	topicTicketAttempts   = 5               // max REGTOPIC attempts per registrar
+	topicRegisterRetry    = 1 * time.Minute // delay before retrying when no ad was placed
+	topicSearchInterval   = 10 * time.Second
+	topicRegisterMinDelay = 10 * time.Second
+)
+
+var errTicketMAC = errors.New("invalid ticket MAC")
+
+// Topic identifies a service advertised through discovery.
+type Topic [32]byte
+
+// NewTopic creates the topic identifier for the given topic name.
+func NewTopic(name string) Topic {
+	return sha256.Sum256([]byte(name))
+}
+
+func (t Topic) String() string {
+	return hexutil.Encode(t[:])
+}
+
+// topicTable stores the ads placed at the local node. Registrants are admitted
+// through tickets: the first REGTOPIC is answered with a ticket and a wait time,
+// and the ad is placed when the registrant comes back with the ticket after waiting.
+// Since the ticket is bound to the registrant's IP, this also ensures that ads can
+// only be placed by nodes which can receive packets on their advertised endpoint.
+type topicTable struct {
+	mu     sync.Mutex
+	clock  mclock.Clock
+	key    []byte // ticket MAC key
+	queues map[Topic][]*topicAd
+	count  int
+}
+
+type topicAd struct {
+	node    *enode.Node
+	expires mclock.AbsTime
+}
+
+// ticket is the content of a ticket issued by topicTable.
+type ticket struct {
+	ID     enode.ID
+	IP     net.IP
+	Topic  Topic
+	Issued uint64 // mclock.AbsTime
+	Wait   uint64 // time.Duration
+}
+
+func newTopicTable(clock mclock.Clock) *topicTable {
+	key := make([]byte, 32)
+	crand.Read(key)
+	return &topicTable{clock: clock, key: key, queues: make(map[Topic][]*topicAd)}
+}
+
+// register handles a registration attempt. If the ad was placed, the returned
+// ticket is nil and the wait time is the ad lifetime. Otherwise the registrant
+// needs to wait for the returned duration and try again using the ticket.
+func (tt *topicTable) register(n *enode.Node, ip net.IP, topic Topic, enc []byte) (time.Duration, []byte) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	now := tt.clock.Now()
+	tt.expire(now)
+
+	if len(enc) > 0 {
+		tk, err := tt.decodeTicket(enc)
+		if err == nil && (tk.ID != n.ID() || !tk.IP.Equal(ip) || tk.Topic != topic) {
+			err = errors.New("ticket issued to different registrant")
+		}
+		if err == nil {
+			ripe := mclock.AbsTime(tk.Issued).Add(time.Duration(tk.Wait))
+			switch {
+			case now < ripe:
+				// Came back too early, keep waiting.
+				return roundWait(ripe.Sub(now)), enc
+			case now <= ripe.Add(ticketGracePeriod):
+				if tt.waitTime(n.ID(), topic, now) == 0 {
+					tt.add(n, topic, now)
+					return topicAdLifetime, nil
+				}
+			}
+		}
+	}
+	wait := roundWait(tt.waitTime(n.ID(), topic, now))
+	return wait, tt.encodeTicket(&ticket{
+		ID:     n.ID(),
+		IP:     ip,
+		Topic:  topic,
+		Issued: uint64(now),
+		Wait:   uint64(wait),
+	})
+}
+
+// query returns the most recently placed ads for the topic.
+func (tt *topicTable) query(topic Topic, limit int) []*enode.Node {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	tt.expire(tt.clock.Now())
+	q := tt.queues[topic]
+	nodes := make([]*enode.Node, 0, min(limit, len(q)))
+	for i := len(q) - 1; i >= 0 && len(nodes) < limit; i-- {
+		nodes = append(nodes, q[i].node)
+	}
+	return nodes
+}
+
+// waitTime computes how long a registrant has to wait until a slot becomes available.
+func (tt *topicTable) waitTime(id enode.ID, topic Topic, now mclock.AbsTime) time.Duration {
+	q := tt.queues[topic]
+	for _, ad := range q {
+		if ad.node.ID() == id {
+			return 0 // refreshing an existing ad doesn't take up another slot.
+		}
+	}
+	var wait time.Duration
+	if len(q) >= topicQueueLimit {
+		wait = q[0].expires.Sub(now)
+	}
+	if tt.count >= topicTableLimit {
+		oldest := mclock.AbsTime(0)
+		for _, q := range tt.queues {
+			if oldest == 0 || q[0].expires < oldest {
+				oldest = q[0].expires
+			}
+		}
+		if w := oldest.Sub(now); w > wait {
+			wait = w
+		}
+	}
+	return wait
+}
+
+// add places an ad, replacing any previous ad of the same node.
+func (tt *topicTable) add(n *enode.Node, topic Topic, now mclock.AbsTime) {
+	q := tt.queues[topic]
+	for i, ad := range q {
+		if ad.node.ID() == n.ID() {
+			q = append(q[:i], q[i+1:]...)
+			tt.count--
+			break
+		}
+	}
+	tt.queues[topic] = append(q, &topicAd{node: n, expires: now.Add(topicAdLifetime)})
+	tt.count++
+}
+
+// expire removes ads which have reached the end of their lifetime.
+func (tt *topicTable) expire(now mclock.AbsTime) {
+	for topic, q := range tt.queues {
+		i := 0
+		for i < len(q) && q[i].expires <= now {
+			i++
+		}
+		if i == len(q) {
+			delete(tt.queues, topic)
+		} else if i > 0 {
+			tt.queues[topic] = append(q[:0], q[i:]...)
+		}
+		tt.count -= i
+	}
+}
+
+func (tt *topicTable) encodeTicket(t *ticket) []byte {
+	enc, _ := rlp.EncodeToBytes(t)
+	mac := hmac.New(sha256.New, tt.key)
+	mac.Write(enc)
+	return mac.Sum(enc)
+}
+
+func (tt *topicTable) decodeTicket(enc []byte) (*ticket, error) {
+	if len(enc) < sha256.Size {
+		return nil, errTicketMAC
+	}
+	content, sum := enc[:len(enc)-sha256.Size], enc[len(enc)-sha256.Size:]
+	mac := hmac.New(sha256.New, tt.key)
+	mac.Write(content)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return nil, errTicketMAC
+	}
+	var t ticket
+	if err := rlp.DecodeBytes(content, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// roundWait rounds d up to whole seconds, the resolution of TICKET wait times.
+func roundWait(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return (d + time.Second - 1).Truncate(time.Second)
+}
+
+// topicSystem implements topic registration and search on top of UDPv5.
+type topicSystem struct {
+	transport *UDPv5
+	table     *topicTable
+
+	mutex  sync.Mutex
+	regs   map[Topic]*topicRegistration
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type topicRegistration struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newTopicSystem(transport *UDPv5) *topicSystem {
+	return &topicSystem{
+		transport: transport,
+		table:     newTopicTable(transport.clock),
+		regs:      make(map[Topic]*topicRegistration),
+	}
+}
+
+// RegisterTopic starts advertising the local node under the given topic. Ads are
+// placed at the nodes closest to the topic identifier and kept alive until
+// UnregisterTopic is called or the transport is closed.
+func (t *UDPv5) RegisterTopic(topic Topic) {
+	t.topics.register(topic)
+}
+
+// UnregisterTopic stops advertising the local node under the given topic. Ads that
+// were already placed remain visible until they expire.
+func (t *UDPv5) UnregisterTopic(topic Topic) {
+	t.topics.unregister(topic)
+}
+
+// TopicSearch returns an iterator over nodes advertising the given topic. The
+// iterator queries the nodes closest to the topic identifier for ads, and repeats
+// the search periodically until it is closed.
+func (t *UDPv5) TopicSearch(topic Topic) enode.Iterator {
+	return t.topics.search(topic)
+}
+
+// regtopic sends REGTOPIC to a node. It returns the wait time and the ticket
+// for the next attempt, or a nil ticket if the ad was placed.
+func (t *UDPv5) regtopic(n *enode.Node, topic Topic, tk []byte) ([]byte, time.Duration, error) {
+	req := &v5wire.Regtopic{Topic: topic, ENR: t.localNode.Node().Record(), Ticket: tk}
+	resp := t.callToNode(n, v5wire.TicketMsg, req)
+	defer t.callDone(resp)
+
+	select {
+	case respMsg := <-resp.ch:
+		r := respMsg.(*v5wire.Ticket)
+		return r.Ticket, time.Duration(r.WaitTime) * time.Second, nil
+	case err := <-resp.err:
+		return nil, 0, err
+	}
+}
+
+// topicQuery sends TOPICQUERY to a node and waits for the ads in the response.
+func (t *UDPv5) topicQuery(n *enode.Node, topic Topic) ([]*enode.Node, error) {
+	resp := t.callToNode(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic})
+	return t.waitForNodes(resp, nil)
+}
+
+// handleRegtopic places an ad for the sender or issues a ticket.
+func (t *UDPv5) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr *net.UDPAddr) {
+	n, err := t.verifyTopicAd(p, fromID, fromAddr)
+	if err != nil {
+		t.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	wait, tk := t.topics.table.register(n, fromAddr.IP, p.Topic, p.Ticket)
+	t.sendResponse(fromID, fromAddr, &v5wire.Ticket{
+		ReqID:    p.ReqID,
+		Ticket:   tk,
+		WaitTime: uint32(wait / time.Second),
+	})
+}
+
+// verifyTopicAd checks that the record in REGTOPIC belongs to the sender.
+func (t *UDPv5) verifyTopicAd(p *v5wire.Regtopic, fromID enode.ID, fromAddr *net.UDPAddr) (*enode.Node, error) {
+	if p.ENR == nil {
+		return nil, errors.New("missing record")
+	}
+	n, err := enode.New(t.validSchemes, p.ENR)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID() != fromID {
+		return nil, fmt.Errorf("record of different node %v", n.ID())
+	}
+	if err := netutil.CheckRelayIP(fromAddr.IP, n.IP()); err != nil {
+		return nil, err
+	}
+	if t.netrestrict != nil && !t.netrestrict.Contains(n.IP()) {
+		return nil, errors.New("not contained in netrestrict list")
+	}
+	if n.UDP() <= 1024 {
+		return nil, errLowPort
+	}
+	return n, nil
+}
+
+// handleTopicQuery returns the ads for a topic to the requester.
+func (t *UDPv5) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr *net.UDPAddr) {
+	var nodes []*enode.Node
+	for _, n := range t.topics.table.query(p.Topic, topicQueryResultLimit) {
+		if netutil.CheckRelayIP(fromAddr.IP, n.IP()) == nil {
+			nodes = append(nodes, n)
+		}
+	}
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		t.sendResponse(fromID, fromAddr, resp)
+	}
+}
+
+// wait blocks until all background goroutines have exited.
+// This is called on transport shutdown, after closeCtx is canceled.
+func (ts *topicSystem) wait() {
+	ts.mutex.Lock()
+	ts.closed = true
+	ts.mutex.Unlock()
+	ts.wg.Wait()
+}
+
+func (ts *topicSystem) register(topic Topic) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.closed || ts.regs[topic] != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ts.transport.closeCtx)
+	reg := &topicRegistration{cancel: cancel, done: make(chan struct{})}
+	ts.regs[topic] = reg
+	ts.wg.Add(1)
+	go func() {
+		defer ts.wg.Done()
+		defer close(reg.done)
+		ts.registerLoop(ctx, topic)
+	}()
+}
+
+func (ts *topicSystem) unregister(topic Topic) {
+	ts.mutex.Lock()
+	reg := ts.regs[topic]
+	delete(ts.regs, topic)
+	ts.mutex.Unlock()
+
+	if reg != nil {
+		reg.cancel()
+		<-reg.done
+	}
+}
+
+// registerLoop keeps ads for the topic alive at the nodes closest to the topic.
+func (ts *topicSystem) registerLoop(ctx context.Context, topic Topic) {
+	t := ts.transport
+	for {
+		nodes := t.newLookup(ctx, enode.ID(topic)).run()
+		if len(nodes) > topicRegistrarCount {
+			nodes = nodes[:topicRegistrarCount]
+		}
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			refresh time.Duration
+		)
+		for _, n := range nodes {
+			wg.Add(1)
+			go func(n *enode.Node) {
+				defer wg.Done()
+				if lifetime, ok := ts.registerAt(ctx, n, topic); ok {
+					mu.Lock()
+					if refresh == 0 || lifetime < refresh {
+						refresh = lifetime
+					}
+					mu.Unlock()
+				}
+			}(n)
+		}
+		wg.Wait()
+
+		// Refresh ads well before the first of them expires.
+		if refresh == 0 {
+			refresh = topicRegisterRetry
+		} else if refresh -= refresh / 4; refresh < topicRegisterMinDelay {
+			refresh = topicRegisterMinDelay
+		}
+		if !ts.sleep(ctx, refresh) {
+			return
+		}
+	}
+}
+
+// registerAt places an ad at a single node, waiting on tickets as needed.
+// It returns the lifetime of the placed ad.
+func (ts *topicSystem) registerAt(ctx context.Context, n *enode.Node, topic Topic) (time.Duration, bool) {
+	t := ts.transport
+	var tk []byte
+	for i := 0; i < topicTicketAttempts; i++ {
+		next, wait, err := t.regtopic(n, topic, tk)
+		if err != nil {
+			t.log.Trace("Topic registration failed", "id", n.ID(), "topic", topic, "err", err)
+			return 0, false
+		}
+		if len(next) == 0 {
+			t.log.Trace("Topic ad placed", "id", n.ID(), "topic", topic, "lifetime", wait)
+			return wait, true
+		}
+		if wait > topicAdLifetime || !ts.sleep(ctx, wait) {
+			return 0, false
+		}
+		tk = next
+	}
+	return 0, false
+}
+
+// sleep waits for d. It returns false if ctx was canceled in the meantime.
+func (ts *topicSystem) sleep(ctx context.Context, d time.Duration) bool {
+	timer := ts.transport.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// search creates a topic search iterator.
+func (ts *topicSystem) search(topic Topic) *topicIterator {
+	ctx, cancel := context.WithCancel(ts.transport.closeCtx)
+	it := &topicIterator{ch: make(chan *enode.Node), ctx: ctx, cancel: cancel}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if ts.closed {
+		cancel()
+		return it
+	}
+	ts.wg.Add(1)
+	go func() {
+		defer ts.wg.Done()
+		ts.searchLoop(ctx, topic, it.ch)
+	}()
+	return it
+}
+
+// searchLoop performs lookups toward the topic identifier, asking every node
+// encountered for ads. Found ads are delivered on ch.
+func (ts *topicSystem) searchLoop(ctx context.Context, topic Topic, ch chan<- *enode.Node) {
+	var (
+		t      = ts.transport
+		target = enode.ID(topic)
+	)
+	for {
+		var (
+			mu   sync.Mutex
+			seen = map[enode.ID]bool{t.Self().ID(): true}
+		)
+		deliver := func(ads []*enode.Node) bool {
+			for _, ad := range ads {
+				mu.Lock()
+				dup := seen[ad.ID()]
+				seen[ad.ID()] = true
+				mu.Unlock()
+				if dup {
+					continue
+				}
+				select {
+				case ch <- ad:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		// The local node might be a registrar for the topic as well.
+		if !deliver(ts.table.query(topic, topicQueryResultLimit)) {
+			return
+		}
+		query := func(n *node) ([]*node, error) {
+			ads, err := t.topicQuery(unwrapNode(n), topic)
+			if errors.Is(err, errClosed) || !deliver(ads) {
+				return nil, errClosed
+			}
+			return t.lookupWorker(n, target)
+		}
+		newLookup(ctx, t.tab, target, query).run()
+
+		if !ts.sleep(ctx, topicSearchInterval) {
+			return
+		}
+	}
+}
+
+// topicIterator is the iterator returned by TopicSearch.
+type topicIterator struct {
+	ch     chan *enode.Node
+	cur    *enode.Node
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Next moves to the next node advertising the topic.
+func (it *topicIterator) Next() bool {
+	select {
+	case n := <-it.ch:
+		it.cur = n
+		return true
+	case <-it.ctx.Done():
+		it.cur = nil
+		return false
+	}
+}
+
+// Node returns the current node.
+func (it *topicIterator) Node() *enode.Node {
+	return it.cur
+}
+
+// Close ends the iterator.
+func (it *topicIterator) Close() {
+	it.cancel()
+}
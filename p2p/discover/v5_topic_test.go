@@ -0,0 +1,198 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package discover
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+func TestTopicTable(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		tt    = newTopicTable(clock)
+		topic = NewTopic("test")
+		ip    = net.IP{10, 0, 0, 1}
+		nodes = make([]*enode.Node, topicQueueLimit+1)
+	)
+	for i := range nodes {
+		nodes[i] = enode.SignNull(new(enr.Record), enode.ID{byte(i), byte(i >> 8)})
+	}
+
+	// The first attempt yields a ticket, which can be used right away while the queue has space.
+	for i, n := range nodes[:topicQueueLimit] {
+		wait, tk := tt.register(n, ip, topic, nil)
+		if wait != 0 || len(tk) == 0 {
+			t.Fatalf("node %d: got wait %v, ticket %x on first attempt", i, wait, tk)
+		}
+		if wait, tk = tt.register(n, ip, topic, tk); wait != topicAdLifetime || tk != nil {
+			t.Fatalf("node %d: ad not placed with ticket (wait %v)", i, wait)
+		}
+		clock.Run(time.Second)
+	}
+	if ads := tt.query(topic, topicQueryResultLimit); len(ads) != topicQueryResultLimit || ads[0] != nodes[topicQueueLimit-1] {
+		t.Fatalf("wrong query result: %d ads", len(ads))
+	}
+
+	// The queue is full now, so the last node has to wait until the first ad expires.
+	last := nodes[topicQueueLimit]
+	wait, tk := tt.register(last, ip, topic, nil)
+	if want := topicAdLifetime - topicQueueLimit*time.Second; wait != want {
+		t.Fatalf("wrong wait time %v, want %v", wait, want)
+	}
+	// Using the ticket early just returns it back.
+	clock.Run(wait / 2)
+	if wait2, tk2 := tt.register(last, ip, topic, tk); wait2 != wait-wait/2 || !bytes.Equal(tk, tk2) {
+		t.Fatalf("wrong result for early attempt: wait %v", wait2)
+	}
+	// The ticket is bound to the registrant.
+	if _, tk2 := tt.register(last, net.IP{10, 0, 0, 2}, topic, tk); tk2 == nil || bytes.Equal(tk, tk2) {
+		t.Fatal("ticket accepted for different IP")
+	}
+	if _, tk2 := tt.register(last, ip, NewTopic("other"), tk); tk2 == nil || bytes.Equal(tk, tk2) {
+		t.Fatal("ticket accepted for different topic")
+	}
+	forged := append([]byte{}, tk...)
+	forged[0]++
+	if _, err := tt.decodeTicket(forged); err != errTicketMAC {
+		t.Fatalf("forged ticket not rejected: %v", err)
+	}
+	// Once the wait time has passed, the ad is placed.
+	clock.Run(wait - wait/2)
+	if wait, tk := tt.register(last, ip, topic, tk); wait != topicAdLifetime || tk != nil {
+		t.Fatalf("ad not placed after waiting (wait %v)", wait)
+	}
+	if ads := tt.query(topic, 1); len(ads) != 1 || ads[0] != last {
+		t.Fatal("newest ad not returned first")
+	}
+	if tt.count != topicQueueLimit {
+		t.Fatalf("wrong ad count %d", tt.count)
+	}
+
+	// All ads expire eventually.
+	clock.Run(topicAdLifetime)
+	if ads := tt.query(topic, topicQueryResultLimit); len(ads) != 0 {
+		t.Fatalf("%d ads left after expiry", len(ads))
+	}
+	if tt.count != 0 || len(tt.queues) != 0 {
+		t.Fatal("table not empty after expiry")
+	}
+}
+
+// This test checks REGTOPIC and TOPICQUERY handling.
+func TestUDPv5_topicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	var (
+		topic  = NewTopic("test")
+		remote = test.getNode(test.remotekey, test.remoteaddr).Node()
+		ticket []byte
+	)
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("1"), Topic: topic, ENR: remote.Record()})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte("1")) {
+			t.Error("wrong request ID in response:", p.ReqID)
+		}
+		if len(p.Ticket) == 0 || p.WaitTime != 0 {
+			t.Errorf("expected ticket without wait time, got wait %d", p.WaitTime)
+		}
+		ticket = p.Ticket
+	})
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("2"), Topic: topic, ENR: remote.Record(), Ticket: ticket})
+	test.waitPacketOut(func(p *v5wire.Ticket, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if len(p.Ticket) != 0 || p.WaitTime != uint32(topicAdLifetime/time.Second) {
+			t.Errorf("ad not placed: wait %d", p.WaitTime)
+		}
+	})
+
+	// Records of other nodes are rejected.
+	other := test.getNode(newkey(), &net.UDPAddr{IP: net.IP{10, 0, 1, 100}, Port: 30303}).Node()
+	test.packetIn(&v5wire.Regtopic{ReqID: []byte("3"), Topic: topic, ENR: other.Record()})
+
+	test.packetIn(&v5wire.TopicQuery{ReqID: []byte("4"), Topic: topic})
+	test.waitPacketOut(func(p *v5wire.Nodes, addr *net.UDPAddr, _ v5wire.Nonce) {
+		if !bytes.Equal(p.ReqID, []byte("4")) {
+			t.Error("wrong request ID in response:", p.ReqID)
+		}
+		if len(p.Nodes) != 1 {
+			t.Fatalf("wrong number of ads in response: %d", len(p.Nodes))
+		}
+		if n, _ := enode.New(enode.ValidSchemesForTesting, p.Nodes[0]); n == nil || n.ID() != remote.ID() {
+			t.Errorf("wrong ad in response")
+		}
+	})
+}
+
+// Real sockets, real crypto: this test checks that an advertised topic can be found.
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 5
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			cfg.Bootnodes = []*enode.Node{nodes[0].Self()}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	topic := NewTopic("test")
+	advertiser, searcher := nodes[1], nodes[N-1]
+	advertiser.RegisterTopic(topic)
+
+	// Wait for the ad to be placed somewhere.
+	deadline := time.Now().Add(10 * time.Second)
+	for placed := false; !placed; {
+		for _, n := range nodes {
+			if len(n.topics.table.query(topic, 1)) > 0 {
+				placed = true
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("ad not placed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	advertiser.UnregisterTopic(topic)
+
+	it := searcher.TopicSearch(topic)
+	defer it.Close()
+	found := make(chan *enode.Node, 1)
+	go func() {
+		if it.Next() {
+			found <- it.Node()
+		}
+	}()
+	select {
+	case n := <-found:
+		if n.ID() != advertiser.Self().ID() {
+			t.Fatalf("found wrong node %v", n.ID())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("topic search timed out")
+	}
+}
@@ -61,6 +61,7 @@ type codecV5 interface {
 type UDPv5 struct {
 	// static fields
 	conn         UDPConn
+	ipv6Only     bool // conn can only reach IPv6 hosts
 	tab          *Table
 	netrestrict  *netutil.Netlist
 	priv         *ecdsa.PrivateKey
@@ -76,6 +77,9 @@ type UDPv5 struct {
 	// talkreq handler registry
 	talk *talkSystem
 
+	// topic advertisement and search
+	topics *topicSystem
+
 	// channels into dispatch
 	packetInCh    chan ReadPacket
 	readNextCh    chan struct{}
@@ -149,6 +153,7 @@ func newUDPv5(conn UDPConn, ln *enode.LocalNode, cfg Config) (*UDPv5, error) {
 	t := &UDPv5{
 		// static fields
 		conn:         newMeteredConn(conn),
+		ipv6Only:     ipv6Only(conn),
 		localNode:    ln,
 		db:           ln.Database(),
 		netrestrict:  cfg.NetRestrict,
@@ -174,6 +179,7 @@ func newUDPv5(conn UDPConn, ln *enode.LocalNode, cfg Config) (*UDPv5, error) {
 		cancelCloseCtx: cancelCloseCtx,
 	}
 	t.talk = newTalkSystem(t)
+	t.topics = newTopicSystem(t)
 	tab, err := newMeteredTable(t, t.db, cfg)
 	if err != nil {
 		return nil, err
@@ -193,6 +199,7 @@ func (t *UDPv5) Close() {
 		t.cancelCloseCtx()
 		t.conn.Close()
 		t.talk.wait()
+		t.topics.wait()
 		t.wg.Wait()
 		t.tab.close()
 	})
@@ -460,7 +467,7 @@ func containsUint(x uint, xs []uint) bool {
 // callToNode sends the given call and sets up a handler for response packets (of message
 // type responseType). Responses are dispatched to the call's response channel.
 func (t *UDPv5) callToNode(n *enode.Node, responseType byte, req v5wire.Packet) *callV5 {
-	addr := &net.UDPAddr{IP: n.IP(), Port: n.UDP()}
+	addr := nodeAddr(n, t.ipv6Only)
 	c := &callV5{id: n.ID(), addr: addr, node: n}
 	t.initCall(c, responseType, req)
 	return c
@@ -771,6 +778,12 @@ func (t *UDPv5) handle(p v5wire.Packet, fromID enode.ID, fromAddr *net.UDPAddr)
 		t.talk.handleRequest(fromID, fromAddr, p)
 	case *v5wire.TalkResponse:
 		t.handleCallResponse(fromID, fromAddr, p)
+	case *v5wire.Regtopic:
+		t.handleRegtopic(p, fromID, fromAddr)
+	case *v5wire.Ticket:
+		t.handleCallResponse(fromID, fromAddr, p)
+	case *v5wire.TopicQuery:
+		t.handleTopicQuery(p, fromID, fromAddr)
 	}
 }
 
@@ -114,6 +114,24 @@ func (n *Node) IP() net.IP {
 	return nil
 }
 
+// IPv4 returns the IPv4 address of the node, or nil if it has none.
+func (n *Node) IPv4() net.IP {
+	var ip4 enr.IPv4
+	if n.Load(&ip4) == nil {
+		return net.IP(ip4)
+	}
+	return nil
+}
+
+// IPv6 returns the IPv6 address of the node, or nil if it has none.
+func (n *Node) IPv6() net.IP {
+	var ip6 enr.IPv6
+	if n.Load(&ip6) == nil {
+		return net.IP(ip6)
+	}
+	return nil
+}
+
 // UDP returns the UDP port of the node.
 func (n *Node) UDP() int {
 	var port enr.UDP
@@ -128,6 +146,52 @@ func (n *Node) TCP() int {
 	return int(port)
 }
 
+// UDP6 returns the UDP port of the node's IPv6 endpoint. As the "udp6" key is
+// only present when the port differs, this falls back to the UDP port.
+func (n *Node) UDP6() int {
+	var port enr.UDP6
+	if n.Load(&port) == nil {
+		return int(port)
+	}
+	return n.UDP()
+}
+
+// TCP6 returns the TCP port of the node's IPv6 endpoint. As the "tcp6" key is
+// only present when the port differs, this falls back to the TCP port.
+func (n *Node) TCP6() int {
+	var port enr.TCP6
+	if n.Load(&port) == nil {
+		return int(port)
+	}
+	return n.TCP()
+}
+
+// UDPEndpoint returns the UDP endpoint of the node in the given address family,
+// or nil if the node has none.
+func (n *Node) UDPEndpoint(ipv6 bool) *net.UDPAddr {
+	if ipv6 {
+		if ip := n.IPv6(); ip != nil && n.UDP6() != 0 {
+			return &net.UDPAddr{IP: ip, Port: n.UDP6()}
+		}
+	} else if ip := n.IPv4(); ip != nil && n.UDP() != 0 {
+		return &net.UDPAddr{IP: ip, Port: n.UDP()}
+	}
+	return nil
+}
+
+// TCPEndpoint returns the TCP endpoint of the node in the given address family,
+// or nil if the node has none.
+func (n *Node) TCPEndpoint(ipv6 bool) *net.TCPAddr {
+	if ipv6 {
+		if ip := n.IPv6(); ip != nil && n.TCP6() != 0 {
+			return &net.TCPAddr{IP: ip, Port: n.TCP6()}
+		}
+	} else if ip := n.IPv4(); ip != nil && n.TCP() != 0 {
+		return &net.TCPAddr{IP: ip, Port: n.TCP()}
+	}
+	return nil
+}
+
 // Pubkey returns the secp256k1 public key of the node, if present.
 func (n *Node) Pubkey() *ecdsa.PublicKey {
 	var key ecdsa.PublicKey
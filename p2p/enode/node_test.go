@@ -21,6 +21,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"net"
 	"testing"
 	"testing/quick"
 
@@ -64,6 +65,73 @@ func TestPythonInterop(t *testing.T) {
 	}
 }
 
+func TestNodeEndpoints(t *testing.T) {
+	var (
+		ip4 = net.IP{10, 0, 0, 1}
+		ip6 = net.ParseIP("2001:db8::1")
+	)
+	tests := []struct {
+		name       string
+		entries    []enr.Entry
+		udp4, tcp4 string
+		udp6, tcp6 string
+	}{
+		{
+			name:    "ipv4",
+			entries: []enr.Entry{enr.IPv4(ip4), enr.UDP(30303), enr.TCP(30303)},
+			udp4:    "10.0.0.1:30303",
+			tcp4:    "10.0.0.1:30303",
+		},
+		{
+			name:    "ipv6 with shared ports",
+			entries: []enr.Entry{enr.IPv6(ip6), enr.UDP(30303), enr.TCP(30304)},
+			udp6:    "[2001:db8::1]:30303",
+			tcp6:    "[2001:db8::1]:30304",
+		},
+		{
+			name:    "dual stack",
+			entries: []enr.Entry{enr.IPv4(ip4), enr.IPv6(ip6), enr.UDP(30303), enr.TCP(30303), enr.UDP6(30305), enr.TCP6(30306)},
+			udp4:    "10.0.0.1:30303",
+			tcp4:    "10.0.0.1:30303",
+			udp6:    "[2001:db8::1]:30305",
+			tcp6:    "[2001:db8::1]:30306",
+		},
+	}
+	for _, test := range tests {
+		var r enr.Record
+		for _, e := range test.entries {
+			r.Set(e)
+		}
+		n := SignNull(&r, ID{})
+		var (
+			udp = func(a *net.UDPAddr) string {
+				if a == nil {
+					return ""
+				}
+				return a.String()
+			}
+			tcp = func(a *net.TCPAddr) string {
+				if a == nil {
+					return ""
+				}
+				return a.String()
+			}
+		)
+		if have := udp(n.UDPEndpoint(false)); have != test.udp4 {
+			t.Errorf("%s: wrong udp4 endpoint: have %q, want %q", test.name, have, test.udp4)
+		}
+		if have := tcp(n.TCPEndpoint(false)); have != test.tcp4 {
+			t.Errorf("%s: wrong tcp4 endpoint: have %q, want %q", test.name, have, test.tcp4)
+		}
+		if have := udp(n.UDPEndpoint(true)); have != test.udp6 {
+			t.Errorf("%s: wrong udp6 endpoint: have %q, want %q", test.name, have, test.udp6)
+		}
+		if have := tcp(n.TCPEndpoint(true)); have != test.tcp6 {
+			t.Errorf("%s: wrong tcp6 endpoint: have %q, want %q", test.name, have, test.tcp6)
+		}
+	}
+}
+
 func TestHexID(t *testing.T) {
 	ref := ID{0, 0, 0, 0, 0, 0, 0, 128, 106, 217, 182, 31, 165, 174, 1, 67, 7, 235, 220, 150, 66, 83, 173, 205, 159, 44, 10, 57, 42, 161, 26, 188}
 	id1 := HexID("0x00000000000000806ad9b61fa5ae014307ebdc964253adcd9f2c0a392aa11abc")
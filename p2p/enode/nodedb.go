@@ -40,6 +40,9 @@ const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
 	dbLocalPrefix  = "local:"
+	dbRepPrefix    = "rep:" // Reputation entries are keyed by ID only, "rep:<ID>"
+	dbBanPrefix    = "ban:" // Bans are keyed as "ban:id:<ID>" and "ban:net:<CIDR>"
+	dbLimitPrefix  = "limit:"
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
 
@@ -315,6 +318,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireReputations()
 		case <-db.quit:
 			return
 		}
@@ -427,6 +431,187 @@ func (db *DB) UpdateFindFailsV5(id ID, ip net.IP, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// NodeReputation is the reputation of a node as stored in the database.
+type NodeReputation struct {
+	Score       int64     // score at the time of the last update
+	Updated     time.Time // time of the last update
+	BannedUntil time.Time // end of the current ban, zero if the node isn't banned
+}
+
+// Banned reports whether the node is banned at the given time.
+func (r NodeReputation) Banned(now time.Time) bool {
+	return now.Before(r.BannedUntil)
+}
+
+func reputationKey(id ID) []byte {
+	return append([]byte(dbRepPrefix), id[:]...)
+}
+
+func encodeReputation(r NodeReputation) []byte {
+	blob := make([]byte, 3*binary.MaxVarintLen64)
+	n := binary.PutVarint(blob, r.Score)
+	n += binary.PutVarint(blob[n:], r.Updated.Unix())
+	if !r.BannedUntil.IsZero() {
+		n += binary.PutVarint(blob[n:], r.BannedUntil.Unix())
+	}
+	return blob[:n]
+}
+
+func decodeReputation(blob []byte) (r NodeReputation) {
+	score, n := binary.Varint(blob)
+	if n <= 0 {
+		return r
+	}
+	updated, m := binary.Varint(blob[n:])
+	if m <= 0 {
+		return r
+	}
+	r.Score, r.Updated = score, time.Unix(updated, 0)
+	if banned, k := binary.Varint(blob[n+m:]); k > 0 {
+		r.BannedUntil = time.Unix(banned, 0)
+	}
+	return r
+}
+
+// Reputation retrieves the stored reputation of a node.
+func (db *DB) Reputation(id ID) NodeReputation {
+	blob, err := db.lvl.Get(reputationKey(id), nil)
+	if err != nil {
+		return NodeReputation{}
+	}
+	return decodeReputation(blob)
+}
+
+// UpdateReputation stores the reputation of a node.
+func (db *DB) UpdateReputation(id ID, r NodeReputation) error {
+	return db.lvl.Put(reputationKey(id), encodeReputation(r), nil)
+}
+
+// DeleteReputation removes the stored reputation of a node.
+func (db *DB) DeleteReputation(id ID) error {
+	return db.lvl.Delete(reputationKey(id), nil)
+}
+
+// expireReputations deletes the reputations of nodes which are not banned and
+// have not been updated for some time. Their scores have decayed to zero by then.
+func (db *DB) expireReputations() {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbRepPrefix)), nil)
+	defer it.Release()
+
+	var (
+		now       = time.Now()
+		threshold = now.Add(-dbNodeExpiration)
+	)
+	for it.Next() {
+		rep := decodeReputation(it.Value())
+		if rep.Updated.Before(threshold) && !rep.Banned(now) {
+			db.lvl.Delete(it.Key(), nil)
+		}
+	}
+}
+
+// Reputations returns the reputations of all nodes in the database.
+func (db *DB) Reputations() map[ID]NodeReputation {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbRepPrefix)), nil)
+	defer it.Release()
+
+	reps := make(map[ID]NodeReputation)
+	for it.Next() {
+		var id ID
+		if len(it.Key()) != len(dbRepPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(dbRepPrefix):])
+		reps[id] = decodeReputation(it.Value())
+	}
+	return reps
+}
+
+func banNodeKey(id ID) []byte {
+	return append([]byte(dbBanPrefix+"id:"), id[:]...)
+}
+
+func banNetworkKey(n *net.IPNet) []byte {
+	return []byte(dbBanPrefix + "net:" + n.String())
+}
+
+// BanNode adds a node to the persistent ban list.
+func (db *DB) BanNode(id ID) error {
+	return db.lvl.Put(banNodeKey(id), nil, nil)
+}
+
+// UnbanNode removes a node from the persistent ban list.
+func (db *DB) UnbanNode(id ID) error {
+	return db.lvl.Delete(banNodeKey(id), nil)
+}
+
+// BannedNodes returns all nodes in the persistent ban list.
+func (db *DB) BannedNodes() []ID {
+	prefix := []byte(dbBanPrefix + "id:")
+	it := db.lvl.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+
+	var ids []ID
+	for it.Next() {
+		var id ID
+		if len(it.Key()) != len(prefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(prefix):])
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// BanNetwork adds an IP network to the persistent ban list.
+func (db *DB) BanNetwork(n *net.IPNet) error {
+	return db.lvl.Put(banNetworkKey(n), nil, nil)
+}
+
+// UnbanNetwork removes an IP network from the persistent ban list.
+func (db *DB) UnbanNetwork(n *net.IPNet) error {
+	return db.lvl.Delete(banNetworkKey(n), nil)
+}
+
+// BannedNetworks returns all IP networks in the persistent ban list.
+func (db *DB) BannedNetworks() []*net.IPNet {
+	prefix := []byte(dbBanPrefix + "net:")
+	it := db.lvl.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+
+	var nets []*net.IPNet
+	for it.Next() {
+		if _, n, err := net.ParseCIDR(string(it.Key()[len(prefix):])); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	return nets
+}
+
+// StorePeerLimit stores a named peer limit, overriding its configured value.
+func (db *DB) StorePeerLimit(name string, v int) error {
+	return db.storeInt64([]byte(dbLimitPrefix+name), int64(v))
+}
+
+// DeletePeerLimit removes a stored peer limit.
+func (db *DB) DeletePeerLimit(name string) error {
+	return db.lvl.Delete([]byte(dbLimitPrefix+name), nil)
+}
+
+// PeerLimits returns all stored peer limits.
+func (db *DB) PeerLimits() map[string]int {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbLimitPrefix)), nil)
+	defer it.Release()
+
+	limits := make(map[string]int)
+	for it.Next() {
+		if v, n := binary.Varint(it.Value()); n > 0 {
+			limits[string(it.Key()[len(dbLimitPrefix):])] = int(v)
+		}
+	}
+	return limits
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
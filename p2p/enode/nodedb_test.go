@@ -467,3 +467,105 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+func TestDBReputation(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		id1    = ID{1}
+		id2    = ID{2}
+		now    = time.Unix(time.Now().Unix(), 0)
+		rep1   = NodeReputation{Score: -42, Updated: now}
+		rep2   = NodeReputation{Score: -150, Updated: now, BannedUntil: now.Add(time.Hour)}
+		noRep  NodeReputation
+		stored NodeReputation
+	)
+	if stored = db.Reputation(id1); stored != noRep {
+		t.Fatalf("non-existing reputation: %+v", stored)
+	}
+	db.UpdateReputation(id1, rep1)
+	db.UpdateReputation(id2, rep2)
+	if stored = db.Reputation(id1); !reflect.DeepEqual(stored, rep1) {
+		t.Fatalf("reputation mismatch: have %+v, want %+v", stored, rep1)
+	}
+	if stored.Banned(now) {
+		t.Fatal("node without ban reported as banned")
+	}
+	if stored = db.Reputation(id2); !reflect.DeepEqual(stored, rep2) {
+		t.Fatalf("reputation mismatch: have %+v, want %+v", stored, rep2)
+	}
+	if !stored.Banned(now) || stored.Banned(now.Add(time.Hour)) {
+		t.Fatal("wrong ban status")
+	}
+	// Reputations are not affected by node expiration.
+	db.UpdateLastPongReceived(id1, net.IP{127, 0, 0, 1}, now.Add(-dbNodeExpiration-time.Minute))
+	db.expireNodes()
+
+	// Stale reputations expire, unless the node is banned.
+	var (
+		id3  = ID{3}
+		id4  = ID{4}
+		old  = now.Add(-dbNodeExpiration - time.Minute)
+		rep4 = NodeReputation{Score: -200, Updated: old, BannedUntil: now.Add(time.Hour)}
+	)
+	db.UpdateReputation(id3, NodeReputation{Score: -1, Updated: old})
+	db.UpdateReputation(id4, rep4)
+	db.expireReputations()
+
+	want := map[ID]NodeReputation{id1: rep1, id2: rep2, id4: rep4}
+	if all := db.Reputations(); !reflect.DeepEqual(all, want) {
+		t.Fatalf("wrong reputations: %v", all)
+	}
+	db.DeleteReputation(id1)
+	delete(want, id1)
+	if all := db.Reputations(); !reflect.DeepEqual(all, want) {
+		t.Fatalf("wrong reputations after delete: %v", all)
+	}
+}
+
+func TestDBBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	_, net1, _ := net.ParseCIDR("10.0.0.0/8")
+	_, net2, _ := net.ParseCIDR("2001:db8::/32")
+	db.BanNode(ID{1})
+	db.BanNode(ID{2})
+	db.BanNetwork(net1)
+	db.BanNetwork(net2)
+	db.UpdateReputation(ID{3}, NodeReputation{Score: -1, Updated: time.Unix(1, 0)})
+
+	if ids := db.BannedNodes(); !reflect.DeepEqual(ids, []ID{{1}, {2}}) {
+		t.Fatalf("wrong banned nodes: %v", ids)
+	}
+	if nets := db.BannedNetworks(); len(nets) != 2 || nets[0].String() != net1.String() || nets[1].String() != net2.String() {
+		t.Fatalf("wrong banned networks: %v", nets)
+	}
+	db.UnbanNode(ID{1})
+	db.UnbanNetwork(net2)
+	if ids := db.BannedNodes(); !reflect.DeepEqual(ids, []ID{{2}}) {
+		t.Fatalf("wrong banned nodes after unban: %v", ids)
+	}
+	if nets := db.BannedNetworks(); len(nets) != 1 || nets[0].String() != net1.String() {
+		t.Fatalf("wrong banned networks after unban: %v", nets)
+	}
+}
+
+func TestDBPeerLimits(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	if limits := db.PeerLimits(); len(limits) != 0 {
+		t.Fatalf("non-empty limits in new database: %v", limits)
+	}
+	db.StorePeerLimit("maxpeers", 100)
+	db.StorePeerLimit("quota:snap", 5)
+	db.StorePeerLimit("quota:eth", 0)
+	db.DeletePeerLimit("quota:eth")
+
+	want := map[string]int{"maxpeers": 100, "quota:snap": 5}
+	if limits := db.PeerLimits(); !reflect.DeepEqual(limits, want) {
+		t.Fatalf("wrong limits: %v", limits)
+	}
+}
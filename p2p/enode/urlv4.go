@@ -101,6 +101,38 @@ func NewV4(pubkey *ecdsa.PublicKey, ip net.IP, tcp, udp int) *Node {
 	return n
 }
 
+// NewV4DualStack creates a node like NewV4, with endpoints in both address
+// families. ip4 and ip6 must be IPv4 and IPv6 addresses respectively.
+func NewV4DualStack(pubkey *ecdsa.PublicKey, ip4 net.IP, tcp, udp int, ip6 net.IP, tcp6, udp6 int) *Node {
+	var r enr.Record
+	r.Set(enr.IPv4(ip4))
+	r.Set(enr.IPv6(ip6))
+	if udp != 0 {
+		r.Set(enr.UDP(udp))
+	}
+	if tcp != 0 {
+		r.Set(enr.TCP(tcp))
+	}
+	if udp6 != 0 && udp6 != udp {
+		r.Set(enr.UDP6(udp6))
+	}
+	if tcp6 != 0 && tcp6 != tcp {
+		r.Set(enr.TCP6(tcp6))
+	}
+	signV4Compat(&r, pubkey)
+	n, err := New(v4CompatID{}, &r)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// IsV4Compat returns true for nodes created by NewV4 or NewV4DualStack, i.e.
+// nodes which aren't backed by a signed record.
+func IsV4Compat(n *Node) bool {
+	return isNewV4(n)
+}
+
 // isNewV4 returns true for nodes created by NewV4.
 func isNewV4(n *Node) bool {
 	var k s256raw
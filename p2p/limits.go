@@ -0,0 +1,150 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// PeerLimits are the peer slot settings of the server, as returned by Server.PeerLimits.
+type PeerLimits struct {
+	MaxPeers       int            `json:"maxPeers"`
+	DialRatio      int            `json:"dialRatio"`
+	ProtocolQuotas map[string]int `json:"protocolQuotas"`
+}
+
+// Names of peer limits in the node database.
+const (
+	dbLimitMaxPeers    = "maxpeers"
+	dbLimitDialRatio   = "dialratio"
+	dbLimitQuotaPrefix = "quota:"
+)
+
+// peerLimits holds the peer limits which can be changed at runtime. Initial values
+// come from the server configuration. Changes are persisted in the node database and
+// take precedence over the configuration on the next start.
+//
+// Protocol quotas reserve peer slots for peers supporting a protocol. A peer that
+// doesn't support the protocol is only accepted if enough slots remain to fill up
+// the quota.
+type peerLimits struct {
+	db        *enode.DB
+	mu        sync.RWMutex
+	maxPeers  int
+	dialRatio int
+	quotas    map[string]int
+}
+
+func newPeerLimits(db *enode.DB, maxPeers, dialRatio int) *peerLimits {
+	l := &peerLimits{db: db, maxPeers: maxPeers, dialRatio: dialRatio, quotas: make(map[string]int)}
+	for name, v := range db.PeerLimits() {
+		switch {
+		case name == dbLimitMaxPeers:
+			l.maxPeers = v
+		case name == dbLimitDialRatio:
+			l.dialRatio = v
+		case strings.HasPrefix(name, dbLimitQuotaPrefix):
+			l.quotas[strings.TrimPrefix(name, dbLimitQuotaPrefix)] = v
+		}
+	}
+	return l
+}
+
+func (l *peerLimits) get() *PeerLimits {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return &PeerLimits{MaxPeers: l.maxPeers, DialRatio: l.dialRatio, ProtocolQuotas: l.protocolQuotasLocked()}
+}
+
+// current returns the maximum peer count and dial ratio.
+func (l *peerLimits) current() (maxPeers, dialRatio int) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.maxPeers, l.dialRatio
+}
+
+func (l *peerLimits) protocolQuotas() map[string]int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.protocolQuotasLocked()
+}
+
+func (l *peerLimits) protocolQuotasLocked() map[string]int {
+	quotas := make(map[string]int, len(l.quotas))
+	for name, n := range l.quotas {
+		quotas[name] = n
+	}
+	return quotas
+}
+
+func (l *peerLimits) totalQuotaLocked() (total int) {
+	for _, n := range l.quotas {
+		total += n
+	}
+	return total
+}
+
+func (l *peerLimits) setMaxPeers(n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n < 0 {
+		return errors.New("negative peer limit")
+	}
+	if total := l.totalQuotaLocked(); n < total {
+		return fmt.Errorf("peer limit %d is below total protocol quota %d", n, total)
+	}
+	l.maxPeers = n
+	return l.db.StorePeerLimit(dbLimitMaxPeers, n)
+}
+
+func (l *peerLimits) setDialRatio(r int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r < 0 {
+		return errors.New("negative dial ratio")
+	}
+	l.dialRatio = r
+	return l.db.StorePeerLimit(dbLimitDialRatio, r)
+}
+
+// setProtocolQuota sets the quota of a protocol. A quota of zero removes it.
+func (l *peerLimits) setProtocolQuota(name string, n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n < 0 {
+		return errors.New("negative protocol quota")
+	}
+	if total := l.totalQuotaLocked() - l.quotas[name] + n; total > l.maxPeers {
+		return fmt.Errorf("total protocol quota %d exceeds peer limit %d", total, l.maxPeers)
+	}
+	if n == 0 {
+		delete(l.quotas, name)
+		return l.db.DeletePeerLimit(dbLimitQuotaPrefix + name)
+	}
+	l.quotas[name] = n
+	return l.db.StorePeerLimit(dbLimitQuotaPrefix+name, n)
+}
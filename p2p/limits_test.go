@@ -0,0 +1,289 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+package p2p
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestPeerLimits(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	l := newPeerLimits(db, 50, 0)
+	if got, want := l.get(), (&PeerLimits{MaxPeers: 50, ProtocolQuotas: map[string]int{}}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong initial limits: %+v", got)
+	}
+	if err := l.setProtocolQuota("snap", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.setProtocolQuota("eth", 41); err == nil {
+		t.Fatal("quota exceeding peer limit accepted")
+	}
+	if err := l.setMaxPeers(9); err == nil {
+		t.Fatal("peer limit below quota accepted")
+	}
+	if err := l.setMaxPeers(20); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.setDialRatio(-1); err == nil {
+		t.Fatal("negative dial ratio accepted")
+	}
+	if err := l.setDialRatio(2); err != nil {
+		t.Fatal(err)
+	}
+	l.setProtocolQuota("eth", 5)
+	l.setProtocolQuota("eth", 0)
+
+	// Changes override the configuration after a restart.
+	want := &PeerLimits{MaxPeers: 20, DialRatio: 2, ProtocolQuotas: map[string]int{"snap": 10}}
+	reloaded := newPeerLimits(db, 50, 0)
+	if got := reloaded.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong limits after reload: %+v", got)
+	}
+}
+
+func TestBanList(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		b  = newBanList(db)
+		n1 = newNode(uintID(1), "10.0.0.1:30303")
+		n2 = newNode(uintID(2), "192.168.0.1:30303")
+	)
+	_, lan, _ := net.ParseCIDR("192.168.0.0/16")
+	b.banNode(n1.ID())
+	b.banNetwork(lan)
+	b.banNetwork(lan)
+	if !b.contains(n1) || !b.contains(n2) {
+		t.Fatal("banned nodes not contained")
+	}
+	if b.contains(newNode(uintID(3), "10.0.0.3:30303")) {
+		t.Fatal("unbanned node contained")
+	}
+	want := &BanList{Nodes: []string{n1.ID().String()}, Networks: []string{"192.168.0.0/16"}}
+	if list := newBanList(db).list(); !reflect.DeepEqual(list, want) {
+		t.Fatalf("wrong ban list after reload: %+v", list)
+	}
+	if !b.unbanNode(n1.ID()) || b.unbanNode(n1.ID()) {
+		t.Fatal("wrong unbanNode result")
+	}
+	if !b.unbanNetwork(lan) || b.unbanNetwork(lan) {
+		t.Fatal("wrong unbanNetwork result")
+	}
+	if b.contains(n1) || b.contains(n2) {
+		t.Fatal("nodes still banned")
+	}
+
+	// Bans which can't be stored are not applied.
+	closed, _ := enode.OpenDB("")
+	b = newBanList(closed)
+	closed.Close()
+	if err := b.banNode(n1.ID()); err == nil {
+		t.Fatal("no error for failed node ban")
+	}
+	if err := b.banNetwork(lan); err == nil {
+		t.Fatal("no error for failed network ban")
+	}
+	if b.contains(n1) || b.contains(n2) {
+		t.Fatal("nodes banned without storing the ban")
+	}
+}
+
+func TestDialOperatorBan(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		bans  = newBanList(db)
+		clock = new(mclock.Simulated)
+		d     = &dialScheduler{dialConfig: dialConfig{bans: bans, clock: clock}.withDefaults()}
+		n     = newNode(uintID(1), "127.0.0.1:30303")
+	)
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	bans.banNetwork(loopback)
+	if err := d.checkDial(n); err != errBanned {
+		t.Fatalf("wrong error for banned node: %v", err)
+	}
+	// The node is checked again after the dial history expires.
+	bans.unbanNetwork(loopback)
+	clock.Run(dialHistoryExpiration + time.Second)
+	d.expireHistory()
+	if err := d.checkDial(n); err != nil {
+		t.Fatalf("unbanned node not dialable: %v", err)
+	}
+}
+
+func TestServerProtocolQuota(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	srv := &Server{Config: Config{
+		PrivateKey: newkey(),
+		Protocols:  []Protocol{{Name: "aaa", Version: 1}, {Name: "bbb", Version: 1}},
+	}}
+	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
+	srv.reputation = newReputationStore(db)
+	srv.bans = newBanList(db)
+	srv.limits = newPeerLimits(db, 3, 0)
+
+	if err := srv.SetProtocolQuota("ccc", 1); err == nil {
+		t.Fatal("quota for unknown protocol accepted")
+	}
+	if err := srv.SetProtocolQuota("bbb", 2); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		peers = make(map[enode.ID]*Peer)
+		aaa   = []Cap{{"aaa", 1}}
+		both  = []Cap{{"aaa", 1}, {"bbb", 1}}
+	)
+	check := func(id uint16, caps []Cap, flags connFlag) error {
+		c := &conn{node: newNode(uintID(id), "127.0.0.1:30303"), caps: caps, flags: flags}
+		return srv.addPeerChecks(peers, 0, c)
+	}
+	add := func(id uint16, caps []Cap) {
+		peers[uintID(id)] = &Peer{running: matchProtocols(srv.Protocols, caps, nil)}
+	}
+
+	// Two slots are reserved for bbb, so only one aaa-only peer is accepted.
+	if err := check(1, aaa, dynDialedConn); err != nil {
+		t.Fatalf("first aaa peer rejected: %v", err)
+	}
+	add(1, aaa)
+	if err := check(2, aaa, dynDialedConn); err != DiscTooManyPeers {
+		t.Fatalf("second aaa peer not rejected: %v", err)
+	}
+	if err := check(2, aaa, trustedConn); err != nil {
+		t.Fatalf("trusted aaa peer rejected: %v", err)
+	}
+	if err := check(2, both, dynDialedConn); err != nil {
+		t.Fatalf("bbb peer rejected: %v", err)
+	}
+	add(2, both)
+
+	// Once a bbb peer is connected, the remaining reserved slot still blocks aaa peers.
+	if err := check(3, aaa, dynDialedConn); err != DiscTooManyPeers {
+		t.Fatalf("aaa peer not rejected: %v", err)
+	}
+	srv.SetProtocolQuota("bbb", 1)
+	if err := check(3, aaa, dynDialedConn); err != nil {
+		t.Fatalf("aaa peer rejected after lowering quota: %v", err)
+	}
+}
+
+func TestServerBans(t *testing.T) {
+	srv1 := &Server{Config: Config{
+		Name:         "srv1",
+		MaxPeers:     10,
+		ListenAddr:   "127.0.0.1:0",
+		NoDiscovery:  true,
+		PrivateKey:   newkey(),
+		NodeDatabase: t.TempDir(),
+		Logger:       testlog.Logger(t, log.LvlTrace),
+	}}
+	if err := srv1.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	// connect starts a server with the given key and dials srv1.
+	key := newkey()
+	connect := func(name string) (*Server, bool) {
+		srv := &Server{Config: Config{
+			Name:        name,
+			MaxPeers:    10,
+			NoDiscovery: true,
+			PrivateKey:  key,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		}}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("could not start server: %v", err)
+		}
+		return srv, syncAddPeer(srv, srv1.Self())
+	}
+
+	events := make(chan *PeerEvent, 10)
+	sub := srv1.SubscribeEvents(events)
+	srv2, ok := connect("srv2")
+	if !ok {
+		t.Fatal("peer not connected")
+	}
+	for ev := range events {
+		if ev.Type == PeerEventTypeAdd {
+			break
+		}
+	}
+	// Banning the node disconnects it.
+	srv1.BanNode(srv2.Self().ID())
+	for ev := range events {
+		if ev.Type == PeerEventTypeDrop {
+			if ev.Error != DiscUselessPeer.Error() {
+				t.Fatalf("wrong disconnect reason %q", ev.Error)
+			}
+			break
+		}
+	}
+	sub.Unsubscribe()
+	srv2.Stop()
+	srv3, ok := connect("srv3")
+	srv3.Stop()
+	if ok {
+		t.Fatal("banned node connected")
+	}
+	if !srv1.UnbanNode(srv2.Self().ID()) {
+		t.Fatal("node not unbanned")
+	}
+
+	// Ban the network and change the peer limit. Both persist across restarts.
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	srv1.BanNetwork(loopback)
+	if err := srv1.SetMaxPeers(20); err != nil {
+		t.Fatal(err)
+	}
+	srv4, ok := connect("srv4")
+	srv4.Stop()
+	if ok {
+		t.Fatal("node in banned network connected")
+	}
+	srv1.Stop()
+
+	srv1 = &Server{Config: srv1.Config}
+	if err := srv1.Start(); err != nil {
+		t.Fatalf("could not restart server: %v", err)
+	}
+	defer srv1.Stop()
+	wantBans := &BanList{Nodes: []string{}, Networks: []string{"127.0.0.0/8"}}
+	if bans := srv1.BanList(); !reflect.DeepEqual(bans, wantBans) {
+		t.Fatalf("wrong ban list after restart: %+v", bans)
+	}
+	if limits := srv1.PeerLimits(); limits.MaxPeers != 20 {
+		t.Fatalf("wrong peer limit after restart: %d", limits.MaxPeers)
+	}
+	srv1.UnbanNetwork(loopback)
+	srv5, ok := connect("srv5")
+	defer srv5.Stop()
+	if !ok {
+		t.Fatal("peer not connected after unban")
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/capture"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -115,6 +116,12 @@ type Peer struct {
 	pingRecv chan struct{}
 	disc     chan DiscReason
 
+	// reputation tracks the behaviour of the peer, if set
+	reputation *reputationStore
+
+	// capture records sent and received messages if set
+	capture func(*capture.Record)
+
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
@@ -214,6 +221,19 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Report records an event affecting the reputation of the peer. Peers whose score
+// falls below the ban threshold are disconnected and refused for a while, unless
+// they are trusted.
+func (p *Peer) Report(ev ReputationEvent) {
+	if p.reputation == nil {
+		return
+	}
+	if p.reputation.report(p.ID(), ev) && !p.rw.is(trustedConn) {
+		p.log.Debug("Banning peer", "event", ev, "duration", banDuration)
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	id := p.ID()
@@ -227,6 +247,19 @@ func (p *Peer) Inbound() bool {
 
 func newPeer(log log.Logger, conn *conn, protocols []Protocol) *Peer {
 	protomap := matchProtocols(protocols, conn.caps, conn)
+	if t, ok := conn.transport.(*quicTransport); ok {
+		var (
+			offsets = make([]uint64, 0, len(protomap))
+			end     = uint64(baseProtocolLength)
+		)
+		for _, proto := range protomap {
+			offsets = append(offsets, proto.offset)
+			if next := proto.offset + proto.Length; next > end {
+				end = next
+			}
+		}
+		t.setProtocolOffsets(offsets, end)
+	}
 	p := &Peer{
 		rw:       conn,
 		running:  protomap,
@@ -384,6 +417,19 @@ func countMatchingProtocols(protocols []Protocol, caps []Cap) int {
 	return n
 }
 
+// supportsProtocol reports whether any of the given protocols with the given name
+// matches caps.
+func supportsProtocol(protocols []Protocol, caps []Cap, name string) bool {
+	for _, cap := range caps {
+		for _, proto := range protocols {
+			if proto.Name == name && proto.Name == cap.Name && proto.Version == cap.Version {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // matchProtocols creates structures for matching named subprotocols.
 func matchProtocols(protocols []Protocol, caps []Cap, rw MsgReadWriter) map[string]*protoRW {
 	slices.SortFunc(caps, Cap.Cmp)
@@ -420,6 +466,9 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name, p.Info().Network.RemoteAddress, p.Info().Network.LocalAddress)
 		}
+		if p.capture != nil {
+			rw = &msgCapturer{MsgReadWriter: rw, write: p.capture, peer: p, proto: proto.Protocol}
+		}
 		p.log.Trace(fmt.Sprintf("Starting protocol %s/%d", proto.Name, proto.Version))
 		go func() {
 			defer p.wg.Done()
@@ -60,6 +60,11 @@ type Protocol struct {
 	// attempts to create connections to them.
 	DialCandidates enode.Iterator
 
+	// DiscoveryTopic, if set, advertises the local node under this topic in the discv5
+	// DHT. Nodes found through a search for the topic are used as dial candidates.
+	// This only has an effect when discovery v5 is enabled.
+	DiscoveryTopic string
+
 	// Attributes contains protocol specific information for the node record.
 	Attributes []enr.Entry
 }
@@ -0,0 +1,247 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"golang.org/x/exp/slices"
+)
+
+// ReputationEvent is an observed behaviour of a remote peer which affects its
+// reputation. Protocol handlers report events through Peer.Report.
+type ReputationEvent uint8
+
+const (
+	// ReputationGoodResponse is reported when a peer delivers requested data.
+	ReputationGoodResponse ReputationEvent = iota
+
+	// ReputationUselessResponse is reported when a peer sends an empty, stale or
+	// unsolicited response.
+	ReputationUselessResponse
+
+	// ReputationTimeout is reported when a peer fails to answer a request in time.
+	ReputationTimeout
+
+	// ReputationInvalidMessage is reported when a peer violates the protocol.
+	ReputationInvalidMessage
+
+	// ReputationInvalidBlock is reported when a peer propagates an invalid block.
+	ReputationInvalidBlock
+)
+
+var reputationEventNames = [...]string{
+	ReputationGoodResponse:    "good response",
+	ReputationUselessResponse: "useless response",
+	ReputationTimeout:         "timeout",
+	ReputationInvalidMessage:  "invalid message",
+	ReputationInvalidBlock:    "invalid block",
+}
+
+// reputationWeights are the score changes caused by each event.
+var reputationWeights = [...]int64{
+	ReputationGoodResponse:    1,
+	ReputationUselessResponse: -5,
+	ReputationTimeout:         -10,
+	ReputationInvalidMessage:  -50,
+	ReputationInvalidBlock:    -100,
+}
+
+func (ev ReputationEvent) String() string {
+	if int(ev) < len(reputationEventNames) {
+		return reputationEventNames[ev]
+	}
+	return fmt.Sprintf("unknown reputation event %d", ev)
+}
+
+const (
+	maxReputation      = 100
+	minReputation      = -200
+	banThreshold       = -100             // score at which a node is banned
+	banDuration        = time.Hour        // how long banned nodes are refused
+	reputationHalfLife = 30 * time.Minute // time after which scores are halved
+	reputationFlush    = time.Minute      // how often scores are written to the node database
+
+	// Dial history expiration is extended by this amount per negative point,
+	// so nodes with a bad reputation are redialed less often.
+	reputationDialPenalty = dialHistoryExpiration / 10
+)
+
+// ReputationInfo is the reputation of a node, as returned by Server.Reputations.
+type ReputationInfo struct {
+	ID          string     `json:"id"`
+	Score       int64      `json:"score"`
+	BannedUntil *time.Time `json:"bannedUntil,omitempty"`
+}
+
+// reputationStore tracks the reputation of remote nodes. Scores decay towards
+// zero over time, so nodes recover from occasional failures. They are kept in
+// memory and written to the node database by flush, which the server calls
+// periodically.
+type reputationStore struct {
+	db  *enode.DB
+	now func() time.Time
+
+	mu    sync.Mutex
+	reps  map[enode.ID]enode.NodeReputation
+	dirty map[enode.ID]struct{}
+}
+
+func newReputationStore(db *enode.DB) *reputationStore {
+	return &reputationStore{
+		db:    db,
+		now:   time.Now,
+		reps:  db.Reputations(),
+		dirty: make(map[enode.ID]struct{}),
+	}
+}
+
+// current returns the reputation of a node, with the score decayed to the current time.
+// The caller must hold s.mu.
+func (s *reputationStore) current(id enode.ID, now time.Time) enode.NodeReputation {
+	rep := s.reps[id]
+	if rep.Score != 0 && now.After(rep.Updated) {
+		halvings := float64(now.Sub(rep.Updated)) / float64(reputationHalfLife)
+		rep.Score = int64(math.Round(float64(rep.Score) * math.Exp2(-halvings)))
+	}
+	rep.Updated = now
+	return rep
+}
+
+// report applies the effect of an event to the reputation of a node. It returns
+// true if the node got banned by the event.
+func (s *reputationStore) report(id enode.ID, ev ReputationEvent) (banned bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rep := s.current(id, now)
+	rep.Score += reputationWeights[ev]
+	if rep.Score > maxReputation {
+		rep.Score = maxReputation
+	}
+	if rep.Score < minReputation {
+		rep.Score = minReputation
+	}
+	if rep.Score <= banThreshold && !rep.Banned(now) {
+		rep.BannedUntil = now.Add(banDuration)
+		banned = true
+	}
+	s.reps[id] = rep
+	s.dirty[id] = struct{}{}
+	return banned
+}
+
+// score returns the current score of a node.
+func (s *reputationStore) score(id enode.ID) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current(id, s.now()).Score
+}
+
+// bannedFor returns the remaining ban time of a node, or zero if it isn't banned.
+func (s *reputationStore) bannedFor(id enode.ID) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if rep := s.reps[id]; rep.Banned(now) {
+		return rep.BannedUntil.Sub(now)
+	}
+	return 0
+}
+
+// banned reports whether a node is currently banned.
+func (s *reputationStore) banned(id enode.ID) bool {
+	return s.bannedFor(id) > 0
+}
+
+// dialPenalty returns the additional redial delay of a node with a negative score.
+func (s *reputationStore) dialPenalty(id enode.ID) time.Duration {
+	if score := s.score(id); score < 0 {
+		return time.Duration(-score) * reputationDialPenalty
+	}
+	return 0
+}
+
+// reset removes the reputation of a node, lifting any ban.
+func (s *reputationStore) reset(id enode.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.reps, id)
+	s.dirty[id] = struct{}{}
+}
+
+// flush writes modified reputations to the node database. Nodes whose score has
+// decayed to zero and which aren't banned are dropped from memory and database.
+func (s *reputationStore) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, rep := range s.reps {
+		if rep.Banned(now) || s.current(id, now).Score != 0 {
+			continue
+		}
+		delete(s.reps, id)
+		s.dirty[id] = struct{}{}
+	}
+	for id := range s.dirty {
+		var err error
+		if rep, ok := s.reps[id]; ok {
+			err = s.db.UpdateReputation(id, rep)
+		} else {
+			err = s.db.DeleteReputation(id)
+		}
+		if err != nil {
+			log.Warn("Failed to store node reputation", "id", id, "err", err)
+			return
+		}
+		delete(s.dirty, id)
+	}
+}
+
+// all returns the current reputations of all nodes.
+func (s *reputationStore) all() []*ReputationInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		now   = s.now()
+		infos []*ReputationInfo
+	)
+	for id := range s.reps {
+		rep := s.current(id, now)
+		info := &ReputationInfo{ID: id.String(), Score: rep.Score}
+		if rep.Banned(now) {
+			until := rep.BannedUntil
+			info.BannedUntil = &until
+		} else if rep.Score == 0 {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b *ReputationInfo) int { return strings.Compare(a.ID, b.ID) })
+	return infos
+}
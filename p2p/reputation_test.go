@@ -0,0 +1,237 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestReputationStore(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		store = newReputationStore(db)
+		now   = time.Unix(1700000000, 0)
+		id    = enode.ID{1}
+	)
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		store.report(id, ReputationGoodResponse)
+	}
+	store.report(id, ReputationTimeout)
+	if score := store.score(id); score != -7 {
+		t.Fatalf("wrong score %d, want -7", score)
+	}
+	if penalty := store.dialPenalty(id); penalty != 7*reputationDialPenalty {
+		t.Fatalf("wrong dial penalty %v", penalty)
+	}
+
+	// Scores decay towards zero.
+	now = now.Add(reputationHalfLife)
+	if score := store.score(id); score != -4 {
+		t.Fatalf("wrong score %d after decay, want -4", score)
+	}
+
+	// Crossing the threshold bans the node.
+	if store.report(id, ReputationInvalidMessage) {
+		t.Fatal("node banned too early")
+	}
+	if !store.report(id, ReputationInvalidMessage) {
+		t.Fatal("node not banned")
+	}
+	if ban := store.bannedFor(id); ban != banDuration {
+		t.Fatalf("wrong ban duration %v", ban)
+	}
+	infos := store.all()
+	if len(infos) != 1 || infos[0].ID != id.String() || infos[0].Score != -104 || infos[0].BannedUntil == nil {
+		t.Fatalf("wrong reputation infos: %+v", infos)
+	}
+
+	// The ban ends, and the node recovers as the score decays.
+	now = now.Add(banDuration)
+	if ban := store.bannedFor(id); ban != 0 {
+		t.Fatalf("node still banned after ban duration: %v", ban)
+	}
+	now = now.Add(20 * reputationHalfLife)
+	if infos := store.all(); len(infos) != 0 {
+		t.Fatalf("node with decayed score listed: %+v", infos)
+	}
+
+	// Reset lifts the ban.
+	store.report(id, ReputationInvalidBlock)
+	store.reset(id)
+	if ban, score := store.bannedFor(id), store.score(id); ban != 0 || score != 0 {
+		t.Fatalf("reputation not reset: ban %v, score %d", ban, score)
+	}
+}
+
+func TestReputationFlush(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		store = newReputationStore(db)
+		now   = time.Unix(1700000000, 0)
+		id    = enode.ID{1}
+	)
+	store.now = func() time.Time { return now }
+
+	// Reports are only written to the database by flush.
+	store.report(id, ReputationTimeout)
+	if reps := db.Reputations(); len(reps) != 0 {
+		t.Fatalf("reputation stored before flush: %v", reps)
+	}
+	store.flush()
+	if rep := db.Reputation(id); rep.Score != -10 {
+		t.Fatalf("wrong stored score %d, want -10", rep.Score)
+	}
+
+	// Stored reputations are loaded on startup.
+	loaded := newReputationStore(db)
+	loaded.now = store.now
+	if score := loaded.score(id); score != -10 {
+		t.Fatalf("wrong loaded score %d, want -10", score)
+	}
+
+	// Decayed scores are dropped.
+	now = now.Add(20 * reputationHalfLife)
+	store.flush()
+	if reps := db.Reputations(); len(reps) != 0 {
+		t.Fatalf("decayed reputation not deleted: %v", reps)
+	}
+	if len(store.reps) != 0 {
+		t.Fatalf("decayed reputation kept in memory: %v", store.reps)
+	}
+}
+
+func TestDialBannedNode(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		store = newReputationStore(db)
+		clock = new(mclock.Simulated)
+		d     = &dialScheduler{dialConfig: dialConfig{reputation: store, clock: clock}.withDefaults()}
+		n     = newNode(uintID(1), "127.0.0.1:30303")
+		now   = time.Unix(1700000000, 0)
+	)
+	store.now = func() time.Time { return now }
+	store.report(n.ID(), ReputationInvalidBlock)
+	if err := d.checkDial(n); err != errBanned {
+		t.Fatalf("wrong error for banned node: %v", err)
+	}
+	// The node stays in dial history until the ban ends.
+	if err := d.checkDial(n); err != errRecentlyDialed {
+		t.Fatalf("wrong error for banned node in history: %v", err)
+	}
+	if exp := d.history.nextExpiry(); exp != clock.Now().Add(banDuration) {
+		t.Fatalf("wrong history expiry %v", exp)
+	}
+}
+
+func TestServerReputationBan(t *testing.T) {
+	var misbehave atomic.Bool
+	misbehave.Store(true)
+	srv1 := &Server{Config: Config{
+		Name:        "srv1",
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		NoDiscovery: true,
+		PrivateKey:  newkey(),
+		Logger:      testlog.Logger(t, log.LvlTrace),
+		Protocols: []Protocol{{
+			Name:    "rep",
+			Version: 1,
+			Run: func(p *Peer, rw MsgReadWriter) error {
+				if misbehave.Load() {
+					p.Report(ReputationInvalidBlock)
+				}
+				_, err := rw.ReadMsg()
+				return err
+			},
+		}},
+	}}
+	if err := srv1.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv1.Stop()
+
+	// connect starts a server with the given key and dials srv1.
+	key := newkey()
+	connect := func(name string, key *ecdsa.PrivateKey) (*Server, bool) {
+		srv := &Server{Config: Config{
+			Name:        name,
+			MaxPeers:    10,
+			NoDiscovery: true,
+			PrivateKey:  key,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+			Protocols:   []Protocol{{Name: "rep", Version: 1, Run: func(p *Peer, rw MsgReadWriter) error { _, err := rw.ReadMsg(); return err }}},
+		}}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("could not start server: %v", err)
+		}
+		return srv, syncAddPeer(srv, srv1.Self())
+	}
+
+	// The first connection gets banned by the protocol.
+	events := make(chan *PeerEvent, 10)
+	sub := srv1.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+	srv2, ok := connect("srv2", key)
+	if !ok {
+		t.Fatal("peer not connected")
+	}
+	for ev := range events {
+		if ev.Type == PeerEventTypeDrop {
+			if ev.Error != DiscUselessPeer.Error() {
+				t.Fatalf("wrong disconnect reason %q", ev.Error)
+			}
+			break
+		}
+	}
+	srv2.Stop()
+	infos := srv1.Reputations()
+	if len(infos) != 1 || infos[0].ID != srv2.Self().ID().String() || infos[0].BannedUntil == nil {
+		t.Fatalf("wrong reputations: %+v", infos)
+	}
+
+	// The banned node can't reconnect.
+	misbehave.Store(false)
+	srv3, ok := connect("srv3", key)
+	srv3.Stop()
+	if ok {
+		t.Fatal("banned node connected")
+	}
+
+	// It can after the reputation is reset.
+	srv1.ResetReputation(srv2.Self().ID())
+	srv4, ok := connect("srv4", key)
+	defer srv4.Stop()
+	if !ok {
+		t.Fatal("node not connected after reset")
+	}
+}
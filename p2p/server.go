@@ -20,10 +20,12 @@ package p2p
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/tls"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -33,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/capture"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
@@ -76,7 +79,9 @@ type Config struct {
 	PrivateKey *ecdsa.PrivateKey `toml:"-"`
 
 	// MaxPeers is the maximum number of peers that can be
-	// connected. It must be greater than zero.
+	// connected. It must be greater than zero. Limits changed through
+	// SetMaxPeers and SetDialRatio are stored in the node database and
+	// take precedence over MaxPeers and DialRatio.
 	MaxPeers int
 
 	// MaxPendingPeers is the maximum number of peers that can be pending in the
@@ -146,6 +151,25 @@ type Config struct {
 	// for TCP and DiscAddr for the UDP discovery protocol.
 	DiscAddr string
 
+	// If ListenAddr6 is set to a non-nil IPv6 address, the server listens on it
+	// in addition to ListenAddr, which is then restricted to IPv4. Discovery runs
+	// over both address families, and the local node record announces endpoints
+	// in both.
+	//
+	// Like ListenAddr, this field is updated with the actual address when the
+	// server is started.
+	ListenAddr6 string `toml:",omitempty"`
+
+	// If DiscAddr6 is set to a non-nil value, the server will use DiscAddr6
+	// instead of ListenAddr6 for IPv6 discovery.
+	DiscAddr6 string `toml:",omitempty"`
+
+	// If QUICAddr is set to a non-nil UDP address, the server accepts devp2p
+	// connections over QUIC on it, and announces the port in the "quic" entry
+	// of the local node record. Nodes announcing a QUIC port are then dialed
+	// over QUIC instead of TCP. This transport is experimental.
+	QUICAddr string `toml:",omitempty"`
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -162,6 +186,10 @@ type Config struct {
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
 
+	// CaptureFile is the path of a file to record all subprotocol messages in,
+	// for debugging. The file can be inspected and replayed with devp2p capture.
+	CaptureFile string `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -183,17 +211,27 @@ type Server struct {
 	running bool
 
 	listener     net.Listener
+	listener6    net.Listener
+	quicListener *quicListener
+	quicTLS      *tls.Config
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
 	log          log.Logger
 
-	nodedb    *enode.DB
-	localnode *enode.LocalNode
-	ntab      *discover.UDPv4
-	DiscV5    *discover.UDPv5
-	discmix   *enode.FairMix
-	dialsched *dialScheduler
+	captureFile *os.File
+	capture     *capture.Writer
+	captureErr  sync.Once
+
+	nodedb     *enode.DB
+	reputation *reputationStore
+	bans       *banList
+	limits     *peerLimits
+	localnode  *enode.LocalNode
+	ntab       *discover.UDPv4
+	DiscV5     *discover.UDPv5
+	discmix    *enode.FairMix
+	dialsched  *dialScheduler
 
 	// This is read by the NAT port mapping loop.
 	portMappingRegister chan *portMapping
@@ -383,6 +421,124 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// Reputations returns the reputation of all nodes with a non-zero score or an
+// active ban.
+func (srv *Server) Reputations() []*ReputationInfo {
+	if srv.reputation == nil {
+		return nil
+	}
+	return srv.reputation.all()
+}
+
+// ResetReputation clears the reputation of the given node, lifting its ban.
+func (srv *Server) ResetReputation(id enode.ID) {
+	if srv.reputation != nil {
+		srv.reputation.reset(id)
+	}
+}
+
+// BanNode bans a node until the ban is lifted through UnbanNode. The node is
+// disconnected if it is connected. Bans are persisted in the node database.
+func (srv *Server) BanNode(id enode.ID) error {
+	if srv.bans == nil {
+		return errServerStopped
+	}
+	if err := srv.bans.banNode(id); err != nil {
+		return err
+	}
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		if p := peers[id]; p != nil {
+			p.Disconnect(DiscUselessPeer)
+		}
+	})
+	return nil
+}
+
+// UnbanNode lifts the ban of a node. It returns false if the node wasn't banned.
+func (srv *Server) UnbanNode(id enode.ID) bool {
+	return srv.bans != nil && srv.bans.unbanNode(id)
+}
+
+// BanNetwork bans all nodes in an IP network until the ban is lifted through
+// UnbanNetwork. Connected peers in the network are disconnected.
+func (srv *Server) BanNetwork(n *net.IPNet) error {
+	if srv.bans == nil {
+		return errServerStopped
+	}
+	if err := srv.bans.banNetwork(n); err != nil {
+		return err
+	}
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		for _, p := range peers {
+			if ip := netutil.AddrIP(p.RemoteAddr()); ip != nil && n.Contains(ip) {
+				p.Disconnect(DiscUselessPeer)
+			}
+		}
+	})
+	return nil
+}
+
+// UnbanNetwork lifts the ban of an IP network. It returns false if the network
+// wasn't banned.
+func (srv *Server) UnbanNetwork(n *net.IPNet) bool {
+	return srv.bans != nil && srv.bans.unbanNetwork(n)
+}
+
+// BanList returns the banned nodes and networks.
+func (srv *Server) BanList() *BanList {
+	if srv.bans == nil {
+		return &BanList{Nodes: []string{}, Networks: []string{}}
+	}
+	return srv.bans.list()
+}
+
+// PeerLimits returns the current peer limits.
+func (srv *Server) PeerLimits() *PeerLimits {
+	if srv.limits == nil {
+		return &PeerLimits{MaxPeers: srv.MaxPeers, DialRatio: srv.DialRatio, ProtocolQuotas: map[string]int{}}
+	}
+	return srv.limits.get()
+}
+
+// SetMaxPeers changes the maximum number of peers. Connected peers are kept when
+// the limit is lowered, but no new peers are accepted until the peer count has
+// fallen below the limit. The new limit is persisted in the node database.
+func (srv *Server) SetMaxPeers(n int) error {
+	if srv.limits == nil {
+		return errServerStopped
+	}
+	if err := srv.limits.setMaxPeers(n); err != nil {
+		return err
+	}
+	srv.dialsched.setMaxDialPeers(srv.maxDialedConns())
+	return nil
+}
+
+// SetDialRatio changes the ratio of inbound to dialed connections. A ratio of
+// zero selects the default. The new ratio is persisted in the node database.
+func (srv *Server) SetDialRatio(r int) error {
+	if srv.limits == nil {
+		return errServerStopped
+	}
+	if err := srv.limits.setDialRatio(r); err != nil {
+		return err
+	}
+	srv.dialsched.setMaxDialPeers(srv.maxDialedConns())
+	return nil
+}
+
+// SetProtocolQuota reserves n peer slots for peers supporting the given protocol.
+// A quota of zero removes the reservation. The quota is persisted in the node database.
+func (srv *Server) SetProtocolQuota(name string, n int) error {
+	if srv.limits == nil {
+		return errServerStopped
+	}
+	if !slices.ContainsFunc(srv.Protocols, func(p Protocol) bool { return p.Name == name }) {
+		return fmt.Errorf("unknown protocol %q", name)
+	}
+	return srv.limits.setProtocolQuota(name, n)
+}
+
 // SubscribeEvents subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -413,6 +569,12 @@ func (srv *Server) Stop() {
 		// this unblocks listener Accept
 		srv.listener.Close()
 	}
+	if srv.listener6 != nil {
+		srv.listener6.Close()
+	}
+	if srv.quicListener != nil {
+		srv.quicListener.Close()
+	}
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
@@ -421,7 +583,7 @@ func (srv *Server) Stop() {
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
 // messages that were found unprocessable and sent to the unhandled channel by the primary listener.
 type sharedUDPConn struct {
-	*net.UDPConn
+	discover.UDPConn
 	unhandled chan discover.ReadPacket
 }
 
@@ -460,7 +622,7 @@ func (srv *Server) Start() (err error) {
 	if srv.clock == nil {
 		srv.clock = mclock.System{}
 	}
-	if srv.NoDial && srv.ListenAddr == "" {
+	if srv.NoDial && srv.ListenAddr == "" && srv.ListenAddr6 == "" && srv.QUICAddr == "" {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
 
@@ -486,13 +648,23 @@ func (srv *Server) Start() (err error) {
 	if err := srv.setupLocalNode(); err != nil {
 		return err
 	}
+	if srv.CaptureFile != "" {
+		if err := srv.setupCapture(); err != nil {
+			return err
+		}
+	}
 	srv.setupPortMapping()
 
-	if srv.ListenAddr != "" {
+	if srv.ListenAddr != "" || srv.ListenAddr6 != "" {
 		if err := srv.setupListening(); err != nil {
 			return err
 		}
 	}
+	if srv.QUICAddr != "" {
+		if err := srv.setupQUIC(); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupDiscovery(); err != nil {
 		return err
 	}
@@ -518,6 +690,16 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputationStore(db)
+	srv.bans = newBanList(db)
+	srv.limits = newPeerLimits(db, srv.MaxPeers, srv.DialRatio)
+	if maxPeers, dialRatio := srv.limits.current(); maxPeers != srv.MaxPeers || dialRatio != srv.DialRatio {
+		srv.log.Warn("Peer limits from node database override configuration",
+			"maxpeers", maxPeers, "configured", srv.MaxPeers, "dialratio", dialRatio, "configuredratio", srv.DialRatio)
+	}
+	if quotas := srv.limits.protocolQuotas(); len(quotas) > 0 {
+		srv.log.Info("Using protocol quotas from node database", "quotas", quotas)
+	}
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -558,6 +740,7 @@ func (srv *Server) setupDiscovery() error {
 			PrivateKey:  srv.PrivateKey,
 			NetRestrict: srv.NetRestrict,
 			Bootnodes:   srv.BootstrapNodes,
+			IsBanned:    srv.reputation.banned,
 			Unhandled:   unhandled,
 			Log:         srv.log,
 		}
@@ -573,6 +756,7 @@ func (srv *Server) setupDiscovery() error {
 			PrivateKey:  srv.PrivateKey,
 			NetRestrict: srv.NetRestrict,
 			Bootnodes:   srv.BootstrapNodesV5,
+			IsBanned:    srv.reputation.banned,
 			Log:         srv.log,
 		}
 		srv.DiscV5, err = discover.ListenV5(sconn, srv.localnode, cfg)
@@ -589,6 +773,17 @@ func (srv *Server) setupDiscovery() error {
 			added[proto.Name] = true
 		}
 	}
+	if srv.DiscV5 != nil {
+		topics := make(map[string]bool)
+		for _, proto := range srv.Protocols {
+			if proto.DiscoveryTopic != "" && !topics[proto.DiscoveryTopic] {
+				topic := discover.NewTopic(proto.DiscoveryTopic)
+				srv.DiscV5.RegisterTopic(topic)
+				srv.discmix.AddSource(srv.DiscV5.TopicSearch(topic))
+				topics[proto.DiscoveryTopic] = true
+			}
+		}
+	}
 	return nil
 }
 
@@ -599,6 +794,8 @@ func (srv *Server) setupDialScheduler() {
 		maxActiveDials: srv.MaxPendingPeers,
 		log:            srv.Logger,
 		netRestrict:    srv.NetRestrict,
+		reputation:     srv.reputation,
+		bans:           srv.bans,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
 	}
@@ -606,7 +803,10 @@ func (srv *Server) setupDialScheduler() {
 		config.resolver = srv.ntab
 	}
 	if config.dialer == nil {
-		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
+		config.dialer = tcpDialer{d: &net.Dialer{Timeout: defaultDialTimeout}, allow: srv.checkDialIP}
+	}
+	if srv.quicTLS != nil {
+		config.dialer = quicDialer{fallback: config.dialer, tlsConf: srv.quicTLS, allow: srv.checkDialIP}
 	}
 	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn)
 	for _, n := range srv.StaticNodes {
@@ -615,17 +815,19 @@ func (srv *Server) setupDialScheduler() {
 }
 
 func (srv *Server) maxInboundConns() int {
-	return srv.MaxPeers - srv.maxDialedConns()
+	maxPeers, _ := srv.limits.current()
+	return maxPeers - srv.maxDialedConns()
 }
 
 func (srv *Server) maxDialedConns() (limit int) {
-	if srv.NoDial || srv.MaxPeers == 0 {
+	maxPeers, dialRatio := srv.limits.current()
+	if srv.NoDial || maxPeers == 0 {
 		return 0
 	}
-	if srv.DialRatio == 0 {
-		limit = srv.MaxPeers / defaultDialRatio
+	if dialRatio == 0 {
+		limit = maxPeers / defaultDialRatio
 	} else {
-		limit = srv.MaxPeers / srv.DialRatio
+		limit = maxPeers / dialRatio
 	}
 	if limit == 0 {
 		limit = 1
@@ -634,33 +836,84 @@ func (srv *Server) maxDialedConns() (limit int) {
 }
 
 func (srv *Server) setupListening() error {
-	// Launch the listener.
-	listener, err := srv.listenFunc("tcp", srv.ListenAddr)
-	if err != nil {
-		return err
+	// Launch the listeners. When listening on IPv6 separately, the main
+	// listener is restricted to IPv4.
+	var tcp4Port int
+	if srv.ListenAddr != "" {
+		network := "tcp"
+		if srv.ListenAddr6 != "" {
+			network = "tcp4"
+		}
+		listener, err := srv.listenFunc(network, srv.ListenAddr)
+		if err != nil {
+			return err
+		}
+		srv.listener = listener
+		srv.ListenAddr = listener.Addr().String()
+
+		// Update the local node record and map the TCP listening port if NAT is configured.
+		tcp, isTCP := listener.Addr().(*net.TCPAddr)
+		if isTCP {
+			tcp4Port = tcp.Port
+			srv.localnode.Set(enr.TCP(tcp.Port))
+			if !tcp.IP.IsLoopback() && !tcp.IP.IsPrivate() {
+				srv.portMappingRegister <- &portMapping{
+					protocol: "TCP",
+					name:     "ethereum p2p",
+					port:     tcp.Port,
+				}
+			}
+		}
+		srv.loopWG.Add(1)
+		go srv.listenLoop(listener)
 	}
-	srv.listener = listener
-	srv.ListenAddr = listener.Addr().String()
-
-	// Update the local node record and map the TCP listening port if NAT is configured.
-	tcp, isTCP := listener.Addr().(*net.TCPAddr)
-	if isTCP {
-		srv.localnode.Set(enr.TCP(tcp.Port))
-		if !tcp.IP.IsLoopback() && !tcp.IP.IsPrivate() {
-			srv.portMappingRegister <- &portMapping{
-				protocol: "TCP",
-				name:     "ethereum p2p",
-				port:     tcp.Port,
+	if srv.ListenAddr6 != "" {
+		listener, err := srv.listenFunc("tcp6", srv.ListenAddr6)
+		if err != nil {
+			if srv.listener != nil {
+				srv.listener.Close()
+			}
+			return err
+		}
+		srv.listener6 = listener
+		srv.ListenAddr6 = listener.Addr().String()
+
+		// The tcp6 entry is only needed if the port differs. There is no port
+		// mapping for IPv6.
+		if tcp, isTCP := listener.Addr().(*net.TCPAddr); isTCP {
+			if tcp.Port != tcp4Port {
+				srv.localnode.Set(enr.TCP6(tcp.Port))
+			} else {
+				srv.localnode.Delete(enr.TCP6(0))
 			}
 		}
+		srv.loopWG.Add(1)
+		go srv.listenLoop(listener)
+	}
+	return nil
+}
+
+// setupQUIC starts the QUIC listener.
+func (srv *Server) setupQUIC() error {
+	tlsConf, err := newQUICTLSConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := listenQUIC(srv.QUICAddr, tlsConf)
+	if err != nil {
+		return err
 	}
+	srv.quicTLS = tlsConf
+	srv.quicListener = listener
+	srv.QUICAddr = listener.Addr().String()
+	srv.localnode.Set(enr.QUIC(listener.Addr().(*net.UDPAddr).Port))
 
 	srv.loopWG.Add(1)
-	go srv.listenLoop()
+	go srv.listenLoop(listener)
 	return nil
 }
 
-func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
+func (srv *Server) setupUDPListening() (discover.UDPConn, error) {
 	listenAddr := srv.ListenAddr
 
 	// Use an alternate listening address for UDP if
@@ -668,17 +921,41 @@ func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
 	if srv.DiscAddr != "" {
 		listenAddr = srv.DiscAddr
 	}
-	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	listenAddr6 := srv.ListenAddr6
+	if srv.DiscAddr6 != "" {
+		listenAddr6 = srv.DiscAddr6
+	}
+	if listenAddr6 == "" {
+		return srv.listenUDP("udp", listenAddr)
+	}
+	conn4, err := srv.listenUDP("udp4", listenAddr)
 	if err != nil {
 		return nil, err
 	}
-	conn, err := net.ListenUDP("udp", addr)
+	conn6, err := srv.listenUDP("udp6", listenAddr6)
+	if err != nil {
+		conn4.Close()
+		return nil, err
+	}
+	srv.localnode.SetFallbackUDP6(conn6.LocalAddr().(*net.UDPAddr).Port)
+	return newDualStackConn(conn4, conn6), nil
+}
+
+func (srv *Server) listenUDP(network, listenAddr string) (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr(network, listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP(network, addr)
 	if err != nil {
 		return nil, err
 	}
 	laddr := conn.LocalAddr().(*net.UDPAddr)
-	srv.localnode.SetFallbackUDP(laddr.Port)
 	srv.log.Debug("UDP listener up", "addr", laddr)
+	if network == "udp6" {
+		return conn, nil
+	}
+	srv.localnode.SetFallbackUDP(laddr.Port)
 	if !laddr.IP.IsLoopback() && !laddr.IP.IsPrivate() {
 		srv.portMappingRegister <- &portMapping{
 			protocol: "UDP",
@@ -686,7 +963,6 @@ func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
 			port:     laddr.Port,
 		}
 	}
-
 	return conn, nil
 }
 
@@ -704,6 +980,10 @@ func (srv *Server) run() {
 	srv.log.Info("Started P2P networking", "self", srv.localnode.Node().URLv4())
 	defer srv.loopWG.Done()
 	defer srv.nodedb.Close()
+	defer srv.reputation.flush()
+	if srv.captureFile != nil {
+		defer srv.captureFile.Close()
+	}
 	defer srv.discmix.Close()
 	defer srv.dialsched.stop()
 
@@ -711,7 +991,9 @@ func (srv *Server) run() {
 		peers        = make(map[enode.ID]*Peer)
 		inboundCount = 0
 		trusted      = make(map[enode.ID]bool, len(srv.TrustedNodes))
+		flush        = time.NewTicker(reputationFlush)
 	)
+	defer flush.Stop()
 	// Put trusted nodes into a map to speed up checks.
 	// Trusted peers are loaded on startup or added via AddTrustedPeer RPC.
 	for _, n := range srv.TrustedNodes {
@@ -743,6 +1025,10 @@ running:
 				p.rw.set(trustedConn, false)
 			}
 
+		case <-flush.C:
+			// Persist the reputation changes since the last flush.
+			srv.reputation.flush()
+
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -815,8 +1101,9 @@ running:
 }
 
 func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount int, c *conn) error {
+	maxPeers, _ := srv.limits.current()
 	switch {
-	case !c.is(trustedConn) && len(peers) >= srv.MaxPeers:
+	case !c.is(trustedConn) && len(peers) >= maxPeers:
 		return DiscTooManyPeers
 	case !c.is(trustedConn) && c.is(inboundConn) && inboundCount >= srv.maxInboundConns():
 		return DiscTooManyPeers
@@ -824,16 +1111,44 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case !c.is(trustedConn) && srv.reputation.bannedFor(c.node.ID()) > 0:
+		return DiscUselessPeer
+	case srv.bans.containsID(c.node.ID()):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
 }
 
+// reservedSlots returns the number of peer slots reserved by quotas of protocols
+// which c doesn't support.
+func (srv *Server) reservedSlots(peers map[enode.ID]*Peer, c *conn) (reserved int) {
+	for name, quota := range srv.limits.protocolQuotas() {
+		if supportsProtocol(srv.Protocols, c.caps, name) {
+			continue
+		}
+		for _, p := range peers {
+			if _, ok := p.running[name]; ok {
+				quota--
+			}
+		}
+		if quota > 0 {
+			reserved += quota
+		}
+	}
+	return reserved
+}
+
 func (srv *Server) addPeerChecks(peers map[enode.ID]*Peer, inboundCount int, c *conn) error {
 	// Drop connections with no matching protocols.
 	if len(srv.Protocols) > 0 && countMatchingProtocols(srv.Protocols, c.caps) == 0 {
 		return DiscUselessPeer
 	}
+	// Keep the slots reserved by protocol quotas free for peers that can fill them.
+	maxPeers, _ := srv.limits.current()
+	if !c.is(trustedConn) && len(peers)+srv.reservedSlots(peers, c) >= maxPeers {
+		return DiscTooManyPeers
+	}
 	// Repeat the post-handshake checks because the
 	// peer set might have changed since those checks were performed.
 	return srv.postHandshakeChecks(peers, inboundCount, c)
@@ -841,8 +1156,8 @@ func (srv *Server) addPeerChecks(peers map[enode.ID]*Peer, inboundCount int, c *
 
 // listenLoop runs in its own goroutine and accepts
 // inbound connections.
-func (srv *Server) listenLoop() {
-	srv.log.Debug("TCP listener up", "addr", srv.listener.Addr())
+func (srv *Server) listenLoop(listener net.Listener) {
+	srv.log.Debug("Listener up", "net", listener.Addr().Network(), "addr", listener.Addr())
 
 	// The slots channel limits accepts of new connections.
 	tokens := defaultMaxPendingPeers
@@ -873,7 +1188,7 @@ func (srv *Server) listenLoop() {
 			lastLog time.Time
 		)
 		for {
-			fd, err = srv.listener.Accept()
+			fd, err = listener.Accept()
 			if netutil.IsTemporaryError(err) {
 				if time.Since(lastLog) > 1*time.Second {
 					srv.log.Debug("Temporary read error", "err", err)
@@ -916,6 +1231,9 @@ func (srv *Server) checkInboundConn(remoteIP net.IP) error {
 	if srv.NetRestrict != nil && !srv.NetRestrict.Contains(remoteIP) {
 		return fmt.Errorf("not in netrestrict list")
 	}
+	if srv.bans.containsIP(remoteIP) {
+		return fmt.Errorf("banned network")
+	}
 	// Reject Internet peers that try too often.
 	now := srv.clock.Now()
 	srv.inboundHistory.expire(now, nil)
@@ -926,15 +1244,29 @@ func (srv *Server) checkInboundConn(remoteIP net.IP) error {
 	return nil
 }
 
+// checkDialIP reports whether an endpoint of a node may be dialed. Nodes announce
+// several endpoints, only those passing the checks applied to inbound connections
+// are dialed.
+func (srv *Server) checkDialIP(ip net.IP) bool {
+	if srv.NetRestrict != nil && !srv.NetRestrict.Contains(ip) {
+		return false
+	}
+	return !srv.bans.containsIP(ip)
+}
+
 // SetupConn runs the handshakes and attempts to add the connection
 // as a peer. It returns when the connection has been added as a peer
 // or the handshakes have failed.
 func (srv *Server) SetupConn(fd net.Conn, flags connFlag, dialDest *enode.Node) error {
 	c := &conn{fd: fd, flags: flags, cont: make(chan error)}
-	if dialDest == nil {
-		c.transport = srv.newTransport(fd, nil)
+	var dialPubkey *ecdsa.PublicKey
+	if dialDest != nil {
+		dialPubkey = dialDest.Pubkey()
+	}
+	if qc, ok := asQUICConn(fd); ok {
+		c.transport = newQUICTransport(fd, qc, dialPubkey)
 	} else {
-		c.transport = srv.newTransport(fd, dialDest.Pubkey())
+		c.transport = srv.newTransport(fd, dialPubkey)
 	}
 
 	err := srv.setupConn(c, flags, dialDest)
@@ -1007,9 +1339,11 @@ func (srv *Server) setupConn(c *conn, flags connFlag, dialDest *enode.Node) erro
 func nodeFromConn(pubkey *ecdsa.PublicKey, conn net.Conn) *enode.Node {
 	var ip net.IP
 	var port int
-	if tcp, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-		ip = tcp.IP
-		port = tcp.Port
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip, port = addr.IP, addr.Port
+	case *net.UDPAddr:
+		ip, port = addr.IP, addr.Port
 	}
 	return enode.NewV4(pubkey, ip, port, port)
 }
@@ -1027,11 +1361,15 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.reputation = srv.reputation
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
 		p.events = &srv.peerFeed
 	}
+	if srv.capture != nil {
+		p.capture = srv.writeCapture
+	}
 	go srv.runPeer(p)
 	return p
 }
@@ -1048,8 +1386,15 @@ func (srv *Server) runPeer(p *Peer) {
 		LocalAddress:  p.LocalAddr().String(),
 	})
 
+	if srv.capture != nil {
+		srv.captureConnect(p)
+	}
+
 	// Run the per-peer main loop.
 	remoteRequested, err := p.run()
+	if srv.capture != nil {
+		srv.captureDisconnect(p, err)
+	}
 
 	// Announce disconnect on the main loop to update the peer set.
 	// The main loop waits for existing peers to be sent on srv.delpeer
@@ -1080,8 +1425,9 @@ type NodeInfo struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
-	ListenAddr string                 `json:"listenAddr"`
-	Protocols  map[string]interface{} `json:"protocols"`
+	ListenAddr  string                 `json:"listenAddr"`
+	ListenAddr6 string                 `json:"listenAddr6,omitempty"`
+	Protocols   map[string]interface{} `json:"protocols"`
 }
 
 // NodeInfo gathers and returns a collection of metadata known about the host.
@@ -1089,12 +1435,13 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	// Gather and assemble the generic node infos
 	node := srv.Self()
 	info := &NodeInfo{
-		Name:       srv.Name,
-		Enode:      node.URLv4(),
-		ID:         node.ID().String(),
-		IP:         node.IP().String(),
-		ListenAddr: srv.ListenAddr,
-		Protocols:  make(map[string]interface{}),
+		Name:        srv.Name,
+		Enode:       node.URLv4(),
+		ID:          node.ID().String(),
+		IP:          node.IP().String(),
+		ListenAddr:  srv.ListenAddr,
+		ListenAddr6: srv.ListenAddr6,
+		Protocols:   make(map[string]interface{}),
 	}
 	info.Ports.Discovery = node.UDP()
 	info.Ports.Listener = node.TCP()
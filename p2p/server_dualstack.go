@@ -0,0 +1,118 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+)
+
+// dualStackPacketSize is the size of the read buffer of a dualStackConn. It
+// exceeds the maximum size of discovery packets.
+const dualStackPacketSize = 1500
+
+var errDualStackClosed = errors.New("dual-stack connection closed")
+
+// dualStackConn is a discovery connection over an IPv4 and an IPv6 socket.
+// Packets from both sockets are read in arrival order, and packets are sent
+// through the socket of the destination address family.
+type dualStackConn struct {
+	conn4, conn6 *net.UDPConn
+
+	packets   chan dualStackPacket
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+type dualStackPacket struct {
+	data []byte
+	addr *net.UDPAddr
+	err  error
+}
+
+func newDualStackConn(conn4, conn6 *net.UDPConn) *dualStackConn {
+	c := &dualStackConn{
+		conn4:   conn4,
+		conn6:   conn6,
+		packets: make(chan dualStackPacket, 16),
+		closed:  make(chan struct{}),
+	}
+	go c.readLoop(conn4)
+	go c.readLoop(conn6)
+	return c
+}
+
+// readLoop forwards the packets of one socket until it fails.
+func (c *dualStackConn) readLoop(conn *net.UDPConn) {
+	for {
+		buf := make([]byte, dualStackPacketSize)
+		n, addr, err := conn.ReadFromUDP(buf)
+		select {
+		case c.packets <- dualStackPacket{data: buf[:n], addr: addr, err: err}:
+		case <-c.closed:
+			return
+		}
+		if err != nil && !netutil.IsTemporaryError(err) {
+			return
+		}
+	}
+}
+
+// ReadFromUDP implements discover.UDPConn.
+func (c *dualStackConn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	select {
+	case p := <-c.packets:
+		if p.err != nil {
+			return 0, nil, p.err
+		}
+		return copy(b, p.data), p.addr, nil
+	case <-c.closed:
+		return 0, nil, errDualStackClosed
+	}
+}
+
+// WriteToUDP implements discover.UDPConn.
+func (c *dualStackConn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	if addr.IP.To4() != nil {
+		return c.conn4.WriteToUDP(b, addr)
+	}
+	return c.conn6.WriteToUDP(b, addr)
+}
+
+// LocalAddr implements discover.UDPConn. It returns the IPv4 address.
+func (c *dualStackConn) LocalAddr() net.Addr {
+	return c.conn4.LocalAddr()
+}
+
+// Close implements discover.UDPConn.
+func (c *dualStackConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.conn4.Close()
+		if err6 := c.conn6.Close(); err == nil {
+			err = err6
+		}
+	})
+	return err
+}
+
+var _ discover.UDPConn = (*dualStackConn)(nil)
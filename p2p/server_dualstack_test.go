@@ -0,0 +1,192 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+)
+
+func TestDualStackConn(t *testing.T) {
+	conn4, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn6, err := net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		t.Skipf("IPv6 unavailable: %v", err)
+	}
+	conn := newDualStackConn(conn4, conn6)
+	defer conn.Close()
+
+	for _, network := range []string{"udp4", "udp6"} {
+		local := conn4.LocalAddr().(*net.UDPAddr)
+		if network == "udp6" {
+			local = conn6.LocalAddr().(*net.UDPAddr)
+		}
+		remote, err := net.DialUDP(network, nil, local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer remote.Close()
+
+		// Read a packet from the remote end and echo it back.
+		msg := []byte("ping " + network)
+		if _, err := remote.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 100)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			t.Fatalf("%s: read error: %v", network, err)
+		}
+		if !bytes.Equal(buf[:n], msg) {
+			t.Fatalf("%s: wrong packet %q", network, buf[:n])
+		}
+		if _, err := conn.WriteToUDP(msg, from); err != nil {
+			t.Fatalf("%s: write error: %v", network, err)
+		}
+		remote.SetReadDeadline(time.Now().Add(time.Second))
+		if n, err = remote.Read(buf); err != nil || !bytes.Equal(buf[:n], msg) {
+			t.Fatalf("%s: echo not received: %q %v", network, buf[:n], err)
+		}
+	}
+
+	conn.Close()
+	if _, _, err := conn.ReadFromUDP(make([]byte, 100)); err == nil {
+		t.Fatal("no error reading from closed connection")
+	}
+}
+
+// This test checks that a dual-stack server announces both endpoints and can be
+// dialed through IPv6.
+func TestServerDualStack(t *testing.T) {
+	if l, err := net.Listen("tcp6", "[::1]:0"); err != nil {
+		t.Skipf("IPv6 unavailable: %v", err)
+	} else {
+		l.Close()
+	}
+	srv1 := &Server{Config: Config{
+		Name:        "dual",
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		ListenAddr6: "[::1]:0",
+		DiscoveryV4: true,
+		PrivateKey:  newkey(),
+		Logger:      testlog.Logger(t, log.LvlTrace),
+	}}
+	if err := srv1.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv1.Stop()
+
+	var (
+		self      = srv1.Self()
+		tcp4      = srv1.listener.Addr().(*net.TCPAddr)
+		tcp6      = srv1.listener6.Addr().(*net.TCPAddr)
+		udp4      = srv1.ntab.Self().UDP()
+		wantTCP6  = tcp6.Port
+		haveTCP6  enr.TCP6
+		haveUDP6  enr.UDP6
+		loadedTCP = self.Load(&haveTCP6) == nil
+		loadedUDP = self.Load(&haveUDP6) == nil
+	)
+	if self.TCP() != tcp4.Port {
+		t.Errorf("wrong tcp port in record: %d, want %d", self.TCP(), tcp4.Port)
+	}
+	if self.TCP6() != wantTCP6 || loadedTCP != (tcp4.Port != tcp6.Port) {
+		t.Errorf("wrong tcp6 port in record: %d, want %d", self.TCP6(), wantTCP6)
+	}
+	if !loadedUDP || self.UDP6() == udp4 {
+		t.Errorf("missing udp6 port in record")
+	}
+
+	// Dial the server through its IPv6 endpoint.
+	srv2 := &Server{Config: Config{
+		Name:        "dialer",
+		MaxPeers:    10,
+		NoDiscovery: true,
+		PrivateKey:  newkey(),
+		Logger:      testlog.Logger(t, log.LvlTrace),
+	}}
+	if err := srv2.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv2.Stop()
+
+	dest := enode.NewV4(&srv1.PrivateKey.PublicKey, net.IPv6loopback, tcp6.Port, 0)
+	if !syncAddPeer(srv2, dest) {
+		t.Fatal("peer not connected through IPv6")
+	}
+	if peers := srv2.Peers(); len(peers) != 1 || peers[0].RemoteAddr().(*net.TCPAddr).IP.To4() != nil {
+		t.Fatalf("wrong peers after dialing: %v", peers)
+	}
+}
+
+// This test checks that endpoints of dual-stack nodes failing the dial checks are
+// not dialed, even though the node has other endpoints passing them.
+func TestTCPDialerAllow(t *testing.T) {
+	l4, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l4.Close()
+	l6, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 unavailable: %v", err)
+	}
+	defer l6.Close()
+
+	var r enr.Record
+	r.Set(enr.IPv4(net.IPv4(127, 0, 0, 1)))
+	r.Set(enr.TCP(l4.Addr().(*net.TCPAddr).Port))
+	r.Set(enr.IPv6(net.IPv6loopback))
+	r.Set(enr.TCP6(l6.Addr().(*net.TCPAddr).Port))
+	if err := enode.SignV4(&r, newkey()); err != nil {
+		t.Fatal(err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restrict := new(netutil.Netlist)
+	restrict.Add("::1/128")
+	d := tcpDialer{d: new(net.Dialer), allow: restrict.Contains}
+
+	fd, err := d.Dial(context.Background(), n)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	fd.Close()
+	if ip := fd.RemoteAddr().(*net.TCPAddr).IP; !ip.Equal(net.IPv6loopback) {
+		t.Fatalf("dialed restricted endpoint %v", ip)
+	}
+	// Nodes without allowed endpoints are not dialed at all.
+	d.allow = func(net.IP) bool { return false }
+	if _, err := d.Dial(context.Background(), n); err != errNetRestrict {
+		t.Fatalf("wrong error for node without allowed endpoints: %v", err)
+	}
+}
@@ -97,12 +97,17 @@ func (t *rlpxTransport) WriteMsg(msg Msg) error {
 
 	// Set metrics.
 	msg.meterSize = size
+	meterEgress(msg)
+	return nil
+}
+
+// meterEgress marks the per-message egress meters of a subprotocol message.
+func meterEgress(msg Msg) {
 	if metrics.Enabled && msg.meterCap.Name != "" { // don't meter non-subprotocol messages
 		m := fmt.Sprintf("%s/%s/%d/%#02x", egressMeterName, msg.meterCap.Name, msg.meterCap.Version, msg.meterCode)
 		metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
 		metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
 	}
-	return nil
 }
 
 func (t *rlpxTransport) close(err error) {
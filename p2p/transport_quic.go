@@ -0,0 +1,507 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/quic-go/quic-go"
+)
+
+// The QUIC transport is an experimental alternative to RLPx over TCP. A session runs
+// over a single QUIC connection:
+//
+//   - The first stream, opened by the dialer, is the control stream. It carries the
+//     RLPx encryption handshake, which authenticates the secp256k1 node keys, and all
+//     base protocol messages (handshake, ping, disconnect) as RLPx frames.
+//   - Messages of each subprotocol are sent on a separate unidirectional stream, so
+//     large responses of one protocol don't delay the messages of others.
+//
+// The QUIC connection is encrypted using TLS with throwaway certificates. To rule out
+// a man in the middle terminating TLS, both ends exchange a TLS keying material export
+// through the authenticated control stream after the RLPx handshake.
+
+const (
+	// quicALPN is the TLS application protocol of devp2p over QUIC.
+	quicALPN = "devp2p"
+
+	// quicBindingMsg is the control stream message carrying the TLS session
+	// binding. It is sent right after the RLPx handshake.
+	quicBindingMsg = 0x0f
+
+	// quicExportLabel is the TLS exporter label of the session binding.
+	quicExportLabel = "EXPORTER-devp2p-quic"
+
+	// quicMaxMsgSize is the maximum size of a subprotocol message.
+	quicMaxMsgSize = 16 * 1024 * 1024
+
+	// quicDiscCodeOffset is added to disconnect reasons when they are sent as
+	// the application error code of the closing QUIC connection.
+	quicDiscCodeOffset = 0x100
+)
+
+var (
+	errQUICBinding  = errors.New("QUIC session binding mismatch")
+	errQUICMsgSize  = errors.New("message too large")
+	errQUICNoStream = errors.New("no stream for message code")
+	errQUICMsgCode  = errors.New("message code outside of subprotocol range")
+)
+
+// quicConfig is the QUIC configuration for devp2p connections. Liveness is checked by
+// the devp2p ping on the control stream, which is well within the idle timeout.
+var quicConfig = &quic.Config{
+	HandshakeIdleTimeout: handshakeTimeout,
+	MaxIdleTimeout:       frameReadTimeout,
+	KeepAlivePeriod:      pingInterval,
+}
+
+// newQUICTLSConfig creates the TLS configuration of the QUIC transport. Certificates
+// are not verified, peers are authenticated by the RLPx handshake instead.
+func newQUICTLSConfig() (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates:       []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+		NextProtos:         []string{quicALPN},
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS13,
+	}, nil
+}
+
+// quicConn is the control stream of a QUIC connection. It is what the server
+// handles as the connection, e.g. to get the remote address.
+type quicConn struct {
+	quic.Stream
+	conn quic.Connection
+}
+
+func (c *quicConn) LocalAddr() net.Addr  { return c.conn.LocalAddr() }
+func (c *quicConn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// Close closes the whole QUIC connection.
+func (c *quicConn) Close() error {
+	return c.conn.CloseWithError(0, "")
+}
+
+// asQUICConn returns the QUIC connection wrapped by fd, if any.
+func asQUICConn(fd net.Conn) (*quicConn, bool) {
+	if m, ok := fd.(*meteredConn); ok {
+		fd = m.Conn
+	}
+	qc, ok := fd.(*quicConn)
+	return qc, ok
+}
+
+// quicListener accepts QUIC connections as net.Conns of their control streams.
+type quicListener struct {
+	ln     *quic.Listener
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func listenQUIC(addr string, tlsConf *tls.Config) (*quicListener, error) {
+	ln, err := quic.ListenAddr(addr, tlsConf, quicConfig)
+	if err != nil {
+		return nil, err
+	}
+	l := &quicListener{ln: ln, conns: make(chan net.Conn), closed: make(chan struct{})}
+	go l.acceptLoop()
+	return l, nil
+}
+
+func (l *quicListener) acceptLoop() {
+	for {
+		conn, err := l.ln.Accept(context.Background())
+		if err != nil {
+			l.Close()
+			return
+		}
+		go func() {
+			// Wait for the dialer to open the control stream.
+			ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+			defer cancel()
+			stream, err := conn.AcceptStream(ctx)
+			if err != nil {
+				conn.CloseWithError(0, "")
+				return
+			}
+			select {
+			case l.conns <- &quicConn{Stream: stream, conn: conn}:
+			case <-l.closed:
+				conn.CloseWithError(0, "")
+			}
+		}()
+	}
+}
+
+// Accept implements net.Listener.
+func (l *quicListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Addr implements net.Listener.
+func (l *quicListener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// Close implements net.Listener.
+func (l *quicListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.closed)
+		err = l.ln.Close()
+	})
+	return err
+}
+
+// quicDialer dials nodes announcing a QUIC port over QUIC, and all other nodes
+// with the fallback dialer.
+type quicDialer struct {
+	fallback NodeDialer
+	tlsConf  *tls.Config
+	allow    func(net.IP) bool // reports whether an endpoint may be dialed, nil allows all
+}
+
+// quicEndpoint returns the QUIC endpoint of n, or nil if it doesn't have one.
+func quicEndpoint(n *enode.Node) *net.UDPAddr {
+	var port enr.QUIC
+	if n.Load(&port) != nil || port == 0 || n.IP() == nil {
+		return nil
+	}
+	return &net.UDPAddr{IP: n.IP(), Port: int(port)}
+}
+
+func (d quicDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	addr := quicEndpoint(dest)
+	if addr == nil || (d.allow != nil && !d.allow(addr.IP)) {
+		return d.fallback.Dial(ctx, dest)
+	}
+	conn, err := quic.DialAddr(ctx, addr.String(), d.tlsConf, quicConfig)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		conn.CloseWithError(0, "")
+		return nil, err
+	}
+	return &quicConn{Stream: stream, conn: conn}, nil
+}
+
+// quicTransport is the transport of QUIC connections.
+type quicTransport struct {
+	conn    quic.Connection
+	control *rlpxTransport
+
+	wmu     sync.Mutex
+	offsets []uint64                   // first message codes of the subprotocols
+	end     uint64                     // code following the last subprotocol message code
+	ready   chan struct{}              // closed once the subprotocols are known
+	streams map[uint64]quic.SendStream // by subprotocol offset
+	wbuf    bytes.Buffer
+
+	msgs      chan quicMsg
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+type quicMsg struct {
+	msg Msg
+	err error
+}
+
+func newQUICTransport(fd net.Conn, qc *quicConn, dialDest *ecdsa.PublicKey) transport {
+	return &quicTransport{
+		conn:    qc.conn,
+		control: newRLPX(fd, dialDest).(*rlpxTransport),
+		ready:   make(chan struct{}),
+		streams: make(map[uint64]quic.SendStream),
+		msgs:    make(chan quicMsg),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (t *quicTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+	pubkey, err := t.control.doEncHandshake(prv)
+	if err != nil {
+		return nil, err
+	}
+	// Bind the RLPx session to the TLS session of the connection.
+	state := t.conn.ConnectionState().TLS
+	binding, err := state.ExportKeyingMaterial(quicExportLabel, nil, 32)
+	if err != nil {
+		return nil, err
+	}
+	werr := make(chan error, 1)
+	go func() {
+		_, err := t.control.conn.Write(quicBindingMsg, binding)
+		werr <- err
+	}()
+	code, data, _, err := t.control.conn.Read()
+	if err != nil {
+		<-werr
+		return nil, err
+	}
+	if err := <-werr; err != nil {
+		return nil, err
+	}
+	if code != quicBindingMsg || !bytes.Equal(data, binding) {
+		return nil, errQUICBinding
+	}
+	return pubkey, nil
+}
+
+func (t *quicTransport) doProtoHandshake(our *protoHandshake) (*protoHandshake, error) {
+	their, err := t.control.doProtoHandshake(our)
+	if err != nil {
+		return nil, err
+	}
+	go t.readControl()
+	go t.acceptStreams()
+	return their, nil
+}
+
+// setProtocolOffsets sets the first message codes of the subprotocols running on the
+// connection, and the code following the last message code of the subprotocols.
+// It must be called before any subprotocol message is written, received messages
+// are held back until it is called.
+func (t *quicTransport) setProtocolOffsets(offsets []uint64, end uint64) {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+
+	t.offsets = append([]uint64{}, offsets...)
+	sort.Slice(t.offsets, func(i, j int) bool { return t.offsets[i] < t.offsets[j] })
+	t.end = end
+	close(t.ready)
+}
+
+func (t *quicTransport) ReadMsg() (Msg, error) {
+	select {
+	case m := <-t.msgs:
+		return m.msg, m.err
+	case <-t.closed:
+		return Msg{}, io.EOF
+	}
+}
+
+// deliver passes a received message or read error to ReadMsg.
+func (t *quicTransport) deliver(msg Msg, err error) bool {
+	if err != nil {
+		err = quicDiscReason(err)
+	}
+	select {
+	case t.msgs <- quicMsg{msg, err}:
+		return err == nil
+	case <-t.closed:
+		return false
+	}
+}
+
+// readControl reads base protocol messages from the control stream.
+func (t *quicTransport) readControl() {
+	for {
+		msg, err := t.control.ReadMsg()
+		if !t.deliver(msg, err) {
+			return
+		}
+	}
+}
+
+// acceptStreams reads the subprotocol streams opened by the remote end.
+func (t *quicTransport) acceptStreams() {
+	for {
+		stream, err := t.conn.AcceptUniStream(context.Background())
+		if err != nil {
+			t.deliver(Msg{}, err)
+			return
+		}
+		go t.readStream(stream)
+	}
+}
+
+// readStream reads the messages of a subprotocol stream. Each message is prefixed by
+// its code and size as uvarints.
+func (t *quicTransport) readStream(stream quic.ReceiveStream) {
+	select {
+	case <-t.ready:
+	case <-t.closed:
+		return
+	}
+	t.wmu.Lock()
+	end := t.end
+	t.wmu.Unlock()
+
+	r := bufio.NewReader(stream)
+	for {
+		code, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.deliver(Msg{}, err)
+			return
+		}
+		// Base protocol messages are only accepted on the control stream.
+		if code < baseProtocolLength || code >= end {
+			t.deliver(Msg{}, errQUICMsgCode)
+			return
+		}
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			t.deliver(Msg{}, err)
+			return
+		}
+		if size > quicMaxMsgSize {
+			t.deliver(Msg{}, errQUICMsgSize)
+			return
+		}
+		// The buffer grows as the data arrives, instead of allocating the announced
+		// size upfront on every stream.
+		var data bytes.Buffer
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			t.deliver(Msg{}, err)
+			return
+		}
+		if metrics.Enabled {
+			ingressTrafficMeter.Mark(int64(size))
+		}
+		msg := Msg{
+			ReceivedAt: time.Now(),
+			Code:       code,
+			Size:       uint32(size),
+			meterSize:  uint32(size),
+			Payload:    bytes.NewReader(data.Bytes()),
+		}
+		if !t.deliver(msg, nil) {
+			return
+		}
+	}
+}
+
+func (t *quicTransport) WriteMsg(msg Msg) error {
+	if msg.Code < baseProtocolLength {
+		return t.control.WriteMsg(msg)
+	}
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+
+	if msg.Size > quicMaxMsgSize {
+		return errQUICMsgSize
+	}
+	stream, err := t.stream(msg.Code)
+	if err != nil {
+		return err
+	}
+	t.wbuf.Reset()
+	var hdr [2 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(hdr[:], msg.Code)
+	n += binary.PutUvarint(hdr[n:], uint64(msg.Size))
+	t.wbuf.Write(hdr[:n])
+	if _, err := io.CopyN(&t.wbuf, msg.Payload, int64(msg.Size)); err != nil {
+		return err
+	}
+	stream.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+	if _, err := stream.Write(t.wbuf.Bytes()); err != nil {
+		return quicDiscReason(err)
+	}
+	msg.meterSize = msg.Size
+	if metrics.Enabled {
+		egressTrafficMeter.Mark(int64(t.wbuf.Len()))
+	}
+	meterEgress(msg)
+	return nil
+}
+
+// stream returns the stream of the subprotocol which the given message code belongs
+// to, opening it if necessary.
+func (t *quicTransport) stream(code uint64) (quic.SendStream, error) {
+	i := sort.Search(len(t.offsets), func(i int) bool { return t.offsets[i] > code })
+	if i == 0 {
+		return nil, errQUICNoStream
+	}
+	offset := t.offsets[i-1]
+	if s := t.streams[offset]; s != nil {
+		return s, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), frameWriteTimeout)
+	defer cancel()
+	s, err := t.conn.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, quicDiscReason(err)
+	}
+	t.streams[offset] = s
+	return s, nil
+}
+
+// close terminates the connection. The disconnect reason is sent as the error
+// code of the connection close, so it arrives even if streams are blocked.
+func (t *quicTransport) close(err error) {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		var code quic.ApplicationErrorCode
+		if r, ok := err.(DiscReason); ok && r != DiscNetworkError {
+			code = quic.ApplicationErrorCode(quicDiscCodeOffset + uint64(r))
+		}
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+		t.conn.CloseWithError(code, msg)
+	})
+}
+
+// quicDiscReason converts the closing of the connection by the remote end into the
+// disconnect reason it sent.
+func quicDiscReason(err error) error {
+	var appErr *quic.ApplicationError
+	if errors.As(err, &appErr) && appErr.Remote && appErr.ErrorCode >= quicDiscCodeOffset {
+		return DiscReason(appErr.ErrorCode - quicDiscCodeOffset)
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+// quicTestProtocols returns two protocols which send a greeting, and report the
+// greeting received from the remote end on the given channel.
+func quicTestProtocols(received chan<- string) []Protocol {
+	var protos []Protocol
+	for _, name := range []string{"aaa", "bbb"} {
+		name := name
+		protos = append(protos, Protocol{
+			Name:    name,
+			Version: 1,
+			Length:  3,
+			Run: func(p *Peer, rw MsgReadWriter) error {
+				if err := Send(rw, 2, name+" from "+p.LocalAddr().String()); err != nil {
+					return err
+				}
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				var greeting string
+				if err := msg.Decode(&greeting); err != nil {
+					return err
+				}
+				received <- fmt.Sprintf("%s:%d:%s", name, msg.Code, greeting)
+				// Keep the protocol running until the peer disconnects.
+				_, err = rw.ReadMsg()
+				return err
+			},
+		})
+	}
+	return protos
+}
+
+func startQUICTestServer(t *testing.T, name string, received chan<- string) *Server {
+	srv := &Server{Config: Config{
+		Name:        name,
+		MaxPeers:    10,
+		NoDiscovery: true,
+		QUICAddr:    "127.0.0.1:0",
+		Protocols:   quicTestProtocols(received),
+		PrivateKey:  newkey(),
+		Logger:      testlog.Logger(t, log.LvlTrace).New("server", name),
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	return srv
+}
+
+func TestServerQUIC(t *testing.T) {
+	var (
+		received1 = make(chan string, 2)
+		received2 = make(chan string, 2)
+		srv1      = startQUICTestServer(t, "srv1", received1)
+		srv2      = startQUICTestServer(t, "srv2", received2)
+	)
+	defer srv1.Stop()
+	defer srv2.Stop()
+
+	// The QUIC port is announced in the node record.
+	var port enr.QUIC
+	if err := srv1.Self().Load(&port); err != nil {
+		t.Fatal("no quic entry in node record:", err)
+	}
+	if want := srv1.quicListener.Addr().(*net.UDPAddr).Port; int(port) != want {
+		t.Fatalf("wrong quic port %d in record, want %d", port, want)
+	}
+
+	// Connect the servers. Since the node has no TCP port, it can only be dialed over QUIC.
+	events := make(chan *PeerEvent, 10)
+	sub := srv1.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+	node1 := srv1.Self()
+	if node1.TCP() != 0 {
+		t.Fatal("unexpected tcp port in node record")
+	}
+	if !syncAddPeer(srv2, node1) {
+		t.Fatal("peer not connected over QUIC")
+	}
+	peers := srv2.Peers()
+	if len(peers) != 1 {
+		t.Fatalf("wrong number of peers: %d", len(peers))
+	}
+	if _, ok := peers[0].RemoteAddr().(*net.UDPAddr); !ok {
+		t.Fatalf("peer not connected over QUIC: %v", peers[0].RemoteAddr())
+	}
+
+	// Each protocol received the greeting of the other end, with the code relative
+	// to the protocol offset.
+	check := func(received <-chan string, want map[string]bool) {
+		t.Helper()
+		for len(want) > 0 {
+			select {
+			case r := <-received:
+				if !want[r] {
+					t.Fatalf("unexpected greeting %q", r)
+				}
+				delete(want, r)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("missing greetings: %v", want)
+			}
+		}
+	}
+	addr1, addr2 := srv1.quicListener.Addr().String(), peers[0].LocalAddr().String()
+	check(received1, map[string]bool{"aaa:2:aaa from " + addr2: true, "bbb:2:bbb from " + addr2: true})
+	check(received2, map[string]bool{"aaa:2:aaa from " + addr1: true, "bbb:2:bbb from " + addr1: true})
+
+	// The disconnect reason is delivered to the remote end.
+	srv2.RemovePeer(node1)
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type != PeerEventTypeDrop {
+				continue
+			}
+			if ev.Error != DiscRequested.Error() {
+				t.Fatalf("wrong disconnect reason %q", ev.Error)
+			}
+			return
+		case <-timeout:
+			t.Fatal("peer not dropped")
+		}
+	}
+}
+
+// fallbackTestDialer records the nodes it is asked to dial.
+type fallbackTestDialer struct{ dialed []*enode.Node }
+
+func (d *fallbackTestDialer) Dial(ctx context.Context, n *enode.Node) (net.Conn, error) {
+	d.dialed = append(d.dialed, n)
+	return nil, errServerStopped
+}
+
+func TestQUICDialFallback(t *testing.T) {
+	var (
+		fallback = new(fallbackTestDialer)
+		d        = quicDialer{fallback: fallback}
+		key      = newkey()
+		n        = enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 0)
+	)
+	if _, err := d.Dial(context.Background(), n); err != errServerStopped {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if len(fallback.dialed) != 1 || fallback.dialed[0] != n {
+		t.Fatal("node without quic entry not dialed with fallback dialer")
+	}
+}
+
+// This test checks that messages with codes outside of the subprotocol range,
+// including base protocol messages, are rejected on subprotocol streams.
+func TestQUICStreamCodes(t *testing.T) {
+	for _, code := range []uint64{discMsg, baseProtocolLength + 6} {
+		var (
+			srv1 = startQUICTestServer(t, "srv1", make(chan string, 2))
+			srv2 = startQUICTestServer(t, "srv2", make(chan string, 2))
+		)
+		events := make(chan *PeerEvent, 10)
+		sub := srv1.SubscribeEvents(events)
+
+		if !syncAddPeer(srv2, srv1.Self()) {
+			t.Fatal("peer not connected over QUIC")
+		}
+		tr := srv2.Peers()[0].rw.transport.(*quicTransport)
+		stream, err := tr.conn.OpenUniStreamSync(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream.Write([]byte{byte(code), 0})
+
+		timeout := time.After(2 * time.Second)
+	wait:
+		for {
+			select {
+			case ev := <-events:
+				if ev.Type != PeerEventTypeDrop {
+					continue
+				}
+				if ev.Error != errQUICMsgCode.Error() {
+					t.Errorf("code %d: wrong disconnect reason %q", code, ev.Error)
+				}
+				break wait
+			case <-timeout:
+				t.Fatalf("code %d: peer not dropped", code)
+			}
+		}
+		sub.Unsubscribe()
+		srv1.Stop()
+		srv2.Stop()
+	}
+}
@@ -88,6 +88,7 @@ type Client struct {
 	// config fields
 	batchItemLimit       int
 	batchResponseMaxSize int
+	limiter              Limiter
 
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
 	// only be accessed outside of dispatch, with the write lock held. The write lock is
@@ -119,7 +120,7 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, clientContextKey{}, c)
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, conn.peerInfo())
-	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize)
+	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize, c.limiter)
 	return &clientConn{conn, handler}
 }
 
@@ -247,6 +248,7 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 		idgen:                cfg.idgen,
 		batchItemLimit:       cfg.batchItemLimit,
 		batchResponseMaxSize: cfg.batchResponseLimit,
+		limiter:              cfg.limiter,
 		writeConn:            conn,
 		close:                make(chan struct{}),
 		closing:              make(chan struct{}),
@@ -538,6 +540,32 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 	return op.sub, nil
 }
 
+// SubscribeResumable registers a subscription like Subscribe, which is re-established
+// when the connection to the server is lost. The client then reconnects and calls
+// "<namespace>_subscribe" again with the arguments returned by args. Since args is
+// invoked for every attempt, it can return arguments which resume the stream at the
+// last received notification.
+//
+// Resubscription is attempted with increasing delays until it succeeds. The
+// subscription ends with an error if the server rejects the subscription request.
+func (c *Client) SubscribeResumable(ctx context.Context, namespace string, channel interface{}, args func() []interface{}) (*ResumableSubscription, error) {
+	sub, err := c.Subscribe(ctx, namespace, channel, args()...)
+	if err != nil {
+		return nil, err
+	}
+	rs := &ResumableSubscription{
+		client:    c,
+		namespace: namespace,
+		channel:   channel,
+		args:      args,
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+		err:       make(chan error, 1),
+	}
+	go rs.run(sub)
+	return rs, nil
+}
+
 // SupportsSubscriptions reports whether subscriptions are supported by the client
 // transport. When this returns false, Subscribe and related methods will return
 // ErrNotificationsUnsupported.
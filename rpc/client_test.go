@@ -806,6 +806,88 @@ func TestClientReconnect(t *testing.T) {
 	}
 }
 
+func TestClientSubscribeResumable(t *testing.T) {
+	startServer := func(addr string, srv *Server) net.Listener {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatal("can't listen:", err)
+		}
+		go http.Serve(l, srv.WebsocketHandler([]string{"*"}))
+		return l
+	}
+	s1 := newTestServer()
+	l1 := startServer("127.0.0.1:0", s1)
+	client, err := Dial("ws://" + l1.Addr().String())
+	if err != nil {
+		t.Fatal("can't dial", err)
+	}
+	defer client.Close()
+
+	// The subscription is resumed at the value following the last received one.
+	var (
+		mu   sync.Mutex
+		next int
+		nc   = make(chan int)
+	)
+	args := func() []interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return []interface{}{"someSubscription", 5, next}
+	}
+	sub, err := client.SubscribeResumable(context.Background(), "nftest", nc, args)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	defer sub.Unsubscribe()
+
+	receive := func(want int) {
+		select {
+		case v := <-nc:
+			if v != want {
+				t.Fatalf("wrong value %d, want %d", v, want)
+			}
+			mu.Lock()
+			next = v + 1
+			mu.Unlock()
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("timeout waiting for value %d", want)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		receive(i)
+	}
+
+	// Restart the server. The subscription should continue where it left off.
+	l1.Close()
+	s1.Stop()
+	time.Sleep(2 * time.Second)
+	s2 := newTestServer()
+	l2 := startServer(l1.Addr().String(), s2)
+	for i := 5; i < 10; i++ {
+		receive(i)
+	}
+
+	// Restart with a server which rejects the subscription.
+	l2.Close()
+	s2.Stop()
+	time.Sleep(2 * time.Second)
+	s3 := NewServer()
+	defer s3.Stop()
+	l3 := startServer(l1.Addr().String(), s3)
+	defer l3.Close()
+	select {
+	case err := <-sub.Err():
+		var rpcErr Error
+		if !errors.As(err, &rpcErr) {
+			t.Fatalf("wrong error %v, want rejection by the server", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for subscription error")
+	}
+}
+
 func httpTestClient(srv *Server, transport string, fl *flakeyListener) (*Client, *httptest.Server) {
 	// Create the HTTP server.
 	var hs *httptest.Server
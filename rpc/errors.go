@@ -61,6 +61,7 @@ const (
 	errcodeDefault          = -32000
 	errcodeTimeout          = -32002
 	errcodeResponseTooLarge = -32003
+	errcodeLimitExceeded    = -32005
 	errcodePanic            = -32603
 	errcodeMarshalError     = -32603
 
@@ -71,6 +72,9 @@ const (
 	errMsgTimeout          = "request timed out"
 	errMsgResponseTooLarge = "response too large"
 	errMsgBatchTooLarge    = "batch too large"
+
+	errMsgRateLimited        = "rate limit exceeded"
+	errMsgConcurrencyLimited = "too many concurrent requests"
 )
 
 type methodNotFoundError struct{ method string }
@@ -0,0 +1,312 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"golang.org/x/time/rate"
+)
+
+// limiterClientCacheSize is the number of idle clients whose limits are tracked.
+// The state of the least recently active clients is dropped beyond it, clients
+// with calls in flight are always tracked.
+const limiterClientCacheSize = 4096
+
+// Limiter decides whether method calls may be served. It is consulted by the
+// server before running every call of a registered method.
+type Limiter interface {
+	// Acquire admits a call of the given method, made by the client described by the
+	// context. Once the call is served, the returned release function must be invoked.
+	// If the call is not admitted, it is answered with the returned error.
+	Acquire(ctx context.Context, method string) (release func(), err error)
+}
+
+// LimitRule restricts the calls a single client may make to the methods matching
+// a pattern.
+type LimitRule struct {
+	// Pattern selects the methods the rule applies to. It is either a method name
+	// (eth_getLogs), a namespace wildcard (debug_*) or * for all methods.
+	Pattern string
+
+	// Rate is the sustained number of calls per second a client may make, and Burst
+	// the number of calls it may make at once. A zero rate disables the limit, a zero
+	// burst defaults to the rate rounded up.
+	Rate  float64 `toml:",omitempty"`
+	Burst int     `toml:",omitempty"`
+
+	// MaxConcurrent is the number of calls a client may have running concurrently.
+	// Zero disables the limit.
+	MaxConcurrent int `toml:",omitempty"`
+}
+
+func (r *LimitRule) matches(method string) bool {
+	switch {
+	case r.Pattern == "*":
+		return true
+	case strings.HasSuffix(r.Pattern, "_*"):
+		return strings.HasPrefix(method, r.Pattern[:len(r.Pattern)-1])
+	default:
+		return r.Pattern == method
+	}
+}
+
+// LimitConfig configures a RateLimiter.
+type LimitConfig struct {
+	// ClientKey selects how calls are attributed to clients. "ip" keys them by the
+	// remote IP address, "jwt" by the subject of the verified JWT the request was
+	// authenticated with and "header:<name>" by the value of an HTTP header. Calls
+	// which don't carry the selected identity are keyed by remote IP address.
+	// Defaults to "ip".
+	ClientKey string `toml:",omitempty"`
+
+	// TrustedProxies lists the addresses (IPs or CIDR ranges) of the proxies whose
+	// requests are keyed by header. The header of requests from other addresses is
+	// ignored, since clients could otherwise pick their own key.
+	TrustedProxies []string `toml:",omitempty"`
+
+	// Rules are the limits applied to calls. A call counts against every rule
+	// matching its method.
+	Rules []LimitRule `toml:",omitempty"`
+}
+
+// RateLimiter is a Limiter applying token bucket rate limits and concurrency caps
+// to the calls of every client.
+type RateLimiter struct {
+	rules     []LimitRule
+	clientKey func(PeerInfo) string
+
+	mu      sync.Mutex
+	idle    lru.BasicLRU[string, *clientLimits] // Clients without calls in flight
+	serving map[string]*clientLimits            // Clients with calls in flight
+}
+
+// clientLimits is the limiter state of a single client, holding a token bucket and
+// a count of running calls for every rule.
+type clientLimits struct {
+	buckets []*rate.Limiter
+	running []int
+	calls   int // Number of calls in flight, across all rules
+}
+
+// NewRateLimiter creates a limiter enforcing the given configuration.
+func NewRateLimiter(config LimitConfig) (*RateLimiter, error) {
+	clientKey, err := parseClientKey(config.ClientKey, config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range config.Rules {
+		if err := validateLimitRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return &RateLimiter{
+		rules:     config.Rules,
+		clientKey: clientKey,
+		idle:      lru.NewBasicLRU[string, *clientLimits](limiterClientCacheSize),
+		serving:   make(map[string]*clientLimits),
+	}, nil
+}
+
+func validateLimitRule(rule LimitRule) error {
+	pattern := rule.Pattern
+	if pattern != "*" {
+		pattern = strings.TrimSuffix(pattern, "_*")
+		if pattern == "" || strings.Contains(pattern, "*") {
+			return fmt.Errorf("invalid limit pattern %q", rule.Pattern)
+		}
+	}
+	if rule.Rate < 0 || rule.Burst < 0 || rule.MaxConcurrent < 0 {
+		return fmt.Errorf("negative limit for %q", rule.Pattern)
+	}
+	return nil
+}
+
+// Acquire implements Limiter.
+func (l *RateLimiter) Acquire(ctx context.Context, method string) (func(), error) {
+	var matched []int
+	for i := range l.rules {
+		if l.rules[i].matches(method) {
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) == 0 {
+		return func() {}, nil
+	}
+	client := l.clientKey(PeerInfoFromContext(ctx))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limits, ok := l.serving[client]
+	if !ok {
+		if limits, ok = l.idle.Get(client); !ok {
+			limits = l.newClientLimits()
+			l.idle.Add(client, limits)
+		}
+	}
+	// Check the concurrency caps before consuming any tokens.
+	for _, i := range matched {
+		if limit := l.rules[i].MaxConcurrent; limit > 0 && limits.running[i] >= limit {
+			return nil, &internalServerError{errcodeLimitExceeded, errMsgConcurrencyLimited}
+		}
+	}
+	var (
+		now          = time.Now()
+		reservations = make([]*rate.Reservation, 0, len(matched))
+	)
+	for _, i := range matched {
+		if limits.buckets[i] == nil {
+			continue
+		}
+		r := limits.buckets[i].ReserveN(now, 1)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			// Refund the tokens taken from other buckets, the call is not served.
+			r.CancelAt(now)
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+			return nil, &internalServerError{errcodeLimitExceeded, errMsgRateLimited}
+		}
+		reservations = append(reservations, r)
+	}
+	for _, i := range matched {
+		limits.running[i]++
+	}
+	// Clients with calls in flight are kept out of the LRU, evicting them would
+	// reset their concurrency counts.
+	if limits.calls == 0 {
+		l.idle.Remove(client)
+		l.serving[client] = limits
+	}
+	limits.calls++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			for _, i := range matched {
+				limits.running[i]--
+			}
+			if limits.calls--; limits.calls == 0 {
+				delete(l.serving, client)
+				l.idle.Add(client, limits)
+			}
+		})
+	}
+	return release, nil
+}
+
+func (l *RateLimiter) newClientLimits() *clientLimits {
+	limits := &clientLimits{
+		buckets: make([]*rate.Limiter, len(l.rules)),
+		running: make([]int, len(l.rules)),
+	}
+	for i, rule := range l.rules {
+		if rule.Rate == 0 {
+			continue
+		}
+		burst := rule.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(rule.Rate))
+		}
+		limits.buckets[i] = rate.NewLimiter(rate.Limit(rule.Rate), burst)
+	}
+	return limits
+}
+
+// parseClientKey returns the function deriving client identities from connection
+// details, as selected by the LimitConfig.ClientKey specification.
+func parseClientKey(spec string, proxies []string) (func(PeerInfo) string, error) {
+	switch {
+	case spec == "" || spec == "ip":
+		return remoteIP, nil
+
+	case spec == "jwt":
+		return func(info PeerInfo) string {
+			if info.authSubject != "" {
+				return "jwt:" + info.authSubject
+			}
+			return remoteIP(info)
+		}, nil
+
+	case strings.HasPrefix(spec, "header:"):
+		name := http.CanonicalHeaderKey(strings.TrimPrefix(spec, "header:"))
+		if name == "" {
+			return nil, fmt.Errorf("missing header name in client key %q", spec)
+		}
+		trusted, err := parseTrustedProxies(proxies)
+		if err != nil {
+			return nil, err
+		}
+		return func(info PeerInfo) string {
+			ip := remoteIP(info)
+			if !trusted(net.ParseIP(ip)) {
+				return ip
+			}
+			if value := info.header.Get(name); value != "" {
+				return "header:" + value
+			}
+			return ip
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid client key %q", spec)
+	}
+}
+
+// parseTrustedProxies parses a list of IP addresses and CIDR ranges.
+func parseTrustedProxies(proxies []string) (func(net.IP) bool, error) {
+	var nets []*net.IPNet
+	for _, proxy := range proxies {
+		if ip := net.ParseIP(proxy); ip != nil {
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(8*len(ip), 8*len(ip))})
+			continue
+		}
+		_, n, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		nets = append(nets, n)
+	}
+	return func(ip net.IP) bool {
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// remoteIP returns the IP address of the client, without the port.
+func remoteIP(info PeerInfo) string {
+	host, _, err := net.SplitHostPort(info.RemoteAddr)
+	if err != nil {
+		return info.RemoteAddr
+	}
+	return host
+}
@@ -27,11 +27,16 @@ var (
 	rpcRequestGauge        = metrics.NewRegisteredGauge("rpc/requests", nil)
 	successfulRequestGauge = metrics.NewRegisteredGauge("rpc/success", nil)
 	failedRequestGauge     = metrics.NewRegisteredGauge("rpc/failure", nil)
+	throttledRequestGauge  = metrics.NewRegisteredGauge("rpc/throttled", nil)
 
 	// serveTimeHistName is the prefix of the per-request serving time histograms.
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// throttledCountName is the prefix of the per-method counters of calls
+	// rejected by the limiter.
+	throttledCountName = "rpc/throttled"
 )
 
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
@@ -48,3 +53,9 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	}
 	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Microseconds())
 }
+
+// updateThrottledMetrics tracks a call rejected by the limiter.
+func updateThrottledMetrics(method string) {
+	throttledRequestGauge.Inc(1)
+	metrics.GetOrRegisterCounter(fmt.Sprintf("%s/%s", throttledCountName, method), nil).Inc(1)
+}
@@ -19,6 +19,7 @@ package rpc
 import (
 	"context"
 	"io"
+	"net/http"
 	"sync"
 	"sync/atomic"
 
@@ -51,6 +52,7 @@ type Server struct {
 	run                atomic.Bool
 	batchItemLimit     int
 	batchResponseLimit int
+	limiter            Limiter
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -78,6 +80,15 @@ func (s *Server) SetBatchLimits(itemLimit, maxResponseSize int) {
 	s.batchResponseLimit = maxResponseSize
 }
 
+// SetLimiter sets the limiter consulted before serving method calls. Calls which
+// the limiter rejects are answered with its error.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetLimiter(limiter Limiter) {
+	s.limiter = limiter
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -103,6 +114,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		limiter:            s.limiter,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -136,7 +148,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 		return
 	}
 
-	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
+	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit, s.limiter)
 	h.allowSubscribe = false
 	defer h.close(io.EOF, nil)
 
@@ -195,7 +207,7 @@ func (s *RPCService) Modules() map[string]string {
 // the current method call.
 type PeerInfo struct {
 	// Transport is name of the protocol used by the client.
-	// This can be "http", "ws" or "ipc".
+	// This can be "http", "ws", "ipc" or "grpc".
 	Transport string
 
 	// Address of client. This will usually contain the IP address and port.
@@ -210,10 +222,41 @@ type PeerInfo struct {
 		Origin    string
 		Host      string
 	}
+
+	// header holds the HTTP headers sent by the client, and authSubject the subject
+	// of the credentials the request was authenticated with. They are used to tell
+	// clients apart when limiting requests.
+	header      http.Header
+	authSubject string
 }
 
 type peerInfoContextKey struct{}
 
+type authSubjectContextKey struct{}
+
+// WithAuthSubject returns a copy of the context of an HTTP or gRPC request,
+// recording the subject of the credentials the request was authenticated with.
+// Authentication handlers wrapping the server call it once the credentials are
+// verified.
+func WithAuthSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, authSubjectContextKey{}, subject)
+}
+
+// WithPeerInfo returns a copy of ctx carrying the given connection details and
+// headers of a client, along with the subject recorded by WithAuthSubject. Gateways
+// forwarding calls through a shared in-process client use it to attribute the
+// calls to their own clients when acquiring the Limiter.
+func WithPeerInfo(ctx context.Context, info PeerInfo, header http.Header) context.Context {
+	info.header = header
+	info.authSubject = authSubjectFromContext(ctx)
+	return context.WithValue(ctx, peerInfoContextKey{}, info)
+}
+
+func authSubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(authSubjectContextKey{}).(string)
+	return subject
+}
+
 // PeerInfoFromContext returns information about the client's network connection.
 // Use this with the context passed to RPC method handler functions.
 //
@@ -29,6 +29,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ethereum/go-ethereum/log"
 )
 
 var (
@@ -384,3 +386,104 @@ func (sub *ClientSubscription) requestUnsubscribe() error {
 	var result interface{}
 	return sub.client.Call(&result, sub.namespace+unsubscribeMethodSuffix, sub.subid)
 }
+
+const (
+	// resubscribeMinDelay and resubscribeMaxDelay bound the delay between attempts
+	// to re-establish a resumable subscription.
+	resubscribeMinDelay = 100 * time.Millisecond
+	resubscribeMaxDelay = 10 * time.Second
+)
+
+// ResumableSubscription is a subscription established through the Client's
+// SubscribeResumable method. Unlike ClientSubscription, it survives the loss of the
+// connection to the server.
+type ResumableSubscription struct {
+	client    *Client
+	namespace string
+	channel   interface{}
+	args      func() []interface{}
+
+	quit     chan struct{} // closed by Unsubscribe
+	quitOnce sync.Once
+	done     chan struct{} // closed when the resubscription loop exits
+	err      chan error
+}
+
+// Err returns the subscription error channel. It receives a value when the
+// subscription has ended because it could not be re-established. The received
+// error is nil if Close has been called on the underlying client.
+//
+// The error channel is closed when Unsubscribe is called on the subscription.
+func (sub *ResumableSubscription) Err() <-chan error {
+	return sub.err
+}
+
+// Unsubscribe unsubscribes the notification and closes the error channel.
+// It can safely be called more than once.
+func (sub *ResumableSubscription) Unsubscribe() {
+	sub.quitOnce.Do(func() {
+		close(sub.quit)
+		<-sub.done
+		close(sub.err)
+	})
+}
+
+// run is the resubscription loop. It waits for the current subscription to fail
+// and establishes a new one.
+func (sub *ResumableSubscription) run(current *ClientSubscription) {
+	defer close(sub.done)
+
+	for {
+		select {
+		case <-sub.quit:
+			current.Unsubscribe()
+			return
+		case err := <-current.Err():
+			if err == nil {
+				// The client was closed.
+				sub.err <- nil
+				return
+			}
+			log.Debug("RPC subscription failed, resubscribing", "namespace", sub.namespace, "err", err)
+			if current, err = sub.resubscribe(); current == nil {
+				if err != nil {
+					sub.err <- err
+				}
+				return
+			}
+		}
+	}
+}
+
+// resubscribe re-establishes the subscription, retrying until it succeeds, the
+// server rejects the request, or the subscription is stopped.
+func (sub *ResumableSubscription) resubscribe() (*ClientSubscription, error) {
+	delay := resubscribeMinDelay
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDialTimeout)
+		s, err := sub.client.Subscribe(ctx, sub.namespace, sub.channel, sub.args()...)
+		cancel()
+
+		var rpcErr Error
+		switch {
+		case err == nil:
+			return s, nil
+		case err == ErrClientQuit:
+			return nil, nil
+		case errors.As(err, &rpcErr) || err == errDead:
+			return nil, err
+		}
+		log.Trace("RPC resubscription failed", "namespace", sub.namespace, "err", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-sub.quit:
+			timer.Stop()
+			return nil, nil
+		}
+		if delay *= 2; delay > resubscribeMaxDelay {
+			delay = resubscribeMaxDelay
+		}
+	}
+}
@@ -60,7 +60,8 @@ func (s *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			log.Debug("WebSocket upgrade failed", "err", err)
 			return
 		}
-		codec := newWebsocketCodec(conn, r.Host, r.Header, wsDefaultReadLimit)
+		codec := newWebsocketCodec(conn, r.Host, r.Header, wsDefaultReadLimit).(*websocketCodec)
+		codec.info.authSubject = authSubjectFromContext(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -306,6 +307,7 @@ func newWebsocketCodec(conn *websocket.Conn, host string, req http.Header, readL
 	wc.info.HTTP.Host = host
 	wc.info.HTTP.Origin = req.Get("Origin")
 	wc.info.HTTP.UserAgent = req.Get("User-Agent")
+	wc.info.header = req
 	// Start pinger.
 	conn.SetPongHandler(func(appData string) error {
 		select {
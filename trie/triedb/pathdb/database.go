@@ -136,6 +136,9 @@ type Database struct {
 	tree       *layerTree               // The group for all known layers
 	freezer    *rawdb.ResettableFreezer // Freezer for storing trie histories, nil possible in tests
 	lock       sync.RWMutex             // Lock to prevent mutations from happening at the same time
+
+	indexQuit chan struct{} // Channel to terminate the background history indexing
+	indexDone chan struct{} // Channel closed when the background history indexing exits
 }
 
 // New attempts to load an already existing layer from a persistent key-value
@@ -179,6 +182,11 @@ func New(diskdb ethdb.Database, config *Config) *Database {
 		if pruned != 0 {
 			log.Warn("Truncated extra state histories", "number", pruned)
 		}
+		// Index the state histories which are not covered by the index yet
+		// in the background.
+		db.indexQuit = make(chan struct{})
+		db.indexDone = make(chan struct{})
+		go db.indexLoop()
 	}
 	// Disable database in case node is still in the initial state sync stage.
 	if rawdb.ReadSnapSyncStatusFlag(diskdb) == rawdb.StateSyncRunning && !db.readOnly {
@@ -300,6 +308,9 @@ func (db *Database) Enable(root common.Hash) error {
 		if err := db.freezer.Reset(); err != nil {
 			return err
 		}
+		if err := deleteHistoryIndexes(db.diskdb); err != nil {
+			return err
+		}
 	}
 	// Re-construct a new disk layer backed by persistent state
 	// with **empty clean cache and node buffer**.
@@ -391,6 +402,16 @@ func (db *Database) Recoverable(root common.Hash) bool {
 
 // Close closes the trie database and the held freezer.
 func (db *Database) Close() error {
+	// Terminate the background history indexing before grabbing the lock,
+	// it's held by the indexer for every batch.
+	if db.indexQuit != nil {
+		select {
+		case <-db.indexQuit:
+		default:
+			close(db.indexQuit)
+		}
+		<-db.indexDone
+	}
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
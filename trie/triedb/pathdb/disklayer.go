@@ -184,6 +184,11 @@ func (dl *diskLayer) commit(bottom *diffLayer, force bool) (*diskLayer, error) {
 		if err != nil {
 			return nil, err
 		}
+		// Index the new history, or the oldest unindexed one if the background
+		// indexing hasn't caught up yet.
+		if _, err := indexHistories(dl.db.diskdb, dl.db.freezer, 1); err != nil {
+			return nil, err
+		}
 		// Determine if the persisted history object has exceeded the configured
 		// limitation, set the overflow as true if so.
 		tail, err := dl.db.freezer.Tail()
@@ -566,7 +566,7 @@ func checkHistories(freezer *rawdb.ResettableFreezer, start, count uint64, check
 
 // truncateFromHead removes the extra state histories from the head with the given
 // parameters. It returns the number of items removed from the head.
-func truncateFromHead(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, nhead uint64) (int, error) {
+func truncateFromHead(db ethdb.KeyValueStore, freezer *rawdb.ResettableFreezer, nhead uint64) (int, error) {
 	ohead, err := freezer.Ancients()
 	if err != nil {
 		return 0, err
@@ -596,6 +596,14 @@ func truncateFromHead(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, nhead
 		}
 		rawdb.DeleteStateID(batch, m.root)
 	}
+	// Remove the index entries of the truncated histories as well, the index
+	// must never refer to histories which are not existent.
+	if err := unindexHistories(batch, db, freezer, nhead+1, ohead); err != nil {
+		return 0, err
+	}
+	if rawdb.ReadStateHistoryIndexHead(db) > nhead {
+		rawdb.WriteStateHistoryIndexHead(batch, nhead)
+	}
 	if err := batch.Write(); err != nil {
 		return 0, err
 	}
@@ -608,7 +616,7 @@ func truncateFromHead(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, nhead
 
 // truncateFromTail removes the extra state histories from the tail with the given
 // parameters. It returns the number of items removed from the tail.
-func truncateFromTail(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, ntail uint64) (int, error) {
+func truncateFromTail(db ethdb.KeyValueStore, freezer *rawdb.ResettableFreezer, ntail uint64) (int, error) {
 	ohead, err := freezer.Ancients()
 	if err != nil {
 		return 0, err
@@ -638,6 +646,9 @@ func truncateFromTail(db ethdb.Batcher, freezer *rawdb.ResettableFreezer, ntail
 		}
 		rawdb.DeleteStateID(batch, m.root)
 	}
+	if err := unindexHistories(batch, db, freezer, otail+1, ntail); err != nil {
+		return 0, err
+	}
 	if err := batch.Write(); err != nil {
 		return 0, err
 	}
@@ -0,0 +1,236 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// State history index
+//
+// State histories are indexed by the mutated accounts and storage slots, which
+// allows to locate the first state history after a given state that touches a
+// specific account or slot. The previous value recorded in that history is the
+// value of the account or slot at the given state, which makes it possible to
+// serve historical state reads without keeping the whole historical tries.
+//
+// Each index entry is a key-value pair with empty value, the key is composed of
+// the account address (and slot hash for storage) followed by the big-endian
+// encoded history id, so that the entries belonging to the same account or slot
+// are sorted by history id in the key-value store.
+//
+// The index covers the histories in range (tail, head] where head is tracked by
+// a dedicated marker in the key-value store. Index entries are added whenever
+// a new state history is written and removed whenever histories are truncated
+// from either side.
+
+const (
+	// historyIndexBatch is the number of state histories indexed at once when
+	// catching up with the freezer, e.g. after upgrading from a version that
+	// didn't maintain the index.
+	historyIndexBatch = 32
+)
+
+// iterateHistoryIndex resolves the account and storage slot lists contained in
+// the state history with the given id and invokes the callbacks on them. Only
+// the metadata and index tables are loaded, the account and storage data are
+// not touched.
+func iterateHistoryIndex(freezer *rawdb.ResettableFreezer, id uint64, onAccount func(common.Address), onSlot func(common.Address, common.Hash), onIncomplete func(common.Address)) error {
+	blob := rawdb.ReadStateHistoryMeta(freezer, id)
+	if len(blob) == 0 {
+		return fmt.Errorf("state history not found %d", id)
+	}
+	var m meta
+	if err := m.decode(blob); err != nil {
+		return err
+	}
+	var (
+		accountIndexes = rawdb.ReadStateAccountIndex(freezer, id)
+		storageIndexes = rawdb.ReadStateStorageIndex(freezer, id)
+	)
+	if len(accountIndexes)%accountIndexSize != 0 {
+		return fmt.Errorf("invalid account index, len: %d", len(accountIndexes))
+	}
+	if len(storageIndexes)%slotIndexSize != 0 {
+		return fmt.Errorf("invalid storage index, len: %d", len(storageIndexes))
+	}
+	for i := 0; i < len(accountIndexes)/accountIndexSize; i++ {
+		var index accountIndex
+		index.decode(accountIndexes[i*accountIndexSize : (i+1)*accountIndexSize])
+		onAccount(index.address)
+
+		for j := uint32(0); j < index.storageSlots; j++ {
+			pos := int(index.storageOffset+j) * slotIndexSize
+			if pos+slotIndexSize > len(storageIndexes) {
+				return errors.New("storage index buffer is corrupted")
+			}
+			var slot slotIndex
+			slot.decode(storageIndexes[pos : pos+slotIndexSize])
+			onSlot(index.address, slot.hash)
+		}
+	}
+	for _, addr := range m.incomplete {
+		onIncomplete(addr)
+	}
+	return nil
+}
+
+// indexHistory adds the index entries of the state history with the given id
+// into the provided batch.
+func indexHistory(batch ethdb.KeyValueWriter, freezer *rawdb.ResettableFreezer, id uint64) error {
+	return iterateHistoryIndex(freezer, id,
+		func(addr common.Address) { rawdb.WriteStateHistoryAccountIndex(batch, addr, id) },
+		func(addr common.Address, slot common.Hash) {
+			rawdb.WriteStateHistoryStorageIndex(batch, addr, slot, id)
+		},
+		func(addr common.Address) { rawdb.WriteStateHistoryIncompleteIndex(batch, addr, id) },
+	)
+}
+
+// unindexHistory removes the index entries of the state history with the given
+// id into the provided batch.
+func unindexHistory(batch ethdb.KeyValueWriter, freezer *rawdb.ResettableFreezer, id uint64) error {
+	return iterateHistoryIndex(freezer, id,
+		func(addr common.Address) { rawdb.DeleteStateHistoryAccountIndex(batch, addr, id) },
+		func(addr common.Address, slot common.Hash) {
+			rawdb.DeleteStateHistoryStorageIndex(batch, addr, slot, id)
+		},
+		func(addr common.Address) { rawdb.DeleteStateHistoryIncompleteIndex(batch, addr, id) },
+	)
+}
+
+// indexHistories indexes at most limit state histories that are not yet covered
+// by the index. It returns a flag whether the index has caught up with the
+// histories stored in the freezer.
+func indexHistories(db ethdb.KeyValueStore, freezer *rawdb.ResettableFreezer, limit uint64) (bool, error) {
+	head, err := freezer.Ancients()
+	if err != nil {
+		return false, err
+	}
+	tail, err := freezer.Tail()
+	if err != nil {
+		return false, err
+	}
+	indexed := rawdb.ReadStateHistoryIndexHead(db)
+	if indexed < tail {
+		indexed = tail
+	}
+	if indexed >= head {
+		return true, nil
+	}
+	last := indexed + limit
+	if last > head {
+		last = head
+	}
+	batch := db.NewBatch()
+	for id := indexed + 1; id <= last; id++ {
+		if err := indexHistory(batch, freezer, id); err != nil {
+			return false, err
+		}
+	}
+	rawdb.WriteStateHistoryIndexHead(batch, last)
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	return last == head, nil
+}
+
+// unindexHistories removes the index entries of the state histories in range
+// [from, to] which are about to be truncated. The ids beyond the indexed range
+// are ignored.
+func unindexHistories(batch ethdb.KeyValueWriter, db ethdb.KeyValueReader, freezer *rawdb.ResettableFreezer, from, to uint64) error {
+	if indexed := rawdb.ReadStateHistoryIndexHead(db); to > indexed {
+		to = indexed
+	}
+	for id := from; id <= to; id++ {
+		if err := unindexHistory(batch, freezer, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteHistoryIndexes wipes all the state history index entries along with
+// the index head marker from the database.
+func deleteHistoryIndexes(db ethdb.KeyValueStore) error {
+	batch := db.NewBatch()
+	for _, prefix := range [][]byte{rawdb.StateHistoryAccountIndexPrefix, rawdb.StateHistoryStorageIndexPrefix, rawdb.StateHistoryIncompleteIndexPrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			if err := batch.Delete(it.Key()); err != nil {
+				it.Release()
+				return err
+			}
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		it.Release()
+	}
+	rawdb.WriteStateHistoryIndexHead(batch, 0)
+	return batch.Write()
+}
+
+// indexLoop indexes the state histories which are not covered by the index in
+// the background, e.g. the ones written before the index was introduced. The
+// database lock is held for every batch to not interfere with the mutations.
+func (db *Database) indexLoop() {
+	defer close(db.indexDone)
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for {
+		select {
+		case <-db.indexQuit:
+			return
+		default:
+		}
+		db.lock.Lock()
+		if db.readOnly || db.waitSync {
+			db.lock.Unlock()
+			return
+		}
+		done, err := indexHistories(db.diskdb, db.freezer, historyIndexBatch)
+		db.lock.Unlock()
+
+		if err != nil {
+			log.Error("Failed to index state histories", "err", err)
+			return
+		}
+		if done {
+			log.Info("Indexed state histories", "head", rawdb.ReadStateHistoryIndexHead(db.diskdb), "elapsed", common.PrettyDuration(time.Since(start)))
+			return
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing state histories", "indexed", rawdb.ReadStateHistoryIndexHead(db.diskdb), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+}
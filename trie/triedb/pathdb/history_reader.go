@@ -0,0 +1,283 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie/triestate"
+)
+
+var (
+	// errHistoryUnavailable is returned if the requested historical state is
+	// not covered by the retained and indexed state histories.
+	errHistoryUnavailable = errors.New("historical state is not available")
+
+	// errHistoryIncomplete is returned if the requested storage slot can't be
+	// resolved because the storage set of the account is incomplete in one of
+	// the relevant state histories.
+	errHistoryIncomplete = errors.New("incomplete state history")
+)
+
+// maxHistoricReadRetries is the maximum number of attempts of a historical state
+// read when the disk layer keeps changing in the meantime.
+const maxHistoricReadRetries = 8
+
+// HistoricalStateReader is a reader for accessing the flat account and storage
+// data of a historical state which is older than the persistent disk layer.
+//
+// The value of an account or slot at the requested state is resolved from the
+// first state history after that state which mutated it, since histories record
+// the original values before the transition. If there is no such history, the
+// value has not changed since and is read from the current disk layer instead.
+type HistoricalStateReader struct {
+	db     *Database
+	root   common.Hash          // The state root of the requested state
+	id     uint64               // The state id of the requested state
+	loader triestate.TrieLoader // Loader for accessing the disk layer state
+}
+
+// HistoricReader constructs a reader for accessing the requested historical
+// state. An error is returned if the state is not older than the disk layer,
+// or if the required state histories are not available or not indexed.
+func (db *Database) HistoricReader(root common.Hash, loader triestate.TrieLoader) (*HistoricalStateReader, error) {
+	if db.freezer == nil {
+		return nil, errors.New("state history is not available")
+	}
+	root = types.TrieRootHash(root)
+	id := rawdb.ReadStateID(db.diskdb, root)
+	if id == nil {
+		return nil, fmt.Errorf("%w: unknown state %#x", errHistoryUnavailable, root)
+	}
+	r := &HistoricalStateReader{
+		db:     db,
+		root:   root,
+		id:     *id,
+		loader: loader,
+	}
+	if err := r.checkAvailable(db.tree.bottom().stateID()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Root returns the state root of the requested state.
+func (r *HistoricalStateReader) Root() common.Hash {
+	return r.root
+}
+
+// checkAvailable ensures that all the state histories after the requested state
+// up to the given disk layer are retained and indexed.
+func (r *HistoricalStateReader) checkAvailable(disk uint64) error {
+	if r.id >= disk {
+		return fmt.Errorf("%w: state %#x is not older than the disk layer", errHistoryUnavailable, r.root)
+	}
+	tail, err := r.db.freezer.Tail()
+	if err != nil {
+		return err
+	}
+	if r.id < tail {
+		return fmt.Errorf("%w: state %#x is pruned, id: %d, tail: %d", errHistoryUnavailable, r.root, r.id, tail+1)
+	}
+	if indexed := rawdb.ReadStateHistoryIndexHead(r.db.diskdb); indexed < disk {
+		return fmt.Errorf("%w: state histories are being indexed, indexed: %d, disk: %d", errHistoryUnavailable, indexed, disk)
+	}
+	return nil
+}
+
+// Account returns the account with the given address at the requested state.
+// Nil is returned if the account was not present.
+func (r *HistoricalStateReader) Account(address common.Address) (*types.StateAccount, error) {
+	for i := 0; i < maxHistoricReadRetries; i++ {
+		dl := r.db.tree.bottom()
+		if err := r.checkAvailable(dl.stateID()); err != nil {
+			return nil, err
+		}
+		if id, ok := rawdb.FindStateHistoryAccountIndex(r.db.diskdb, address, r.id+1, dl.stateID()); ok {
+			blob, err := readHistoryAccount(r.db.freezer, id, address)
+			if err != nil {
+				return nil, err
+			}
+			// The histories might have been pruned while the index was being
+			// looked up, ensure the resolved one is still valid.
+			if err := r.checkAvailable(r.db.tree.bottom().stateID()); err != nil {
+				return nil, err
+			}
+			return decodeAccount(blob)
+		}
+		account, err := r.diskAccount(dl.rootHash(), address)
+		if r.db.tree.bottom() != dl {
+			continue // disk layer changed during the read, retry
+		}
+		return account, err
+	}
+	return nil, errors.New("disk layer changed too frequently")
+}
+
+// Storage returns the storage slot with the given address and slot hash at the
+// requested state. The returned value is RLP-encoded as stored in the trie, nil
+// is returned if the slot was not present.
+func (r *HistoricalStateReader) Storage(address common.Address, slot common.Hash) ([]byte, error) {
+	for i := 0; i < maxHistoricReadRetries; i++ {
+		dl := r.db.tree.bottom()
+		if err := r.checkAvailable(dl.stateID()); err != nil {
+			return nil, err
+		}
+		id, ok := rawdb.FindStateHistoryStorageIndex(r.db.diskdb, address, slot, r.id+1, dl.stateID())
+
+		// The storage changes of the account might be partially dropped from the
+		// histories, the unrecorded changes might precede the found one.
+		last := dl.stateID()
+		if ok {
+			last = id - 1
+		}
+		if r.id+1 <= last {
+			if _, incomplete := rawdb.FindStateHistoryIncompleteIndex(r.db.diskdb, address, r.id+1, last); incomplete {
+				return nil, errHistoryIncomplete
+			}
+		}
+		if ok {
+			blob, err := readHistoryStorage(r.db.freezer, id, address, slot)
+			if err != nil {
+				return nil, err
+			}
+			if err := r.checkAvailable(r.db.tree.bottom().stateID()); err != nil {
+				return nil, err
+			}
+			return blob, nil
+		}
+		blob, err := r.diskStorage(dl.rootHash(), address, slot)
+		if r.db.tree.bottom() != dl {
+			continue // disk layer changed during the read, retry
+		}
+		return blob, err
+	}
+	return nil, errors.New("disk layer changed too frequently")
+}
+
+// diskAccount reads the account from the state with the given root, which is
+// expected to be the disk layer.
+func (r *HistoricalStateReader) diskAccount(root common.Hash, address common.Address) (*types.StateAccount, error) {
+	tr, err := r.loader.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := tr.Get(crypto.Keccak256(address.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	return decodeAccount(blob)
+}
+
+// diskStorage reads the storage slot from the state with the given root, which
+// is expected to be the disk layer.
+func (r *HistoricalStateReader) diskStorage(root common.Hash, address common.Address, slot common.Hash) ([]byte, error) {
+	account, err := r.diskAccount(root, address)
+	if err != nil || account == nil || account.Root == types.EmptyRootHash {
+		return nil, err
+	}
+	tr, err := r.loader.OpenStorageTrie(root, crypto.Keccak256Hash(address.Bytes()), account.Root)
+	if err != nil {
+		return nil, err
+	}
+	return tr.Get(slot.Bytes())
+}
+
+// decodeAccount decodes the account in either 'slim' or full RLP format.
+func decodeAccount(blob []byte) (*types.StateAccount, error) {
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return types.FullAccount(blob)
+}
+
+// findHistoryAccount locates the index of the given account in the state history
+// with the given id.
+func findHistoryAccount(freezer *rawdb.ResettableFreezer, id uint64, address common.Address) (accountIndex, error) {
+	indexes := rawdb.ReadStateAccountIndex(freezer, id)
+	if len(indexes) == 0 || len(indexes)%accountIndexSize != 0 {
+		return accountIndex{}, fmt.Errorf("invalid account index of state history %d, len: %d", id, len(indexes))
+	}
+	n := len(indexes) / accountIndexSize
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(indexes[i*accountIndexSize:i*accountIndexSize+common.AddressLength], address.Bytes()) >= 0
+	})
+	if pos == n {
+		return accountIndex{}, fmt.Errorf("account %#x is not in state history %d", address, id)
+	}
+	var index accountIndex
+	index.decode(indexes[pos*accountIndexSize : (pos+1)*accountIndexSize])
+	if index.address != address {
+		return accountIndex{}, fmt.Errorf("account %#x is not in state history %d", address, id)
+	}
+	return index, nil
+}
+
+// readHistoryAccount returns the original value of the given account recorded
+// in the state history with the given id.
+func readHistoryAccount(freezer *rawdb.ResettableFreezer, id uint64, address common.Address) ([]byte, error) {
+	index, err := findHistoryAccount(freezer, id, address)
+	if err != nil {
+		return nil, err
+	}
+	data := rawdb.ReadStateAccountHistory(freezer, id)
+	end := int(index.offset) + int(index.length)
+	if end > len(data) {
+		return nil, fmt.Errorf("account data of state history %d is corrupted", id)
+	}
+	return data[index.offset:end], nil
+}
+
+// readHistoryStorage returns the original value of the given storage slot
+// recorded in the state history with the given id.
+func readHistoryStorage(freezer *rawdb.ResettableFreezer, id uint64, address common.Address, slot common.Hash) ([]byte, error) {
+	account, err := findHistoryAccount(freezer, id, address)
+	if err != nil {
+		return nil, err
+	}
+	indexes := rawdb.ReadStateStorageIndex(freezer, id)
+	if int(account.storageOffset+account.storageSlots)*slotIndexSize > len(indexes) {
+		return nil, fmt.Errorf("storage index of state history %d is corrupted", id)
+	}
+	indexes = indexes[int(account.storageOffset)*slotIndexSize : int(account.storageOffset+account.storageSlots)*slotIndexSize]
+
+	n := int(account.storageSlots)
+	pos := sort.Search(n, func(i int) bool {
+		return bytes.Compare(indexes[i*slotIndexSize:i*slotIndexSize+common.HashLength], slot.Bytes()) >= 0
+	})
+	if pos == n {
+		return nil, fmt.Errorf("slot %#x of account %#x is not in state history %d", slot, address, id)
+	}
+	var index slotIndex
+	index.decode(indexes[pos*slotIndexSize : (pos+1)*slotIndexSize])
+	if index.hash != slot {
+		return nil, fmt.Errorf("slot %#x of account %#x is not in state history %d", slot, address, id)
+	}
+	data := rawdb.ReadStateStorageHistory(freezer, id)
+	end := int(index.offset) + int(index.length)
+	if end > len(data) {
+		return nil, fmt.Errorf("storage data of state history %d is corrupted", id)
+	}
+	return data[index.offset:end], nil
+}
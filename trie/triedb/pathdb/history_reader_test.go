@@ -0,0 +1,169 @@
+// Copyright 2023 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// verifyHistoricState checks that a random subset of the accounts and storage
+// slots known by the tester are resolved to their values at the given state.
+func (t *tester) verifyHistoricState(reader *HistoricalStateReader, root common.Hash) error {
+	var (
+		accounts, storages = t.snapAccounts[root], t.snapStorages[root]
+		checked            int
+	)
+	for addrHash, addr := range t.preimages {
+		if checked++; checked > 32 {
+			break
+		}
+		account, err := reader.Account(addr)
+		if err != nil {
+			return err
+		}
+		blob, exist := accounts[addrHash]
+		if !exist {
+			if account != nil {
+				return errors.New("unexpected account")
+			}
+		} else {
+			want, _ := types.FullAccount(blob)
+			if account == nil || !bytes.Equal(types.SlimAccountRLP(*account), types.SlimAccountRLP(*want)) {
+				return errors.New("account is mismatched")
+			}
+		}
+		for slot := range t.storages[addrHash] {
+			blob, err := reader.Storage(addr, slot)
+			if err != nil {
+				return err
+			}
+			if want := storages[addrHash][slot]; !bytes.Equal(blob, want) {
+				return errors.New("slot is mismatched")
+			}
+		}
+		for slot, want := range storages[addrHash] {
+			blob, err := reader.Storage(addr, slot)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(blob, want) {
+				return errors.New("slot is mismatched")
+			}
+		}
+	}
+	return nil
+}
+
+func TestHistoricalStateReader(t *testing.T) {
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	bottom := tester.bottomIndex()
+	if head := rawdb.ReadStateHistoryIndexHead(tester.db.diskdb); head != uint64(bottom+1) {
+		t.Fatalf("Unexpected index head, want: %d, got: %d", bottom+1, head)
+	}
+	diskRoot := tester.roots[bottom]
+	loader := newHashLoader(tester.snapAccounts[diskRoot], tester.snapStorages[diskRoot])
+
+	roots := append([]common.Hash{types.EmptyRootHash}, tester.roots[:bottom]...)
+	for i := 0; i < len(roots); i += 16 {
+		reader, err := tester.db.HistoricReader(roots[i], loader)
+		if err != nil {
+			t.Fatalf("Failed to open historic reader %d, err: %v", i, err)
+		}
+		if err := tester.verifyHistoricState(reader, roots[i]); err != nil {
+			t.Fatalf("Invalid historic state %d, err: %v", i, err)
+		}
+	}
+	// The states not older than the disk layer are not served.
+	for _, root := range []common.Hash{diskRoot, tester.roots[bottom+1], {0x1}} {
+		if _, err := tester.db.HistoricReader(root, loader); err == nil {
+			t.Fatalf("Historic reader is unexpectedly opened for %x", root)
+		}
+	}
+}
+
+func TestHistoricalStateReaderAfterRecover(t *testing.T) {
+	tester := newTester(t, 0)
+	defer tester.release()
+
+	// Rollback the database by a few states, the index entries of the
+	// truncated histories should be removed.
+	var (
+		bottom = tester.bottomIndex()
+		target = bottom - 10
+	)
+	for i := bottom; i > target; i-- {
+		loader := newHashLoader(tester.snapAccounts[tester.roots[i]], tester.snapStorages[tester.roots[i]])
+		if err := tester.db.Recover(tester.roots[i-1], loader); err != nil {
+			t.Fatalf("Failed to revert db, err: %v", err)
+		}
+	}
+	if head := rawdb.ReadStateHistoryIndexHead(tester.db.diskdb); head != uint64(target+1) {
+		t.Fatalf("Unexpected index head, want: %d, got: %d", target+1, head)
+	}
+	for addr := range tester.preimages {
+		if _, ok := rawdb.FindStateHistoryAccountIndex(tester.db.diskdb, tester.preimages[addr], uint64(target+2), uint64(bottom+1)); ok {
+			t.Fatal("Index entry of truncated history is not removed")
+		}
+	}
+	diskRoot := tester.roots[target]
+	loader := newHashLoader(tester.snapAccounts[diskRoot], tester.snapStorages[diskRoot])
+	for i := target - 20; i < target; i += 4 {
+		reader, err := tester.db.HistoricReader(tester.roots[i], loader)
+		if err != nil {
+			t.Fatalf("Failed to open historic reader %d, err: %v", i, err)
+		}
+		if err := tester.verifyHistoricState(reader, tester.roots[i]); err != nil {
+			t.Fatalf("Invalid historic state %d, err: %v", i, err)
+		}
+	}
+}
+
+func TestHistoricalStateReaderPruned(t *testing.T) {
+	tester := newTester(t, 10)
+	defer tester.release()
+
+	var (
+		bottom   = tester.bottomIndex()
+		diskRoot = tester.roots[bottom]
+		loader   = newHashLoader(tester.snapAccounts[diskRoot], tester.snapStorages[diskRoot])
+	)
+	if _, err := tester.db.HistoricReader(tester.roots[bottom-20], loader); !errors.Is(err, errHistoryUnavailable) {
+		t.Fatalf("Unexpected error for pruned state, err: %v", err)
+	}
+	reader, err := tester.db.HistoricReader(tester.roots[bottom-5], loader)
+	if err != nil {
+		t.Fatalf("Failed to open historic reader, err: %v", err)
+	}
+	if err := tester.verifyHistoricState(reader, tester.roots[bottom-5]); err != nil {
+		t.Fatalf("Invalid historic state, err: %v", err)
+	}
+	// The index entries of the pruned histories should be removed.
+	tail, _ := tester.db.freezer.Tail()
+	for _, addr := range tester.preimages {
+		if _, ok := rawdb.FindStateHistoryAccountIndex(tester.db.diskdb, addr, 1, tail); ok {
+			t.Fatal("Index entry of pruned history is not removed")
+		}
+	}
+}